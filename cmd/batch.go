@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/config"
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// applyJobConfig loads a job file and sets every flag it defines that was
+// not given on the command line, so CLI flags always take precedence.
+// Flags are set through the FlagSet so required-flag checks see them.
+func applyJobConfig(flags *pflag.FlagSet, path string) error {
+	job, err := config.LoadJobConfig(path)
+	if err != nil {
+		return fmt.Errorf("error: %w", err)
+	}
+
+	if len(job.Exports) > 0 {
+		if flags.Changed("sql") || flags.Changed("sqlfile") || flags.Changed("sqlfile-glob") || flags.Changed("table-export") || flags.Changed("output") {
+			return fmt.Errorf("error: --sql, --sqlfile, --sqlfile-glob, --table-export and --output cannot be used with a batch job file")
+		}
+		batchExports = resolveBatchExports(flags, job)
+		// Each export has its own output, so --output is no longer required
+		if err := flags.SetAnnotation("output", cobra.BashCompOneRequiredFlag, []string{"false"}); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+	}
+
+	port := ""
+	if job.Connection.Port != 0 {
+		port = strconv.Itoa(job.Connection.Port)
+	}
+
+	values := []struct{ flag, value string }{
+		{"sql", job.SQL},
+		{"sqlfile", job.SQLFile},
+		{"format", job.Format},
+		{"output", job.Output},
+		{"table", job.Table},
+		{"compression", job.Compression},
+		{"time-format", job.TimeFormat},
+		{"time-zone", job.TimeZone},
+		{"dsn", job.Connection.DSN},
+		{"host", job.Connection.Host},
+		{"port", port},
+		{"user", job.Connection.User},
+		{"password", job.Connection.Password},
+		{"database", job.Connection.Database},
+	}
+
+	// A query given on the command line replaces the one from the file entirely
+	queryOnCLI := flags.Changed("sql") || flags.Changed("sqlfile") || flags.Changed("sqlfile-glob") || flags.Changed("table-export")
+
+	for _, v := range values {
+		if v.value == "" || flags.Changed(v.flag) {
+			continue
+		}
+		if queryOnCLI && (v.flag == "sql" || v.flag == "sqlfile") {
+			continue
+		}
+		if err := flags.Set(v.flag, v.value); err != nil {
+			return fmt.Errorf("error: Invalid %s in job config: %v", v.flag, err)
+		}
+		logger.Debug("Using --%s from job config", v.flag)
+	}
+
+	return nil
+}
+
+// resolveBatchExports fills the settings of each batch export: a flag given
+// on the command line wins, then the export's own value, then the top-level
+// value of the job file, then the flag default.
+func resolveBatchExports(flags *pflag.FlagSet, job *config.JobConfig) []config.JobExport {
+	pick := func(flag, exportValue, jobValue string) string {
+		switch {
+		case flags.Changed(flag):
+			return flags.Lookup(flag).Value.String()
+		case exportValue != "":
+			return exportValue
+		case jobValue != "":
+			return jobValue
+		default:
+			return flags.Lookup(flag).DefValue
+		}
+	}
+
+	exports := make([]config.JobExport, len(job.Exports))
+	for i, e := range job.Exports {
+		exports[i] = config.JobExport{
+			Name:        strings.TrimSpace(e.Name),
+			SQL:         e.SQL,
+			SQLFile:     e.SQLFile,
+			Output:      e.Output,
+			Format:      pick("format", e.Format, job.Format),
+			Table:       pick("table", e.Table, job.Table),
+			Compression: pick("compression", e.Compression, job.Compression),
+			TimeFormat:  pick("time-format", e.TimeFormat, job.TimeFormat),
+			TimeZone:    pick("time-zone", e.TimeZone, job.TimeZone),
+		}
+	}
+	return exports
+}
+
+// useBatchExport loads the settings of one batch export into the flag variables.
+func useBatchExport(e config.JobExport) {
+	sqlQuery = e.SQL
+	sqlFile = e.SQLFile
+	sqlFileGlob = ""
+	outputPath = e.Output
+	format = e.Format
+	tableName = e.Table
+	compression = e.Compression
+	timeFormat = e.TimeFormat
+	timeZone = e.TimeZone
+}
+
+// validateBatchExports runs the usual parameter validation for every export
+// of a batch job file, so a mistake in the last one fails before anything runs.
+func validateBatchExports() error {
+	if countFile != "" {
+		return fmt.Errorf("error: --count-file is not supported with batch job files, use --porcelain to get one line per export")
+	}
+	if checkpointEvery != 0 || resumeExport {
+		return fmt.Errorf("error: --checkpoint-every and --resume are not supported with batch job files")
+	}
+	if refcursors {
+		return fmt.Errorf("error: --refcursors is not supported with batch job files")
+	}
+	if rowsPerFile != 0 || indexFile != "" {
+		return fmt.Errorf("error: --rows-per-file and --index-file are not supported with batch job files")
+	}
+	if strictFormat {
+		return fmt.Errorf("error: --strict-format is not supported with batch job files, set the format of each export")
+	}
+	for _, e := range batchExports {
+		if e.Output == stdoutPath {
+			return fmt.Errorf("error: Export '%s': output - (stdout) is not supported in batch job files", e.Name)
+		}
+		useBatchExport(e)
+		if err := validateExportParams(); err != nil {
+			return fmt.Errorf("error: Export '%s': %s", e.Name, strings.TrimPrefix(err.Error(), "error: "))
+		}
+	}
+	return nil
+}
+
+// batchJob is a batch export ready to run.
+type batchJob struct {
+	name    string
+	query   string
+	options exporters.ExportOptions
+}
+
+// runBatchExports exports every entry of a batch job file in order over a
+// single connection and prints a row count summary. A failing export does
+// not stop the remaining ones.
+func runBatchExports(ctx context.Context, dbUrl string) error {
+	now := time.Now()
+	jobs := make([]batchJob, 0, len(batchExports))
+	for _, e := range batchExports {
+		useBatchExport(e)
+		format = strings.ToLower(strings.TrimSpace(format))
+		if err := applyOutputTemplate(now); err != nil {
+			return fmt.Errorf("export '%s': %w", e.Name, err)
+		}
+
+		queries, err := loadQueryJobs()
+		if err != nil {
+			return fmt.Errorf("export '%s': %w", e.Name, err)
+		}
+		options, err := buildExportOptions(ctx)
+		if err != nil {
+			return fmt.Errorf("export '%s': %w", e.Name, err)
+		}
+		if options, err = withJobTable(options, queries[0]); err != nil {
+			return fmt.Errorf("export '%s': %w", e.Name, err)
+		}
+		jobs = append(jobs, batchJob{name: e.Name, query: queries[0].query, options: options})
+	}
+
+	store, err := newPgStore(dbUrl)
+	if err != nil {
+		return err
+	}
+
+	setRunPhase("connecting to the database")
+	if err := store.ConnectContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	defer store.Close()
+
+	return exportBatchJobs(store, jobs)
+}
+
+// exportBatchJobs runs the jobs of a batch over store, logging the verbose
+// summary of each export and then a row count table of the whole batch.
+func exportBatchJobs(store *db.PgStore, jobs []batchJob) error {
+	failed := 0
+	var summary bytes.Buffer
+	tw := tabwriter.NewWriter(&summary, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  EXPORT\tOUTPUT\tROWS")
+
+	for _, job := range jobs {
+		logger.Info("Exporting %s -> %s", job.name, job.options.OutputPath)
+
+		target := finalOutputPath(job.options)
+		started := time.Now()
+		rowCount, err := runQueryExport(store, job.query, job.options)
+		duration := time.Since(started)
+		if err == nil {
+			err = handleExportResult(rowCount, target)
+		} else {
+			err = fmt.Errorf("export failed: %w", err)
+		}
+
+		if err != nil {
+			failed++
+			logger.Error("%s: %v", job.name, err)
+			fmt.Fprintf(tw, "  %s\t%s\tFAILED (%v)\n", job.name, target, err)
+			continue
+		}
+		printSummary(newExportResult(job.options, rowCount, duration))
+		fmt.Fprintf(tw, "  %s\t%s\t%d\n", job.name, target, rowCount)
+	}
+	tw.Flush()
+
+	logger.Info("Summary: %d/%d exports completed", len(jobs)-failed, len(jobs))
+	for _, line := range strings.Split(strings.TrimRight(summary.String(), "\n"), "\n") {
+		logger.Info("%s", line)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d exports failed", failed, len(jobs))
+	}
+	return nil
+}
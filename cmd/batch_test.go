@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/config"
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestApplyJobConfig(t *testing.T) {
+	jobContent := `sql: SELECT * FROM users
+format: json
+output: users.json
+compression: gzip
+connection:
+  host: db.example.com
+  port: 5433
+  user: reporter
+`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]string
+	}{
+		{
+			name: "file values fill unset flags",
+			expected: map[string]string{
+				"sql":         "SELECT * FROM users",
+				"format":      "json",
+				"output":      "users.json",
+				"compression": "gzip",
+				"host":        "db.example.com",
+				"port":        "5433",
+				"user":        "reporter",
+				"time-format": "yyyy-MM-dd HH:mm:ss",
+			},
+		},
+		{
+			name: "cli flags override file",
+			args: []string{"--format", "csv", "--output", "out.csv", "--port", "6000"},
+			expected: map[string]string{
+				"format":      "csv",
+				"output":      "out.csv",
+				"port":        "6000",
+				"compression": "gzip",
+			},
+		},
+		{
+			name: "cli query replaces file query",
+			args: []string{"--sqlfile", "other.sql"},
+			expected: map[string]string{
+				"sql":     "",
+				"sqlfile": "other.sql",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "job.yaml")
+			if err := os.WriteFile(path, []byte(jobContent), 0644); err != nil {
+				t.Fatalf("Failed to write job file: %v", err)
+			}
+
+			flags := newJobConfigFlags()
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Failed to parse args: %v", err)
+			}
+
+			if err := applyJobConfig(flags, path); err != nil {
+				t.Fatalf("applyJobConfig() unexpected error: %v", err)
+			}
+
+			for name, want := range tt.expected {
+				if got := flags.Lookup(name).Value.String(); got != want {
+					t.Errorf("--%s = %q, want %q", name, got, want)
+				}
+			}
+			if !flags.Changed("output") {
+				t.Error("--output should be marked as set so required-flag checks pass")
+			}
+		})
+	}
+}
+
+func TestApplyJobConfigInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(path, []byte("sql: SELECT 1\nouput: x.csv\n"), 0644); err != nil {
+		t.Fatalf("Failed to write job file: %v", err)
+	}
+
+	err := applyJobConfig(pflag.NewFlagSet("test", pflag.ContinueOnError), path)
+	if err == nil || !strings.Contains(err.Error(), "field ouput not found") {
+		t.Errorf("applyJobConfig() error = %v, want unknown key error", err)
+	}
+}
+
+func TestApplyJobConfigBatch(t *testing.T) {
+	originalBatchExports := batchExports
+	// validateBatchExports loads each export into the flag variables
+	originalSQLQuery, originalSQLFile, originalSQLFileGlob, originalOutputPath := sqlQuery, sqlFile, sqlFileGlob, outputPath
+	originalFormat, originalTableName, originalCompression := format, tableName, compression
+	originalTimeFormat, originalTimeZone := timeFormat, timeZone
+	defer func() {
+		batchExports = originalBatchExports
+		sqlQuery, sqlFile, sqlFileGlob, outputPath = originalSQLQuery, originalSQLFile, originalSQLFileGlob, originalOutputPath
+		format, tableName, compression = originalFormat, originalTableName, originalCompression
+		timeFormat, timeZone = originalTimeFormat, originalTimeZone
+	}()
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "jobs.yaml")
+	jobContent := `format: csv
+time_zone: UTC
+exports:
+  - name: users
+    sql: SELECT * FROM users
+    output: ` + filepath.Join(tmpDir, "users.csv") + `
+  - name: orders
+    sql: SELECT * FROM orders
+    format: sql
+    table: orders
+    output: ` + filepath.Join(tmpDir, "orders.sql") + `
+`
+	if err := os.WriteFile(path, []byte(jobContent), 0644); err != nil {
+		t.Fatalf("Failed to write job file: %v", err)
+	}
+
+	flags := newJobConfigFlags()
+	if err := flags.Parse([]string{"--compression", "gzip"}); err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+
+	if err := applyJobConfig(flags, path); err != nil {
+		t.Fatalf("applyJobConfig() unexpected error: %v", err)
+	}
+
+	expected := []config.JobExport{
+		{
+			Name: "users", SQL: "SELECT * FROM users", Output: filepath.Join(tmpDir, "users.csv"),
+			Format: "csv", Compression: "gzip", TimeFormat: "yyyy-MM-dd HH:mm:ss", TimeZone: "UTC",
+		},
+		{
+			Name: "orders", SQL: "SELECT * FROM orders", Output: filepath.Join(tmpDir, "orders.sql"),
+			Format: "sql", Table: "orders", Compression: "gzip", TimeFormat: "yyyy-MM-dd HH:mm:ss", TimeZone: "UTC",
+		},
+	}
+	if !reflect.DeepEqual(batchExports, expected) {
+		t.Errorf("batchExports = %+v, want %+v", batchExports, expected)
+	}
+
+	if required := flags.Lookup("output").Annotations[cobra.BashCompOneRequiredFlag]; len(required) != 1 || required[0] != "false" {
+		t.Errorf("--output should no longer be required in batch mode, annotation = %v", required)
+	}
+
+	if err := validateBatchExports(); err != nil {
+		t.Errorf("validateBatchExports() unexpected error: %v", err)
+	}
+
+	// A batch entry missing a required setting is reported by name
+	batchExports[1].Table = ""
+	err := validateBatchExports()
+	if err == nil || !strings.Contains(err.Error(), "Export 'orders': --table (-t) is required") {
+		t.Errorf("validateBatchExports() error = %v, want error naming the export", err)
+	}
+}
+
+func TestApplyJobConfigBatchRejectsQueryFlags(t *testing.T) {
+	originalBatchExports := batchExports
+	defer func() { batchExports = originalBatchExports }()
+
+	path := filepath.Join(t.TempDir(), "jobs.yaml")
+	if err := os.WriteFile(path, []byte("exports:\n  - {name: a, sql: SELECT 1, output: a.csv}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write job file: %v", err)
+	}
+
+	flags := newJobConfigFlags()
+	if err := flags.Parse([]string{"--output", "x.csv"}); err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+
+	err := applyJobConfig(flags, path)
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with a batch job file") {
+		t.Errorf("applyJobConfig() error = %v, want batch conflict error", err)
+	}
+}
+
+func TestValidateBatchExportsCountFile(t *testing.T) {
+	originalBatchExports := batchExports
+	originalCountFile := countFile
+	defer func() {
+		batchExports = originalBatchExports
+		countFile = originalCountFile
+	}()
+
+	batchExports = []config.JobExport{{Name: "users", SQL: "SELECT 1", Output: "users.csv", Format: "csv"}}
+	countFile = "rows.count"
+
+	err := validateBatchExports()
+	if err == nil || !strings.Contains(err.Error(), "--count-file is not supported with batch job files") {
+		t.Errorf("validateBatchExports() error = %v, want count file error", err)
+	}
+}
+
+func TestValidateBatchExportsStdout(t *testing.T) {
+	originalBatchExports := batchExports
+	defer func() { batchExports = originalBatchExports }()
+
+	batchExports = []config.JobExport{{Name: "users", SQL: "SELECT 1", Output: "-", Format: "csv"}}
+
+	err := validateBatchExports()
+	if err == nil || !strings.Contains(err.Error(), "output - (stdout) is not supported in batch job files") {
+		t.Errorf("validateBatchExports() error = %v, want stdout error", err)
+	}
+}
+
+func TestExportBatchJobsSummary(t *testing.T) {
+	originalVerbose := logger.IsVerbose()
+	originalRunQueryExport := runQueryExport
+	defer func() {
+		logger.SetVerbose(originalVerbose)
+		logger.GetLogger().SetOutput(os.Stdout)
+		runQueryExport = originalRunQueryExport
+	}()
+
+	runQueryExport = func(store *db.PgStore, query string, options exporters.ExportOptions) (int, error) {
+		if query == "SELECT broken" {
+			return 0, errors.New("syntax error")
+		}
+		return 3, os.WriteFile(options.OutputPath, []byte("id\n1\n2\n3\n"), 0o644)
+	}
+
+	tmpDir := t.TempDir()
+	job := func(name, query string) batchJob {
+		return batchJob{name: name, query: query, options: exporters.ExportOptions{
+			Format:      exporters.FormatCSV,
+			Compression: "none",
+			OutputPath:  filepath.Join(tmpDir, name+".csv"),
+		}}
+	}
+	jobs := []batchJob{job("users", "SELECT 1"), job("broken", "SELECT broken"), job("orders", "SELECT 2")}
+
+	var logs bytes.Buffer
+	logger.GetLogger().SetOutput(&logs)
+	logger.SetVerbose(true)
+
+	err := exportBatchJobs(nil, jobs)
+	if err == nil || err.Error() != "1 of 3 exports failed" {
+		t.Errorf("exportBatchJobs() error = %v, want 1 of 3 exports failed", err)
+	}
+
+	if got := strings.Count(logs.String(), "Export summary:"); got != 2 {
+		t.Errorf("logs should contain one summary per successful export, got %d:\n%s", got, logs.String())
+	}
+	for _, want := range []string{
+		"Output       " + filepath.Join(tmpDir, "users.csv"),
+		"Output       " + filepath.Join(tmpDir, "orders.csv"),
+		"Size         9 B",
+		"Summary: 2/3 exports completed",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs should contain %q, got:\n%s", want, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "Output       "+filepath.Join(tmpDir, "broken.csv")) {
+		t.Errorf("a failed export should have no summary, got:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func TestPrintColumns(t *testing.T) {
+	var buf bytes.Buffer
+	err := printColumns(&buf, []db.Column{
+		{Name: "id", Type: "integer", Nullable: false},
+		{Name: "email", Type: "character varying", Nullable: true},
+	})
+	if err != nil {
+		t.Fatalf("printColumns() error: %v", err)
+	}
+
+	want := "NAME   TYPE               NULLABLE\n" +
+		"id     integer            NO\n" +
+		"email  character varying  YES\n"
+	if buf.String() != want {
+		t.Errorf("printColumns() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestColumnsCommandArgs(t *testing.T) {
+	defer rootCmd.SetArgs(nil)
+
+	tests := []struct {
+		name        string
+		args        []string
+		errContains string
+	}{
+		{
+			name:        "missing table",
+			args:        []string{"columns"},
+			errContains: "accepts 1 arg(s)",
+		},
+		{
+			name:        "too many tables",
+			args:        []string{"columns", "users", "orders"},
+			errContains: "accepts 1 arg(s)",
+		},
+		{
+			name:        "empty schema",
+			args:        []string{"columns", ".users"},
+			errContains: "has an empty part",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd.SetArgs(tt.args)
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Execute() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+// Integration test, skipped if DB_TEST_URL is not set
+func TestColumnsCommandIntegration(t *testing.T) {
+	testURL := os.Getenv("DB_TEST_URL")
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	originalConnString := connString
+	defer func() {
+		connString = originalConnString
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"columns", "pg_catalog.pg_namespace", "--dsn", testURL})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 3 || fields[0] != "oid" || fields[1] != "oid" || fields[2] != "NO" {
+		t.Errorf("first column = %q, want oid, oid, NO", lines[1])
+	}
+
+	rootCmd.SetArgs([]string{"columns", "PG_NAMESPACE", "--dsn", testURL})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Execute() error = %v, want a missing table error", err)
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+)
+
+var dumpSchema string
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Export every table of a schema, one file per table",
+	Long: `Export every table of a schema into a directory, one file per table named
+after it (e.g. out/orders.csv). Tables are listed from information_schema;
+views are skipped. Each table is exported with SELECT * through the same
+pipeline as a regular export, and a failing table does not stop the others.
+
+Meant for small databases and quick copies, not as a replacement for pg_dump:
+only data is exported, without schema definitions or constraints.`,
+	Example: `  pgxport dump --schema public -o out/
+  pgxport dump --schema sales -o out/ -f json -z gzip`,
+	Args:          cobra.NoArgs,
+	PreRunE:       func(cmd *cobra.Command, args []string) error { return validateDumpParams() },
+	RunE:          runDump,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	dumpCmd.Flags().SortFlags = false
+	addConnectionFlags(dumpCmd.Flags())
+	dumpCmd.Flags().StringVar(&dumpSchema, "schema", "public", "Schema whose tables are exported (case-sensitive)")
+	dumpCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output directory, created if missing (required)")
+	dumpCmd.Flags().StringVarP(&format, "format", "f", "csv", "Output format of every table (template is not supported)")
+	dumpCmd.Flags().StringVarP(&compression, "compression", "z", "none", "Compression to apply to each output file (none, gzip, zip, zstd, lz4)")
+	dumpCmd.Flags().StringVar(&columnOrder, "column-order", "natural", "Order of the output columns (natural: table order, alpha, pk-first: primary key first)")
+	dumpCmd.Flags().StringArrayVar(&sessionParams, "session-param", nil, "Session parameter set before the export as name=value, e.g. work_mem=256MB (repeatable)")
+
+	if err := dumpCmd.MarkFlagRequired("output"); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateDumpParams checks the dump flags before connecting.
+func validateDumpParams() error {
+	if dumpSchema == "" {
+		return fmt.Errorf("error: --schema cannot be empty")
+	}
+	if _, err := parseSessionParams(sessionParams); err != nil {
+		return fmt.Errorf("error: Invalid --session-param: %v", err)
+	}
+	if err := validateFormatAndCompression(); err != nil {
+		return err
+	}
+	if err := validateColumnOrder(); err != nil {
+		return err
+	}
+	if format == "template" {
+		return fmt.Errorf("error: the template format is not supported by dump")
+	}
+	if info, err := os.Stat(outputPath); err == nil && !info.IsDir() {
+		return fmt.Errorf("error: --output must be a directory when using dump")
+	}
+	return nil
+}
+
+func runDump(cmd *cobra.Command, args []string) error {
+	dbUrl, err := connectionURL()
+	if err != nil {
+		return err
+	}
+
+	options, err := buildExportOptions(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	store, err := newPgStore(dbUrl)
+	if err != nil {
+		return err
+	}
+	if err := store.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer store.Close()
+
+	tables, err := store.Tables(cmd.Context(), dumpSchema)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := dumpJobs(tables, outputPath, format)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no tables found in schema %q (names are case-sensitive)", dumpSchema)
+	}
+
+	return runGlobExports(store, jobs, options, "tables")
+}
+
+// dumpFileName keeps a table name containing a path separator inside the
+// output directory.
+var dumpFileName = strings.NewReplacer("/", "_", `\`, "_")
+
+// dumpJobs builds one SELECT * job per base table, writing <outputDir>/<table>.<ext>.
+// The job table is the schema-qualified name, used as the INSERT target by the
+// sql format, and the job relation its quoted form.
+func dumpJobs(tables []db.Table, outputDir, format string) ([]queryJob, error) {
+	ext, ok := formatExtensions[format]
+	if !ok {
+		ext = "." + format
+	}
+
+	var jobs []queryJob
+	for _, t := range tables {
+		if t.Type != "table" {
+			logger.Debug("Skipping %s %s.%s", t.Type, t.Schema, t.Name)
+			continue
+		}
+		jobs = append(jobs, queryJob{
+			source:     t.Schema + "." + t.Name,
+			query:      "SELECT * FROM " + pgx.Identifier{t.Schema, t.Name}.Sanitize(),
+			outputPath: filepath.Join(outputDir, dumpFileName.Replace(t.Name)+ext),
+			table:      t.Schema + "." + t.Name,
+			relation:   pgx.Identifier{t.Schema, t.Name}.Sanitize(),
+		})
+	}
+
+	if len(jobs) > 0 {
+		if err := os.MkdirAll(outputDir, 0o755); err != nil {
+			return nil, fmt.Errorf("unable to create output directory: %w", err)
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func TestDumpJobs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	tables := []db.Table{
+		{Schema: "sales", Name: "orders", Type: "table"},
+		{Schema: "sales", Name: "big_orders", Type: "view"},
+		{Schema: "sales", Name: `Odd"Name`, Type: "table"},
+		{Schema: "sales", Name: "a/b", Type: "table"},
+	}
+
+	jobs, err := dumpJobs(tables, dir, "json")
+	if err != nil {
+		t.Fatalf("dumpJobs() error: %v", err)
+	}
+
+	want := []queryJob{
+		{source: "sales.orders", query: `SELECT * FROM "sales"."orders"`, outputPath: filepath.Join(dir, "orders.json"), table: "sales.orders", relation: `"sales"."orders"`},
+		{source: `sales.Odd"Name`, query: `SELECT * FROM "sales"."Odd""Name"`, outputPath: filepath.Join(dir, `Odd"Name.json`), table: `sales.Odd"Name`, relation: `"sales"."Odd""Name"`},
+		{source: "sales.a/b", query: `SELECT * FROM "sales"."a/b"`, outputPath: filepath.Join(dir, "a_b.json"), table: "sales.a/b", relation: `"sales"."a/b"`},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("dumpJobs() =\n%+v\nwant:\n%+v", jobs, want)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("output directory was not created: %v", err)
+	}
+}
+
+func TestValidateDumpParams(t *testing.T) {
+	originalSchema := dumpSchema
+	originalFormat := format
+	originalCompression := compression
+	originalOutputPath := outputPath
+	originalColumnOrder := columnOrder
+	defer func() {
+		dumpSchema = originalSchema
+		format = originalFormat
+		compression = originalCompression
+		outputPath = originalOutputPath
+		columnOrder = originalColumnOrder
+	}()
+
+	file := filepath.Join(t.TempDir(), "file.csv")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		setupFunc   func()
+		errContains string
+	}{
+		{
+			name:      "defaults",
+			setupFunc: func() {},
+		},
+		{
+			name: "format and compression are normalized",
+			setupFunc: func() {
+				format = " JSON "
+				compression = "GZIP"
+			},
+		},
+		{
+			name:        "empty schema",
+			setupFunc:   func() { dumpSchema = "" },
+			errContains: "--schema cannot be empty",
+		},
+		{
+			name:        "unknown format",
+			setupFunc:   func() { format = "parquet" },
+			errContains: "Invalid format 'parquet'",
+		},
+		{
+			name:        "unknown compression",
+			setupFunc:   func() { compression = "bzip2" },
+			errContains: "Invalid compression 'bzip2'",
+		},
+		{
+			name:        "template format",
+			setupFunc:   func() { format = "template" },
+			errContains: "template format is not supported by dump",
+		},
+		{
+			name:      "primary key first",
+			setupFunc: func() { columnOrder = " PK-First " },
+		},
+		{
+			name:        "unknown column order",
+			setupFunc:   func() { columnOrder = "reverse" },
+			errContains: "Invalid --column-order 'reverse'",
+		},
+		{
+			name:        "output is a file",
+			setupFunc:   func() { outputPath = file },
+			errContains: "--output must be a directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dumpSchema = "public"
+			format = "csv"
+			compression = "none"
+			outputPath = filepath.Join(t.TempDir(), "out")
+			columnOrder = "natural"
+			tt.setupFunc()
+
+			err := validateDumpParams()
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("validateDumpParams() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("validateDumpParams() error = %v, want it to contain %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+// Integration test, skipped if DB_TEST_URL is not set
+func TestDumpCommandIntegration(t *testing.T) {
+	testURL := os.Getenv("DB_TEST_URL")
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := db.NewPgStore(testURL)
+	if err := store.Connect(); err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if _, err := store.Conn().Exec(ctx, `
+		CREATE SCHEMA pgxport_dump;
+		CREATE TABLE pgxport_dump.customers (id integer, name text);
+		CREATE TABLE pgxport_dump.orders (id integer, customer_id integer);
+		CREATE VIEW pgxport_dump.customer_orders AS SELECT * FROM pgxport_dump.orders;
+		INSERT INTO pgxport_dump.customers VALUES (1, 'Alice'), (2, 'Bob');
+		INSERT INTO pgxport_dump.orders VALUES (10, 1)`); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	defer store.Conn().Exec(ctx, `DROP SCHEMA pgxport_dump CASCADE`)
+
+	originalConnString := connString
+	originalSchema := dumpSchema
+	originalFormat := format
+	originalCompression := compression
+	originalOutputPath := outputPath
+	defer func() {
+		connString = originalConnString
+		dumpSchema = originalSchema
+		format = originalFormat
+		compression = originalCompression
+		outputPath = originalOutputPath
+		rootCmd.SetArgs(nil)
+	}()
+
+	dir := filepath.Join(t.TempDir(), "out")
+	rootCmd.SetArgs([]string{"dump", "--dsn", testURL, "--schema", "pgxport_dump", "-o", dir, "-f", "csv"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read output directory: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"customers.csv", "orders.csv"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("output files = %v, want %v", names, want)
+	}
+
+	customers, err := os.ReadFile(filepath.Join(dir, "customers.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read customers.csv: %v", err)
+	}
+	if want := "id,name\n1,Alice\n2,Bob\n"; string(customers) != want {
+		t.Errorf("customers.csv = %q, want %q", customers, want)
+	}
+
+	// Compression applies to every file
+	gzDir := filepath.Join(t.TempDir(), "gz")
+	rootCmd.SetArgs([]string{"dump", "--dsn", testURL, "--schema", "pgxport_dump", "-o", gzDir, "-f", "csv", "-z", "gzip"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	for _, name := range []string{"customers.csv.gz", "orders.csv.gz"} {
+		content, err := os.ReadFile(filepath.Join(gzDir, name))
+		if err != nil {
+			t.Errorf("missing %s: %v", name, err)
+			continue
+		}
+		if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+			t.Errorf("%s is not gzip compressed", name)
+		}
+	}
+
+	// A schema without tables is an error
+	rootCmd.SetArgs([]string{"dump", "--dsn", testURL, "--schema", "PGXPORT_DUMP", "-o", t.TempDir()})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no tables found") {
+		t.Errorf("Execute() error = %v, want a no tables error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/validation"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// loadGlobJobs expands pattern and builds one job per SQL file, writing
+// reports/foo.sql to <outputDir>/foo.<ext>. Every query is validated before
+// anything is exported.
+func loadGlobJobs(pattern, outputDir, format string) ([]queryJob, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --sqlfile-glob pattern: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SQL files match pattern %q", pattern)
+	}
+	sort.Strings(files)
+
+	ext, ok := formatExtensions[format]
+	if !ok {
+		ext = "." + format
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create output directory: %w", err)
+	}
+
+	jobs := make([]queryJob, 0, len(files))
+	seen := make(map[string]string, len(files))
+	for _, file := range files {
+		base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		target := filepath.Join(outputDir, base+ext)
+		if prev, dup := seen[target]; dup {
+			return nil, fmt.Errorf("SQL files %s and %s would both be exported to %s", prev, file, target)
+		}
+		seen[target] = file
+
+		logger.Debug("Reading SQL from file: %s", file)
+		query, err := readSQLFromFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading SQL file %s: %w", file, err)
+		}
+		directives, err := parseSQLDirectives(query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if err := validation.ValidateQueryWithOptions(query, queryValidationOptions()); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		jobs = append(jobs, queryJob{source: file, query: query, outputPath: target, table: directives.table})
+	}
+
+	logger.Debug("Matched %d SQL files for pattern %q", len(jobs), pattern)
+	return jobs, nil
+}
+
+// runGlobExports exports every job over the same connection and reports a
+// per-job summary, counting jobs as unit (e.g. "SQL files"). A failing job
+// does not stop the remaining ones.
+func runGlobExports(store *db.PgStore, jobs []queryJob, options exporters.ExportOptions, unit string) error {
+	failed := 0
+	summary := make([]string, 0, len(jobs))
+
+	for _, job := range jobs {
+		logger.Info("Exporting %s -> %s", job.source, job.outputPath)
+		options.OutputPath = job.outputPath
+		target := finalOutputPath(options)
+
+		jobOptions, err := withJobTable(options, job)
+		if err == nil {
+			jobOptions, err = withPrimaryKey(store, jobOptions, job)
+		}
+		var rowCount int
+		started := time.Now()
+		if err == nil {
+			rowCount, err = runQueryExport(store, job.query, jobOptions)
+		}
+		duration := time.Since(started)
+		if err == nil {
+			err = handleExportResult(rowCount, target)
+		} else {
+			err = fmt.Errorf("export failed: %w", err)
+		}
+
+		if err != nil {
+			failed++
+			logger.Error("%s: %v", job.source, err)
+			summary = append(summary, fmt.Sprintf("  %s: FAILED (%v)", job.source, err))
+			continue
+		}
+		printSummary(newExportResult(jobOptions, rowCount, duration))
+		summary = append(summary, fmt.Sprintf("  %s -> %s: %d rows", job.source, target, rowCount))
+	}
+
+	logger.Info("Summary: %d/%d %s exported", len(jobs)-failed, len(jobs), unit)
+	for _, line := range summary {
+		logger.Info("%s", line)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("export failed for %d of %d %s", failed, len(jobs), unit)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGlobJobs(t *testing.T) {
+	writeSQL := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write SQL file: %v", err)
+		}
+	}
+
+	t.Run("one output per file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSQL(t, filepath.Join(tmpDir, "reports", "foo.sql"), "SELECT 1")
+		writeSQL(t, filepath.Join(tmpDir, "reports", "bar.sql"), "\xEF\xBB\xBFWITH t AS (SELECT 2) SELECT * FROM t")
+		outDir := filepath.Join(tmpDir, "out")
+
+		jobs, err := loadGlobJobs(filepath.Join(tmpDir, "reports", "*.sql"), outDir, "json")
+		if err != nil {
+			t.Fatalf("loadGlobJobs() unexpected error: %v", err)
+		}
+
+		expected := []string{
+			filepath.Join(outDir, "bar.json"),
+			filepath.Join(outDir, "foo.json"),
+		}
+		if len(jobs) != len(expected) {
+			t.Fatalf("loadGlobJobs() returned %d jobs, want %d", len(jobs), len(expected))
+		}
+		for i, job := range jobs {
+			if job.outputPath != expected[i] {
+				t.Errorf("job %d outputPath = %q, want %q", i, job.outputPath, expected[i])
+			}
+		}
+		if jobs[1].query != "SELECT 1" {
+			t.Errorf("job query = %q, want %q", jobs[1].query, "SELECT 1")
+		}
+
+		if info, err := os.Stat(outDir); err != nil || !info.IsDir() {
+			t.Errorf("loadGlobJobs() should create output directory %s", outDir)
+		}
+	})
+
+	t.Run("table directive per file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSQL(t, filepath.Join(tmpDir, "orders.sql"), "-- pgxport: table=sales.orders\nSELECT 1")
+		writeSQL(t, filepath.Join(tmpDir, "users.sql"), "SELECT 2")
+
+		jobs, err := loadGlobJobs(filepath.Join(tmpDir, "*.sql"), filepath.Join(tmpDir, "out"), "sql")
+		if err != nil {
+			t.Fatalf("loadGlobJobs() unexpected error: %v", err)
+		}
+		if jobs[0].table != "sales.orders" || jobs[1].table != "" {
+			t.Errorf("tables = %q, %q, want %q, %q", jobs[0].table, jobs[1].table, "sales.orders", "")
+		}
+	})
+
+	t.Run("template format uses txt extension", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSQL(t, filepath.Join(tmpDir, "daily.sql"), "SELECT 1")
+
+		jobs, err := loadGlobJobs(filepath.Join(tmpDir, "*.sql"), tmpDir, "template")
+		if err != nil {
+			t.Fatalf("loadGlobJobs() unexpected error: %v", err)
+		}
+		if want := filepath.Join(tmpDir, "daily.txt"); jobs[0].outputPath != want {
+			t.Errorf("outputPath = %q, want %q", jobs[0].outputPath, want)
+		}
+	})
+
+	t.Run("invalid query in one file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSQL(t, filepath.Join(tmpDir, "ok.sql"), "SELECT 1")
+		writeSQL(t, filepath.Join(tmpDir, "bad.sql"), "DELETE FROM users")
+
+		_, err := loadGlobJobs(filepath.Join(tmpDir, "*.sql"), filepath.Join(tmpDir, "out"), "csv")
+		if err == nil {
+			t.Fatal("loadGlobJobs() expected error for forbidden query, got nil")
+		}
+		if !strings.Contains(err.Error(), "bad.sql") {
+			t.Errorf("error %q should name the offending file", err.Error())
+		}
+	})
+
+	t.Run("no matching files", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		_, err := loadGlobJobs(filepath.Join(tmpDir, "*.sql"), tmpDir, "csv")
+		if err == nil || !strings.Contains(err.Error(), "no SQL files match") {
+			t.Errorf("loadGlobJobs() error = %v, want no match error", err)
+		}
+	})
+
+	t.Run("colliding output names", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeSQL(t, filepath.Join(tmpDir, "a", "report.sql"), "SELECT 1")
+		writeSQL(t, filepath.Join(tmpDir, "b", "report.sql"), "SELECT 2")
+
+		_, err := loadGlobJobs(filepath.Join(tmpDir, "*", "*.sql"), filepath.Join(tmpDir, "out"), "csv")
+		if err == nil || !strings.Contains(err.Error(), "would both be exported") {
+			t.Errorf("loadGlobJobs() error = %v, want collision error", err)
+		}
+	})
+}
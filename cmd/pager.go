@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"golang.org/x/term"
+)
+
+// stdoutPath is the --output value that writes the export to stdout.
+const stdoutPath = "-"
+
+// --pager modes for output written to stdout
+const (
+	pagerNever  = "never"  // write straight to stdout (default)
+	pagerAuto   = "auto"   // page when stdout is a terminal
+	pagerAlways = "always" // page even when stdout is redirected
+)
+
+// defaultPager is run when $PAGER is not set.
+const defaultPager = "less"
+
+// errPagerClosed is returned by writes made after the pager exited, e.g.
+// because the user quit it before the end of the output.
+var errPagerClosed = errors.New("the pager was closed")
+
+// stdoutIsTerminal reports whether stdout is a terminal. Tests replace it.
+var stdoutIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// validatePager normalizes and checks --pager.
+func validatePager() error {
+	pagerMode = strings.ToLower(strings.TrimSpace(pagerMode))
+	switch pagerMode {
+	case pagerNever:
+	case pagerAuto, pagerAlways:
+		if outputPath != stdoutPath {
+			return fmt.Errorf("error: --pager %s requires --output -", pagerMode)
+		}
+	default:
+		return fmt.Errorf("error: Invalid --pager '%s'. Valid options are: %s, %s, %s",
+			pagerMode, pagerNever, pagerAuto, pagerAlways)
+	}
+	return nil
+}
+
+// usePager reports whether output written to stdout goes through a pager.
+// Binary output, such as xlsx or a compressed stream, is never paged.
+func usePager(mode string, terminal bool, binary bool) bool {
+	if binary {
+		return false
+	}
+	switch mode {
+	case pagerAlways:
+		return true
+	case pagerAuto:
+		return terminal
+	default:
+		return false
+	}
+}
+
+// pagerCommand returns the command line of $PAGER, or less when it is unset.
+// An empty $PAGER, or "cat", disables paging and returns nil.
+func pagerCommand() []string {
+	value, ok := os.LookupEnv("PAGER")
+	if !ok {
+		return []string{defaultPager}
+	}
+	args := strings.Fields(value)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	return args
+}
+
+// openStdout returns the writer for --output -: stdout itself, or the input
+// of a pager started according to mode. Closing it leaves stdout open and
+// waits for the pager to exit.
+func openStdout(mode string, binary bool) (io.WriteCloser, error) {
+	stdout := nopCloser{os.Stdout}
+	if !usePager(mode, stdoutIsTerminal(), binary) {
+		return stdout, nil
+	}
+	args := pagerCommand()
+	if args == nil {
+		logger.Debug("Paging disabled by $PAGER")
+		return stdout, nil
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		logger.Debug("Pager %q not found, writing to stdout: %v", args[0], err)
+		return stdout, nil
+	}
+	return startPager(args)
+}
+
+// nopCloser is an io.WriteCloser whose Close does nothing.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// pager feeds the output to a running pager process.
+type pager struct {
+	cmd   *exec.Cmd
+	stdin *os.File
+	done  chan struct{} // closed when the pager exits
+	once  sync.Once
+}
+
+// startPager runs args with its output on the terminal and returns a writer
+// to its input. less is told to exit when the output fits on one screen and
+// to keep colors, unless $LESS already says otherwise.
+func startPager(args []string) (*pager, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	// An os.Pipe rather than StdinPipe, which Wait closes under a pending write
+	r, stdin, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("error starting pager: %w", err)
+	}
+	cmd.Stdin = r
+	err = cmd.Start()
+	r.Close()
+	if err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("error starting pager %q: %w", args[0], err)
+	}
+	logger.Debug("Paging output through %s", strings.Join(args, " "))
+
+	p := &pager{cmd: cmd, stdin: stdin, done: make(chan struct{})}
+	go func() {
+		// A pager quitting with an error status does not fail the export
+		_ = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Write sends data to the pager. A write fails once the pager has closed its
+// input, which it does when it exits: the error is then errPagerClosed, so
+// the export stops without reporting a write failure.
+func (p *pager) Write(data []byte) (int, error) {
+	n, err := p.stdin.Write(data)
+	if err != nil {
+		<-p.done
+		return n, errPagerClosed
+	}
+	return n, nil
+}
+
+// Close ends the pager input and waits for the user to quit the pager.
+func (p *pager) Close() error {
+	p.once.Do(func() {
+		p.stdin.Close()
+		<-p.done
+	})
+	return nil
+}
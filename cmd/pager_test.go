@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUsePager(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		terminal bool
+		binary   bool
+		want     bool
+	}{
+		{"never on a terminal", pagerNever, true, false, false},
+		{"auto on a terminal", pagerAuto, true, false, true},
+		{"auto skipped when not a terminal", pagerAuto, false, false, false},
+		{"always when not a terminal", pagerAlways, false, false, true},
+		{"binary output on a terminal", pagerAuto, true, true, false},
+		{"binary output always", pagerAlways, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usePager(tt.mode, tt.terminal, tt.binary); got != tt.want {
+				t.Errorf("usePager(%q, %v, %v) = %v, want %v", tt.mode, tt.terminal, tt.binary, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagerCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   bool
+		pager string
+		want  []string
+	}{
+		{name: "unset", want: []string{"less"}},
+		{name: "with arguments", set: true, pager: "less -S", want: []string{"less", "-S"}},
+		{name: "empty disables paging", set: true, pager: "", want: nil},
+		{name: "cat disables paging", set: true, pager: "cat", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAGER", tt.pager)
+			if !tt.set {
+				os.Unsetenv("PAGER")
+			}
+			got := pagerCommand()
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") || (got == nil) != (tt.want == nil) {
+				t.Errorf("pagerCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenStdoutSkipsPagerWhenNotTerminal(t *testing.T) {
+	original := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = original }()
+	stdoutIsTerminal = func() bool { return false }
+	t.Setenv("PAGER", "pager-that-must-not-run")
+
+	w, err := openStdout(pagerAuto, false)
+	if err != nil {
+		t.Fatalf("openStdout() error = %v", err)
+	}
+	defer w.Close()
+	if _, ok := w.(*pager); ok {
+		t.Fatal("openStdout() started a pager although stdout is not a terminal")
+	}
+}
+
+func TestPager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test pagers are shell commands")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh unavailable")
+	}
+
+	t.Run("reads the whole output", func(t *testing.T) {
+		out := t.TempDir() + "/paged.txt"
+		p, err := startPager([]string{"sh", "-c", "cat > " + out})
+		if err != nil {
+			t.Fatalf("startPager() error = %v", err)
+		}
+		if _, err := p.Write([]byte("id,name\n1,alice\n")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "id,name\n1,alice\n" {
+			t.Errorf("pager received %q", data)
+		}
+	})
+
+	t.Run("quit before the end", func(t *testing.T) {
+		p, err := startPager([]string{"sh", "-c", "exit 0"})
+		if err != nil {
+			t.Fatalf("startPager() error = %v", err)
+		}
+		defer p.Close()
+
+		chunk := bytes.Repeat([]byte("x"), 64*1024)
+		for i := 0; i < 100; i++ {
+			if _, err = p.Write(chunk); err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, errPagerClosed) {
+			t.Errorf("Write() after the pager exited: error = %v, want errPagerClosed", err)
+		}
+	})
+}
+
+func TestStdoutOutputSubprocess(t *testing.T) {
+	testURL := os.Getenv("DB_TEST_URL")
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	// Stdout is a pipe, so --pager auto writes straight to it
+	args := []string{"-s", "SELECT 1 AS n", "-o", "-", "--pager", "auto", "--dsn", testURL}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExitCodeSubprocess$")
+	cmd.Env = append(os.Environ(), "PGXPORT_EXIT_TEST_ARGS="+strings.Join(args, "\x1f"), "PAGER=pager-that-must-not-run")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, stderr.String())
+	}
+	if string(stdout) != "n\n1\n" {
+		t.Errorf("stdout = %q, want only the CSV output", stdout)
+	}
+	if !strings.Contains(stderr.String(), "Export completed: 1 rows -> -") {
+		t.Errorf("stderr = %q, want the completion message", stderr.String())
+	}
+}
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/core/validation"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+)
+
+// serveTokenEnv holds the serve token, so it does not show up in process lists.
+const serveTokenEnv = "PGXPORT_SERVE_TOKEN"
+
+// maxExportRequestBytes bounds the JSON body of POST /export.
+const maxExportRequestBytes = 1 << 20
+
+var (
+	serveAddr  string
+	serveToken string
+	serveAllow []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve exports over HTTP",
+	Long: `Run an HTTP server that exports query results on request. POST /export
+takes a JSON body with the query, format and options, and streams the
+exported file back as an attachment.
+
+Every request opens its own database connection with the connection flags
+and runs its query in a read-only transaction. Queries are checked like on
+the command line (read-only, no user-defined functions in FROM clauses).
+Use --session-param role=<role> to export as a restricted role. Protect the
+server with a bearer token
+(--token or ` + serveTokenEnv + `) and/or a client address allowlist (--allow-ip).`,
+	Example: `  PGXPORT_SERVE_TOKEN=secret pgxport serve --addr 127.0.0.1:8080
+  curl -H "Authorization: Bearer secret" -d '{"query":"SELECT * FROM users","format":"json"}' \
+    http://127.0.0.1:8080/export -o users.json`,
+	Args:          cobra.NoArgs,
+	PreRunE:       func(cmd *cobra.Command, args []string) error { return validateServeParams() },
+	RunE:          runServe,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	serveCmd.Flags().SortFlags = false
+	addConnectionFlags(serveCmd.Flags())
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address the HTTP server listens on (host:port)")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token required in the Authorization header (default $"+serveTokenEnv+")")
+	serveCmd.Flags().StringSliceVar(&serveAllow, "allow-ip", nil, "Client IPs or CIDR ranges allowed to export, e.g. 10.0.0.0/8 (comma-separated or repeatable; default any)")
+	serveCmd.Flags().StringArrayVar(&sessionParams, "session-param", nil, "Session parameter set on every connection as name=value, e.g. statement_timeout=60s (repeatable)")
+}
+
+// validateServeParams checks the serve flags before listening.
+func validateServeParams() error {
+	if _, _, err := net.SplitHostPort(serveAddr); err != nil {
+		return fmt.Errorf("error: Invalid --addr '%s': %v", serveAddr, err)
+	}
+	if _, err := parseAllowList(serveAllow); err != nil {
+		return fmt.Errorf("error: Invalid --allow-ip: %v", err)
+	}
+	if _, err := parseSessionParams(sessionParams); err != nil {
+		return fmt.Errorf("error: Invalid --session-param: %v", err)
+	}
+	return nil
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	dbUrl, err := connectionURL()
+	if err != nil {
+		return err
+	}
+
+	token := serveToken
+	if token == "" {
+		token = os.Getenv(serveTokenEnv)
+	}
+	allowed, err := parseAllowList(serveAllow)
+	if err != nil {
+		return err
+	}
+	if token == "" && len(allowed) == 0 {
+		logger.Warn("No --token or --allow-ip set: anyone who can reach %s can export data", serveAddr)
+	}
+
+	srv := &http.Server{
+		Addr: serveAddr,
+		Handler: newServeMux(&exportServer{
+			token:   token,
+			allowed: allowed,
+			query: func(ctx context.Context, query string) (pgx.Rows, func(), error) {
+				return queryOnNewConnection(ctx, dbUrl, query)
+			},
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	logger.Info("Serving exports on http://%s/export", serveAddr)
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down, waiting for running exports...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// queryOnNewConnection connects to the database and runs query in a
+// read-only transaction, so a function the query validation missed still
+// cannot modify data. Connecting gives up when ctx is done, e.g. when the
+// client disconnects. release closes the rows and the connection, which
+// rolls the transaction back.
+func queryOnNewConnection(ctx context.Context, dbUrl, query string) (pgx.Rows, func(), error) {
+	store, err := newPgStore(dbUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := store.ConnectContext(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := store.BeginReadOnly(ctx); err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	fracDigits := moneyFractionDigits(ctx, store)
+	rows, err := store.Query(ctx, query)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	return exporters.ExactMoney(rows, fracDigits), func() {
+		rows.Close()
+		store.Close()
+	}, nil
+}
+
+// parseAllowList parses --allow-ip entries, single addresses or CIDR ranges.
+func parseAllowList(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// newServeMux routes POST /export to s.
+func newServeMux(s *exportServer) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/export", s)
+	return mux
+}
+
+// exportServer handles POST /export.
+type exportServer struct {
+	token   string         // required bearer token ("" = none)
+	allowed []netip.Prefix // client addresses allowed (nil = any)
+	// query runs a validated query; release frees the rows and their connection
+	query func(ctx context.Context, query string) (rows pgx.Rows, release func(), err error)
+}
+
+// exportRequest is the JSON body of POST /export. The fields follow the
+// flags and job file keys of the same name.
+type exportRequest struct {
+	Query       string `json:"query"`
+	Format      string `json:"format"`      // default csv
+	Compression string `json:"compression"` // default none
+	Table       string `json:"table"`       // SQL format
+	TimeFormat  string `json:"time_format"`
+	TimeZone    string `json:"time_zone"`
+	Delimiter   string `json:"delimiter"` // CSV, a single character as for --delimiter
+	NoHeader    bool   `json:"no_header"`
+	XmlRootTag  string `json:"xml_root_tag"`
+	XmlRowTag   string `json:"xml_row_tag"`
+	Compact     bool   `json:"compact"`
+}
+
+func (s *exportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.clientAllowed(r.RemoteAddr) {
+		logger.Warn("Export refused for client %s", r.RemoteAddr)
+		httpError(w, http.StatusForbidden, "client address not allowed")
+		return
+	}
+	if !s.authorized(r.Header.Get("Authorization")) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pgxport"`)
+		httpError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+
+	var req exportRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxExportRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	options, err := req.exportOptions()
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	options.Context = r.Context()
+	options.SourceQuery = req.Query
+
+	start := time.Now()
+	rows, release, err := s.query(r.Context(), req.Query)
+	if err != nil {
+		logger.Error("Export for %s failed: %v", r.RemoteAddr, err)
+		httpError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer release()
+
+	// Headers go out with the first byte, so later errors cannot change the status
+	w.Header().Set("Content-Type", downloadContentType(options.Format, options.Compression))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFileName(options)))
+
+	rowCount, err := exporters.ExportTo(w, rows, options)
+	if err != nil {
+		logger.Error("Export for %s failed after %d rows: %v", r.RemoteAddr, rowCount, err)
+		// Drop the connection so the client sees a truncated response, not a complete file
+		panic(http.ErrAbortHandler)
+	}
+	logger.Info("Exported %d rows as %s for %s in %v", rowCount, options.Format, r.RemoteAddr, time.Since(start))
+}
+
+// clientAllowed reports whether remoteAddr (host:port) is in the allowlist.
+func (s *exportServer) clientAllowed(remoteAddr string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range s.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized reports whether header carries the bearer token, compared in
+// constant time.
+func (s *exportServer) authorized(header string) bool {
+	if s.token == "" {
+		return true
+	}
+	given, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// exportOptions validates the request and turns it into exporter options.
+func (req *exportRequest) exportOptions() (exporters.ExportOptions, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return exporters.ExportOptions{}, fmt.Errorf("query is required")
+	}
+	opts := validation.QueryOptions{CheckFunction: validation.RejectUserFunctions}
+	if err := validation.ValidateQueryWithOptions(req.Query, opts); err != nil {
+		return exporters.ExportOptions{}, err
+	}
+
+	options := exporters.ExportOptions{
+		Format:          strings.ToLower(strings.TrimSpace(req.Format)),
+		Compression:     strings.ToLower(strings.TrimSpace(req.Compression)),
+		TableName:       req.Table,
+		TimeFormat:      req.TimeFormat,
+		TimeZone:        req.TimeZone,
+		Delimiter:       ',',
+		NoHeader:        req.NoHeader,
+		XmlRootElement:  req.XmlRootTag,
+		XmlRowElement:   req.XmlRowTag,
+		Compact:         req.Compact,
+		RowPerStatement: 1,
+		TruncateMarker:  defaultTruncateMarker,
+	}
+	if options.Format == "" {
+		options.Format = exporters.FormatCSV
+	}
+	if options.Compression == "" {
+		options.Compression = output.None
+	}
+	if options.TimeFormat == "" {
+		options.TimeFormat = defaultTimeFormat
+	}
+	if options.XmlRootElement == "" {
+		options.XmlRootElement = "results"
+	}
+	if options.XmlRowElement == "" {
+		options.XmlRowElement = "row"
+	}
+
+	if _, err := exporters.Get(options.Format); err != nil {
+		return options, err
+	}
+	if options.Format == exporters.FormatTemplate {
+		return options, fmt.Errorf("format template is not supported by the server")
+	}
+	switch options.Compression {
+	case output.None, output.GZIP, output.ZIP, output.ZSTD, output.LZ4:
+	default:
+		return options, fmt.Errorf("invalid compression %q, valid options are: none, gzip, zip, zstd, lz4", req.Compression)
+	}
+	if options.Format == exporters.FormatSQL {
+		if options.TableName == "" {
+			return options, fmt.Errorf("table is required for format sql")
+		}
+		if err := validateTableName(options.TableName); err != nil {
+			return options, err
+		}
+	}
+	if err := validation.ValidateTimeFormat(options.TimeFormat); err != nil {
+		return options, fmt.Errorf("invalid time_format %q", req.TimeFormat)
+	}
+	if options.TimeZone != "" {
+		if err := validation.ValidateTimeZone(options.TimeZone); err != nil {
+			return options, fmt.Errorf("invalid time_zone %q", req.TimeZone)
+		}
+	}
+	if req.Delimiter != "" {
+		if options.Format != exporters.FormatCSV {
+			return options, fmt.Errorf("delimiter requires format csv")
+		}
+		// The same values as --delimiter, limited to a single character
+		sep, err := parseDelimiter(req.Delimiter)
+		if err != nil {
+			return options, fmt.Errorf("invalid delimiter: %w", err)
+		}
+		if utf8.RuneCountInString(sep) != 1 {
+			return options, fmt.Errorf("delimiter must be a single character")
+		}
+		options.Delimiter, _ = utf8.DecodeRuneInString(sep)
+	}
+	if options.Format == exporters.FormatXML {
+		for _, tag := range []struct{ field, name string }{{"xml_root_tag", options.XmlRootElement}, {"xml_row_tag", options.XmlRowElement}} {
+			if !exporters.ValidXMLName(tag.name) {
+				return options, fmt.Errorf("invalid %s %q: not a valid XML element name", tag.field, tag.name)
+			}
+		}
+	}
+	if options.Compact && options.Format != exporters.FormatJSON && options.Format != exporters.FormatXML && options.Format != exporters.FormatYAML {
+		return options, fmt.Errorf("compact is only supported for json, xml and yaml formats")
+	}
+	return options, nil
+}
+
+// exportFileName returns the download name of an export, e.g. export.csv.gz.
+func exportFileName(options exporters.ExportOptions) string {
+	ext, ok := formatExtensions[options.Format]
+	if !ok {
+		ext = "." + options.Format
+	}
+	return output.FinalPath(output.OutputConfig{
+		Path:        "export" + ext,
+		Compression: options.Compression,
+		Format:      options.Format,
+	})
+}
+
+// downloadContentType returns the Content-Type of an export sent as a file.
+// gzip and zstd output gets the type of a gzip or zstd file rather than a
+// Content-Encoding, which clients may undo transparently and then save plain
+// data under the export.csv.gz name of the Content-Disposition.
+func downloadContentType(format, compression string) string {
+	contentType, contentEncoding := output.ContentType(format, compression)
+	switch contentEncoding {
+	case "gzip":
+		return "application/gzip"
+	case "zstd":
+		return "application/zstd"
+	}
+	return contentType
+}
+
+// httpError writes message as a JSON error body with status.
+func httpError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// serveRows is an in-memory pgx.Rows of (id int4, name text) rows.
+type serveRows struct {
+	data   [][]any
+	pos    int
+	err    error // returned by Err once the rows are read
+	closed bool
+}
+
+func newServeRows(data ...[]any) *serveRows {
+	return &serveRows{data: data, pos: -1}
+}
+
+func (r *serveRows) Close()                        { r.closed = true }
+func (r *serveRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *serveRows) RawValues() [][]byte           { return nil }
+func (r *serveRows) Conn() *pgx.Conn               { return nil }
+func (r *serveRows) Values() ([]any, error)        { return r.data[r.pos], nil }
+
+func (r *serveRows) Err() error {
+	if r.pos+1 >= len(r.data) {
+		return r.err
+	}
+	return nil
+}
+
+func (r *serveRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{
+		{Name: "id", DataTypeOID: pgtype.Int4OID},
+		{Name: "name", DataTypeOID: pgtype.TextOID},
+	}
+}
+
+func (r *serveRows) Next() bool {
+	if r.closed || r.pos+1 >= len(r.data) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// Scan round-trips each value through its binary wire format.
+func (r *serveRows) Scan(dest ...any) error {
+	m := pgtype.NewMap()
+	fields := r.FieldDescriptions()
+	for i, d := range dest {
+		buf, err := m.Encode(fields[i].DataTypeOID, pgtype.BinaryFormatCode, r.data[r.pos][i], nil)
+		if err != nil {
+			return err
+		}
+		if err := m.Scan(fields[i].DataTypeOID, pgtype.BinaryFormatCode, buf, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeExportServer returns a server answering every query with rows, and a
+// pointer to the last query it ran.
+func fakeExportServer(rows *serveRows) (*exportServer, *string) {
+	var ran string
+	return &exportServer{
+		token: "secret",
+		query: func(ctx context.Context, query string) (pgx.Rows, func(), error) {
+			ran = query
+			return rows, rows.Close, nil
+		},
+	}, &ran
+}
+
+func TestExportServerRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		remoteAddr string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "client not allowed", remoteAddr: "203.0.113.9:4000", body: `{"query":"SELECT 1"}`, wantStatus: http.StatusForbidden},
+		{name: "missing token", token: "-", body: `{"query":"SELECT 1"}`, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "guess", body: `{"query":"SELECT 1"}`, wantStatus: http.StatusUnauthorized},
+		{name: "get", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid json", body: `{"query":`, wantStatus: http.StatusBadRequest, wantBody: "invalid request body"},
+		{name: "unknown field", body: `{"query":"SELECT 1","sql":"x"}`, wantStatus: http.StatusBadRequest, wantBody: "unknown field"},
+		{name: "missing query", body: `{"format":"csv"}`, wantStatus: http.StatusBadRequest, wantBody: "query is required"},
+		{name: "unsafe query", body: `{"query":"DELETE FROM users"}`, wantStatus: http.StatusBadRequest, wantBody: "DELETE"},
+		{name: "user function", body: `{"query":"SELECT * FROM my_report()"}`, wantStatus: http.StatusBadRequest, wantBody: "my_report"},
+		{name: "unknown format", body: `{"query":"SELECT 1","format":"parquet"}`, wantStatus: http.StatusBadRequest, wantBody: "parquet"},
+		{name: "template", body: `{"query":"SELECT 1","format":"template"}`, wantStatus: http.StatusBadRequest, wantBody: "template is not supported"},
+		{name: "invalid compression", body: `{"query":"SELECT 1","compression":"rar"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid compression"},
+		{name: "sql without table", body: `{"query":"SELECT 1","format":"sql"}`, wantStatus: http.StatusBadRequest, wantBody: "table is required"},
+		{name: "long delimiter", body: `{"query":"SELECT 1","delimiter":"||"}`, wantStatus: http.StatusBadRequest, wantBody: "single character"},
+		{name: "double quote delimiter", body: `{"query":"SELECT 1","delimiter":"\""}`, wantStatus: http.StatusBadRequest, wantBody: "double quote"},
+		{name: "escaped double quote delimiter", body: `{"query":"SELECT 1","delimiter":"\\x22"}`, wantStatus: http.StatusBadRequest, wantBody: "double quote"},
+		{name: "line feed delimiter", body: `{"query":"SELECT 1","delimiter":"\n"}`, wantStatus: http.StatusBadRequest, wantBody: "line break"},
+		{name: "carriage return delimiter", body: `{"query":"SELECT 1","delimiter":"\r"}`, wantStatus: http.StatusBadRequest, wantBody: "line break"},
+		{name: "invalid xml root tag", body: `{"query":"SELECT 1","format":"xml","xml_root_tag":"my results"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid xml_root_tag"},
+		{name: "invalid xml row tag", body: `{"query":"SELECT 1","format":"xml","xml_row_tag":"1row"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid xml_row_tag"},
+		{name: "compact csv", body: `{"query":"SELECT 1","compact":true}`, wantStatus: http.StatusBadRequest, wantBody: "compact"},
+		{name: "invalid time zone", body: `{"query":"SELECT 1","time_zone":"Mars/Base"}`, wantStatus: http.StatusBadRequest, wantBody: "time_zone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, ran := fakeExportServer(newServeRows())
+			srv.allowed, _ = parseAllowList([]string{"192.0.2.0/24"})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/export", strings.NewReader(tt.body))
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			switch tt.token {
+			case "":
+				req.Header.Set("Authorization", "Bearer secret")
+			case "-":
+			default:
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			newServeMux(srv).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if *ran != "" {
+				t.Errorf("query %q should not run", *ran)
+			}
+		})
+	}
+}
+
+func TestExportServerStreams(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantType        string
+		gzipped         bool
+		wantDisposition string
+		wantContent     string
+	}{
+		{
+			name:            "csv",
+			body:            `{"query":"SELECT id, name FROM users"}`,
+			wantType:        "text/csv",
+			wantDisposition: `attachment; filename="export.csv"`,
+			wantContent:     "id,name\n1,alice\n2,bob\n",
+		},
+		{
+			name:            "csv options",
+			body:            `{"query":"SELECT id, name FROM users","delimiter":";","no_header":true}`,
+			wantType:        "text/csv",
+			wantDisposition: `attachment; filename="export.csv"`,
+			wantContent:     "1;alice\n2;bob\n",
+		},
+		{
+			name:            "named delimiter",
+			body:            `{"query":"SELECT id, name FROM users","delimiter":"tab"}`,
+			wantType:        "text/csv",
+			wantDisposition: `attachment; filename="export.csv"`,
+			wantContent:     "id\tname\n1\talice\n2\tbob\n",
+		},
+		{
+			name:            "compact json",
+			body:            `{"query":"SELECT id, name FROM users","format":"JSON","compact":true}`,
+			wantType:        "application/json",
+			wantDisposition: `attachment; filename="export.json"`,
+			wantContent:     "[\n{\"id\":1,\"name\":\"alice\"},\n{\"id\":2,\"name\":\"bob\"}\n]\n",
+		},
+		{
+			name:            "gzip yaml",
+			body:            `{"query":"SELECT id, name FROM users","format":"yaml","compression":"gzip"}`,
+			wantType:        "application/gzip",
+			gzipped:         true,
+			wantDisposition: `attachment; filename="export.yaml.gz"`,
+			wantContent:     "- id: 1\n  name: alice\n- id: 2\n  name: bob\n",
+		},
+		{
+			name:            "sql",
+			body:            `{"query":"SELECT id, name FROM users","format":"sql","table":"users"}`,
+			wantType:        "application/sql",
+			wantDisposition: `attachment; filename="export.sql"`,
+			wantContent:     "INSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\nINSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(2, 'bob');\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newServeRows([]any{int32(1), "alice"}, []any{int32(2), "bob"})
+			srv, ran := fakeExportServer(rows)
+
+			req := httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			newServeMux(srv).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.wantDisposition)
+			}
+
+			var body io.Reader = rec.Body
+			if tt.gzipped {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("response is not gzip: %v", err)
+				}
+				body = gz
+			}
+			content, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("Failed to read response: %v", err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			if *ran != "SELECT id, name FROM users" {
+				t.Errorf("query = %q", *ran)
+			}
+			if !rows.closed {
+				t.Error("rows should be released after the export")
+			}
+		})
+	}
+}
+
+func TestDownloadContentType(t *testing.T) {
+	tests := []struct {
+		format      string
+		compression string
+		want        string
+	}{
+		{"csv", "none", "text/csv"},
+		{"json", "gzip", "application/gzip"},
+		{"xml", "zstd", "application/zstd"},
+		{"csv", "zip", "application/zip"},
+		{"yaml", "lz4", "application/x-lz4"},
+	}
+
+	for _, tt := range tests {
+		if got := downloadContentType(tt.format, tt.compression); got != tt.want {
+			t.Errorf("downloadContentType(%q, %q) = %q, want %q", tt.format, tt.compression, got, tt.want)
+		}
+	}
+}
+
+func TestExportServerQueryError(t *testing.T) {
+	srv := &exportServer{
+		query: func(ctx context.Context, query string) (pgx.Rows, func(), error) {
+			return nil, nil, errors.New(`relation "users" does not exist`)
+		},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(`{"query":"SELECT * FROM users"}`))
+	rec := httptest.NewRecorder()
+	newServeMux(srv).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	if !strings.Contains(rec.Body.String(), "does not exist") {
+		t.Errorf("body = %s, want the query error", rec.Body.String())
+	}
+}
+
+func TestExportServerAbortsFailedExport(t *testing.T) {
+	rows := newServeRows([]any{int32(1), "alice"})
+	rows.err = errors.New("connection reset")
+	srv, _ := fakeExportServer(rows)
+	ts := httptest.NewServer(newServeMux(srv))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/export", strings.NewReader(`{"query":"SELECT id, name FROM users","format":"json"}`))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		return // the connection was dropped before the headers arrived
+	}
+	defer resp.Body.Close()
+
+	// The response must not read as a complete file
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Error("reading a failed export should fail")
+	}
+}
+
+func TestQueryOnNewConnectionCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 192.0.2.1 (TEST-NET-1) never answers: only the context can stop the connect
+	start := time.Now()
+	_, _, err := queryOnNewConnection(ctx, "postgres://reporter@192.0.2.1:5432/app?connect_timeout=30", "SELECT 1")
+	if err == nil {
+		t.Fatal("queryOnNewConnection() expected an error for a cancelled request")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("queryOnNewConnection() took %v, want it to stop with the request context", elapsed)
+	}
+}
+
+func TestParseAllowList(t *testing.T) {
+	allowed, err := parseAllowList([]string{"10.0.0.0/8", " 192.0.2.7 ", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("parseAllowList() error = %v", err)
+	}
+	srv := &exportServer{allowed: allowed}
+
+	tests := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{"10.1.2.3:5000", true},
+		{"192.0.2.7:5000", true},
+		{"192.0.2.8:5000", false},
+		{"[2001:db8::1]:5000", true},
+		{"[::ffff:10.0.0.1]:5000", true},
+		{"[2001:db9::1]:5000", false},
+		{"not-an-address", false},
+	}
+	for _, tt := range tests {
+		if got := srv.clientAllowed(tt.remoteAddr); got != tt.want {
+			t.Errorf("clientAllowed(%q) = %v, want %v", tt.remoteAddr, got, tt.want)
+		}
+	}
+
+	for _, entry := range []string{"10.0.0.0/33", "example.com", ""} {
+		if _, err := parseAllowList([]string{entry}); err == nil {
+			t.Errorf("parseAllowList(%q) should fail", entry)
+		}
+	}
+}
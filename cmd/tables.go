@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/spf13/cobra"
+)
+
+var tablesJSON bool
+
+var tablesCmd = &cobra.Command{
+	Use:   "tables [schema]",
+	Short: "List the tables and views that can be exported",
+	Long: `List the tables and views visible to the connected user, with their schema
+and type. Without a schema, every schema except the system ones is listed.
+The schema name is matched exactly, so it is case-sensitive.`,
+	Example: `  pgxport tables
+  pgxport tables sales --json`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE:          runTables,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	tablesCmd.Flags().SortFlags = false
+	tablesCmd.Flags().BoolVar(&tablesJSON, "json", false, "Print the tables as a JSON array of {schema, name, type} objects")
+	addConnectionFlags(tablesCmd.Flags())
+}
+
+func runTables(cmd *cobra.Command, args []string) error {
+	var schema string
+	if len(args) == 1 {
+		if schema = args[0]; schema == "" {
+			return fmt.Errorf("error: schema name cannot be empty")
+		}
+	}
+
+	dbUrl, err := connectionURL()
+	if err != nil {
+		return err
+	}
+
+	store := db.NewPgStore(dbUrl)
+	if err := store.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer store.Close()
+
+	tables, err := store.Tables(cmd.Context(), schema)
+	if err != nil {
+		return err
+	}
+
+	if tablesJSON {
+		return printTablesJSON(cmd.OutOrStdout(), tables)
+	}
+	return printTables(cmd.OutOrStdout(), tables)
+}
+
+// printTables writes tables as an aligned SCHEMA / NAME / TYPE table.
+func printTables(w io.Writer, tables []db.Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCHEMA\tNAME\tTYPE")
+	for _, t := range tables {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Schema, t.Name, t.Type)
+	}
+	return tw.Flush()
+}
+
+// printTablesJSON writes tables as an indented JSON array, [] when empty.
+func printTablesJSON(w io.Writer, tables []db.Table) error {
+	if tables == nil {
+		tables = []db.Table{}
+	}
+	out, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding tables: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func TestPrintTables(t *testing.T) {
+	tables := []db.Table{
+		{Schema: "public", Name: "users", Type: "table"},
+		{Schema: "sales", Name: "big_orders", Type: "view"},
+	}
+
+	tests := []struct {
+		name   string
+		print  func(*bytes.Buffer, []db.Table) error
+		tables []db.Table
+		want   string
+	}{
+		{
+			name:   "text",
+			print:  func(b *bytes.Buffer, t []db.Table) error { return printTables(b, t) },
+			tables: tables,
+			want: "SCHEMA  NAME        TYPE\n" +
+				"public  users       table\n" +
+				"sales   big_orders  view\n",
+		},
+		{
+			name:   "text without tables",
+			print:  func(b *bytes.Buffer, t []db.Table) error { return printTables(b, t) },
+			tables: nil,
+			want:   "SCHEMA  NAME  TYPE\n",
+		},
+		{
+			name:   "json",
+			print:  func(b *bytes.Buffer, t []db.Table) error { return printTablesJSON(b, t) },
+			tables: tables[:1],
+			want:   "[\n  {\n    \"schema\": \"public\",\n    \"name\": \"users\",\n    \"type\": \"table\"\n  }\n]\n",
+		},
+		{
+			name:   "json without tables",
+			print:  func(b *bytes.Buffer, t []db.Table) error { return printTablesJSON(b, t) },
+			tables: nil,
+			want:   "[]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.print(&buf, tt.tables); err != nil {
+				t.Fatalf("print error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output =\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTablesCommandArgs(t *testing.T) {
+	defer rootCmd.SetArgs(nil)
+
+	rootCmd.SetArgs([]string{"tables", "public", "sales"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "accepts at most 1 arg(s)") {
+		t.Errorf("Execute() error = %v, want an argument count error", err)
+	}
+
+	rootCmd.SetArgs([]string{"tables", ""})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "schema name cannot be empty") {
+		t.Errorf("Execute() error = %v, want an empty schema error", err)
+	}
+}
+
+// Integration test, skipped if DB_TEST_URL is not set
+func TestTablesCommandIntegration(t *testing.T) {
+	testURL := os.Getenv("DB_TEST_URL")
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := db.NewPgStore(testURL)
+	if err := store.Connect(); err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if _, err := store.Conn().Exec(ctx, `
+		CREATE SCHEMA pgxport_tables_cmd;
+		CREATE TABLE pgxport_tables_cmd.orders (id integer);
+		CREATE VIEW pgxport_tables_cmd.recent_orders AS SELECT * FROM pgxport_tables_cmd.orders`); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	defer store.Conn().Exec(ctx, `DROP SCHEMA pgxport_tables_cmd CASCADE`)
+
+	originalConnString := connString
+	originalTablesJSON := tablesJSON
+	defer func() {
+		connString = originalConnString
+		tablesJSON = originalTablesJSON
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+
+	run := func(args ...string) string {
+		t.Helper()
+		tablesJSON = false
+		var buf bytes.Buffer
+		rootCmd.SetOut(&buf)
+		rootCmd.SetArgs(append([]string{"tables", "--dsn", testURL}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute(%v) error: %v", args, err)
+		}
+		return buf.String()
+	}
+
+	want := "SCHEMA              NAME           TYPE\n" +
+		"pgxport_tables_cmd  orders         table\n" +
+		"pgxport_tables_cmd  recent_orders  view\n"
+	if got := run("pgxport_tables_cmd"); got != want {
+		t.Errorf("text output =\n%s\nwant:\n%s", got, want)
+	}
+
+	var tables []db.Table
+	if err := json.Unmarshal([]byte(run("pgxport_tables_cmd", "--json")), &tables); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	wantTables := []db.Table{
+		{Schema: "pgxport_tables_cmd", Name: "orders", Type: "table"},
+		{Schema: "pgxport_tables_cmd", Name: "recent_orders", Type: "view"},
+	}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Errorf("JSON output = %+v, want %+v", tables, wantTables)
+	}
+
+	// Another schema does not list the test tables
+	if out := run("information_schema"); strings.Contains(out, "pgxport_tables_cmd") {
+		t.Errorf("schema filter not applied:\n%s", out)
+	}
+}
@@ -0,0 +1,1029 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fbz-tec/pgxport/core/encoders"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/core/validation"
+)
+
+// validateExportParams validates all export parameters before execution.
+// Returns an error if any parameter is invalid or conflicting. The checks run
+// in order, so options normalized by one (e.g. --format) are seen by the next.
+func validateExportParams() error {
+	validators := []func() error{
+		validateLogFlags,
+		validateQuerySource,
+		validateSessionParams,
+		validateOutputTemplate,
+		validateSQLFileGlob,
+		validateExplain,
+		validateFormatAndCompression,
+		validateSQLFormat,
+		validateXMLFormat,
+		validateXLSXFormat,
+		validateOutputEncoding,
+		validateOutputLayout,
+		validateProgressAndTimeout,
+		validateFlushEvery,
+		validateCheckpoint,
+		validateNamedPipe,
+		validateRefcursors,
+		validateRowsPerFile,
+		validateWorkers,
+		validateTruncation,
+		validateValueRewrites,
+		validateCSVTrailer,
+		validateTemplateFormat,
+		validateCopyMode,
+		validateCSVDialect,
+		validateHeaderFile,
+		validateCSVQuoting,
+		validateJSONNumbers,
+		validateCSVTextColumns,
+		validateSkipWhere,
+		validateOnError,
+		validateEmptyFile,
+		validateStdout,
+		validatePager,
+		validateJSONShape,
+		validateCSVSpecialFloats,
+		validateColumnOptions,
+		validateTimeOptions,
+	}
+	for _, validate := range validators {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLogFlags checks that the logging modes are not combined.
+func validateLogFlags() error {
+	if verbose && quiet {
+		return fmt.Errorf("error: Cannot use --verbose and --quiet flags together")
+	}
+	if verbose && porcelain {
+		return fmt.Errorf("error: Cannot use --verbose and --porcelain flags together")
+	}
+	return nil
+}
+
+// validateQuerySource checks that exactly one query source is given, with
+// the --table-export options only when exporting a table.
+func validateQuerySource() error {
+	if sqlQuery == "" && sqlFile == "" && sqlFileGlob == "" && tableExport == "" {
+		return fmt.Errorf("error: Either --sql, --sqlfile, --sqlfile-glob or --table-export must be provided")
+	}
+
+	if tableExport != "" && (sqlQuery != "" || sqlFile != "" || sqlFileGlob != "") {
+		return fmt.Errorf("error: --table-export cannot be combined with --sql, --sqlfile or --sqlfile-glob")
+	}
+
+	if tableExport == "" && (whereClause != "" || orderBy != "" || limitRows != 0) {
+		return fmt.Errorf("error: --where, --order-by and --limit require --table-export")
+	}
+
+	if limitRows < 0 {
+		return fmt.Errorf("error: --limit cannot be negative")
+	}
+
+	if tableExport != "" {
+		if _, err := tableExportQuery(tableExport, whereClause, orderBy, limitRows); err != nil {
+			return fmt.Errorf("error: Invalid --table-export query: %v", err)
+		}
+	}
+
+	if sqlQuery != "" && sqlFile != "" {
+		return fmt.Errorf("error: Cannot use both --sql and --sqlfile at the same time")
+	}
+	return nil
+}
+
+// validateSessionParams checks the --session-param pairs.
+func validateSessionParams() error {
+	if _, err := parseSessionParams(sessionParams); err != nil {
+		return fmt.Errorf("error: Invalid --session-param: %v", err)
+	}
+	return nil
+}
+
+// validateOutputTemplate checks the placeholders of --output and the
+// --output-var values they use. They are expanded again at run time; this
+// catches mistakes before connecting.
+func validateOutputTemplate() error {
+	if outputPath != "" {
+		_, err := expandedOutputPath()
+		return err
+	}
+	if len(outputVars) > 0 {
+		return fmt.Errorf("error: --output-var requires --output")
+	}
+	return nil
+}
+
+// expandedOutputPath returns --output with its placeholders expanded the way
+// they are at run time.
+func expandedOutputPath() (string, error) {
+	vars, err := parseOutputVars(outputVars)
+	if err != nil {
+		return "", fmt.Errorf("error: Invalid --output-var: %v", err)
+	}
+	path, err := expandOutputPath(outputPath, time.Now(), vars)
+	if err != nil {
+		return "", fmt.Errorf("error: Invalid --output: %v", err)
+	}
+	return path, nil
+}
+
+// validateSQLFileGlob checks --sqlfile-glob and the output directory it
+// writes to.
+func validateSQLFileGlob() error {
+	if sqlFileGlob == "" {
+		return nil
+	}
+	if sqlQuery != "" || sqlFile != "" {
+		return fmt.Errorf("error: --sqlfile-glob cannot be combined with --sql or --sqlfile")
+	}
+	if _, err := filepath.Match(sqlFileGlob, ""); err != nil {
+		return fmt.Errorf("error: Invalid --sqlfile-glob pattern '%s'", sqlFileGlob)
+	}
+	outputDir := outputPath
+	if outputPath != "" {
+		outputDir, _ = expandedOutputPath() // validated by validateOutputTemplate
+	}
+	if info, err := os.Stat(outputDir); err == nil && !info.IsDir() {
+		return fmt.Errorf("error: --output must be a directory when using --sqlfile-glob")
+	}
+	if countFile != "" {
+		return fmt.Errorf("error: --count-file is not supported with --sqlfile-glob, use --porcelain to get one line per file")
+	}
+	return nil
+}
+
+// validateExplain checks the --allow-explain options.
+func validateExplain() error {
+	if allowAnalyze && !allowExplain {
+		return fmt.Errorf("error: --allow-explain-analyze requires --allow-explain")
+	}
+
+	if allowExplain && withCopy {
+		return fmt.Errorf("error: --allow-explain is not supported with --with-copy")
+	}
+	return nil
+}
+
+// validateFormatAndCompression normalizes --format and --compression and
+// checks them against the registered formats and supported compressions.
+func validateFormatAndCompression() error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	validFormats := exporters.List()
+
+	isValid := false
+	for _, f := range validFormats {
+		if format == f {
+			isValid = true
+			break
+		}
+	}
+
+	if !isValid {
+		if suggestion := closestFormat(format); suggestion != "" {
+			return fmt.Errorf("error: Invalid format '%s'. Did you mean '%s'? Valid formats are: %s",
+				format, suggestion, strings.Join(validFormats, ", "))
+		}
+		return fmt.Errorf("error: Invalid format '%s'. Valid formats are: %s",
+			format, strings.Join(validFormats, ", "))
+	}
+
+	compression = strings.ToLower(strings.TrimSpace(compression))
+	if compression == "" {
+		compression = "none"
+	}
+	validCompressions := []string{"none", "gzip", "zip", "zstd", "lz4"}
+	compressionValid := false
+	for _, c := range validCompressions {
+		if compression == c {
+			compressionValid = true
+			break
+		}
+	}
+
+	if !compressionValid {
+		return fmt.Errorf("error: Invalid compression '%s'. Valid options are: %s",
+			compression, strings.Join(validCompressions, ", "))
+	}
+	return nil
+}
+
+// validateSQLFormat checks the options of the SQL format.
+func validateSQLFormat() error {
+	// Validate table name for SQL format. A SQL file may name its table with a
+	// "-- pgxport: table=..." directive, checked once the file is read.
+	if format == "sql" && strings.TrimSpace(tableName) == "" && sqlFile == "" && sqlFileGlob == "" && tableExport == "" {
+		return fmt.Errorf("error: --table (-t) is required when using SQL format")
+	}
+
+	if format == "sql" && rowPerStatement < 1 {
+		return fmt.Errorf("error: --insert-batch must be at least 1")
+	}
+
+	if format == "sql" && maxStmtBytes < 0 {
+		return fmt.Errorf("error: --max-statement-bytes cannot be negative")
+	}
+
+	if sqlSourceCmt && format != "sql" {
+		return fmt.Errorf("error: --sql-source-comment requires --format sql")
+	}
+
+	if noQuoteIdents {
+		if format != "sql" {
+			return fmt.Errorf("error: --no-quote-identifiers requires --format sql")
+		}
+		if strings.TrimSpace(tableName) != "" {
+			if _, err := formatters.BareIdent(tableName); err != nil {
+				return fmt.Errorf("error: Invalid --table for --no-quote-identifiers: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateXMLFormat checks the options of the XML format and normalizes
+// --xml-null.
+func validateXMLFormat() error {
+	if len(xmlRootAttrs) > 0 || xmlRowCountAttr != "" {
+		if format != "xml" {
+			return fmt.Errorf("error: --xml-root-attr and --xml-row-count-attr require --format xml")
+		}
+		attrs, err := parseXMLAttrs(xmlRootAttrs)
+		if err != nil {
+			return fmt.Errorf("error: Invalid --xml-root-attr: %v", err)
+		}
+		if xmlRowCountAttr != "" {
+			if !xmlNamePattern.MatchString(xmlRowCountAttr) {
+				return fmt.Errorf("error: Invalid --xml-row-count-attr '%s'", xmlRowCountAttr)
+			}
+			for _, attr := range attrs {
+				if attr.Name.Local == xmlRowCountAttr {
+					return fmt.Errorf("error: --xml-row-count-attr '%s' is also set by --xml-root-attr", xmlRowCountAttr)
+				}
+			}
+			if compression != "none" {
+				return fmt.Errorf("error: --xml-row-count-attr requires --compression none")
+			}
+		}
+	}
+
+	if xmlSanitize && format != "xml" {
+		return fmt.Errorf("error: --xml-sanitize-names requires --format xml")
+	}
+	if format == "xml" && !xmlSanitize {
+		for _, tag := range []struct{ flag, name string }{{"--xml-root-tag", xmlRootElement}, {"--xml-row-tag", xmlRowElement}} {
+			if !exporters.ValidXMLName(tag.name) {
+				return fmt.Errorf("error: Invalid %s '%s': not a valid XML element name (use --xml-sanitize-names to replace invalid characters)", tag.flag, tag.name)
+			}
+		}
+	}
+
+	xmlNull = strings.ToLower(strings.TrimSpace(xmlNull))
+	switch xmlNull {
+	case exporters.XmlNullEmpty, exporters.XmlNullXsiNil, exporters.XmlNullOmit:
+	default:
+		return fmt.Errorf("error: Invalid --xml-null '%s'. Valid options are: %s, %s, %s",
+			xmlNull, exporters.XmlNullEmpty, exporters.XmlNullXsiNil, exporters.XmlNullOmit)
+	}
+	if xmlNull != exporters.XmlNullEmpty && format != "xml" {
+		return fmt.Errorf("error: --xml-null requires --format xml")
+	}
+	return nil
+}
+
+// validateXLSXFormat checks the options of the XLSX format.
+func validateXLSXFormat() error {
+	if len(xlsxFormats) > 0 {
+		if format != "xlsx" {
+			return fmt.Errorf("error: --xlsx-format requires --format xlsx")
+		}
+		if _, err := parseXLSXFormats(xlsxFormats); err != nil {
+			return fmt.Errorf("error: Invalid --xlsx-format: %v", err)
+		}
+	}
+
+	if xlsxTotals && format != "xlsx" {
+		return fmt.Errorf("error: --xlsx-totals requires --format xlsx")
+	}
+
+	if xlsxTotalsSheet && !xlsxTotals {
+		return fmt.Errorf("error: --xlsx-totals-per-sheet requires --xlsx-totals")
+	}
+
+	if xlsxSheetBy != "" && format != "xlsx" {
+		return fmt.Errorf("error: --xlsx-sheet-by requires --format xlsx")
+	}
+
+	if xlsxMaxRows < 0 {
+		return fmt.Errorf("error: --xlsx-max-rows cannot be negative")
+	}
+
+	if xlsxMaxRows > 0 && format != "xlsx" {
+		return fmt.Errorf("error: --xlsx-max-rows requires --format xlsx")
+	}
+	return nil
+}
+
+// validateOutputEncoding checks --output-encoding and normalizes
+// --output-encoding-errors.
+func validateOutputEncoding() error {
+	enc, err := output.NormalizeEncoding(outputEncoding)
+	if err != nil {
+		return fmt.Errorf("error: Invalid --output-encoding: %v", err)
+	}
+	if enc != output.UTF8 {
+		switch format {
+		case "csv", "tsv", "xml", "sql", "template":
+		default:
+			return fmt.Errorf("error: --output-encoding is only supported for csv, tsv, xml, sql and template formats")
+		}
+	}
+
+	encodingErrors = strings.ToLower(strings.TrimSpace(encodingErrors))
+	if encodingErrors != "replace" && encodingErrors != "error" {
+		return fmt.Errorf("error: Invalid --output-encoding-errors '%s'. Valid options are: replace, error", encodingErrors)
+	}
+	return nil
+}
+
+// validateOutputLayout checks the options that add to or reshape the
+// output document: --emit-schema, --compact and the generated comment.
+func validateOutputLayout() error {
+	if emitSchema && format != "json" && format != "xml" {
+		return fmt.Errorf("error: --emit-schema is only supported for json and xml formats")
+	}
+
+	if (compactOutput || !prettyOutput) && format != "json" && format != "xml" && format != "yaml" {
+		return fmt.Errorf("error: --compact is only supported for json, xml and yaml formats")
+	}
+
+	if genComment && format == "template" {
+		return fmt.Errorf("error: --include-generated-comment is not supported for format template")
+	}
+	return nil
+}
+
+// validateProgressAndTimeout checks --progress-total and --timeout-total.
+func validateProgressAndTimeout() error {
+	if progressTotal < 0 {
+		return fmt.Errorf("error: --progress-total cannot be negative")
+	}
+
+	if totalTimeout < 0 {
+		return fmt.Errorf("error: --timeout-total cannot be negative")
+	}
+	return nil
+}
+
+// validateFlushEvery checks --flush-every.
+func validateFlushEvery() error {
+	if flushEvery < 0 {
+		return fmt.Errorf("error: --flush-every cannot be negative")
+	}
+
+	if flushEvery > 0 && format != "csv" && format != "xml" {
+		return fmt.Errorf("error: --flush-every is only supported for csv and xml formats")
+	}
+
+	if flushEvery > 0 && withCopy {
+		return fmt.Errorf("error: --flush-every is not supported with --with-copy")
+	}
+	return nil
+}
+
+// validateCheckpoint checks --checkpoint-every and the --resume and
+// --allow-unordered options that need it.
+func validateCheckpoint() error {
+	if checkpointEvery < 0 {
+		return fmt.Errorf("error: --checkpoint-every cannot be negative")
+	}
+
+	if checkpointEvery > 0 {
+		if format != "csv" {
+			return fmt.Errorf("error: --checkpoint-every is only supported for csv format")
+		}
+		if compression != "none" {
+			return fmt.Errorf("error: --checkpoint-every requires --compression none")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --checkpoint-every is not supported with --with-copy")
+		}
+		if atomicOutput {
+			return fmt.Errorf("error: --checkpoint-every cannot be combined with --atomic")
+		}
+		if sqlFileGlob != "" {
+			return fmt.Errorf("error: --checkpoint-every is not supported with --sqlfile-glob")
+		}
+		if allowExplain {
+			return fmt.Errorf("error: --checkpoint-every is not supported with --allow-explain")
+		}
+	}
+
+	if resumeExport && checkpointEvery == 0 {
+		return fmt.Errorf("error: --resume requires --checkpoint-every")
+	}
+
+	if allowUnordered && checkpointEvery == 0 {
+		return fmt.Errorf("error: --allow-unordered requires --checkpoint-every")
+	}
+	return nil
+}
+
+// validateNamedPipe rejects the options that cannot write to a named pipe.
+func validateNamedPipe() error {
+	if output.IsFIFO(outputPath) {
+		// A pipe can be neither renamed into place nor truncated to resume
+		if atomicOutput {
+			return fmt.Errorf("error: --atomic is not supported when --output is a named pipe")
+		}
+		if checkpointEvery > 0 {
+			return fmt.Errorf("error: --checkpoint-every is not supported when --output is a named pipe")
+		}
+	}
+	return nil
+}
+
+// validateRefcursors checks the options that --refcursors supports.
+func validateRefcursors() error {
+	if refcursors {
+		if sqlQuery == "" && sqlFile == "" {
+			return fmt.Errorf("error: --refcursors requires --sql or --sqlfile")
+		}
+		if noFunctions || !allowFunctions {
+			return fmt.Errorf("error: --refcursors cannot be combined with --no-functions")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --refcursors is not supported with --with-copy")
+		}
+		if checkpointEvery > 0 {
+			return fmt.Errorf("error: --refcursors is not supported with --checkpoint-every")
+		}
+		if allowExplain {
+			return fmt.Errorf("error: --refcursors is not supported with --allow-explain")
+		}
+		if countFile != "" {
+			return fmt.Errorf("error: --count-file is not supported with --refcursors, use --porcelain to get one line per cursor")
+		}
+		if flattenJSON != "" && len(flattenKeys) == 0 {
+			return fmt.Errorf("error: --flatten-json with --refcursors requires --flatten-keys")
+		}
+	}
+	return nil
+}
+
+// validateRowsPerFile checks --rows-per-file and --index-file.
+func validateRowsPerFile() error {
+	if rowsPerFile < 0 {
+		return fmt.Errorf("error: --rows-per-file cannot be negative")
+	}
+
+	if indexFile != "" && rowsPerFile == 0 {
+		return fmt.Errorf("error: --index-file requires --rows-per-file")
+	}
+
+	if rowsPerFile > 0 {
+		if withCopy {
+			return fmt.Errorf("error: --rows-per-file is not supported with --with-copy")
+		}
+		if checkpointEvery > 0 {
+			return fmt.Errorf("error: --rows-per-file is not supported with --checkpoint-every")
+		}
+		if refcursors {
+			return fmt.Errorf("error: --rows-per-file is not supported with --refcursors")
+		}
+		if sqlFileGlob != "" {
+			return fmt.Errorf("error: --rows-per-file is not supported with --sqlfile-glob")
+		}
+	}
+	return nil
+}
+
+// validateWorkers checks --workers.
+func validateWorkers() error {
+	if workers < 1 {
+		return fmt.Errorf("error: --workers must be at least 1")
+	}
+
+	if workers > 1 && format != "csv" {
+		return fmt.Errorf("error: --workers is only supported for csv format")
+	}
+
+	if workers > 1 && withCopy {
+		return fmt.Errorf("error: --workers is not supported with --with-copy")
+	}
+	return nil
+}
+
+// validateTruncation checks --max-field-length and --truncate-marker.
+func validateTruncation() error {
+	if maxFieldLen < 0 {
+		return fmt.Errorf("error: --max-field-length cannot be negative")
+	}
+
+	if maxFieldLen > 0 {
+		if format != "csv" && format != "xlsx" && format != "json" {
+			return fmt.Errorf("error: --max-field-length is only supported for csv, xlsx and json formats")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --max-field-length is not supported with --with-copy")
+		}
+	}
+
+	if truncMarker != defaultTruncateMarker && maxFieldLen == 0 {
+		return fmt.Errorf("error: --truncate-marker requires --max-field-length")
+	}
+	return nil
+}
+
+// validateValueRewrites checks --trim-text and --coalesce, which change
+// values before they are formatted.
+func validateValueRewrites() error {
+	if trimText && withCopy {
+		return fmt.Errorf("error: --trim-text is not supported with --with-copy")
+	}
+
+	if len(coalesceCols) > 0 {
+		if withCopy {
+			return fmt.Errorf("error: --coalesce is not supported with --with-copy")
+		}
+		if _, err := parseCoalesce(coalesceCols); err != nil {
+			return fmt.Errorf("error: Invalid --coalesce: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateCSVTrailer checks the --csv-trailer options.
+func validateCSVTrailer() error {
+	if csvTrailerAll && !csvTrailer {
+		return fmt.Errorf("error: --csv-trailer-always requires --csv-trailer")
+	}
+
+	if csvTrailer && strings.TrimSpace(csvTrailerPfx) == "" {
+		return fmt.Errorf("error: --csv-trailer-prefix cannot be empty when --csv-trailer is set")
+	}
+	return nil
+}
+
+// validateTemplateFormat checks the options of the template format and
+// that its template files can be read.
+func validateTemplateFormat() error {
+	hasFull := templateFile != ""
+	hasStreaming := templateRow != "" || templateHeader != "" || templateFooter != ""
+
+	if tplMaxRows < 0 {
+		return fmt.Errorf("error: --tpl-max-rows cannot be negative")
+	}
+
+	if tplStrict && format != "template" {
+		return fmt.Errorf("error: --tpl-strict requires --format template")
+	}
+
+	if tplHTML && format != "template" {
+		return fmt.Errorf("error: --tpl-html requires --format template")
+	}
+
+	if tplMaxRows > 0 && !hasFull {
+		return fmt.Errorf("error: --tpl-max-rows only applies to full-mode templates (--tpl-file)")
+	}
+
+	if format != "template" && (hasFull || hasStreaming) {
+		return fmt.Errorf("error: --tpl-file, --tpl-header, --tpl-row and --tpl-footer require --format template")
+	}
+
+	if format == "template" {
+		if hasFull && hasStreaming {
+			return fmt.Errorf("error: Use either --tpl-file (full mode) OR --tpl-row (streaming mode), not both")
+		}
+		if hasStreaming && templateRow == "" {
+			return fmt.Errorf("error: Template streaming mode requires --tpl-row to be specified")
+		}
+		if !hasFull && !hasStreaming {
+			return fmt.Errorf("error: Template format requires either --tpl-file (full mode) OR --tpl-row (streaming mode)")
+		}
+		templateFlags := []struct{ flag, path string }{
+			{"--tpl-file", templateFile},
+			{"--tpl-header", templateHeader},
+			{"--tpl-row", templateRow},
+			{"--tpl-footer", templateFooter},
+		}
+		for _, tf := range templateFlags {
+			if tf.path == "" {
+				continue
+			}
+			info, err := os.Stat(tf.path)
+			if err != nil {
+				return fmt.Errorf("error: Cannot access %s file '%s': %v", tf.flag, tf.path, err)
+			}
+			if info.IsDir() {
+				return fmt.Errorf("error: %s must be a file, '%s' is a directory", tf.flag, tf.path)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCopyMode checks that the format supports --with-copy before
+// connecting.
+func validateCopyMode() error {
+	if withCopy {
+		exp, err := exporters.Get(format)
+		if err != nil {
+			return fmt.Errorf("error: %v", err)
+		}
+		if _, ok := exp.(exporters.CopyCapable); !ok {
+			return fmt.Errorf("error: --with-copy is not supported for format '%s'", format)
+		}
+	}
+	return nil
+}
+
+// validateCSVDialect checks the CSV delimiter, line terminator and quote,
+// including their MySQL-style aliases, which it maps onto --delimiter.
+func validateCSVDialect() error {
+	// Validate MySQL compatibility flags; they map onto the CSV options
+	if fieldsTerminatedBy != "" || linesTerminatedBy != "" || enclosedBy != "" {
+		if format != "csv" {
+			return fmt.Errorf("error: --fields-terminated-by, --lines-terminated-by and --enclosed-by apply to CSV format only")
+		}
+		if withCopy && (linesTerminatedBy != "" || enclosedBy != "") {
+			return fmt.Errorf("error: --lines-terminated-by and --enclosed-by are not supported with --with-copy")
+		}
+	}
+
+	if fieldsTerminatedBy != "" {
+		if delimiter != "," && delimiter != fieldsTerminatedBy {
+			return fmt.Errorf("error: Cannot use both --delimiter and --fields-terminated-by")
+		}
+		delimiter = fieldsTerminatedBy
+	}
+
+	// Validate CSV delimiter and quoting
+	if format == "csv" {
+		sep, err := parseDelimiter(delimiter)
+		if err != nil {
+			return fmt.Errorf("error: Invalid delimiter: %v", err)
+		}
+		if withCopy && utf8.RuneCountInString(sep) != 1 {
+			return fmt.Errorf("error: --with-copy requires a single-character delimiter, got %q", sep)
+		}
+		if withCopy {
+			r, _ := utf8.DecodeRuneInString(sep)
+			if err := exporters.ValidateCopyDelimiter(r); err != nil {
+				return fmt.Errorf("error: --with-copy: %v", err)
+			}
+		}
+		if linesTerminatedBy != "" {
+			terminator, err := parseLineTerminator(linesTerminatedBy)
+			if err != nil {
+				return fmt.Errorf("error: Invalid --lines-terminated-by: %v", err)
+			}
+			if strings.Contains(terminator, sep) || strings.Contains(sep, terminator) {
+				return fmt.Errorf("error: --lines-terminated-by cannot overlap with the delimiter")
+			}
+		}
+		if enclosedBy != "" {
+			quote, err := parseEnclosedBy(enclosedBy)
+			if err != nil {
+				return fmt.Errorf("error: Invalid --enclosed-by: %v", err)
+			}
+			if strings.ContainsRune(sep, quote) {
+				return fmt.Errorf("error: --enclosed-by cannot be part of the delimiter")
+			}
+		}
+	}
+	return nil
+}
+
+// validateHeaderFile checks that --header-from-file can be read with the
+// delimiter of the format.
+func validateHeaderFile() error {
+	if headerFile != "" {
+		if format != "csv" && format != "tsv" {
+			return fmt.Errorf("error: --header-from-file requires --format csv or tsv")
+		}
+		if noHeader {
+			return fmt.Errorf("error: --header-from-file cannot be used with --no-header")
+		}
+		sep := "\t"
+		if format == "csv" {
+			sep, _ = parseDelimiter(delimiter) // validated by validateCSVDialect
+		}
+		if _, err := readHeaderFile(headerFile, sep); err != nil {
+			return fmt.Errorf("error: Invalid --header-from-file: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateCSVQuoting normalizes and checks --csv-quote and --csv-quote-empty.
+func validateCSVQuoting() error {
+	csvQuoteMode = strings.ToLower(strings.TrimSpace(csvQuoteMode))
+	switch csvQuoteMode {
+	case exporters.QuoteMinimal, exporters.QuoteAll, exporters.QuoteNone:
+	default:
+		return fmt.Errorf("error: Invalid --csv-quote '%s'. Valid options are: %s, %s, %s",
+			csvQuoteMode, exporters.QuoteMinimal, exporters.QuoteAll, exporters.QuoteNone)
+	}
+
+	csvQuoteEmpty = strings.ToLower(strings.TrimSpace(csvQuoteEmpty))
+	switch csvQuoteEmpty {
+	case exporters.QuoteEmptyNone, exporters.QuoteEmptyStrings, exporters.QuoteEmptyNulls:
+	default:
+		return fmt.Errorf("error: Invalid --csv-quote-empty '%s'. Valid options are: %s, %s, %s",
+			csvQuoteEmpty, exporters.QuoteEmptyNone, exporters.QuoteEmptyStrings, exporters.QuoteEmptyNulls)
+	}
+	if csvQuoteEmpty != exporters.QuoteEmptyNone {
+		if format != "csv" {
+			return fmt.Errorf("error: --csv-quote-empty requires --format csv")
+		}
+		if csvQuoteMode != exporters.QuoteMinimal {
+			return fmt.Errorf("error: --csv-quote-empty requires --csv-quote minimal")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --csv-quote-empty is not supported with --with-copy")
+		}
+	}
+
+	if withCopy && csvQuoteMode != exporters.QuoteMinimal {
+		return fmt.Errorf("error: --csv-quote is not supported with --with-copy")
+	}
+	return nil
+}
+
+// validateJSONNumbers normalizes and checks how JSON writes numbers.
+func validateJSONNumbers() error {
+	jsonNumbers = strings.ToLower(strings.TrimSpace(jsonNumbers))
+	switch jsonNumbers {
+	case encoders.JSONNumbersNumber, encoders.JSONNumbersString:
+	default:
+		return fmt.Errorf("error: Invalid --json-numbers '%s'. Valid options are: %s, %s",
+			jsonNumbers, encoders.JSONNumbersNumber, encoders.JSONNumbersString)
+	}
+
+	jsonSpecials = strings.ToLower(strings.TrimSpace(jsonSpecials))
+	switch jsonSpecials {
+	case encoders.JSONSpecialFloatsNull, encoders.JSONSpecialFloatsString:
+	default:
+		return fmt.Errorf("error: Invalid --json-special-floats '%s'. Valid options are: %s, %s",
+			jsonSpecials, encoders.JSONSpecialFloatsNull, encoders.JSONSpecialFloatsString)
+	}
+
+	jsonFloats = strings.ToLower(strings.TrimSpace(jsonFloats))
+	switch jsonFloats {
+	case encoders.JSONFloatsAuto, encoders.JSONFloatsDecimal:
+	default:
+		return fmt.Errorf("error: Invalid --json-float-format '%s'. Valid options are: %s, %s",
+			jsonFloats, encoders.JSONFloatsAuto, encoders.JSONFloatsDecimal)
+	}
+	return nil
+}
+
+// validateCSVTextColumns normalizes --csv-text-hint and checks the columns
+// it applies to.
+func validateCSVTextColumns() error {
+	csvTextHint = strings.ToLower(strings.TrimSpace(csvTextHint))
+	switch csvTextHint {
+	case exporters.TextHintNone, exporters.TextHintEquals, exporters.TextHintTab:
+	default:
+		return fmt.Errorf("error: Invalid --csv-text-hint '%s'. Valid options are: %s, %s, %s",
+			csvTextHint, exporters.TextHintNone, exporters.TextHintEquals, exporters.TextHintTab)
+	}
+
+	if len(csvTextColumns) > 0 {
+		if format != "csv" {
+			return fmt.Errorf("error: --csv-force-text-columns requires --format csv")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --csv-force-text-columns is not supported with --with-copy")
+		}
+		if csvQuoteMode == exporters.QuoteNone {
+			return fmt.Errorf("error: --csv-force-text-columns cannot be used with --csv-quote none")
+		}
+		for _, name := range csvTextColumns {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("error: --csv-force-text-columns contains an empty column name")
+			}
+		}
+	} else if csvTextHint != exporters.TextHintNone {
+		return fmt.Errorf("error: --csv-text-hint requires --csv-force-text-columns")
+	}
+	return nil
+}
+
+// validateSkipWhere checks --skip-where and its filter expression.
+func validateSkipWhere() error {
+	if skipWhere != "" {
+		if format != "csv" {
+			return fmt.Errorf("error: --skip-where requires --format csv")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --skip-where is not supported with --with-copy")
+		}
+		if checkpointEvery > 0 {
+			return fmt.Errorf("error: --skip-where cannot be combined with --checkpoint-every, a resumed export counts the rows of the query")
+		}
+		if _, err := exporters.ParseRowFilter(skipWhere); err != nil {
+			return fmt.Errorf("error: Invalid --skip-where: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateOnError normalizes and checks --on-error.
+func validateOnError() error {
+	onError = strings.ToLower(strings.TrimSpace(onError))
+	switch onError {
+	case exporters.OnErrorStop:
+	case exporters.OnErrorSkip:
+		if format == "template" {
+			return fmt.Errorf("error: --on-error %s is not supported for template format", exporters.OnErrorSkip)
+		}
+		if withCopy {
+			return fmt.Errorf("error: --on-error %s is not supported with --with-copy, PostgreSQL formats the rows", exporters.OnErrorSkip)
+		}
+		if checkpointEvery > 0 {
+			return fmt.Errorf("error: --on-error %s cannot be combined with --checkpoint-every, a resumed export counts the rows of the query", exporters.OnErrorSkip)
+		}
+	default:
+		return fmt.Errorf("error: Invalid --on-error '%s'. Valid options are: %s, %s",
+			onError, exporters.OnErrorStop, exporters.OnErrorSkip)
+	}
+	return nil
+}
+
+// validateEmptyFile normalizes and checks --empty-file.
+func validateEmptyFile() error {
+	emptyFile = strings.ToLower(strings.TrimSpace(emptyFile))
+	switch emptyFile {
+	case emptyFileHeader, emptyFileEmpty, emptyFileNone:
+	default:
+		return fmt.Errorf("error: Invalid --empty-file '%s'. Valid options are: %s, %s, %s",
+			emptyFile, emptyFileHeader, emptyFileEmpty, emptyFileNone)
+	}
+	return nil
+}
+
+// validateStdout rejects the options that cannot write to stdout. It runs
+// after --empty-file is normalized.
+func validateStdout() error {
+	if outputPath == stdoutPath {
+		// Stdout is a single stream that cannot be renamed, resumed or removed
+		switch {
+		case sqlFileGlob != "":
+			return fmt.Errorf("error: --output - is not supported with --sqlfile-glob")
+		case rowsPerFile > 0:
+			return fmt.Errorf("error: --output - is not supported with --rows-per-file")
+		case refcursors:
+			return fmt.Errorf("error: --output - is not supported with --refcursors")
+		case atomicOutput:
+			return fmt.Errorf("error: --atomic is not supported with --output -")
+		case checkpointEvery > 0:
+			return fmt.Errorf("error: --checkpoint-every is not supported with --output -")
+		case emitSchema:
+			return fmt.Errorf("error: --emit-schema is not supported with --output -, it writes a file next to the output")
+		case compression == "zip":
+			return fmt.Errorf("error: --compression zip is not supported with --output -, use gzip, zstd or lz4")
+		case emptyFile == emptyFileEmpty || emptyFile == emptyFileNone:
+			return fmt.Errorf("error: --empty-file %s is not supported with --output -", emptyFile)
+		}
+	}
+	return nil
+}
+
+// validateJSONShape checks --json-key-by and the --flatten-json options.
+func validateJSONShape() error {
+	if jsonKeyBy != "" && format != "json" {
+		return fmt.Errorf("error: --json-key-by requires --format json")
+	}
+
+	if flattenJSON != "" {
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("error: --flatten-json is only supported for csv and json formats")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --flatten-json is not supported with --with-copy")
+		}
+		if checkpointEvery > 0 && len(flattenKeys) == 0 {
+			return fmt.Errorf("error: --checkpoint-every with --flatten-json requires --flatten-keys, so a resumed export has the same columns")
+		}
+		for _, key := range flattenKeys {
+			if key == "" {
+				return fmt.Errorf("error: --flatten-keys contains an empty key")
+			}
+		}
+	} else if len(flattenKeys) > 0 {
+		return fmt.Errorf("error: --flatten-keys requires --flatten-json")
+	}
+	return nil
+}
+
+// validateCSVSpecialFloats checks --csv-special-floats.
+func validateCSVSpecialFloats() error {
+	if csvSpecials != defaultCSVSpecialFloats {
+		if format != "csv" {
+			return fmt.Errorf("error: --csv-special-floats requires --format csv")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --csv-special-floats is not supported with --with-copy")
+		}
+		if _, err := parseSpecialFloats(csvSpecials); err != nil {
+			return fmt.Errorf("error: Invalid --csv-special-floats: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateColumnOptions normalizes and checks the options that rename or
+// reorder columns.
+func validateColumnOptions() error {
+	dedupeColumns = strings.ToLower(strings.TrimSpace(dedupeColumns))
+	switch dedupeColumns {
+	case exporters.DedupeWarn, exporters.DedupeError, exporters.DedupeSuffix:
+	default:
+		return fmt.Errorf("error: Invalid --dedupe-columns '%s'. Valid options are: %s, %s, %s",
+			dedupeColumns, exporters.DedupeWarn, exporters.DedupeError, exporters.DedupeSuffix)
+	}
+
+	if err := validateColumnOrder(); err != nil {
+		return err
+	}
+	if columnOrder == exporters.ColumnOrderPKFirst && tableExport == "" {
+		return fmt.Errorf("error: --column-order %s requires --table-export", exporters.ColumnOrderPKFirst)
+	}
+	if columnOrder != exporters.ColumnOrderNatural && withCopy {
+		return fmt.Errorf("error: --column-order is not supported with --with-copy")
+	}
+
+	headerCase = strings.ToLower(strings.TrimSpace(headerCase))
+	if headerCase != "" {
+		switch headerCase {
+		case exporters.HeaderCaseUpper, exporters.HeaderCaseLower, exporters.HeaderCaseTitle,
+			exporters.HeaderCaseSnake, exporters.HeaderCaseCamel:
+		default:
+			return fmt.Errorf("error: Invalid --header-case '%s'. Valid options are: %s, %s, %s, %s, %s", headerCase,
+				exporters.HeaderCaseUpper, exporters.HeaderCaseLower, exporters.HeaderCaseTitle,
+				exporters.HeaderCaseSnake, exporters.HeaderCaseCamel)
+		}
+		if format != "csv" && format != "xlsx" && format != "json" && format != "xml" {
+			return fmt.Errorf("error: --header-case is only supported for csv, xlsx, json and xml formats")
+		}
+		if withCopy {
+			return fmt.Errorf("error: --header-case is not supported with --with-copy")
+		}
+	}
+	return nil
+}
+
+// validateColumnOrder normalizes --column-order and checks its value.
+func validateColumnOrder() error {
+	columnOrder = strings.ToLower(strings.TrimSpace(columnOrder))
+	switch columnOrder {
+	case exporters.ColumnOrderNatural, exporters.ColumnOrderAlpha, exporters.ColumnOrderPKFirst:
+		return nil
+	}
+	return fmt.Errorf("error: Invalid --column-order '%s'. Valid options are: %s, %s, %s",
+		columnOrder, exporters.ColumnOrderNatural, exporters.ColumnOrderAlpha, exporters.ColumnOrderPKFirst)
+}
+
+// validateTimeOptions checks the time format and time zone.
+func validateTimeOptions() error {
+	if timeFormatGo != "" {
+		if timeFormat != defaultTimeFormat {
+			return fmt.Errorf("error: Cannot use both --time-format and --time-format-go")
+		}
+		if err := validation.ValidateTimeFormat(effectiveTimeFormat()); err != nil {
+			return fmt.Errorf("error: Invalid --time-format-go '%s': %v", timeFormatGo, err)
+		}
+	} else if timeFormat != "" {
+		// Validate time format if provided
+		if err := validation.ValidateTimeFormat(timeFormat); err != nil {
+			return fmt.Errorf("error: Invalid time format '%s'. Use format like 'yyyy-MM-dd HH:mm:ss'", timeFormat)
+		}
+	}
+
+	// Validate timezone if provided
+	if timeZone != "" {
+		if err := validation.ValidateTimeZone(timeZone); err != nil {
+			return fmt.Errorf("error: Invalid timezone '%s'. Use format like 'UTC' or 'Europe/Paris'", timeZone)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,2649 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+)
+
+func TestValidateExportParams(t *testing.T) {
+	// Save original values
+	originalSqlQuery := sqlQuery
+	originalSqlFile := sqlFile
+	originalFormat := format
+	originalCompression := compression
+	originalTableName := tableName
+	originalTimeFormat := timeFormat
+	originalTimeZone := timeZone
+	originalRowPerStatement := rowPerStatement
+	originalMaxStmtBytes := maxStmtBytes
+	originalCsvTrailer := csvTrailer
+	originalCsvTrailerAll := csvTrailerAll
+	originalCsvTrailerPfx := csvTrailerPfx
+
+	// Restore original values after test
+	defer func() {
+		rowPerStatement = originalRowPerStatement
+		maxStmtBytes = originalMaxStmtBytes
+		csvTrailer = originalCsvTrailer
+		csvTrailerAll = originalCsvTrailerAll
+		csvTrailerPfx = originalCsvTrailerPfx
+		sqlQuery = originalSqlQuery
+		sqlFile = originalSqlFile
+		format = originalFormat
+		compression = originalCompression
+		tableName = originalTableName
+		timeFormat = originalTimeFormat
+		timeZone = originalTimeZone
+	}()
+
+	tests := []struct {
+		name        string
+		setupFunc   func()
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "valid CSV format",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = "yyyy-MM-dd HH:mm:ss"
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid JSON format",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "json"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid XML format",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "xml"
+				compression = "gzip"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid SQL format with table name",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "sql"
+				compression = "none"
+				tableName = "users_backup"
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 1
+			},
+			wantErr: false,
+		},
+		{
+			name: "no SQL query or file",
+			setupFunc: func() {
+				sqlQuery = ""
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr:     true,
+			errContains: "Either --sql, --sqlfile, --sqlfile-glob or --table-export must be provided",
+		},
+		{
+			name: "both SQL query and file",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = "query.sql"
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr:     true,
+			errContains: "Cannot use both --sql and --sqlfile",
+		},
+		{
+			name: "invalid format",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "txt"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr:     true,
+			errContains: "Invalid format",
+		},
+		{
+			name: "misspelled format",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "jsn"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr:     true,
+			errContains: "Invalid format 'jsn'. Did you mean 'json'?",
+		},
+		{
+			name: "SQL format without table name",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "sql"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 1
+			},
+			wantErr:     true,
+			errContains: "--table (-t) is required",
+		},
+		{
+			name: "SQL format from file without table name",
+			setupFunc: func() {
+				sqlQuery = ""
+				sqlFile = "query.sql"
+				format = "sql"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 1
+			},
+			wantErr: false, // the table may come from a directive in the file
+		},
+		{
+			name: "SQL format with whitespace-only table name",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "sql"
+				compression = "none"
+				tableName = "   "
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 1
+			},
+			wantErr:     true,
+			errContains: "--table (-t) is required",
+		},
+		{
+			name: "SQL format with invalid insert batch",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "sql"
+				compression = "none"
+				tableName = "users_backup"
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 0
+			},
+			wantErr:     true,
+			errContains: "--insert-batch must be at least 1",
+		},
+		{
+			name: "SQL format with negative max statement bytes",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "sql"
+				compression = "none"
+				tableName = "users_backup"
+				timeFormat = ""
+				timeZone = ""
+				rowPerStatement = 1
+				maxStmtBytes = -1
+			},
+			wantErr:     true,
+			errContains: "--max-statement-bytes cannot be negative",
+		},
+		{
+			name: "CSV trailer always without trailer",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+				csvTrailer = false
+				csvTrailerAll = true
+			},
+			wantErr:     true,
+			errContains: "--csv-trailer-always requires --csv-trailer",
+		},
+		{
+			name: "CSV trailer with empty prefix",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+				csvTrailer = true
+				csvTrailerAll = false
+				csvTrailerPfx = " "
+			},
+			wantErr:     true,
+			errContains: "--csv-trailer-prefix cannot be empty",
+		},
+		{
+			name: "valid CSV trailer",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+				csvTrailer = true
+				csvTrailerAll = true
+				csvTrailerPfx = "#ROWS="
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid compression",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "bzip2"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr:     true,
+			errContains: "Invalid compression",
+		},
+		{
+			name: "invalid timezone",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = "yyyy-MM-dd HH:mm:ss"
+				timeZone = "Invalid/Timezone"
+			},
+			wantErr:     true,
+			errContains: "Invalid timezone",
+		},
+		{
+			name: "valid timezone UTC",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = "yyyy-MM-dd HH:mm:ss"
+				timeZone = "UTC"
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid timezone America/New_York",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				tableName = ""
+				timeFormat = "yyyy-MM-dd HH:mm:ss"
+				timeZone = "America/New_York"
+			},
+			wantErr: false,
+		},
+		{
+			name: "compression with uppercase",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "GZIP"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+		{
+			name: "compression with whitespace",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "  zip  "
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+		{
+			name: "format with uppercase",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "CSV"
+				compression = "none"
+				tableName = ""
+				timeFormat = ""
+				timeZone = ""
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupFunc()
+			err := validateExportParams()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("validateExportParams() expected error, got nil")
+					return
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("validateExportParams() error = %q, should contain %q", err.Error(), tt.errContains)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("validateExportParams() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateExportParamsFormatSpecific(t *testing.T) {
+	// Save original values
+	originalSqlQuery := sqlQuery
+	originalFormat := format
+	originalTableName := tableName
+	originalDelimiter := delimiter
+	originalWithCopy := withCopy
+	originalCsvQuoteMode := csvQuoteMode
+	originalCsvQuoteEmpty := csvQuoteEmpty
+	originalDedupeColumns := dedupeColumns
+	originalColumnOrder := columnOrder
+	originalHeaderCase := headerCase
+	originalJsonNumbers := jsonNumbers
+	originalJsonSpecials := jsonSpecials
+	originalJsonFloats := jsonFloats
+	originalJsonKeyBy := jsonKeyBy
+	originalFlattenJSON := flattenJSON
+	originalFlattenKeys := flattenKeys
+	originalCsvTextColumns := csvTextColumns
+	originalCsvTextHint := csvTextHint
+	originalCsvSpecials := csvSpecials
+	originalTrimText := trimText
+	originalCoalesceCols := coalesceCols
+	originalNoHeader := noHeader
+	originalHeaderFile := headerFile
+	originalAllowExplain := allowExplain
+	originalAllowAnalyze := allowAnalyze
+	originalMaxFieldLen := maxFieldLen
+	originalTruncMarker := truncMarker
+	originalFlushEvery := flushEvery
+	originalCheckpointEvery := checkpointEvery
+	originalResumeExport := resumeExport
+	originalAllowUnordered := allowUnordered
+	originalWorkers := workers
+	originalGenComment := genComment
+	originalEmitSchema := emitSchema
+	originalPrettyOutput := prettyOutput
+	originalCompactOutput := compactOutput
+	originalRefcursors := refcursors
+	originalAllowFunctions := allowFunctions
+	originalNoFunctions := noFunctions
+	originalOutputEncoding := outputEncoding
+	originalEncodingErrors := encodingErrors
+	originalProgressTotal := progressTotal
+	originalTotalTimeout := totalTimeout
+	originalSkipWhere := skipWhere
+	originalOnError := onError
+	originalEmptyFile := emptyFile
+	originalXmlRootAttrs := xmlRootAttrs
+	originalXmlRowCountAttr := xmlRowCountAttr
+	originalXmlNull := xmlNull
+	originalXmlSanitize := xmlSanitize
+	originalXmlRootElement := xmlRootElement
+	originalXmlRowElement := xmlRowElement
+	originalXlsxFormats := xlsxFormats
+	originalXlsxTotals := xlsxTotals
+	originalXlsxTotalsSheet := xlsxTotalsSheet
+	originalXlsxMaxRows := xlsxMaxRows
+	originalXlsxSheetBy := xlsxSheetBy
+	originalNoQuoteIdents := noQuoteIdents
+	originalSqlSourceCmt := sqlSourceCmt
+	originalRowsPerFile := rowsPerFile
+	originalIndexFile := indexFile
+	originalTimeFormat := timeFormat
+	originalTimeFormatGo := timeFormatGo
+	originalCompression := compression
+	originalFieldsTerminatedBy := fieldsTerminatedBy
+	originalLinesTerminatedBy := linesTerminatedBy
+	originalEnclosedBy := enclosedBy
+	originalTemplateFile := templateFile
+	originalTemplateHeader := templateHeader
+	originalTemplateRow := templateRow
+	originalTemplateFooter := templateFooter
+	originalTplMaxRows := tplMaxRows
+	originalTplStrict := tplStrict
+	originalTplHTML := tplHTML
+
+	// Restore original values after test
+	defer func() {
+		sqlQuery = originalSqlQuery
+		format = originalFormat
+		tableName = originalTableName
+		delimiter = originalDelimiter
+		withCopy = originalWithCopy
+		csvQuoteMode = originalCsvQuoteMode
+		csvQuoteEmpty = originalCsvQuoteEmpty
+		dedupeColumns = originalDedupeColumns
+		columnOrder = originalColumnOrder
+		headerCase = originalHeaderCase
+		jsonNumbers = originalJsonNumbers
+		jsonSpecials = originalJsonSpecials
+		jsonFloats = originalJsonFloats
+		jsonKeyBy = originalJsonKeyBy
+		flattenJSON = originalFlattenJSON
+		flattenKeys = originalFlattenKeys
+		csvTextColumns = originalCsvTextColumns
+		csvTextHint = originalCsvTextHint
+		csvSpecials = originalCsvSpecials
+		trimText = originalTrimText
+		coalesceCols = originalCoalesceCols
+		noHeader = originalNoHeader
+		headerFile = originalHeaderFile
+		allowExplain = originalAllowExplain
+		allowAnalyze = originalAllowAnalyze
+		maxFieldLen = originalMaxFieldLen
+		truncMarker = originalTruncMarker
+		flushEvery = originalFlushEvery
+		checkpointEvery = originalCheckpointEvery
+		resumeExport = originalResumeExport
+		allowUnordered = originalAllowUnordered
+		workers = originalWorkers
+		genComment = originalGenComment
+		emitSchema = originalEmitSchema
+		prettyOutput = originalPrettyOutput
+		compactOutput = originalCompactOutput
+		refcursors = originalRefcursors
+		allowFunctions = originalAllowFunctions
+		noFunctions = originalNoFunctions
+		outputEncoding = originalOutputEncoding
+		encodingErrors = originalEncodingErrors
+		progressTotal = originalProgressTotal
+		totalTimeout = originalTotalTimeout
+		skipWhere = originalSkipWhere
+		onError = originalOnError
+		emptyFile = originalEmptyFile
+		xmlRootAttrs = originalXmlRootAttrs
+		xmlRowCountAttr = originalXmlRowCountAttr
+		xmlNull = originalXmlNull
+		xmlSanitize = originalXmlSanitize
+		xmlRootElement = originalXmlRootElement
+		xmlRowElement = originalXmlRowElement
+		xlsxFormats = originalXlsxFormats
+		xlsxTotals = originalXlsxTotals
+		xlsxTotalsSheet = originalXlsxTotalsSheet
+		xlsxMaxRows = originalXlsxMaxRows
+		xlsxSheetBy = originalXlsxSheetBy
+		noQuoteIdents = originalNoQuoteIdents
+		sqlSourceCmt = originalSqlSourceCmt
+		rowsPerFile = originalRowsPerFile
+		indexFile = originalIndexFile
+		timeFormat = originalTimeFormat
+		timeFormatGo = originalTimeFormatGo
+		compression = originalCompression
+		fieldsTerminatedBy = originalFieldsTerminatedBy
+		linesTerminatedBy = originalLinesTerminatedBy
+		enclosedBy = originalEnclosedBy
+		templateFile = originalTemplateFile
+		templateHeader = originalTemplateHeader
+		templateRow = originalTemplateRow
+		templateFooter = originalTemplateFooter
+		tplMaxRows = originalTplMaxRows
+		tplStrict = originalTplStrict
+		tplHTML = originalTplHTML
+	}()
+
+	tmpDir := t.TempDir()
+	tplPath := filepath.Join(tmpDir, "report.tpl")
+	if err := os.WriteFile(tplPath, []byte("{{.Count}}"), 0644); err != nil {
+		t.Fatalf("Failed to create template file: %v", err)
+	}
+	headerPath := filepath.Join(tmpDir, "headers.txt")
+	if err := os.WriteFile(headerPath, []byte("CUST_ID\nCUST_NAME\n"), 0644); err != nil {
+		t.Fatalf("Failed to create header file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		setupFunc   func()
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "csv text columns with hint",
+			setupFunc: func() {
+				format = "csv"
+				csvTextColumns = []string{"zip", "phone"}
+				csvTextHint = "EQUALS"
+			},
+			wantErr: false,
+		},
+		{
+			name: "csv text columns with json format",
+			setupFunc: func() {
+				format = "json"
+				csvTextColumns = []string{"zip"}
+			},
+			wantErr:     true,
+			errContains: "--csv-force-text-columns requires --format csv",
+		},
+		{
+			name: "csv text columns with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				csvTextColumns = []string{"zip"}
+			},
+			wantErr:     true,
+			errContains: "not supported with --with-copy",
+		},
+		{
+			name: "csv text columns with quote none",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteMode = "none"
+				csvTextColumns = []string{"zip"}
+			},
+			wantErr:     true,
+			errContains: "cannot be used with --csv-quote none",
+		},
+		{
+			name: "csv text columns with empty name",
+			setupFunc: func() {
+				format = "csv"
+				csvTextColumns = []string{"zip", " "}
+			},
+			wantErr:     true,
+			errContains: "empty column name",
+		},
+		{
+			name: "csv text hint without columns",
+			setupFunc: func() {
+				format = "csv"
+				csvTextHint = "tab"
+			},
+			wantErr:     true,
+			errContains: "--csv-text-hint requires --csv-force-text-columns",
+		},
+		{
+			name: "invalid csv text hint",
+			setupFunc: func() {
+				format = "csv"
+				csvTextColumns = []string{"zip"}
+				csvTextHint = "apostrophe"
+			},
+			wantErr:     true,
+			errContains: "Invalid --csv-text-hint 'apostrophe'",
+		},
+		{
+			name: "json key by with json format",
+			setupFunc: func() {
+				format = "json"
+				jsonKeyBy = "id"
+			},
+			wantErr: false,
+		},
+		{
+			name: "json key by with csv format",
+			setupFunc: func() {
+				format = "csv"
+				jsonKeyBy = "id"
+			},
+			wantErr:     true,
+			errContains: "--json-key-by requires --format json",
+		},
+		{
+			name: "template flags with csv format",
+			setupFunc: func() {
+				format = "csv"
+				templateFile = tplPath
+			},
+			wantErr:     true,
+			errContains: "require --format template",
+		},
+		{
+			name: "template with full and streaming modes",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+				templateRow = tplPath
+			},
+			wantErr:     true,
+			errContains: "not both",
+		},
+		{
+			name: "template full mode with max rows",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+				tplMaxRows = 1000
+			},
+			wantErr: false,
+		},
+		{
+			name: "template max rows in streaming mode",
+			setupFunc: func() {
+				format = "template"
+				templateRow = tplPath
+				tplMaxRows = 1000
+			},
+			wantErr:     true,
+			errContains: "--tpl-max-rows only applies to full-mode templates",
+		},
+		{
+			name: "template strict",
+			setupFunc: func() {
+				format = "template"
+				templateRow = tplPath
+				tplStrict = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "template strict without template format",
+			setupFunc: func() {
+				format = "csv"
+				tplStrict = true
+			},
+			wantErr:     true,
+			errContains: "--tpl-strict requires --format template",
+		},
+		{
+			name: "template html",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+				tplHTML = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "template html without template format",
+			setupFunc: func() {
+				format = "json"
+				tplHTML = true
+			},
+			wantErr:     true,
+			errContains: "--tpl-html requires --format template",
+		},
+		{
+			name: "negative template max rows",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+				tplMaxRows = -1
+			},
+			wantErr:     true,
+			errContains: "--tpl-max-rows cannot be negative",
+		},
+		{
+			name: "template streaming header without row",
+			setupFunc: func() {
+				format = "template"
+				templateHeader = tplPath
+			},
+			wantErr:     true,
+			errContains: "requires --tpl-row",
+		},
+		{
+			name: "template without any template file",
+			setupFunc: func() {
+				format = "template"
+			},
+			wantErr:     true,
+			errContains: "requires either --tpl-file",
+		},
+		{
+			name: "template file does not exist",
+			setupFunc: func() {
+				format = "template"
+				templateFile = filepath.Join(tmpDir, "missing.tpl")
+			},
+			wantErr:     true,
+			errContains: "Cannot access --tpl-file",
+		},
+		{
+			name: "template row is a directory",
+			setupFunc: func() {
+				format = "template"
+				templateRow = tmpDir
+			},
+			wantErr:     true,
+			errContains: "--tpl-row must be a file",
+		},
+		{
+			name: "valid template full mode",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid template streaming mode",
+			setupFunc: func() {
+				format = "template"
+				templateHeader = tplPath
+				templateRow = tplPath
+				templateFooter = tplPath
+			},
+			wantErr: false,
+		},
+		{
+			name: "with-copy on json format",
+			setupFunc: func() {
+				format = "json"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--with-copy is not supported for format 'json'",
+		},
+		{
+			name: "with-copy on csv format",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "with-copy on tsv format",
+			setupFunc: func() {
+				format = "tsv"
+				withCopy = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "csv with delimiter containing a quote",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = `;"`
+			},
+			wantErr:     true,
+			errContains: "Invalid delimiter",
+		},
+		{
+			name: "csv with multi-character delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = "||"
+			},
+			wantErr: false,
+		},
+		{
+			name: "with-copy with multi-character delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = "||"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--with-copy requires a single-character delimiter",
+		},
+		{
+			name: "with-copy with semicolon delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = ";"
+				withCopy = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "with-copy with tab delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = `\t`
+				withCopy = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "with-copy with multi-byte delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = "€"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "COPY requires a single-byte delimiter",
+		},
+		{
+			name: "invalid csv quote mode",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteMode = "sometimes"
+			},
+			wantErr:     true,
+			errContains: "Invalid --csv-quote",
+		},
+		{
+			name: "csv quote empty strings",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteEmpty = " Empty "
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid csv quote empty",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteEmpty = "both"
+			},
+			wantErr:     true,
+			errContains: "Invalid --csv-quote-empty 'both'",
+		},
+		{
+			name: "csv quote empty with json",
+			setupFunc: func() {
+				format = "json"
+				csvQuoteEmpty = "null"
+			},
+			wantErr:     true,
+			errContains: "--csv-quote-empty requires --format csv",
+		},
+		{
+			name: "csv quote empty with quote all",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteMode = "all"
+				csvQuoteEmpty = "empty"
+			},
+			wantErr:     true,
+			errContains: "--csv-quote-empty requires --csv-quote minimal",
+		},
+		{
+			name: "csv quote empty with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				csvQuoteEmpty = "empty"
+			},
+			wantErr:     true,
+			errContains: "--csv-quote-empty is not supported with --with-copy",
+		},
+		{
+			name: "mysql compatibility flags on csv",
+			setupFunc: func() {
+				format = "csv"
+				fieldsTerminatedBy = `\t`
+				linesTerminatedBy = `\r\n`
+				enclosedBy = "'"
+			},
+			wantErr: false,
+		},
+		{
+			name: "fields-terminated-by with json",
+			setupFunc: func() {
+				format = "json"
+				fieldsTerminatedBy = ";"
+			},
+			wantErr:     true,
+			errContains: "apply to CSV format only",
+		},
+		{
+			name: "fields-terminated-by conflicts with delimiter",
+			setupFunc: func() {
+				format = "csv"
+				delimiter = ";"
+				fieldsTerminatedBy = "|"
+			},
+			wantErr:     true,
+			errContains: "Cannot use both --delimiter and --fields-terminated-by",
+		},
+		{
+			name: "invalid fields-terminated-by",
+			setupFunc: func() {
+				format = "csv"
+				fieldsTerminatedBy = `\n`
+			},
+			wantErr:     true,
+			errContains: "Invalid delimiter",
+		},
+		{
+			name: "lines-terminated-by with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				linesTerminatedBy = `\r\n`
+			},
+			wantErr:     true,
+			errContains: "not supported with --with-copy",
+		},
+		{
+			name: "fields-terminated-by with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				fieldsTerminatedBy = ";"
+			},
+			wantErr: false,
+		},
+		{
+			name: "line terminator overlapping delimiter",
+			setupFunc: func() {
+				format = "csv"
+				fieldsTerminatedBy = "|"
+				linesTerminatedBy = "|"
+			},
+			wantErr:     true,
+			errContains: "cannot overlap with the delimiter",
+		},
+		{
+			name: "multi-character enclosed-by",
+			setupFunc: func() {
+				format = "csv"
+				enclosedBy = "''"
+			},
+			wantErr:     true,
+			errContains: "Invalid --enclosed-by",
+		},
+		{
+			name: "enclosed-by equal to delimiter",
+			setupFunc: func() {
+				format = "csv"
+				fieldsTerminatedBy = "'"
+				enclosedBy = "'"
+			},
+			wantErr:     true,
+			errContains: "--enclosed-by cannot be part of the delimiter",
+		},
+		{
+			name: "xml root attributes",
+			setupFunc: func() {
+				format = "xml"
+				xmlRootAttrs = []string{"xmlns:xsi=http://www.w3.org/2001/XMLSchema-instance", "xsi:noNamespaceSchemaLocation=results.xsd"}
+				xmlRowCountAttr = "count"
+			},
+			wantErr: false,
+		},
+		{
+			name: "xml root attribute with csv",
+			setupFunc: func() {
+				format = "csv"
+				xmlRootAttrs = []string{"version=1"}
+			},
+			wantErr:     true,
+			errContains: "require --format xml",
+		},
+		{
+			name: "malformed xml root attribute",
+			setupFunc: func() {
+				format = "xml"
+				xmlRootAttrs = []string{"novalue"}
+			},
+			wantErr:     true,
+			errContains: "Invalid --xml-root-attr",
+		},
+		{
+			name: "row count attribute with compression",
+			setupFunc: func() {
+				format = "xml"
+				compression = "gzip"
+				xmlRowCountAttr = "count"
+			},
+			wantErr:     true,
+			errContains: "--xml-row-count-attr requires --compression none",
+		},
+		{
+			name: "row count attribute also set as root attribute",
+			setupFunc: func() {
+				format = "xml"
+				xmlRootAttrs = []string{"count=1"}
+				xmlRowCountAttr = "count"
+			},
+			wantErr:     true,
+			errContains: "is also set by --xml-root-attr",
+		},
+		{
+			name: "invalid row count attribute name",
+			setupFunc: func() {
+				format = "xml"
+				xmlRowCountAttr = "1count"
+			},
+			wantErr:     true,
+			errContains: "Invalid --xml-row-count-attr",
+		},
+		{
+			name: "flush every with xml",
+			setupFunc: func() {
+				format = "xml"
+				flushEvery = 100
+			},
+			wantErr: false,
+		},
+		{
+			name: "flush every with json",
+			setupFunc: func() {
+				format = "json"
+				flushEvery = 100
+			},
+			wantErr:     true,
+			errContains: "--flush-every is only supported for csv and xml",
+		},
+		{
+			name: "negative flush every",
+			setupFunc: func() {
+				format = "csv"
+				flushEvery = -1
+			},
+			wantErr:     true,
+			errContains: "--flush-every cannot be negative",
+		},
+		{
+			name: "flush every with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				flushEvery = 10
+			},
+			wantErr:     true,
+			errContains: "--flush-every is not supported with --with-copy",
+		},
+		{
+			name: "emit schema with json",
+			setupFunc: func() {
+				format = "json"
+				emitSchema = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "emit schema with xml",
+			setupFunc: func() {
+				format = "xml"
+				emitSchema = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "emit schema with csv",
+			setupFunc: func() {
+				format = "csv"
+				emitSchema = true
+			},
+			wantErr:     true,
+			errContains: "--emit-schema is only supported for json and xml",
+		},
+		{
+			name: "compact with json",
+			setupFunc: func() {
+				format = "json"
+				compactOutput = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "no pretty with yaml",
+			setupFunc: func() {
+				format = "yaml"
+				prettyOutput = false
+			},
+			wantErr: false,
+		},
+		{
+			name: "compact with csv",
+			setupFunc: func() {
+				format = "csv"
+				compactOutput = true
+			},
+			wantErr:     true,
+			errContains: "--compact is only supported for json, xml and yaml",
+		},
+		{
+			name: "no pretty with xlsx",
+			setupFunc: func() {
+				format = "xlsx"
+				prettyOutput = false
+			},
+			wantErr:     true,
+			errContains: "--compact is only supported for json, xml and yaml",
+		},
+		{
+			name: "xml null xsi-nil",
+			setupFunc: func() {
+				format = "xml"
+				xmlNull = " XSI-NIL "
+			},
+			wantErr: false,
+		},
+		{
+			name: "xml null omit",
+			setupFunc: func() {
+				format = "xml"
+				xmlNull = "omit"
+			},
+			wantErr: false,
+		},
+		{
+			name: "xml null invalid",
+			setupFunc: func() {
+				format = "xml"
+				xmlNull = "nil"
+			},
+			wantErr:     true,
+			errContains: "Invalid --xml-null 'nil'",
+		},
+		{
+			name: "xml null with json",
+			setupFunc: func() {
+				format = "json"
+				xmlNull = "omit"
+			},
+			wantErr:     true,
+			errContains: "--xml-null requires --format xml",
+		},
+		{
+			name: "invalid xml root tag",
+			setupFunc: func() {
+				format = "xml"
+				xmlRootElement = "2024 results"
+			},
+			wantErr:     true,
+			errContains: "Invalid --xml-root-tag '2024 results': not a valid XML element name",
+		},
+		{
+			name: "invalid xml row tag",
+			setupFunc: func() {
+				format = "xml"
+				xmlRowElement = "my row"
+			},
+			wantErr:     true,
+			errContains: "Invalid --xml-row-tag 'my row'",
+		},
+		{
+			name: "invalid xml tags are sanitized",
+			setupFunc: func() {
+				format = "xml"
+				xmlRootElement = "2024 results"
+				xmlSanitize = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "xml sanitize names with csv",
+			setupFunc: func() {
+				format = "csv"
+				xmlSanitize = true
+			},
+			wantErr:     true,
+			errContains: "--xml-sanitize-names requires --format xml",
+		},
+		{
+			name: "flatten json with csv",
+			setupFunc: func() {
+				format = "csv"
+				flattenJSON = "attrs"
+			},
+			wantErr: false,
+		},
+		{
+			name: "flatten json with keys and checkpoint",
+			setupFunc: func() {
+				format = "csv"
+				flattenJSON = "attrs"
+				flattenKeys = []string{"city", "zip"}
+				checkpointEvery = 1000
+			},
+			wantErr: false,
+		},
+		{
+			name: "flatten json with xml",
+			setupFunc: func() {
+				format = "xml"
+				flattenJSON = "attrs"
+			},
+			wantErr:     true,
+			errContains: "--flatten-json is only supported for csv and json",
+		},
+		{
+			name: "flatten json with copy",
+			setupFunc: func() {
+				format = "csv"
+				flattenJSON = "attrs"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--flatten-json is not supported with --with-copy",
+		},
+		{
+			name: "flatten json with checkpoint and no keys",
+			setupFunc: func() {
+				format = "csv"
+				flattenJSON = "attrs"
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "requires --flatten-keys",
+		},
+		{
+			name: "flatten keys without flatten json",
+			setupFunc: func() {
+				format = "json"
+				flattenKeys = []string{"city"}
+			},
+			wantErr:     true,
+			errContains: "--flatten-keys requires --flatten-json",
+		},
+		{
+			name: "refcursors with json",
+			setupFunc: func() {
+				format = "json"
+				refcursors = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "refcursors with copy",
+			setupFunc: func() {
+				format = "csv"
+				refcursors = true
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--refcursors is not supported with --with-copy",
+		},
+		{
+			name: "refcursors with no functions",
+			setupFunc: func() {
+				refcursors = true
+				noFunctions = true
+			},
+			wantErr:     true,
+			errContains: "--refcursors cannot be combined with --no-functions",
+		},
+		{
+			name: "refcursors with checkpoint every",
+			setupFunc: func() {
+				format = "csv"
+				refcursors = true
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "--refcursors is not supported with --checkpoint-every",
+		},
+		{
+			name: "checkpoint every with csv",
+			setupFunc: func() {
+				format = "csv"
+				checkpointEvery = 1000
+				resumeExport = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative checkpoint every",
+			setupFunc: func() {
+				format = "csv"
+				checkpointEvery = -1
+			},
+			wantErr:     true,
+			errContains: "--checkpoint-every cannot be negative",
+		},
+		{
+			name: "checkpoint every with json",
+			setupFunc: func() {
+				format = "json"
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "--checkpoint-every is only supported for csv",
+		},
+		{
+			name: "checkpoint every with compression",
+			setupFunc: func() {
+				format = "csv"
+				compression = "gzip"
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "--checkpoint-every requires --compression none",
+		},
+		{
+			name: "checkpoint every with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "--checkpoint-every is not supported with --with-copy",
+		},
+		{
+			name: "checkpoint every with explain",
+			setupFunc: func() {
+				format = "csv"
+				allowExplain = true
+				checkpointEvery = 1000
+			},
+			wantErr:     true,
+			errContains: "--checkpoint-every is not supported with --allow-explain",
+		},
+		{
+			name: "allow unordered with checkpoint every",
+			setupFunc: func() {
+				format = "csv"
+				checkpointEvery = 1000
+				allowUnordered = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "allow unordered without checkpoint every",
+			setupFunc: func() {
+				format = "csv"
+				allowUnordered = true
+			},
+			wantErr:     true,
+			errContains: "--allow-unordered requires --checkpoint-every",
+		},
+		{
+			name: "resume without checkpoint every",
+			setupFunc: func() {
+				format = "csv"
+				resumeExport = true
+			},
+			wantErr:     true,
+			errContains: "--resume requires --checkpoint-every",
+		},
+		{
+			name: "workers with csv",
+			setupFunc: func() {
+				format = "csv"
+				workers = 4
+			},
+			wantErr: false,
+		},
+		{
+			name: "workers zero",
+			setupFunc: func() {
+				format = "csv"
+				workers = 0
+			},
+			wantErr:     true,
+			errContains: "--workers must be at least 1",
+		},
+		{
+			name: "workers with json",
+			setupFunc: func() {
+				format = "json"
+				workers = 4
+			},
+			wantErr:     true,
+			errContains: "--workers is only supported for csv format",
+		},
+		{
+			name: "workers with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				workers = 4
+			},
+			wantErr:     true,
+			errContains: "--workers is not supported with --with-copy",
+		},
+		{
+			name: "max field length with xlsx",
+			setupFunc: func() {
+				format = "xlsx"
+				maxFieldLen = 100
+				truncMarker = "[...]"
+			},
+			wantErr: false,
+		},
+		{
+			name: "max field length negative",
+			setupFunc: func() {
+				format = "csv"
+				maxFieldLen = -1
+			},
+			wantErr:     true,
+			errContains: "--max-field-length cannot be negative",
+		},
+		{
+			name: "max field length with xml",
+			setupFunc: func() {
+				format = "xml"
+				maxFieldLen = 100
+			},
+			wantErr:     true,
+			errContains: "--max-field-length is only supported for csv, xlsx and json formats",
+		},
+		{
+			name: "max field length with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				maxFieldLen = 100
+			},
+			wantErr:     true,
+			errContains: "--max-field-length is not supported with --with-copy",
+		},
+		{
+			name: "truncate marker without max field length",
+			setupFunc: func() {
+				format = "csv"
+				truncMarker = "~"
+			},
+			wantErr:     true,
+			errContains: "--truncate-marker requires --max-field-length",
+		},
+		{
+			name: "allow explain",
+			setupFunc: func() {
+				format = "json"
+				allowExplain = true
+				allowAnalyze = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "allow explain analyze alone",
+			setupFunc: func() {
+				format = "json"
+				allowAnalyze = true
+			},
+			wantErr:     true,
+			errContains: "--allow-explain-analyze requires --allow-explain",
+		},
+		{
+			name: "allow explain with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				allowExplain = true
+			},
+			wantErr:     true,
+			errContains: "--allow-explain is not supported with --with-copy",
+		},
+		{
+			name: "trim text with json",
+			setupFunc: func() {
+				format = "json"
+				trimText = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "trim text with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				trimText = true
+			},
+			wantErr:     true,
+			errContains: "--trim-text is not supported with --with-copy",
+		},
+		{
+			name: "header file with tsv",
+			setupFunc: func() {
+				format = "tsv"
+				headerFile = headerPath
+			},
+			wantErr: false,
+		},
+		{
+			name: "header file with json",
+			setupFunc: func() {
+				format = "json"
+				headerFile = headerPath
+			},
+			wantErr:     true,
+			errContains: "--header-from-file requires --format csv or tsv",
+		},
+		{
+			name: "header file with no header",
+			setupFunc: func() {
+				format = "csv"
+				headerFile = headerPath
+				noHeader = true
+			},
+			wantErr:     true,
+			errContains: "--header-from-file cannot be used with --no-header",
+		},
+		{
+			name: "missing header file",
+			setupFunc: func() {
+				format = "csv"
+				headerFile = filepath.Join(tmpDir, "missing.txt")
+			},
+			wantErr:     true,
+			errContains: "Invalid --header-from-file: unable to read file",
+		},
+		{
+			name: "coalesce with sql",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "users"
+				coalesceCols = []string{"middle_name=", "discount=0"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "coalesce without equals",
+			setupFunc: func() {
+				format = "csv"
+				coalesceCols = []string{"middle_name"}
+			},
+			wantErr:     true,
+			errContains: "Invalid --coalesce: expected column=default",
+		},
+		{
+			name: "coalesce with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				coalesceCols = []string{"discount=0"}
+			},
+			wantErr:     true,
+			errContains: "--coalesce is not supported with --with-copy",
+		},
+		{
+			name: "no quote identifiers with sql",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "public.users"
+				noQuoteIdents = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "no quote identifiers with csv",
+			setupFunc: func() {
+				format = "csv"
+				noQuoteIdents = true
+			},
+			wantErr:     true,
+			errContains: "--no-quote-identifiers requires --format sql",
+		},
+		{
+			name: "no quote identifiers with uppercase table",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "public.Users"
+				noQuoteIdents = true
+			},
+			wantErr:     true,
+			errContains: `Invalid --table for --no-quote-identifiers: "public.Users" cannot be written without quotes`,
+		},
+		{
+			name: "no quote identifiers with reserved table",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "order"
+				noQuoteIdents = true
+			},
+			wantErr:     true,
+			errContains: `"order" is a reserved keyword`,
+		},
+		{
+			name: "sql source comment with sql",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "users"
+				sqlSourceCmt = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "sql source comment with csv",
+			setupFunc: func() {
+				format = "csv"
+				sqlSourceCmt = true
+			},
+			wantErr:     true,
+			errContains: "--sql-source-comment requires --format sql",
+		},
+		{
+			name: "rows per file with index",
+			setupFunc: func() {
+				format = "csv"
+				rowsPerFile = 1000
+				indexFile = "index.json"
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative rows per file",
+			setupFunc: func() {
+				format = "csv"
+				rowsPerFile = -1
+			},
+			wantErr:     true,
+			errContains: "--rows-per-file cannot be negative",
+		},
+		{
+			name: "index file without rows per file",
+			setupFunc: func() {
+				format = "csv"
+				indexFile = "index.csv"
+			},
+			wantErr:     true,
+			errContains: "--index-file requires --rows-per-file",
+		},
+		{
+			name: "rows per file with copy",
+			setupFunc: func() {
+				format = "csv"
+				rowsPerFile = 1000
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--rows-per-file is not supported with --with-copy",
+		},
+		{
+			name: "rows per file with checkpoint",
+			setupFunc: func() {
+				format = "csv"
+				rowsPerFile = 1000
+				checkpointEvery = 500
+			},
+			wantErr:     true,
+			errContains: "--rows-per-file is not supported with --checkpoint-every",
+		},
+		{
+			name: "generated comment with sql",
+			setupFunc: func() {
+				format = "sql"
+				tableName = "users"
+				genComment = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "generated comment with template",
+			setupFunc: func() {
+				format = "template"
+				templateRow = "row.tpl"
+				genComment = true
+			},
+			wantErr:     true,
+			errContains: "--include-generated-comment is not supported for format template",
+		},
+		{
+			name: "progress total",
+			setupFunc: func() {
+				format = "csv"
+				progressTotal = 1000
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative progress total",
+			setupFunc: func() {
+				format = "csv"
+				progressTotal = -1
+			},
+			wantErr:     true,
+			errContains: "--progress-total cannot be negative",
+		},
+		{
+			name: "total timeout",
+			setupFunc: func() {
+				format = "csv"
+				totalTimeout = 30 * time.Minute
+			},
+			wantErr: false,
+		},
+		{
+			name: "skip where",
+			setupFunc: func() {
+				format = "csv"
+				skipWhere = "status==deleted"
+			},
+			wantErr: false,
+		},
+		{
+			name: "skip where invalid",
+			setupFunc: func() {
+				format = "csv"
+				skipWhere = "status=deleted"
+			},
+			wantErr:     true,
+			errContains: "Invalid --skip-where",
+		},
+		{
+			name: "skip where requires csv",
+			setupFunc: func() {
+				format = "json"
+				skipWhere = "status==deleted"
+			},
+			wantErr:     true,
+			errContains: "--skip-where requires --format csv",
+		},
+		{
+			name: "skip where with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				skipWhere = "status==deleted"
+			},
+			wantErr:     true,
+			errContains: "--skip-where is not supported with --with-copy",
+		},
+		{
+			name: "skip where with checkpoints",
+			setupFunc: func() {
+				format = "csv"
+				checkpointEvery = 1000
+				skipWhere = "status==deleted"
+			},
+			wantErr:     true,
+			errContains: "--skip-where cannot be combined with --checkpoint-every",
+		},
+		{
+			name: "on error skip",
+			setupFunc: func() {
+				format = "json"
+				onError = "Skip"
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty file none is case-insensitive",
+			setupFunc: func() {
+				format = "json"
+				emptyFile = " NONE "
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid empty file",
+			setupFunc: func() {
+				format = "csv"
+				emptyFile = "skip"
+			},
+			wantErr:     true,
+			errContains: "Invalid --empty-file 'skip'. Valid options are: header, empty, none",
+		},
+		{
+			name: "invalid on error",
+			setupFunc: func() {
+				format = "csv"
+				onError = "continue"
+			},
+			wantErr:     true,
+			errContains: "Invalid --on-error 'continue'",
+		},
+		{
+			name: "on error skip with template",
+			setupFunc: func() {
+				format = "template"
+				templateFile = tplPath
+				onError = exporters.OnErrorSkip
+			},
+			wantErr:     true,
+			errContains: "--on-error skip is not supported for template format",
+		},
+		{
+			name: "on error skip with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				onError = exporters.OnErrorSkip
+			},
+			wantErr:     true,
+			errContains: "--on-error skip is not supported with --with-copy",
+		},
+		{
+			name: "on error skip with checkpoints",
+			setupFunc: func() {
+				format = "csv"
+				checkpointEvery = 1000
+				onError = exporters.OnErrorSkip
+			},
+			wantErr:     true,
+			errContains: "--on-error skip cannot be combined with --checkpoint-every",
+		},
+		{
+			name: "negative total timeout",
+			setupFunc: func() {
+				format = "csv"
+				totalTimeout = -time.Second
+			},
+			wantErr:     true,
+			errContains: "--timeout-total cannot be negative",
+		},
+		{
+			name: "xlsx number formats",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxFormats = []string{"amount=#,##0.00,rate=0.00%"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "xlsx number formats with csv",
+			setupFunc: func() {
+				format = "csv"
+				xlsxFormats = []string{"amount=#,##0.00"}
+			},
+			wantErr:     true,
+			errContains: "--xlsx-format requires --format xlsx",
+		},
+		{
+			name: "invalid xlsx number format",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxFormats = []string{"amount="}
+			},
+			wantErr:     true,
+			errContains: "Invalid --xlsx-format",
+		},
+		{
+			name: "csv latin1 output",
+			setupFunc: func() {
+				format = "csv"
+				outputEncoding = "ISO-8859-1"
+				encodingErrors = "ERROR"
+			},
+			wantErr: false,
+		},
+		{
+			name: "json with non-utf8 output",
+			setupFunc: func() {
+				format = "json"
+				outputEncoding = "latin1"
+			},
+			wantErr:     true,
+			errContains: "--output-encoding is only supported for csv, tsv, xml, sql and template",
+		},
+		{
+			name: "unknown output encoding",
+			setupFunc: func() {
+				format = "csv"
+				outputEncoding = "ebcdic"
+			},
+			wantErr:     true,
+			errContains: "Invalid --output-encoding",
+		},
+		{
+			name: "invalid output encoding errors",
+			setupFunc: func() {
+				format = "csv"
+				encodingErrors = "skip"
+			},
+			wantErr:     true,
+			errContains: "Invalid --output-encoding-errors",
+		},
+		{
+			name: "xlsx totals per sheet",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxTotals = true
+				xlsxTotalsSheet = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "xlsx totals with csv",
+			setupFunc: func() {
+				format = "csv"
+				xlsxTotals = true
+			},
+			wantErr:     true,
+			errContains: "--xlsx-totals requires --format xlsx",
+		},
+		{
+			name: "xlsx totals per sheet without totals",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxTotalsSheet = true
+			},
+			wantErr:     true,
+			errContains: "--xlsx-totals-per-sheet requires --xlsx-totals",
+		},
+		{
+			name: "xlsx max rows",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxMaxRows = 500000
+			},
+			wantErr: false,
+		},
+		{
+			name: "xlsx max rows negative",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxMaxRows = -1
+			},
+			wantErr:     true,
+			errContains: "--xlsx-max-rows cannot be negative",
+		},
+		{
+			name: "xlsx max rows with csv",
+			setupFunc: func() {
+				format = "csv"
+				xlsxMaxRows = 10
+			},
+			wantErr:     true,
+			errContains: "--xlsx-max-rows requires --format xlsx",
+		},
+		{
+			name: "xlsx sheet by with xlsx",
+			setupFunc: func() {
+				format = "xlsx"
+				xlsxSheetBy = "category"
+			},
+			wantErr: false,
+		},
+		{
+			name: "xlsx sheet by without xlsx",
+			setupFunc: func() {
+				format = "csv"
+				xlsxSheetBy = "category"
+			},
+			wantErr:     true,
+			errContains: "--xlsx-sheet-by requires --format xlsx",
+		},
+		{
+			name: "go time layout",
+			setupFunc: func() {
+				format = "json"
+				timeFormatGo = "2006-01-02T15:04:05.000Z07:00"
+			},
+			wantErr: false,
+		},
+		{
+			name: "go time layout with time-format",
+			setupFunc: func() {
+				timeFormat = "dd/MM/yyyy"
+				timeFormatGo = "02/01/2006"
+			},
+			wantErr:     true,
+			errContains: "Cannot use both --time-format and --time-format-go",
+		},
+		{
+			name: "go time layout without reference elements",
+			setupFunc: func() {
+				timeFormatGo = "yyyy-MM-dd"
+			},
+			wantErr:     true,
+			errContains: "contains no reference time elements",
+		},
+		{
+			name: "json special floats as strings",
+			setupFunc: func() {
+				format = "json"
+				jsonSpecials = "String"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid json special floats",
+			setupFunc: func() {
+				format = "json"
+				jsonSpecials = "zero"
+			},
+			wantErr:     true,
+			errContains: "Invalid --json-special-floats",
+		},
+		{
+			name: "json decimal floats",
+			setupFunc: func() {
+				format = "json"
+				jsonFloats = "Decimal"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid json float format",
+			setupFunc: func() {
+				format = "json"
+				jsonFloats = "scientific"
+			},
+			wantErr:     true,
+			errContains: "Invalid --json-float-format",
+		},
+		{
+			name: "csv special floats",
+			setupFunc: func() {
+				format = "csv"
+				csvSpecials = "NA"
+			},
+			wantErr: false,
+		},
+		{
+			name: "csv special floats with json",
+			setupFunc: func() {
+				format = "json"
+				csvSpecials = "NA"
+			},
+			wantErr:     true,
+			errContains: "--csv-special-floats requires --format csv",
+		},
+		{
+			name: "csv special floats with copy",
+			setupFunc: func() {
+				format = "csv"
+				csvSpecials = "NA"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--csv-special-floats is not supported with --with-copy",
+		},
+		{
+			name: "csv special floats with two values",
+			setupFunc: func() {
+				format = "csv"
+				csvSpecials = "NA,Inf"
+			},
+			wantErr:     true,
+			errContains: "Invalid --csv-special-floats",
+		},
+		{
+			name: "json numbers as strings",
+			setupFunc: func() {
+				format = "json"
+				jsonNumbers = "STRING"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid json numbers mode",
+			setupFunc: func() {
+				format = "json"
+				jsonNumbers = "float"
+			},
+			wantErr:     true,
+			errContains: "Invalid --json-numbers",
+		},
+		{
+			name: "invalid dedupe columns mode",
+			setupFunc: func() {
+				format = "json"
+				dedupeColumns = "drop"
+			},
+			wantErr:     true,
+			errContains: "Invalid --dedupe-columns",
+		},
+		{
+			name: "dedupe columns suffix with uppercase",
+			setupFunc: func() {
+				format = "json"
+				dedupeColumns = " SUFFIX "
+			},
+			wantErr: false,
+		},
+		{
+			name: "alpha column order",
+			setupFunc: func() {
+				format = "json"
+				columnOrder = " Alpha "
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid column order",
+			setupFunc: func() {
+				format = "json"
+				columnOrder = "reverse"
+			},
+			wantErr:     true,
+			errContains: "Invalid --column-order",
+		},
+		{
+			name: "column order with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				columnOrder = "alpha"
+			},
+			wantErr:     true,
+			errContains: "--column-order is not supported with --with-copy",
+		},
+		{
+			name: "header case for xlsx",
+			setupFunc: func() {
+				format = "xlsx"
+				headerCase = " Title "
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid header case",
+			setupFunc: func() {
+				format = "csv"
+				headerCase = "kebab"
+			},
+			wantErr:     true,
+			errContains: "Invalid --header-case 'kebab'",
+		},
+		{
+			name: "header case for yaml",
+			setupFunc: func() {
+				format = "yaml"
+				headerCase = "upper"
+			},
+			wantErr:     true,
+			errContains: "--header-case is only supported for csv, xlsx, json and xml formats",
+		},
+		{
+			name: "header case with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+				headerCase = "upper"
+			},
+			wantErr:     true,
+			errContains: "--header-case is not supported with --with-copy",
+		},
+		{
+			name: "csv quote all with copy",
+			setupFunc: func() {
+				format = "csv"
+				csvQuoteMode = "all"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--csv-quote is not supported with --with-copy",
+		},
+		{
+			name: "json ignores csv delimiter",
+			setupFunc: func() {
+				format = "json"
+				delimiter = ";;"
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlQuery = "SELECT * FROM users"
+			tableName = ""
+			delimiter = ","
+			withCopy = false
+			csvQuoteMode = "minimal"
+			csvQuoteEmpty = "none"
+			dedupeColumns = "warn"
+			columnOrder = "natural"
+			headerCase = ""
+			jsonNumbers = "number"
+			jsonSpecials = "null"
+			jsonFloats = "auto"
+			jsonKeyBy = ""
+			flattenJSON = ""
+			flattenKeys = nil
+			csvTextColumns = nil
+			csvTextHint = "none"
+			csvSpecials = defaultCSVSpecialFloats
+			trimText = false
+			coalesceCols = nil
+			noHeader = false
+			headerFile = ""
+			allowExplain = false
+			allowAnalyze = false
+			maxFieldLen = 0
+			truncMarker = defaultTruncateMarker
+			flushEvery = 0
+			checkpointEvery = 0
+			resumeExport = false
+			allowUnordered = false
+			workers = 1
+			genComment = false
+			emitSchema = false
+			prettyOutput = true
+			compactOutput = false
+			refcursors = false
+			allowFunctions = true
+			noFunctions = false
+			outputEncoding = "utf-8"
+			encodingErrors = "replace"
+			progressTotal = 0
+			totalTimeout = 0
+			skipWhere = ""
+			onError = exporters.OnErrorStop
+			emptyFile = emptyFileHeader
+			xmlRootAttrs = nil
+			xmlRowCountAttr = ""
+			xmlNull = exporters.XmlNullEmpty
+			xmlSanitize = false
+			xmlRootElement = "results"
+			xmlRowElement = "row"
+			xlsxFormats = nil
+			xlsxTotals = false
+			xlsxTotalsSheet = false
+			xlsxMaxRows = 0
+			xlsxSheetBy = ""
+			noQuoteIdents = false
+			sqlSourceCmt = false
+			rowsPerFile = 0
+			indexFile = ""
+			timeFormat = defaultTimeFormat
+			timeFormatGo = ""
+			compression = "none"
+			fieldsTerminatedBy = ""
+			linesTerminatedBy = ""
+			enclosedBy = ""
+			templateFile = ""
+			templateHeader = ""
+			templateRow = ""
+			templateFooter = ""
+			tplMaxRows = 0
+			tplStrict = false
+			tplHTML = false
+			tt.setupFunc()
+
+			err := validateExportParams()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("validateExportParams() expected error, got nil")
+					return
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("validateExportParams() error = %q, should contain %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("validateExportParams() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateExportParamsAcceptsRegisteredFormats(t *testing.T) {
+	// Save original values
+	originalSqlQuery := sqlQuery
+	originalFormat := format
+	originalTableName := tableName
+	originalTemplateFile := templateFile
+
+	// Restore original values after test
+	defer func() {
+		sqlQuery = originalSqlQuery
+		format = originalFormat
+		tableName = originalTableName
+		templateFile = originalTemplateFile
+	}()
+
+	tplPath := filepath.Join(t.TempDir(), "report.tpl")
+	if err := os.WriteFile(tplPath, []byte("{{.Count}}"), 0644); err != nil {
+		t.Fatalf("Failed to create template file: %v", err)
+	}
+
+	formats := exporters.List()
+	if len(formats) == 0 {
+		t.Fatal("No exporters registered")
+	}
+
+	for _, f := range formats {
+		t.Run(f, func(t *testing.T) {
+			sqlQuery = "SELECT * FROM users"
+			format = strings.ToUpper(f)
+			tableName = ""
+			templateFile = ""
+
+			// Satisfy format-specific requirements
+			switch f {
+			case exporters.FormatSQL:
+				tableName = "users_backup"
+			case exporters.FormatTemplate:
+				templateFile = tplPath
+			}
+
+			if err := validateExportParams(); err != nil {
+				t.Errorf("validateExportParams() rejected registered format %q: %v", f, err)
+			}
+
+			if _, err := exporters.Get(format); err != nil {
+				t.Errorf("exporters.Get(%q) after validation: %v", format, err)
+			}
+		})
+	}
+
+	usage := rootCmd.Flags().Lookup("format").Usage
+	for _, f := range formats {
+		if !strings.Contains(usage, f) {
+			t.Errorf("--format help %q does not mention registered format %q", usage, f)
+		}
+	}
+}
+
+func TestValidateExportParamsStdout(t *testing.T) {
+	originalSqlQuery := sqlQuery
+	originalOutputPath := outputPath
+	originalFormat := format
+	originalCompression := compression
+	originalRowsPerFile := rowsPerFile
+	originalAtomic := atomicOutput
+	originalEmitSchema := emitSchema
+	originalEmptyFile := emptyFile
+	originalPagerMode := pagerMode
+	defer func() {
+		sqlQuery = originalSqlQuery
+		outputPath = originalOutputPath
+		format = originalFormat
+		compression = originalCompression
+		rowsPerFile = originalRowsPerFile
+		atomicOutput = originalAtomic
+		emitSchema = originalEmitSchema
+		emptyFile = originalEmptyFile
+		pagerMode = originalPagerMode
+	}()
+
+	tests := []struct {
+		name        string
+		setupFunc   func()
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:      "stdout",
+			setupFunc: func() {},
+		},
+		{
+			name:      "stdout with pager auto",
+			setupFunc: func() { pagerMode = "AUTO" },
+		},
+		{
+			name:      "stdout with gzip",
+			setupFunc: func() { compression = "gzip" },
+		},
+		{
+			name:        "stdout with zip",
+			setupFunc:   func() { compression = "zip" },
+			wantErr:     true,
+			errContains: "--compression zip is not supported with --output -",
+		},
+		{
+			name:        "stdout with rows per file",
+			setupFunc:   func() { rowsPerFile = 10 },
+			wantErr:     true,
+			errContains: "--output - is not supported with --rows-per-file",
+		},
+		{
+			name:        "stdout with atomic",
+			setupFunc:   func() { atomicOutput = true },
+			wantErr:     true,
+			errContains: "--atomic is not supported with --output -",
+		},
+		{
+			name:        "stdout with emit schema",
+			setupFunc:   func() { format = "json"; emitSchema = true },
+			wantErr:     true,
+			errContains: "--emit-schema is not supported with --output -",
+		},
+		{
+			name:        "stdout with empty file none",
+			setupFunc:   func() { emptyFile = emptyFileNone },
+			wantErr:     true,
+			errContains: "--empty-file none is not supported with --output -",
+		},
+		{
+			name:        "pager with a file output",
+			setupFunc:   func() { outputPath = "out.csv"; pagerMode = pagerAlways },
+			wantErr:     true,
+			errContains: "--pager always requires --output -",
+		},
+		{
+			name:        "invalid pager",
+			setupFunc:   func() { pagerMode = "sometimes" },
+			wantErr:     true,
+			errContains: "Invalid --pager 'sometimes'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlQuery = "SELECT 1"
+			outputPath = stdoutPath
+			format = "csv"
+			compression = "none"
+			rowsPerFile = 0
+			atomicOutput = false
+			emitSchema = false
+			emptyFile = emptyFileHeader
+			pagerMode = pagerNever
+			tt.setupFunc()
+
+			err := validateExportParams()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("validateExportParams() expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("validateExportParams() error = %q, should contain %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("validateExportParams() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateExportParamsSqlFileGlob(t *testing.T) {
+	originalSqlQuery := sqlQuery
+	originalSqlFile := sqlFile
+	originalSqlFileGlob := sqlFileGlob
+	originalOutputPath := outputPath
+	originalFormat := format
+	originalCountFile := countFile
+	defer func() {
+		sqlQuery = originalSqlQuery
+		sqlFile = originalSqlFile
+		sqlFileGlob = originalSqlFileGlob
+		outputPath = originalOutputPath
+		format = originalFormat
+		countFile = originalCountFile
+	}()
+
+	tmpDir := t.TempDir()
+	existingFile := filepath.Join(tmpDir, "out.csv")
+	if err := os.WriteFile(existingFile, nil, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		setupFunc   func()
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "glob with output directory",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = tmpDir
+			},
+			wantErr: false,
+		},
+		{
+			name: "glob with missing output directory",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = filepath.Join(tmpDir, "new")
+			},
+			wantErr: false,
+		},
+		{
+			name: "glob with output file",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = existingFile
+			},
+			wantErr:     true,
+			errContains: "--output must be a directory",
+		},
+		{
+			name: "glob combined with sql",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				sqlQuery = "SELECT 1"
+				outputPath = tmpDir
+			},
+			wantErr:     true,
+			errContains: "--sqlfile-glob cannot be combined",
+		},
+		{
+			name: "glob combined with sqlfile",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				sqlFile = "query.sql"
+				outputPath = tmpDir
+			},
+			wantErr:     true,
+			errContains: "--sqlfile-glob cannot be combined",
+		},
+		{
+			name: "malformed glob pattern",
+			setupFunc: func() {
+				sqlFileGlob = "reports/[.sql"
+				outputPath = tmpDir
+			},
+			wantErr:     true,
+			errContains: "Invalid --sqlfile-glob pattern",
+		},
+		{
+			name: "glob with dated output directory",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = filepath.Join(tmpDir, "{date}")
+			},
+			wantErr: false,
+		},
+		{
+			name: "glob with unknown output placeholder",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = filepath.Join(tmpDir, "{day}")
+			},
+			wantErr:     true,
+			errContains: "Invalid --output: unknown placeholder {day}",
+		},
+		{
+			name: "glob with count file",
+			setupFunc: func() {
+				sqlFileGlob = "reports/*.sql"
+				outputPath = tmpDir
+				countFile = "rows.count"
+			},
+			wantErr:     true,
+			errContains: "--count-file is not supported with --sqlfile-glob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlQuery = ""
+			sqlFile = ""
+			format = "csv"
+			countFile = ""
+			tt.setupFunc()
+
+			err := validateExportParams()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("validateExportParams() expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("validateExportParams() error = %q, should contain %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("validateExportParams() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateExportParamsPorcelainVerbose(t *testing.T) {
+	originalSqlQuery := sqlQuery
+	originalVerbose := verbose
+	originalPorcelain := porcelain
+	defer func() {
+		sqlQuery = originalSqlQuery
+		verbose = originalVerbose
+		porcelain = originalPorcelain
+	}()
+
+	sqlQuery = "SELECT 1"
+	verbose = true
+	porcelain = true
+
+	err := validateExportParams()
+	if err == nil || !strings.Contains(err.Error(), "Cannot use --verbose and --porcelain") {
+		t.Errorf("validateExportParams() error = %v, want verbose/porcelain conflict", err)
+	}
+}
+
+func TestValidateSessionParams(t *testing.T) {
+	originalSQLQuery := sqlQuery
+	originalSessionParams := sessionParams
+	defer func() {
+		sqlQuery = originalSQLQuery
+		sessionParams = originalSessionParams
+	}()
+
+	sqlQuery = "SELECT 1"
+	sessionParams = []string{"work_mem=256MB"}
+	if err := validateExportParams(); err != nil {
+		t.Errorf("validateExportParams() unexpected error: %v", err)
+	}
+
+	sessionParams = []string{"work_mem"}
+	err := validateExportParams()
+	if err == nil || !strings.Contains(err.Error(), "Invalid --session-param") {
+		t.Errorf("validateExportParams() error = %v, want an invalid --session-param error", err)
+	}
+}
@@ -1,16 +1,32 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/fbz-tec/pgxport/internal/version"
 	"github.com/spf13/cobra"
 )
 
+var versionJSON bool
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(version.GetInfo())
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if versionJSON {
+			out, err := json.Marshal(version.Get())
+			if err != nil {
+				return fmt.Errorf("error encoding version information: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return nil
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), version.GetInfo())
+		return nil
 	},
 }
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print version information as JSON")
+}
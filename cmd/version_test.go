@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+func TestVersionCommand(t *testing.T) {
+	originalVersionJSON := versionJSON
+	defer func() {
+		versionJSON = originalVersionJSON
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, out string)
+	}{
+		{
+			name: "human readable",
+			args: []string{"version"},
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, "Version: "+version.AppVersion) {
+					t.Errorf("output should contain the version, got:\n%s", out)
+				}
+			},
+		},
+		{
+			name: "json",
+			args: []string{"version", "--json"},
+			check: func(t *testing.T, out string) {
+				var info map[string]string
+				if err := json.Unmarshal([]byte(out), &info); err != nil {
+					t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+				}
+				expected := map[string]string{
+					"version":  version.AppVersion,
+					"build":    version.BuildTime,
+					"commit":   version.GitCommit,
+					"go":       runtime.Version(),
+					"platform": runtime.GOOS + "/" + runtime.GOARCH,
+				}
+				for key, want := range expected {
+					if info[key] != want {
+						t.Errorf("%s = %q, want %q", key, info[key], want)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versionJSON = false
+			var buf bytes.Buffer
+			rootCmd.SetOut(&buf)
+			rootCmd.SetArgs(tt.args)
+
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			tt.check(t, buf.String())
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JobConfig describes a repeatable export loaded with --config.
+// Every field is optional; command-line flags override the file.
+//
+// When Exports is set, the file runs in batch mode: each entry is exported in
+// turn and the top-level settings act as defaults for every entry.
+type JobConfig struct {
+	SQL         string        `yaml:"sql"`
+	SQLFile     string        `yaml:"sqlfile"`
+	Format      string        `yaml:"format"`
+	Output      string        `yaml:"output"`
+	Table       string        `yaml:"table"`
+	Compression string        `yaml:"compression"`
+	TimeFormat  string        `yaml:"time_format"`
+	TimeZone    string        `yaml:"time_zone"`
+	Connection  JobConnection `yaml:"connection"`
+	Exports     []JobExport   `yaml:"exports"`
+}
+
+// JobExport is one named export of a batch job file.
+type JobExport struct {
+	Name        string `yaml:"name"`
+	SQL         string `yaml:"sql"`
+	SQLFile     string `yaml:"sqlfile"`
+	Format      string `yaml:"format"`
+	Output      string `yaml:"output"`
+	Table       string `yaml:"table"`
+	Compression string `yaml:"compression"`
+	TimeFormat  string `yaml:"time_format"`
+	TimeZone    string `yaml:"time_zone"`
+}
+
+// JobConnection holds the database connection of a job, either as a DSN or
+// as individual settings.
+type JobConnection struct {
+	DSN      string `yaml:"dsn"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Database string `yaml:"database"`
+}
+
+// DefaultsFileName is the name of the user defaults file in the config directory.
+const DefaultsFileName = "config.yaml"
+
+// LoadJobConfig reads and validates a YAML job file.
+// Unknown keys are rejected so that typos do not silently fall back to defaults.
+func LoadJobConfig(path string) (*JobConfig, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("unsupported job config %q: only YAML files (.yaml, .yml) are supported", path)
+	}
+	return readJobFile(path, "job config")
+}
+
+// LoadDefaults reads the user defaults file, a job file limited to the
+// settings shared by every export: format, table, compression, time_format,
+// time_zone and connection. A query, output or exports list is rejected,
+// since it belongs in a --config job file.
+func LoadDefaults(path string) (*JobConfig, error) {
+	job, err := readJobFile(path, "config")
+	if err != nil {
+		return nil, err
+	}
+	if job.SQL != "" || job.SQLFile != "" || job.Output != "" || len(job.Exports) > 0 {
+		return nil, fmt.Errorf("invalid config %q: sql, sqlfile, output and exports cannot be set as defaults, use a --config job file", path)
+	}
+	return job, nil
+}
+
+// DefaultConfigDir returns the directory searched for the user defaults file:
+// $XDG_CONFIG_HOME/pgxport or ~/.config/pgxport, or %AppData%\pgxport on Windows.
+func DefaultConfigDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "pgxport"), nil
+	}
+
+	// macOS also uses ~/.config rather than ~/Library/Application Support,
+	// like most command-line tools
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pgxport"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "pgxport"), nil
+}
+
+// readJobFile decodes and validates a YAML job file; what names the file in errors.
+func readJobFile(path, what string) (*JobConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", what, err)
+	}
+
+	var job JobConfig
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&job); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s %q is empty", what, path)
+		}
+		return nil, fmt.Errorf("invalid %s %q: %w", what, path, err)
+	}
+
+	if err := job.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", what, path, err)
+	}
+
+	return &job, nil
+}
+
+// Validate checks the job for conflicting or out-of-range settings.
+// Required values (query, output) are checked later, once merged with the CLI flags.
+func (j JobConfig) Validate() error {
+	if j.SQL != "" && j.SQLFile != "" {
+		return fmt.Errorf("sql and sqlfile cannot be used together")
+	}
+
+	c := j.Connection
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("connection.port must be a valid port number (1-65535)")
+	}
+
+	if c.DSN != "" && (c.Host != "" || c.Port != 0 || c.User != "" || c.Password != "" || c.Database != "") {
+		return fmt.Errorf("connection.dsn cannot be combined with host, port, user, password or database")
+	}
+
+	if len(j.Exports) > 0 {
+		if j.SQL != "" || j.SQLFile != "" || j.Output != "" {
+			return fmt.Errorf("sql, sqlfile and output must be set on each entry of exports, not at the top level")
+		}
+		return validateExports(j.Exports)
+	}
+
+	return nil
+}
+
+// validateExports checks that every batch entry has a unique name, exactly
+// one query source and its own output file.
+func validateExports(exports []JobExport) error {
+	names := make(map[string]bool, len(exports))
+	outputs := make(map[string]string, len(exports))
+
+	for i, e := range exports {
+		name := strings.TrimSpace(e.Name)
+		if name == "" {
+			return fmt.Errorf("exports[%d]: name is required", i)
+		}
+		if names[name] {
+			return fmt.Errorf("exports[%d]: duplicate name %q", i, name)
+		}
+		names[name] = true
+
+		if e.SQL == "" && e.SQLFile == "" {
+			return fmt.Errorf("export %q: one of sql or sqlfile is required", name)
+		}
+		if e.SQL != "" && e.SQLFile != "" {
+			return fmt.Errorf("export %q: sql and sqlfile cannot be used together", name)
+		}
+
+		if strings.TrimSpace(e.Output) == "" {
+			return fmt.Errorf("export %q: output is required", name)
+		}
+		out := filepath.Clean(e.Output)
+		if other, ok := outputs[out]; ok {
+			return fmt.Errorf("export %q: output %q is already used by export %q", name, e.Output, other)
+		}
+		outputs[out] = name
+	}
+
+	return nil
+}
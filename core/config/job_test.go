@@ -0,0 +1,335 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadJobConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		content     string
+		expected    JobConfig
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:     "full job file",
+			fileName: "job.yaml",
+			content: `sql: SELECT * FROM users
+format: json
+output: users.json
+compression: gzip
+time_format: yyyy-MM-dd
+time_zone: UTC
+connection:
+  host: db.example.com
+  port: 5433
+  user: reporter
+  password: secret
+  database: app
+`,
+			expected: JobConfig{
+				SQL:         "SELECT * FROM users",
+				Format:      "json",
+				Output:      "users.json",
+				Compression: "gzip",
+				TimeFormat:  "yyyy-MM-dd",
+				TimeZone:    "UTC",
+				Connection: JobConnection{
+					Host:     "db.example.com",
+					Port:     5433,
+					User:     "reporter",
+					Password: "secret",
+					Database: "app",
+				},
+			},
+		},
+		{
+			name:     "sqlfile and dsn with yml extension",
+			fileName: "job.yml",
+			content: `sqlfile: queries/report.sql
+format: sql
+table: report
+output: report.sql
+connection:
+  dsn: postgres://u:p@localhost:5432/app
+`,
+			expected: JobConfig{
+				SQLFile:    "queries/report.sql",
+				Format:     "sql",
+				Table:      "report",
+				Output:     "report.sql",
+				Connection: JobConnection{DSN: "postgres://u:p@localhost:5432/app"},
+			},
+		},
+		{
+			name:     "batch job with two exports",
+			fileName: "jobs.yaml",
+			content: `format: csv
+compression: gzip
+connection:
+  dsn: postgres://u:p@localhost:5432/app
+exports:
+  - name: users
+    sql: SELECT * FROM users
+    output: users.csv.gz
+  - name: orders
+    sqlfile: orders.sql
+    format: json
+    output: orders.json
+`,
+			expected: JobConfig{
+				Format:      "csv",
+				Compression: "gzip",
+				Connection:  JobConnection{DSN: "postgres://u:p@localhost:5432/app"},
+				Exports: []JobExport{
+					{Name: "users", SQL: "SELECT * FROM users", Output: "users.csv.gz"},
+					{Name: "orders", SQLFile: "orders.sql", Format: "json", Output: "orders.json"},
+				},
+			},
+		},
+		{
+			name:        "batch export without name",
+			fileName:    "jobs.yaml",
+			content:     "exports:\n  - sql: SELECT 1\n    output: a.csv\n",
+			wantErr:     true,
+			errContains: "exports[0]: name is required",
+		},
+		{
+			name:        "batch duplicate names",
+			fileName:    "jobs.yaml",
+			content:     "exports:\n  - {name: a, sql: SELECT 1, output: a.csv}\n  - {name: a, sql: SELECT 2, output: b.csv}\n",
+			wantErr:     true,
+			errContains: `duplicate name "a"`,
+		},
+		{
+			name:        "batch export without query",
+			fileName:    "jobs.yaml",
+			content:     "exports:\n  - {name: a, output: a.csv}\n",
+			wantErr:     true,
+			errContains: `export "a": one of sql or sqlfile is required`,
+		},
+		{
+			name:        "batch export without output",
+			fileName:    "jobs.yaml",
+			content:     "exports:\n  - {name: a, sql: SELECT 1}\n",
+			wantErr:     true,
+			errContains: `export "a": output is required`,
+		},
+		{
+			name:        "batch shared output",
+			fileName:    "jobs.yaml",
+			content:     "exports:\n  - {name: a, sql: SELECT 1, output: out.csv}\n  - {name: b, sql: SELECT 2, output: ./out.csv}\n",
+			wantErr:     true,
+			errContains: `already used by export "a"`,
+		},
+		{
+			name:        "batch with top-level query",
+			fileName:    "jobs.yaml",
+			content:     "sql: SELECT 1\nexports:\n  - {name: a, sql: SELECT 1, output: a.csv}\n",
+			wantErr:     true,
+			errContains: "must be set on each entry of exports",
+		},
+		{
+			name:        "unknown key",
+			fileName:    "job.yaml",
+			content:     "sql: SELECT 1\nformt: csv\n",
+			wantErr:     true,
+			errContains: "field formt not found",
+		},
+		{
+			name:        "unknown connection key",
+			fileName:    "job.yaml",
+			content:     "connection:\n  hostname: localhost\n",
+			wantErr:     true,
+			errContains: "field hostname not found",
+		},
+		{
+			name:        "sql and sqlfile",
+			fileName:    "job.yaml",
+			content:     "sql: SELECT 1\nsqlfile: q.sql\n",
+			wantErr:     true,
+			errContains: "sql and sqlfile cannot be used together",
+		},
+		{
+			name:        "dsn with host",
+			fileName:    "job.yaml",
+			content:     "connection:\n  dsn: postgres://localhost/app\n  host: other\n",
+			wantErr:     true,
+			errContains: "connection.dsn cannot be combined",
+		},
+		{
+			name:        "invalid port",
+			fileName:    "job.yaml",
+			content:     "connection:\n  port: 70000\n",
+			wantErr:     true,
+			errContains: "connection.port must be a valid port number",
+		},
+		{
+			name:        "wrong type",
+			fileName:    "job.yaml",
+			content:     "connection:\n  port: five\n",
+			wantErr:     true,
+			errContains: "invalid job config",
+		},
+		{
+			name:        "empty file",
+			fileName:    "job.yaml",
+			content:     "",
+			wantErr:     true,
+			errContains: "is empty",
+		},
+		{
+			name:        "toml not supported",
+			fileName:    "job.toml",
+			content:     "sql = \"SELECT 1\"\n",
+			wantErr:     true,
+			errContains: "only YAML files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write job file: %v", err)
+			}
+
+			job, err := LoadJobConfig(path)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadJobConfig() expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("LoadJobConfig() error = %q, want it to contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LoadJobConfig() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*job, tt.expected) {
+				t.Errorf("LoadJobConfig() = %+v, want %+v", *job, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadJobConfigMissingFile(t *testing.T) {
+	_, err := LoadJobConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "unable to read job config") {
+		t.Errorf("LoadJobConfig() error = %v, want read error", err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    JobConfig
+		errContains string
+	}{
+		{
+			name: "defaults",
+			content: `format: json
+compression: gzip
+time_format: yyyy-MM-dd
+connection:
+  host: db.example.com
+  user: reporter
+`,
+			expected: JobConfig{
+				Format:      "json",
+				Compression: "gzip",
+				TimeFormat:  "yyyy-MM-dd",
+				Connection:  JobConnection{Host: "db.example.com", User: "reporter"},
+			},
+		},
+		{
+			name:        "query not allowed",
+			content:     "sql: SELECT 1\n",
+			errContains: "sql, sqlfile, output and exports cannot be set as defaults",
+		},
+		{
+			name:        "output not allowed",
+			content:     "output: out.csv\n",
+			errContains: "cannot be set as defaults",
+		},
+		{
+			name:        "exports not allowed",
+			content:     "exports:\n  - name: users\n    sql: SELECT 1\n    output: users.csv\n",
+			errContains: "cannot be set as defaults",
+		},
+		{
+			name:        "unknown key",
+			content:     "formt: json\n",
+			errContains: "field formt not found",
+		},
+		{
+			name:        "empty file",
+			content:     "",
+			errContains: "is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultsFileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			defaults, err := LoadDefaults(path)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("LoadDefaults() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				if strings.Contains(err.Error(), "job config") {
+					t.Errorf("LoadDefaults() error = %q, should not call the file a job config", err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadDefaults() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*defaults, tt.expected) {
+				t.Errorf("LoadDefaults() = %+v, want %+v", *defaults, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows uses %AppData%")
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		t.Fatalf("DefaultConfigDir() error: %v", err)
+	}
+	if want := filepath.Join(xdg, "pgxport"); dir != want {
+		t.Errorf("DefaultConfigDir() = %q, want %q", dir, want)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", home)
+	dir, err = DefaultConfigDir()
+	if err != nil {
+		t.Fatalf("DefaultConfigDir() error: %v", err)
+	}
+	if want := filepath.Join(home, ".config", "pgxport"); dir != want {
+		t.Errorf("DefaultConfigDir() = %q, want %q", dir, want)
+	}
+}
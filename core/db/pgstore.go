@@ -2,18 +2,31 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // PgStore represents a PostgreSQL database store connection.
 type PgStore struct {
-	dsn  string
-	conn *pgx.Conn
+	dsn           string
+	conn          *pgx.Conn
+	sessionParams []SessionParam
+	noLogQuery    bool
+}
+
+// SessionParam is a run-time parameter (e.g. work_mem or statement_timeout)
+// set for the whole session right after connecting.
+type SessionParam struct {
+	Name  string
+	Value string
 }
 
 // NewPgStore creates a new PostgreSQL store instance with the given DSN.
@@ -24,11 +37,17 @@ func NewPgStore(dsn string) *PgStore {
 // Connect establishes a connection to the PostgreSQL database.
 // Returns an error if the connection fails or if ping fails.
 func (s *PgStore) Connect() error {
+	return s.ConnectContext(context.Background())
+}
+
+// ConnectContext is Connect, giving up when ctx is done before the
+// connection timeout. Its errors match ErrConnection.
+func (s *PgStore) ConnectContext(ctx context.Context) error {
 	if s.conn != nil {
 		return nil // already connected
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	logger.Debug("Connection timeout: 10s")
@@ -36,7 +55,7 @@ func (s *PgStore) Connect() error {
 
 	conn, err := pgx.Connect(ctx, s.dsn)
 	if err != nil {
-		return fmt.Errorf("unable to connect to database: %w", err)
+		return &connectionError{err: fmt.Errorf("unable to connect to database: %w", err)}
 	}
 
 	logger.Debug("Connection established, verifying connectivity (ping)...")
@@ -44,14 +63,36 @@ func (s *PgStore) Connect() error {
 	// Ping the database
 	if err := conn.Ping(ctx); err != nil {
 		conn.Close(ctx)
-		return fmt.Errorf("unable to ping database: %w", err)
+		return &connectionError{err: fmt.Errorf("unable to ping database: %w", err)}
 	}
 
 	logger.Debug("Database ping successful")
+
+	// set_config(name, value, false) is SET for the session, with the name and
+	// value passed as parameters rather than spliced into the statement
+	for _, p := range s.sessionParams {
+		logger.Debug("Setting session parameter %s = %s", p.Name, p.Value)
+		if _, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", p.Name, p.Value); err != nil {
+			conn.Close(ctx)
+			return &connectionError{err: fmt.Errorf("unable to set session parameter %s: %w", p.Name, err)}
+		}
+	}
+
 	s.conn = conn
 	return nil
 }
 
+// SetSessionParams sets the parameters applied, in order, by the next Connect.
+func (s *PgStore) SetSessionParams(params []SessionParam) {
+	s.sessionParams = params
+}
+
+// SetLogQuery controls whether the text of executed queries is logged in
+// verbose mode. When disabled only their length is logged.
+func (s *PgStore) SetLogQuery(enabled bool) {
+	s.noLogQuery = !enabled
+}
+
 // Close closes the database connection.
 // Returns an error if the close operation fails.
 func (s *PgStore) Close() error {
@@ -76,11 +117,11 @@ func (s *PgStore) Close() error {
 func (s *PgStore) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
 	if s.conn == nil {
 		logger.Debug("No active database connection; query cannot be executed")
-		return nil, fmt.Errorf("database not connected")
+		return nil, errNotConnected
 	}
 
 	logger.Debug("Executing SQL query...")
-	logger.Debug("Query: %s", sql)
+	s.logQuery(sql)
 
 	startTime := time.Now()
 	rows, err := s.conn.Query(ctx, sql, args...)
@@ -94,12 +135,364 @@ func (s *PgStore) Query(ctx context.Context, sql string, args ...any) (pgx.Rows,
 	return rows, nil
 }
 
+// EstimateRows asks the planner how many rows query will return, using
+// EXPLAIN (FORMAT JSON). The query is planned but not executed, so the result
+// is an estimate based on table statistics (pg_class.reltuples), not an exact count.
+func (s *PgStore) EstimateRows(ctx context.Context, query string) (int64, error) {
+	if s.conn == nil {
+		return 0, errNotConnected
+	}
+
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+
+	var plan []byte
+	if err := s.conn.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+query).Scan(&plan); err != nil {
+		return 0, fmt.Errorf("unable to explain query: %w", err)
+	}
+
+	return parsePlanRows(plan)
+}
+
+// MoneyFractionDigits returns the number of fraction digits of money values
+// in the session, which follows lc_monetary (2 for en_US, 0 for ja_JP).
+// PostgreSQL prints every money value with exactly that many digits.
+func (s *PgStore) MoneyFractionDigits(ctx context.Context) (int, error) {
+	if s.conn == nil {
+		return 0, errNotConnected
+	}
+
+	var digits int
+	if err := s.conn.QueryRow(ctx, "SELECT scale(1::money::numeric)").Scan(&digits); err != nil {
+		return 0, fmt.Errorf("unable to read money fraction digits: %w", err)
+	}
+	return digits, nil
+}
+
+// ExplainQuery returns the plan of query as printed by EXPLAIN (FORMAT TEXT),
+// one line per plan node. The query is planned but never executed: ANALYZE
+// is not used.
+func (s *PgStore) ExplainQuery(ctx context.Context, query string) (string, error) {
+	if s.conn == nil {
+		return "", errNotConnected
+	}
+
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+
+	rows, err := s.conn.Query(ctx, "EXPLAIN (FORMAT TEXT) "+query)
+	if err != nil {
+		return "", fmt.Errorf("unable to explain query: %w", err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", fmt.Errorf("unable to explain query: %w", err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parsePlanRows extracts the top-level "Plan Rows" from EXPLAIN (FORMAT JSON) output.
+func parsePlanRows(plan []byte) (int64, error) {
+	var explain []struct {
+		Plan struct {
+			PlanRows *float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explain); err != nil {
+		return 0, fmt.Errorf("unable to parse query plan: %w", err)
+	}
+	if len(explain) == 0 || explain[0].Plan.PlanRows == nil {
+		return 0, fmt.Errorf("query plan has no row estimate")
+	}
+	return int64(*explain[0].Plan.PlanRows), nil
+}
+
+// RelationExists reports whether name refers to a table, view or other
+// relation visible on the search path. name is written as in SQL, so quoted
+// identifiers keep their case (e.g. "public"."Users").
+func (s *PgStore) RelationExists(ctx context.Context, name string) (bool, error) {
+	if s.conn == nil {
+		return false, errNotConnected
+	}
+
+	var exists bool
+	if err := s.conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("unable to look up relation %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// Column describes a table column as reported by information_schema.columns.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+// columnsQuery lists the columns of the relation named by $1 in table order.
+// The relation is resolved with to_regclass, so name follows the same quoting
+// and search path rules as RelationExists. Array and user-defined types are
+// reported by their type name instead of "ARRAY" and "USER-DEFINED".
+const columnsQuery = `SELECT c.column_name,
+       CASE c.data_type
+           WHEN 'ARRAY' THEN ltrim(c.udt_name, '_') || '[]'
+           WHEN 'USER-DEFINED' THEN c.udt_name
+           ELSE c.data_type
+       END,
+       c.is_nullable = 'YES'
+FROM information_schema.columns c
+JOIN pg_catalog.pg_class r ON r.relname = c.table_name
+JOIN pg_catalog.pg_namespace n ON n.oid = r.relnamespace AND n.nspname = c.table_schema
+WHERE r.oid = to_regclass($1)
+ORDER BY c.ordinal_position`
+
+// Columns returns the columns of the table or view name, written as in SQL
+// (e.g. "public"."Users"). A relation the current user cannot see returns no
+// columns; use RelationExists to tell it apart from a missing one.
+func (s *PgStore) Columns(ctx context.Context, name string) ([]Column, error) {
+	if s.conn == nil {
+		return nil, errNotConnected
+	}
+
+	rows, err := s.conn.Query(ctx, columnsQuery, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list columns of %s: %w", name, err)
+	}
+
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Column, error) {
+		var c Column
+		err := row.Scan(&c.Name, &c.Type, &c.Nullable)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list columns of %s: %w", name, err)
+	}
+	return columns, nil
+}
+
+// primaryKeyQuery lists the primary key columns of the relation named by $1
+// in key order, resolving it like columnsQuery.
+const primaryKeyQuery = `SELECT k.column_name
+FROM information_schema.table_constraints c
+JOIN information_schema.key_column_usage k
+  ON k.constraint_schema = c.constraint_schema
+ AND k.constraint_name = c.constraint_name
+ AND k.table_schema = c.table_schema
+ AND k.table_name = c.table_name
+JOIN pg_catalog.pg_class r ON r.relname = c.table_name
+JOIN pg_catalog.pg_namespace n ON n.oid = r.relnamespace AND n.nspname = c.table_schema
+WHERE c.constraint_type = 'PRIMARY KEY'
+  AND r.oid = to_regclass($1)
+ORDER BY k.ordinal_position`
+
+// PrimaryKey returns the primary key columns of the table name, written as in
+// SQL, in key order. Views and tables without a primary key return none.
+func (s *PgStore) PrimaryKey(ctx context.Context, name string) ([]string, error) {
+	if s.conn == nil {
+		return nil, errNotConnected
+	}
+
+	rows, err := s.conn.Query(ctx, primaryKeyQuery, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up the primary key of %s: %w", name, err)
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up the primary key of %s: %w", name, err)
+	}
+	return columns, nil
+}
+
+// Table describes a table or view as reported by information_schema.tables.
+type Table struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+}
+
+// tablesQuery lists the tables and views of schema $1, or of every schema
+// except the system ones when $1 is empty. table_type is reported in lower
+// case ("table", "view", "foreign table", "temporary table").
+const tablesQuery = `SELECT table_schema,
+       table_name,
+       CASE table_type
+           WHEN 'BASE TABLE' THEN 'table'
+           WHEN 'VIEW' THEN 'view'
+           WHEN 'FOREIGN' THEN 'foreign table'
+           WHEN 'LOCAL TEMPORARY' THEN 'temporary table'
+           ELSE lower(table_type)
+       END
+FROM information_schema.tables
+WHERE CASE WHEN $1 = ''
+           THEN table_schema NOT IN ('pg_catalog', 'information_schema')
+                AND table_schema NOT LIKE 'pg\_toast%'
+                AND table_schema NOT LIKE 'pg\_temp\_%'
+           ELSE table_schema = $1
+      END
+ORDER BY table_schema, table_name`
+
+// Tables returns the tables and views visible to the current user in schema,
+// or in every non-system schema when schema is empty. schema is matched
+// exactly, so it is case-sensitive.
+func (s *PgStore) Tables(ctx context.Context, schema string) ([]Table, error) {
+	if s.conn == nil {
+		return nil, errNotConnected
+	}
+
+	rows, err := s.conn.Query(ctx, tablesQuery, schema)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Table, error) {
+		var t Table
+		err := row.Scan(&t.Schema, &t.Name, &t.Type)
+		return t, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// JSONKeys returns the keys found in the JSON objects of column across all
+// rows of query, sorted. The query runs in full, so this costs as much as
+// the export itself. Values that are not objects, and NULLs, are skipped.
+func (s *PgStore) JSONKeys(ctx context.Context, query, column string) ([]string, error) {
+	if s.conn == nil {
+		return nil, errNotConnected
+	}
+
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	value := "pgxport_keys." + pgx.Identifier{column}.Sanitize() + "::jsonb"
+	keysQuery := fmt.Sprintf(`SELECT DISTINCT k
+FROM (
+%s
+) AS pgxport_keys
+CROSS JOIN LATERAL jsonb_object_keys(CASE WHEN jsonb_typeof(%s) = 'object' THEN %s END) AS k
+ORDER BY k`, query, value, value)
+
+	rows, err := s.conn.Query(ctx, keysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list keys of %s: %w", column, err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("unable to list keys of %s: %w", column, err)
+	}
+	return keys, nil
+}
+
+// refcursorOID is the OID of the refcursor type, which pgtype does not name.
+const refcursorOID = 1790
+
+// QueryCursors runs query, which must return a single refcursor column such
+// as SELECT * FROM f() for a function returning SETOF refcursor, then fetches
+// each cursor in turn and passes its rows to fn, which reads them before the
+// next cursor is fetched. Cursors only live until the end of their
+// transaction, so everything runs in one; it is committed once every cursor
+// has been read. Returns the number of cursors read.
+func (s *PgStore) QueryCursors(ctx context.Context, query string, fn func(name string, rows pgx.Rows) error) (int, error) {
+	if s.conn == nil {
+		return 0, errNotConnected
+	}
+
+	logger.Debug("Executing refcursor query...")
+	s.logQuery(query)
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to start transaction: %w", err)
+	}
+	// A no-op once committed
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("query execution failed: %w", err)
+	}
+	fields := rows.FieldDescriptions()
+	if len(fields) != 1 || fields[0].DataTypeOID != refcursorOID {
+		rows.Close()
+		return 0, fmt.Errorf("query must return a single refcursor column, e.g. SELECT * FROM f() for a function returning SETOF refcursor")
+	}
+	names, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
+		var name pgtype.Text
+		if err := row.Scan(&name); err != nil {
+			return "", err
+		}
+		if !name.Valid {
+			return "", fmt.Errorf("query returned a NULL refcursor")
+		}
+		return name.String, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to read refcursors: %w", err)
+	}
+	logger.Debug("Query returned %d refcursors", len(names))
+
+	for i, name := range names {
+		rows, err := tx.Query(ctx, "FETCH ALL FROM "+pgx.Identifier{name}.Sanitize())
+		if err != nil {
+			return i, fmt.Errorf("unable to fetch refcursor %s: %w", name, err)
+		}
+		err = fn(name, rows)
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return len(names), fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return len(names), nil
+}
+
+// BeginReadOnly starts a read-only transaction on the connection, so the
+// queries that follow, and the functions they call, cannot modify data. The
+// transaction is rolled back when the connection is closed.
+func (s *PgStore) BeginReadOnly(ctx context.Context) error {
+	if s.conn == nil {
+		return errNotConnected
+	}
+	logger.Debug("Starting read-only transaction")
+	if _, err := s.conn.Exec(ctx, "BEGIN TRANSACTION READ ONLY"); err != nil {
+		return fmt.Errorf("unable to start read-only transaction: %w", err)
+	}
+	return nil
+}
+
 // Conn returns the underlying PostgreSQL connection.
 // This is useful for advanced operations like COPY that require direct connection access.
 func (s *PgStore) Conn() *pgx.Conn {
 	return s.conn
 }
 
+// logQuery logs query in verbose mode, with literals that look like secrets
+// masked, or only its length when query logging is disabled.
+func (s *PgStore) logQuery(query string) {
+	if s.noLogQuery {
+		logger.Debug("Query: <%d characters, not logged>", len(query))
+		return
+	}
+	logger.Debug("Query: %s", MaskQuery(query))
+}
+
+// secretLiteral matches a string literal following a name that suggests a
+// secret, e.g. password = 'x', PASSWORD 'x', api_token := 'x' or
+// secret LIKE 'x'. The name and operator are kept in group 1.
+var secretLiteral = regexp.MustCompile(`(?i)(\b\w*(?:password|passwd|pwd|token|secret|api_?key)\w*\s*(?:=>|:=|<>|!=|=|\bto\b|\bas\b|\blike\b|\bis\b)?\s*)[eE]?'(?:[^']|'')*'`)
+
+// MaskQuery replaces string literals that follow a password, token, secret or
+// API key name in query with '***', so the query can be logged. It is a
+// heuristic on the query text: literals elsewhere, such as in a function
+// argument list, are left as they are.
+func MaskQuery(query string) string {
+	return secretLiteral.ReplaceAllString(query, "${1}'***'")
+}
+
 // sanitizeDSN masks the password inside a PostgreSQL DSN before logging.
 func sanitizeDSN(dsn string) string {
 	u, err := url.Parse(dsn)
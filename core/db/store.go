@@ -2,10 +2,27 @@ package db
 
 import (
 	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// ErrConnection is matched by errors.Is when the database cannot be reached
+// or the session cannot be set up, and when a store is used before Connect.
+var ErrConnection = errors.New("database connection failed")
+
+// connectionError tags a failure with ErrConnection, keeping its message.
+// errors.As still reaches the underlying error (e.g. *pgconn.ConnectError).
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string   { return e.err.Error() }
+func (e *connectionError) Unwrap() []error { return []error{ErrConnection, e.err} }
+
+// errNotConnected is returned when a store is used before Connect.
+var errNotConnected = &connectionError{err: errors.New("database not connected")}
+
 // Store defines the interface for database operations.
 // Implementations should handle connection management and query execution.
 type Store interface {
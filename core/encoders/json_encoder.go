@@ -2,68 +2,289 @@ package encoders
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/elliotchance/orderedmap/v3"
 	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// JSON number modes for numeric and bigint columns
+const (
+	JSONNumbersNumber = "number" // exact JSON number literal (default)
+	JSONNumbersString = "string" // exact value as a JSON string
+)
+
+// JSON modes for NaN and infinite float or numeric values, which have no JSON number form
+const (
+	JSONSpecialFloatsNull   = "null"   // write null (default)
+	JSONSpecialFloatsString = "string" // write "NaN", "Infinity" or "-Infinity"
+)
+
+// JSON modes for real and double precision values
+const (
+	JSONFloatsAuto    = "auto"    // shortest form, with an exponent for very small or large values (default)
+	JSONFloatsDecimal = "decimal" // plain decimal digits, never an exponent
 )
 
 // OrderedJsonEncoder encodes JSON while preserving key order.
 type OrderedJsonEncoder struct {
-	timeLayout string
-	timezone   string
+	timeLayout            string
+	timezone              string
+	numbersAsString       bool
+	specialFloatsAsString bool
+	decimalFloats         bool
+	maxFieldLength        int
+	truncateMarker        string
+	compact               bool
+	keys                  *quotedKeys
+}
+
+// quotedKeys caches the JSON form of column names, which repeat on every row.
+type quotedKeys struct {
+	mu     sync.Mutex
+	quoted map[string][]byte
+}
+
+// get returns key as a quoted JSON string.
+func (q *quotedKeys) get(key string) ([]byte, error) {
+	if q == nil {
+		return marshalWithoutHTMLEscape(key)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if quoted, ok := q.quoted[key]; ok {
+		return quoted, nil
+	}
+	quoted, err := marshalWithoutHTMLEscape(key)
+	if err != nil {
+		return nil, err
+	}
+	q.quoted[key] = quoted
+	return quoted, nil
+}
+
+// rowState holds the buffers and encoders reused across rows.
+type rowState struct {
+	row      bytes.Buffer
+	value    bytes.Buffer
+	plain    *json.Encoder // writes to value
+	indented *json.Encoder // writes to value, for JSON objects
+}
+
+var rowStates = sync.Pool{
+	New: func() any {
+		st := &rowState{}
+		st.plain = json.NewEncoder(&st.value)
+		st.plain.SetEscapeHTML(false)
+		st.indented = json.NewEncoder(&st.value)
+		st.indented.SetEscapeHTML(false)
+		st.indented.SetIndent("    ", "  ")
+		return st
+	},
 }
 
 // NewOrderedJsonEncoder creates a new ordered JSON encoder with time formatting options.
-func NewOrderedJsonEncoder(timeFormat, timeZone string) OrderedJsonEncoder {
+// numbers selects how numeric and bigint values are written (JSONNumbersNumber or JSONNumbersString),
+// specialFloats how NaN and infinities are written (JSONSpecialFloatsNull or JSONSpecialFloatsString).
+func NewOrderedJsonEncoder(timeFormat, timeZone, numbers, specialFloats string) OrderedJsonEncoder {
 	return OrderedJsonEncoder{
-		timeLayout: timeFormat,
-		timezone:   timeZone,
+		timeLayout:            timeFormat,
+		timezone:              timeZone,
+		numbersAsString:       numbers == JSONNumbersString,
+		specialFloatsAsString: specialFloats == JSONSpecialFloatsString,
+		keys:                  &quotedKeys{quoted: make(map[string][]byte)},
 	}
 }
 
+// WithTruncation returns a copy of the encoder that cuts text, binary and JSON
+// values longer than maxLength characters and appends marker. JSON and array
+// values are measured by their serialized length and, once cut, written as a
+// string since the remaining text is no longer valid JSON.
+func (o OrderedJsonEncoder) WithTruncation(maxLength int, marker string) OrderedJsonEncoder {
+	o.maxFieldLength = maxLength
+	o.truncateMarker = marker
+	return o
+}
+
+// WithCompact returns a copy of the encoder that writes each row on a single
+// line, without the indentation and spaces of the default pretty form.
+func (o OrderedJsonEncoder) WithCompact(compact bool) OrderedJsonEncoder {
+	o.compact = compact
+	return o
+}
+
+// WithFloatFormat returns a copy of the encoder that writes real and double
+// precision values in the given mode (JSONFloatsAuto or JSONFloatsDecimal).
+// Decimal values use the shortest digits that parse back to the same float,
+// so 1e-7 is written 0.0000001 and 1e21 is written 1000000000000000000000.
+func (o OrderedJsonEncoder) WithFloatFormat(format string) OrderedJsonEncoder {
+	o.decimalFloats = format == JSONFloatsDecimal
+	return o
+}
+
 // EncodeRow encodes a row of data to JSON preserving key order with proper indentation.
 // Returns the JSON bytes and an error if encoding fails.
 func (o OrderedJsonEncoder) EncodeRow(rowData *orderedmap.OrderedMap[string, DataParams]) ([]byte, error) {
+	st := rowStates.Get().(*rowState)
+	defer rowStates.Put(st)
 
-	if rowData.Len() == 0 {
-		return []byte("{}"), nil
+	if err := o.encodeRow(st, rowData); err != nil {
+		return nil, err
 	}
+	return bytes.Clone(st.row.Bytes()), nil
+}
 
-	var row bytes.Buffer
+// WriteRow encodes a row like EncodeRow and writes it to w. The encoding
+// buffers are pooled, so a large export does not allocate them for every row.
+func (o OrderedJsonEncoder) WriteRow(w io.Writer, rowData *orderedmap.OrderedMap[string, DataParams]) error {
+	st := rowStates.Get().(*rowState)
+	defer rowStates.Put(st)
 
-	// Pre-allocate memory to avoid reallocation
-	row.Grow(rowData.Len() * 32)
+	if err := o.encodeRow(st, rowData); err != nil {
+		return err
+	}
+	_, err := w.Write(st.row.Bytes())
+	return err
+}
 
-	row.WriteString("{\n")
+// encodeRow encodes rowData into st.row.
+func (o OrderedJsonEncoder) encodeRow(st *rowState, rowData *orderedmap.OrderedMap[string, DataParams]) error {
+	st.row.Reset()
+
+	if rowData.Len() == 0 {
+		st.row.WriteString("{}")
+		return nil
+	}
+
+	// Pretty rows indent their members by 4 spaces
+	open, sep, colon, end := "{\n    ", ",\n    ", ": ", "\n  }"
+	if o.compact {
+		open, sep, colon, end = "{", ",", ":", "}"
+	}
+	st.row.WriteString(open)
 
 	i := 0
 
 	for k, v := range rowData.AllFromFront() {
 
 		if i > 0 {
-			row.WriteString(",\n")
+			st.row.WriteString(sep)
 		}
-		// Add indentation (4 spaces for inner content)
-		row.WriteString("    ")
 
-		row.WriteString(fmt.Sprintf("%q", k))
-		row.WriteString(": ")
-		// value
-		formattedValue := formatters.FormatJSONValue(v.Value, v.ValueType, o.timeLayout, o.timezone)
-		// Marshal formatted value with HTML escaping disabled
-		valueJSON, err := marshalWithoutHTMLEscape(formattedValue)
+		key, err := o.keys.get(k)
 		if err != nil {
-			return nil, fmt.Errorf("error marshaling value for key %q: %w", k, err)
+			return fmt.Errorf("error marshaling key %q: %w", k, err)
+		}
+		st.row.Write(key)
+		st.row.WriteString(colon)
+		// value
+		formattedValue := o.formatValue(v)
+		if o.maxFieldLength > 0 && formatters.Truncatable(v.Value, v.ValueType) {
+			formattedValue = o.truncate(formattedValue)
+		}
+		// Encode formatted value with HTML escaping disabled
+		if err := st.encodeValue(formattedValue, o.compact); err != nil {
+			return fmt.Errorf("error marshaling value for key %q: %w", k, err)
 		}
 
-		row.Write(valueJSON)
+		st.row.Write(bytes.TrimSuffix(st.value.Bytes(), []byte("\n")))
 		i++
 	}
 
-	row.WriteString("\n  }")
-	return row.Bytes(), nil
+	st.row.WriteString(end)
+	return nil
+}
+
+// encodeValue encodes v into st.value the way marshalWithoutHTMLEscape does.
+// JSON objects are indented to match the row unless compact is set.
+func (st *rowState) encodeValue(v any, compact bool) error {
+	st.value.Reset()
+	if _, ok := v.(map[string]interface{}); ok && !compact {
+		return st.indented.Encode(v)
+	}
+	return st.plain.Encode(v)
+}
+
+// formatValue converts a value to its JSON representation. Numeric and money
+// values keep their exact decimal digits instead of going through float64, bigint follows
+// the same number mode, and bytea is written as PostgreSQL hex text (\x...) so
+// binary data always yields valid JSON. Floats are written without an exponent
+// when decimal floats are enabled. NaN and infinities become null or a
+// string, since JSON has no number for them.
+func (o OrderedJsonEncoder) formatValue(v DataParams) any {
+	if text, ok := formatters.SpecialFloatText(v.Value); ok {
+		if o.specialFloatsAsString {
+			return text
+		}
+		return nil
+	}
+
+	switch v.ValueType {
+	case pgtype.NumericOID:
+		if num, ok := v.Value.(pgtype.Numeric); ok {
+			if text, ok := formatters.NumericText(num); ok {
+				if o.numbersAsString {
+					return text
+				}
+				return json.Number(text)
+			}
+		}
+	case formatters.MoneyOID:
+		if str, ok := v.Value.(string); ok {
+			if text, ok := formatters.MoneyText(str); ok {
+				if o.numbersAsString {
+					return text
+				}
+				return json.Number(text)
+			}
+		}
+	case pgtype.Int8OID:
+		if n, ok := v.Value.(int64); ok && o.numbersAsString {
+			return strconv.FormatInt(n, 10)
+		}
+	case pgtype.ByteaOID:
+		if b, ok := v.Value.([]byte); ok {
+			return `\x` + hex.EncodeToString(b)
+		}
+	case pgtype.Float4OID, pgtype.Float8OID:
+		if o.decimalFloats {
+			switch f := v.Value.(type) {
+			case float32:
+				return json.Number(strconv.FormatFloat(float64(f), 'f', -1, 32))
+			case float64:
+				return json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+			}
+		}
+	}
+	return formatters.FormatJSONValue(v.Value, v.ValueType, o.timeLayout, o.timezone)
+}
+
+// truncate cuts a formatted value to maxFieldLength characters. Values that are
+// not strings are cut by their compact JSON serialization.
+func (o OrderedJsonEncoder) truncate(val any) any {
+	text, ok := val.(string)
+	if !ok {
+		b, err := marshalWithoutHTMLEscape(val)
+		if err != nil {
+			return val
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, b); err != nil {
+			return val
+		}
+		text = compact.String()
+		if utf8.RuneCountInString(text) <= o.maxFieldLength {
+			return val
+		}
+	}
+	return formatters.TruncateText(text, o.maxFieldLength, o.truncateMarker)
 }
 
 func marshalWithoutHTMLEscape(v interface{}) ([]byte, error) {
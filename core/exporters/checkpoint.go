@@ -0,0 +1,79 @@
+package exporters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// checkpointSuffix is appended to the output path to name its checkpoint file.
+const checkpointSuffix = ".checkpoint"
+
+// Checkpoint records how far an export has got: the rows and bytes written to
+// the output when it was last flushed, and which query produced them. An
+// interrupted export resumes by truncating the output to Bytes and skipping
+// the first Rows rows of the query.
+type Checkpoint struct {
+	Rows  int    `json:"rows"`
+	Bytes int64  `json:"bytes"`
+	Query string `json:"query"` // QueryFingerprint of the exported query
+}
+
+// CheckpointPath returns the checkpoint file kept next to outputPath.
+func CheckpointPath(outputPath string) string {
+	return outputPath + checkpointSuffix
+}
+
+// QueryFingerprint identifies a query in a checkpoint, so a checkpoint is
+// never applied to the output of another query.
+func QueryFingerprint(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadCheckpoint reads the checkpoint file at path.
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	var cp Checkpoint
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cp, err
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	if cp.Rows < 0 || cp.Bytes < 0 {
+		return cp, fmt.Errorf("invalid checkpoint file %s: negative position", path)
+	}
+	return cp, nil
+}
+
+// writeCheckpoint replaces the checkpoint file at path. It writes a temporary
+// file and renames it, so a crash never leaves a half-written checkpoint.
+func writeCheckpoint(path string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	logger.Debug("Checkpoint written: %d rows, %d bytes", cp.Rows, cp.Bytes)
+	return nil
+}
+
+// resumeBytes returns the output size to resume from, or 0 for a new output.
+func (o ExportOptions) resumeBytes() int64 {
+	if o.Resume == nil {
+		return 0
+	}
+	return o.Resume.Bytes
+}
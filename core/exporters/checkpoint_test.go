@@ -0,0 +1,145 @@
+package exporters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := CheckpointPath(filepath.Join(t.TempDir(), "output.csv"))
+	want := Checkpoint{Rows: 42, Bytes: 1234, Query: QueryFingerprint("SELECT 1")}
+
+	if err := writeCheckpoint(path, want); err != nil {
+		t.Fatalf("writeCheckpoint() error = %v", err)
+	}
+	got, err := ReadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ReadCheckpoint() = %+v, want %+v", got, want)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary checkpoint file left behind: %v", err)
+	}
+}
+
+func TestReadCheckpointInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not json", "rows=3", "invalid checkpoint file"},
+		{"negative rows", `{"rows":-1,"bytes":10}`, "negative position"},
+		{"negative bytes", `{"rows":1,"bytes":-10}`, "negative position"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "output.csv.checkpoint")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("Failed to write checkpoint: %v", err)
+			}
+			_, err := ReadCheckpoint(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadCheckpoint() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSVCheckpointAndResume(t *testing.T) {
+	names := []string{"id", "name"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID}
+	data := [][]any{
+		{int32(1), "alice"}, {int32(2), "bob"}, {int32(3), "carol"},
+		{int32(4), "dave"}, {int32(5), "erin"}, {int32(6), "frank"},
+	}
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+
+	// Output up to the checkpoint at row 4
+	const checkpointed = "id,name\n1,alice\n2,bob\n3,carol\n4,dave\n"
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			dir := t.TempDir()
+			options := ExportOptions{
+				Format:           FormatCSV,
+				Delimiter:        ',',
+				Compression:      "none",
+				Workers:          workers,
+				CsvTrailer:       true,
+				CsvTrailerPrefix: "#ROWS=",
+				CheckpointEvery:  2,
+				CheckpointQuery:  QueryFingerprint("SELECT * FROM users ORDER BY id"),
+			}
+
+			// Reference: the whole result in one run
+			options.OutputPath = filepath.Join(dir, "full.csv")
+			if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			want, err := os.ReadFile(options.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+
+			// First run: the connection drops after 5 rows, so the last
+			// checkpoint is at row 4 and row 5 was only partly written
+			options.OutputPath = filepath.Join(dir, "resumed.csv")
+			options.CsvTrailer = false
+			if _, err := exporter.Export(newFakeRows(names, oids, data[:5]), options); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			cp, err := ReadCheckpoint(CheckpointPath(options.OutputPath))
+			if err != nil {
+				t.Fatalf("ReadCheckpoint() error = %v", err)
+			}
+			if cp.Rows != 4 || cp.Query != options.CheckpointQuery {
+				t.Fatalf("checkpoint = %+v, want 4 rows of the exported query", cp)
+			}
+			if cp.Bytes != int64(len(checkpointed)) {
+				t.Fatalf("checkpoint bytes = %d, want %d", cp.Bytes, len(checkpointed))
+			}
+			if err := os.WriteFile(options.OutputPath, []byte(checkpointed+"5,er"), 0o644); err != nil {
+				t.Fatalf("Failed to write output: %v", err)
+			}
+
+			// Second run: the query skips the checkpointed rows (OFFSET 4)
+			options.CsvTrailer = true
+			options.Resume = &cp
+			rowCount, err := exporter.Export(newFakeRows(names, oids, data[4:]), options)
+			if err != nil {
+				t.Fatalf("resumed Export() error = %v", err)
+			}
+			if rowCount != len(data) {
+				t.Errorf("resumed Export() = %d rows, want %d", rowCount, len(data))
+			}
+			got, err := os.ReadFile(options.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("resumed output = %q, want %q", got, want)
+			}
+
+			cp, err = ReadCheckpoint(CheckpointPath(options.OutputPath))
+			if err != nil {
+				t.Fatalf("ReadCheckpoint() error = %v", err)
+			}
+			if cp.Rows != 6 {
+				t.Errorf("checkpoint rows after resume = %d, want 6", cp.Rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package exporters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// coalescedRows replaces NULL in some columns with a default value. The
+// defaults are converted to the column type once, so every exporter formats
+// them like values read from the database.
+type coalescedRows struct {
+	pgx.Rows
+	defaults []any // default of each column, nil when the column is not coalesced
+	columns  []int // positions of the coalesced columns
+}
+
+// CoalesceColumns wraps rows to replace NULL in the columns of
+// options.Coalesce with their default, or returns rows unchanged when it is
+// not set. Every listed column must be in the result and its default must
+// be a valid value of the column type.
+func CoalesceColumns(rows pgx.Rows, options ExportOptions) (pgx.Rows, error) {
+	if len(options.Coalesce) == 0 {
+		return rows, nil
+	}
+
+	names := make([]string, 0, len(options.Coalesce))
+	for name := range options.Coalesce {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := rows.FieldDescriptions()
+	defaults := make([]any, len(fields))
+	var columns []int
+	for _, name := range names {
+		found := false
+		for i, fd := range fields {
+			if fd.Name != name {
+				continue
+			}
+			value, err := formatters.CoalesceValue(options.Coalesce[name], fd.DataTypeOID)
+			if err != nil {
+				return nil, fmt.Errorf("--coalesce column %q: %w", name, err)
+			}
+			defaults[i] = value
+			columns = append(columns, i)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("--coalesce column %q not found in query results", name)
+		}
+	}
+	sort.Ints(columns)
+	logger.Debug("Replacing NULL with a default in columns: %s", strings.Join(names, ", "))
+
+	return &coalescedRows{Rows: rows, defaults: defaults, columns: columns}, nil
+}
+
+// Scan is not supported: a NULL cannot be told apart in arbitrary scan
+// destinations, so coalescedRows is always read with Values.
+func (r *coalescedRows) Scan(dest ...any) error {
+	return fmt.Errorf("coalesced rows are read with Values")
+}
+
+// Values returns the current row with NULL in the coalesced columns replaced
+// by their default.
+func (r *coalescedRows) Values() ([]any, error) {
+	values, err := r.Rows.Values()
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range r.columns {
+		if values[i] == nil {
+			values[i] = r.defaults[i]
+		}
+	}
+	return values, nil
+}
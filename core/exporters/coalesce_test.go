@@ -0,0 +1,157 @@
+package exporters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCoalesceColumns(t *testing.T) {
+	names := []string{"name", "middle_name", "discount"}
+	oids := []uint32{pgtype.TextOID, pgtype.TextOID, pgtype.Int4OID}
+
+	tests := []struct {
+		name        string
+		coalesce    map[string]string
+		errContains string
+	}{
+		{
+			name:     "listed columns",
+			coalesce: map[string]string{"middle_name": "", "discount": "0"},
+		},
+		{
+			name:        "unknown column",
+			coalesce:    map[string]string{"nickname": ""},
+			errContains: `--coalesce column "nickname" not found in query results`,
+		},
+		{
+			name:        "default not valid for the column type",
+			coalesce:    map[string]string{"discount": "none"},
+			errContains: `--coalesce column "discount": "none" is not a valid integer`,
+		},
+		{
+			name:        "empty default for a number",
+			coalesce:    map[string]string{"discount": ""},
+			errContains: `"" is not a valid integer`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newFakeRows(names, oids, [][]any{{"alice", nil, nil}, {"bob", "j", int32(10)}})
+			coalesced, err := CoalesceColumns(rows, ExportOptions{Coalesce: tt.coalesce})
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("CoalesceColumns() error = %v, want containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CoalesceColumns() error: %v", err)
+			}
+
+			want := [][]any{{"alice", "", int32(0)}, {"bob", "j", int32(10)}}
+			for i := 0; coalesced.Next(); i++ {
+				values, err := coalesced.Values()
+				if err != nil {
+					t.Fatalf("Values() error: %v", err)
+				}
+				for j, v := range values {
+					if v != want[i][j] {
+						t.Errorf("row %d column %s = %#v, want %#v", i+1, names[j], v, want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCoalesceColumnsUnset(t *testing.T) {
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, nil)
+	coalesced, err := CoalesceColumns(rows, ExportOptions{})
+	if err != nil {
+		t.Fatalf("CoalesceColumns() error: %v", err)
+	}
+	if coalesced != rows {
+		t.Error("CoalesceColumns() without Coalesce should return rows unchanged")
+	}
+}
+
+func TestExportCoalesce(t *testing.T) {
+	names := []string{"id", "middle_name", "discount", "active"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.NumericOID, pgtype.BoolOID}
+	data := [][]any{
+		{int32(1), "Jane", nil, nil},
+		{int32(2), nil, nil, true},
+	}
+	coalesce := map[string]string{"middle_name": "", "discount": "0", "active": "false"}
+
+	tests := []struct {
+		name     string
+		format   string
+		workers  int
+		expected string
+	}{
+		{
+			name:     "csv",
+			format:   FormatCSV,
+			expected: "id,middle_name,discount,active\n1,Jane,0,false\n2,,0,true\n",
+		},
+		{
+			name:     "csv with workers",
+			format:   FormatCSV,
+			workers:  2,
+			expected: "id,middle_name,discount,active\n1,Jane,0,false\n2,,0,true\n",
+		},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			expected: "[\n{\"id\":1,\"middle_name\":\"Jane\",\"discount\":0,\"active\":false},\n{\"id\":2,\"middle_name\":\"\",\"discount\":0,\"active\":true}\n]\n",
+		},
+		{
+			name:     "sql",
+			format:   FormatSQL,
+			expected: "INSERT INTO \"people\" (\"id\", \"middle_name\", \"discount\", \"active\") VALUES\n\t(1, 'Jane', 0, false);\nINSERT INTO \"people\" (\"id\", \"middle_name\", \"discount\", \"active\") VALUES\n\t(2, '', 0, true);\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			options := ExportOptions{
+				Format:          tt.format,
+				Compression:     "none",
+				OutputPath:      outputPath,
+				Delimiter:       ',',
+				TableName:       "people",
+				RowPerStatement: 1,
+				Workers:         tt.workers,
+				Compact:         true,
+				Coalesce:        coalesce,
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			rows, err := CoalesceColumns(newFakeRows(names, oids, data), options)
+			if err != nil {
+				t.Fatalf("CoalesceColumns() error: %v", err)
+			}
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output =\n%s\nwant\n%s", content, tt.expected)
+			}
+		})
+	}
+}
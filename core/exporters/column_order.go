@@ -0,0 +1,110 @@
+package exporters
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Column ordering modes
+const (
+	ColumnOrderNatural = "natural"  // query order (default)
+	ColumnOrderAlpha   = "alpha"    // sorted by name, ignoring case
+	ColumnOrderPKFirst = "pk-first" // primary key columns first, then query order
+)
+
+// orderedRows presents the columns of rows in another order. Values and Scan
+// are permuted, so the scan fast path of rowReader still applies.
+type orderedRows struct {
+	pgx.Rows
+	order  []int // order[i] is the source position of output column i
+	fields []pgconn.FieldDescription
+	dests  []any
+}
+
+// OrderColumns wraps rows so their columns follow options.ColumnOrder, or
+// returns rows unchanged for natural order. pk-first moves the columns of
+// options.PrimaryKey to the front in key order; the other columns, and
+// every column in alpha order, keep their query order among equals.
+func OrderColumns(rows pgx.Rows, options ExportOptions) pgx.Rows {
+	source := rows.FieldDescriptions()
+	order := make([]int, len(source))
+	for i := range order {
+		order[i] = i
+	}
+
+	switch options.ColumnOrder {
+	case ColumnOrderAlpha:
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(strings.ToLower(source[a].Name), strings.ToLower(source[b].Name))
+		})
+	case ColumnOrderPKFirst:
+		rank := func(i int) int {
+			if pos := slices.Index(options.PrimaryKey, source[i].Name); pos >= 0 {
+				return pos
+			}
+			return len(options.PrimaryKey)
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(rank(a), rank(b))
+		})
+	default:
+		return rows
+	}
+
+	fields := make([]pgconn.FieldDescription, len(order))
+	for i, src := range order {
+		fields[i] = source[src]
+	}
+	logger.Debug("Column order (%s): %s", options.ColumnOrder, strings.Join(fieldNames(fields), ", "))
+
+	return &orderedRows{
+		Rows:   rows,
+		order:  order,
+		fields: fields,
+		dests:  make([]any, len(order)),
+	}
+}
+
+func (r *orderedRows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.fields
+}
+
+// Scan scans the current row into dest, given in output column order.
+func (r *orderedRows) Scan(dest ...any) error {
+	if len(dest) != len(r.order) {
+		return fmt.Errorf("number of field descriptions must equal number of destinations, got %d and %d", len(r.order), len(dest))
+	}
+	for i, src := range r.order {
+		r.dests[src] = dest[i]
+	}
+	return r.Rows.Scan(r.dests...)
+}
+
+// Values returns the current row in output column order. Each row gets a new
+// slice, as with pgx.Rows.
+func (r *orderedRows) Values() ([]any, error) {
+	source, err := r.Rows.Values()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(r.order))
+	for i, src := range r.order {
+		values[i] = source[src]
+	}
+	return values, nil
+}
+
+// fieldNames returns the names of fields.
+func fieldNames(fields []pgconn.FieldDescription) []string {
+	names := make([]string, len(fields))
+	for i, fd := range fields {
+		names[i] = fd.Name
+	}
+	return names
+}
@@ -0,0 +1,131 @@
+package exporters
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestOrderColumns(t *testing.T) {
+	names := []string{"name", "id", "Region", "created_at", "amount"}
+
+	tests := []struct {
+		name       string
+		order      string
+		primaryKey []string
+		expected   []string
+	}{
+		{
+			name:     "natural keeps query order",
+			order:    ColumnOrderNatural,
+			expected: names,
+		},
+		{
+			name:     "empty is natural",
+			order:    "",
+			expected: names,
+		},
+		{
+			name:     "alpha ignores case",
+			order:    ColumnOrderAlpha,
+			expected: []string{"amount", "created_at", "id", "name", "Region"},
+		},
+		{
+			name:       "primary key first in key order",
+			order:      ColumnOrderPKFirst,
+			primaryKey: []string{"Region", "id"},
+			expected:   []string{"Region", "id", "name", "created_at", "amount"},
+		},
+		{
+			name:     "no primary key keeps query order",
+			order:    ColumnOrderPKFirst,
+			expected: names,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oids := make([]uint32, len(names))
+			for i := range oids {
+				oids[i] = pgtype.TextOID
+			}
+			rows := OrderColumns(newFakeRows(names, oids, nil), ExportOptions{ColumnOrder: tt.order, PrimaryKey: tt.primaryKey})
+
+			got := fieldNames(rows.FieldDescriptions())
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("OrderColumns() columns = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportColumnOrderAlpha(t *testing.T) {
+	names := []string{"name", "id", "city"}
+	oids := []uint32{pgtype.TextOID, pgtype.Int4OID, pgtype.TextOID}
+	data := [][]any{
+		{"alice", int32(1), "Paris"},
+		{"bob", int32(2), nil},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		workers  int
+		expected string
+	}{
+		{
+			name:     "csv",
+			format:   FormatCSV,
+			expected: "city,id,name\nParis,1,alice\n,2,bob\n",
+		},
+		{
+			name:     "csv with workers",
+			format:   FormatCSV,
+			workers:  2,
+			expected: "city,id,name\nParis,1,alice\n,2,bob\n",
+		},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			expected: "[\n{\"city\":\"Paris\",\"id\":1,\"name\":\"alice\"},\n{\"city\":null,\"id\":2,\"name\":\"bob\"}\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			options := ExportOptions{
+				Format:      tt.format,
+				Compression: "none",
+				OutputPath:  outputPath,
+				Delimiter:   ',',
+				Workers:     tt.workers,
+				Compact:     true,
+				ColumnOrder: ColumnOrderAlpha,
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			rowCount, err := exporter.Export(OrderColumns(newFakeRows(names, oids, data), options), options)
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != 2 {
+				t.Errorf("Export() rowCount = %d, want 2", rowCount)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output =\n%s\nwant\n%s", content, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Duplicate column handling modes
+const (
+	DedupeWarn   = "warn"   // log a warning (default)
+	DedupeError  = "error"  // abort the export
+	DedupeSuffix = "suffix" // rename later occurrences silently: id, id_2, id_3
+)
+
+// Header case transforms
+const (
+	HeaderCaseUpper = "upper" // ORDER_ID
+	HeaderCaseLower = "lower" // order_id
+	HeaderCaseTitle = "title" // Order Id
+	HeaderCaseSnake = "snake" // order_id
+	HeaderCaseCamel = "camel" // orderId
+)
+
+// ErrNoColumns is returned when the query result has no columns, e.g.
+// "SELECT FROM users", so there is nothing to write.
+var ErrNoColumns = errors.New("query returned no columns, nothing to export")
+
+// ColumnNames returns the output column names for fields, detecting duplicate
+// names (e.g. "SELECT a.id, b.id") and handling them according to
+// options.DedupeColumns.
+//
+// Positional formats (CSV, XLSX, XML) keep duplicate names as-is since every
+// value stays in its own column. Keyed formats (JSON, YAML, template, SQL)
+// would collapse or reject them, so duplicates are always suffixed there
+// unless the export is configured to fail.
+//
+// A result without columns is rejected with ErrNoColumns; every exporter calls
+// ColumnNames before creating the output file, so no malformed file is left.
+func ColumnNames(fields []pgconn.FieldDescription, options ExportOptions, keyed bool) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, ErrNoColumns
+	}
+
+	names := make([]string, len(fields))
+	for i, fd := range fields {
+		names[i] = fd.Name
+	}
+
+	dups := duplicateNames(names)
+	if len(dups) == 0 {
+		return names, nil
+	}
+
+	if options.DedupeColumns == DedupeError {
+		return nil, fmt.Errorf("duplicate column names in result: %s", strings.Join(dups, ", "))
+	}
+
+	if !keyed {
+		logger.Debug("Keeping duplicate column names in positional output: %s", strings.Join(dups, ", "))
+		return names, nil
+	}
+
+	if options.DedupeColumns == DedupeSuffix {
+		logger.Debug("Renaming duplicate columns: %s", strings.Join(dups, ", "))
+	} else {
+		logger.Warn("Duplicate column names in result: %s (renamed with _2, _3, ... suffixes)", strings.Join(dups, ", "))
+	}
+	return suffixDuplicates(names), nil
+}
+
+// duplicateNames returns each name that appears more than once, in order of
+// first occurrence.
+func duplicateNames(names []string) []string {
+	counts := make(map[string]int, len(names))
+	var dups []string
+	for _, name := range names {
+		counts[name]++
+		if counts[name] == 2 {
+			dups = append(dups, name)
+		}
+	}
+	return dups
+}
+
+// suffixDuplicates keeps the first occurrence of each name and appends _2, _3,
+// ... to later ones, skipping suffixes already taken by another column.
+func suffixDuplicates(names []string) []string {
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+
+	result := make([]string, len(names))
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		seen[name]++
+		if seen[name] == 1 {
+			result[i] = name
+			continue
+		}
+		n := seen[name]
+		candidate := name + "_" + strconv.Itoa(n)
+		for taken[candidate] {
+			n++
+			candidate = name + "_" + strconv.Itoa(n)
+		}
+		seen[name] = n
+		taken[candidate] = true
+		result[i] = candidate
+	}
+	return result
+}
+
+// HeaderNames returns the names written for columns in headers, JSON keys and
+// XML elements, transformed by options.HeaderCase. Columns are still looked up
+// by their original names (e.g. --json-key-by). In keyed formats, names that
+// the transform makes equal are suffixed like duplicates. In XML, a name the
+// transform changes must remain a valid element name unless XmlSanitizeNames
+// is set.
+func HeaderNames(columns []string, options ExportOptions, keyed bool) ([]string, error) {
+	if options.HeaderCase == "" {
+		return columns, nil
+	}
+
+	headers := make([]string, len(columns))
+	for i, name := range columns {
+		headers[i] = headerCase(name, options.HeaderCase)
+		if options.Format == FormatXML && !options.XmlSanitizeNames && headers[i] != name && !ValidXMLName(headers[i]) {
+			return nil, fmt.Errorf("--header-case %s turns column %q into %q, which is not a valid XML element name", options.HeaderCase, name, headers[i])
+		}
+	}
+
+	if dups := duplicateNames(headers); keyed && len(dups) > 0 {
+		logger.Warn("--header-case %s gives several columns the same name: %s (renamed with _2, _3, ... suffixes)", options.HeaderCase, strings.Join(dups, ", "))
+		headers = suffixDuplicates(headers)
+	}
+	return headers, nil
+}
+
+// customHeaders returns options.CustomHeaders in place of the header row
+// built from the columns, or headers when they are not set. There must be
+// exactly one custom header per column.
+func customHeaders(headers []string, options ExportOptions) ([]string, error) {
+	if options.CustomHeaders == nil {
+		return headers, nil
+	}
+	if len(options.CustomHeaders) != len(headers) {
+		return nil, fmt.Errorf("--header-from-file has %d headers but the query returns %d columns", len(options.CustomHeaders), len(headers))
+	}
+	logger.Debug("Replacing header row with --header-from-file: %s", strings.Join(options.CustomHeaders, ", "))
+	return options.CustomHeaders, nil
+}
+
+var titleCaser = cases.Title(language.English)
+
+// headerCase applies a header case transform to a column name. Title, snake
+// and camel split the name into words at spaces, punctuation and lower to
+// upper case changes, so order_id, orderId and "Order ID" give the same words.
+func headerCase(name, mode string) string {
+	switch mode {
+	case HeaderCaseUpper:
+		return strings.ToUpper(name)
+	case HeaderCaseLower:
+		return strings.ToLower(name)
+	}
+
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	for i, w := range words {
+		switch {
+		case mode == HeaderCaseTitle, mode == HeaderCaseCamel && i > 0:
+			words[i] = titleCaser.String(w)
+		default:
+			words[i] = strings.ToLower(w)
+		}
+	}
+
+	switch mode {
+	case HeaderCaseTitle:
+		return strings.Join(words, " ")
+	case HeaderCaseSnake:
+		return strings.Join(words, "_")
+	case HeaderCaseCamel:
+		return strings.Join(words, "")
+	}
+	return name
+}
+
+// splitWords splits a column name into words: runs of letters and digits,
+// also broken where a lower case letter or digit is followed by an upper case
+// one (orderId) and before the last capital of an acronym (HTTPStatus).
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
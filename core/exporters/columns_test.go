@@ -0,0 +1,587 @@
+package exporters
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSuffixDuplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "no duplicates",
+			input:    []string{"id", "name"},
+			expected: []string{"id", "name"},
+		},
+		{
+			name:     "single duplicate",
+			input:    []string{"id", "id"},
+			expected: []string{"id", "id_2"},
+		},
+		{
+			name:     "three occurrences",
+			input:    []string{"id", "name", "id", "id"},
+			expected: []string{"id", "name", "id_2", "id_3"},
+		},
+		{
+			name:     "suffix already taken",
+			input:    []string{"id", "id_2", "id"},
+			expected: []string{"id", "id_2", "id_3"},
+		},
+		{
+			name:     "several duplicated names",
+			input:    []string{"id", "name", "id", "name"},
+			expected: []string{"id", "name", "id_2", "name_2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := suffixDuplicates(tt.input)
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("suffixDuplicates(%v) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	fields := []pgconn.FieldDescription{{Name: "id"}, {Name: "name"}, {Name: "id"}}
+
+	tests := []struct {
+		name     string
+		mode     string
+		keyed    bool
+		expected []string
+		wantErr  bool
+	}{
+		{name: "positional default keeps names", mode: "", expected: []string{"id", "name", "id"}},
+		{name: "positional warn keeps names", mode: DedupeWarn, expected: []string{"id", "name", "id"}},
+		{name: "positional suffix keeps names", mode: DedupeSuffix, expected: []string{"id", "name", "id"}},
+		{name: "positional error", mode: DedupeError, wantErr: true},
+		{name: "keyed default suffixes", mode: "", keyed: true, expected: []string{"id", "name", "id_2"}},
+		{name: "keyed warn suffixes", mode: DedupeWarn, keyed: true, expected: []string{"id", "name", "id_2"}},
+		{name: "keyed suffix", mode: DedupeSuffix, keyed: true, expected: []string{"id", "name", "id_2"}},
+		{name: "keyed error", mode: DedupeError, keyed: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ColumnNames(fields, ExportOptions{DedupeColumns: tt.mode}, tt.keyed)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "duplicate column names") {
+					t.Errorf("ColumnNames() error = %v, want duplicate column error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ColumnNames() unexpected error: %v", err)
+			}
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("ColumnNames() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportDuplicateColumns(t *testing.T) {
+	formats := []struct {
+		format string
+		want   string // substring expected in the default (warn) mode
+	}{
+		// positional formats keep both columns under the same name
+		{FormatCSV, "id,id\n1,2\n"},
+		{FormatXML, "<id>1</id>\n    <id>2</id>"},
+		// keyed formats suffix the later occurrence
+		{FormatJSON, "\"id\": 1,\n    \"id_2\": 2"},
+		{FormatYAML, "id: 1\n  id_2: 2"},
+		{FormatSQL, `("id", "id_2")`},
+	}
+
+	for _, ff := range formats {
+		for _, mode := range []string{DedupeWarn, DedupeError} {
+			t.Run(ff.format+"/"+mode, func(t *testing.T) {
+				outputPath := filepath.Join(t.TempDir(), "output."+ff.format)
+				rows := newFakeRows(
+					[]string{"id", "id"},
+					[]uint32{pgtype.Int4OID, pgtype.Int4OID},
+					[][]any{{int32(1), int32(2)}},
+				)
+
+				exporter, err := Get(ff.format)
+				if err != nil {
+					t.Fatalf("Failed to get %s exporter: %v", ff.format, err)
+				}
+				options := ExportOptions{
+					Format:          ff.format,
+					Delimiter:       ',',
+					Compression:     "none",
+					OutputPath:      outputPath,
+					TableName:       "t",
+					RowPerStatement: 1,
+					XmlRootElement:  "results",
+					XmlRowElement:   "row",
+					DedupeColumns:   mode,
+				}
+
+				_, err = exporter.Export(rows, options)
+
+				if mode == DedupeError {
+					if err == nil {
+						t.Fatal("Export() expected error for duplicate columns, got nil")
+					}
+					if _, statErr := os.Stat(outputPath); statErr == nil {
+						t.Error("Export() should not create the output file when rejecting duplicate columns")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Export() error: %v", err)
+				}
+
+				content, err := os.ReadFile(outputPath)
+				if err != nil {
+					t.Fatalf("Failed to read output file: %v", err)
+				}
+
+				if !strings.Contains(string(content), ff.want) {
+					t.Errorf("output should contain %q, got:\n%s", ff.want, content)
+				}
+			})
+		}
+	}
+}
+
+func TestExportDuplicateColumnsXLSX(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+	rows := newFakeRows(
+		[]string{"id", "id"},
+		[]uint32{pgtype.Int4OID, pgtype.Int4OID},
+		[][]any{{int32(1), int32(2)}},
+	)
+
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:        FormatXLSX,
+		Compression:   "none",
+		OutputPath:    outputPath,
+		DedupeColumns: DedupeSuffix,
+	}
+
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX file: %v", err)
+	}
+	defer f.Close()
+
+	sheetRows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+
+	expected := [][]string{{"id", "id"}, {"1", "2"}}
+	if len(sheetRows) != len(expected) {
+		t.Fatalf("got %d rows, want %d", len(sheetRows), len(expected))
+	}
+	for i := range expected {
+		if !slices.Equal(sheetRows[i], expected[i]) {
+			t.Errorf("row %d = %v, want %v", i, sheetRows[i], expected[i])
+		}
+	}
+}
+
+func TestExportNoColumns(t *testing.T) {
+	for _, format := range List() {
+		t.Run(format, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputPath := filepath.Join(tmpDir, "output."+format)
+			tplPath := filepath.Join(tmpDir, "report.tpl")
+			if err := os.WriteFile(tplPath, []byte("{{range .Rows}}x{{end}}"), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			// e.g. "SELECT FROM generate_series(1, 2)": rows but no columns
+			rows := newFakeRows(nil, nil, [][]any{{}, {}})
+
+			exporter, err := Get(format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", format, err)
+			}
+			options := ExportOptions{
+				Format:          format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				TemplateFile:    tplPath,
+			}
+
+			_, err = exporter.Export(rows, options)
+			if !errors.Is(err, ErrNoColumns) {
+				t.Fatalf("Export() error = %v, want ErrNoColumns", err)
+			}
+			if _, statErr := os.Stat(outputPath); statErr == nil {
+				t.Error("Export() should not create the output file for a result without columns")
+			}
+		})
+	}
+}
+
+func TestHeaderCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string // mode -> expected name
+	}{
+		{
+			name:  "snake case",
+			input: "order_id",
+			want: map[string]string{
+				HeaderCaseUpper: "ORDER_ID",
+				HeaderCaseLower: "order_id",
+				HeaderCaseTitle: "Order Id",
+				HeaderCaseSnake: "order_id",
+				HeaderCaseCamel: "orderId",
+			},
+		},
+		{
+			name:  "camel case",
+			input: "createdAt",
+			want: map[string]string{
+				HeaderCaseUpper: "CREATEDAT",
+				HeaderCaseLower: "createdat",
+				HeaderCaseTitle: "Created At",
+				HeaderCaseSnake: "created_at",
+				HeaderCaseCamel: "createdAt",
+			},
+		},
+		{
+			name:  "acronym and digits",
+			input: "HTTPStatus2xx",
+			want: map[string]string{
+				HeaderCaseUpper: "HTTPSTATUS2XX",
+				HeaderCaseLower: "httpstatus2xx",
+				HeaderCaseTitle: "Http Status2xx",
+				HeaderCaseSnake: "http_status2xx",
+				HeaderCaseCamel: "httpStatus2xx",
+			},
+		},
+		{
+			name:  "spaces and punctuation",
+			input: "Total amount (EUR)",
+			want: map[string]string{
+				HeaderCaseUpper: "TOTAL AMOUNT (EUR)",
+				HeaderCaseLower: "total amount (eur)",
+				HeaderCaseTitle: "Total Amount Eur",
+				HeaderCaseSnake: "total_amount_eur",
+				HeaderCaseCamel: "totalAmountEur",
+			},
+		},
+		{
+			name:  "accented letters",
+			input: "prénom_élève",
+			want: map[string]string{
+				HeaderCaseUpper: "PRÉNOM_ÉLÈVE",
+				HeaderCaseLower: "prénom_élève",
+				HeaderCaseTitle: "Prénom Élève",
+				HeaderCaseSnake: "prénom_élève",
+				HeaderCaseCamel: "prénomÉlève",
+			},
+		},
+		{
+			name:  "no words",
+			input: "?column?",
+			want: map[string]string{
+				HeaderCaseTitle: "Column",
+				HeaderCaseSnake: "column",
+			},
+		},
+		{
+			name:  "only punctuation is kept",
+			input: "_",
+			want: map[string]string{
+				HeaderCaseTitle: "_",
+				HeaderCaseCamel: "_",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				if got := headerCase(tt.input, mode); got != want {
+					t.Errorf("headerCase(%q, %s) = %q, want %q", tt.input, mode, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestHeaderNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		columns     []string
+		format      string
+		mode        string
+		keyed       bool
+		want        []string
+		errContains string
+	}{
+		{
+			name:    "no transform",
+			columns: []string{"order_id", "Total"},
+			format:  FormatCSV,
+			want:    []string{"order_id", "Total"},
+		},
+		{
+			name:    "title in csv",
+			columns: []string{"order_id", "created_at"},
+			format:  FormatCSV,
+			mode:    HeaderCaseTitle,
+			want:    []string{"Order Id", "Created At"},
+		},
+		{
+			name:        "title in xml is not an element name",
+			columns:     []string{"id", "order_id"},
+			format:      FormatXML,
+			mode:        HeaderCaseTitle,
+			errContains: `--header-case title turns column "order_id" into "Order Id", which is not a valid XML element name`,
+		},
+		{
+			name:    "camel in xml",
+			columns: []string{"order_id", "created_at"},
+			format:  FormatXML,
+			mode:    HeaderCaseCamel,
+			want:    []string{"orderId", "createdAt"},
+		},
+		{
+			name:        "digit first in xml",
+			columns:     []string{"_2fa"},
+			format:      FormatXML,
+			mode:        HeaderCaseSnake,
+			errContains: `into "2fa"`,
+		},
+		{
+			name:    "merged names are suffixed in keyed formats",
+			columns: []string{"Id", "id", "ID"},
+			format:  FormatJSON,
+			mode:    HeaderCaseLower,
+			keyed:   true,
+			want:    []string{"id", "id_2", "id_3"},
+		},
+		{
+			name:    "merged names are kept in positional formats",
+			columns: []string{"Id", "id"},
+			format:  FormatCSV,
+			mode:    HeaderCaseUpper,
+			want:    []string{"ID", "ID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HeaderNames(tt.columns, ExportOptions{Format: tt.format, HeaderCase: tt.mode}, tt.keyed)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("HeaderNames() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HeaderNames() unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("HeaderNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportHeaderCase(t *testing.T) {
+	formats := []struct {
+		format      string
+		textColumns []string
+		want        string
+	}{
+		// --csv-force-text-columns still names the query column
+		{FormatCSV, []string{"customer_name"}, "ORDER_ID,\"CUSTOMER_NAME\"\n1,\"alice\"\n"},
+		{FormatJSON, nil, `{"ORDER_ID":1,"CUSTOMER_NAME":"alice"}`},
+		{FormatXML, nil, "<row><ORDER_ID>1</ORDER_ID><CUSTOMER_NAME>alice</CUSTOMER_NAME></row>"},
+	}
+
+	for _, ff := range formats {
+		t.Run(ff.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+ff.format)
+			rows := newFakeRows(
+				[]string{"order_id", "customer_name"},
+				[]uint32{pgtype.Int4OID, pgtype.TextOID},
+				[][]any{{int32(1), "alice"}},
+			)
+
+			exporter, err := Get(ff.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", ff.format, err)
+			}
+			options := ExportOptions{
+				Format:         ff.format,
+				Delimiter:      ',',
+				Compression:    "none",
+				OutputPath:     outputPath,
+				XmlRootElement: "results",
+				XmlRowElement:  "row",
+				Compact:        true,
+				HeaderCase:     HeaderCaseUpper,
+				CsvTextColumns: ff.textColumns,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if !strings.Contains(string(content), ff.want) {
+				t.Errorf("output should contain %q, got:\n%s", ff.want, content)
+			}
+		})
+	}
+}
+
+func TestExportHeaderCaseXLSX(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+	rows := newFakeRows(
+		[]string{"order_id", "created_at"},
+		[]uint32{pgtype.Int4OID, pgtype.TextOID},
+		[][]any{{int32(1), "today"}},
+	)
+
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:      FormatXLSX,
+		Compression: "none",
+		OutputPath:  outputPath,
+		HeaderCase:  HeaderCaseTitle,
+	}
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX file: %v", err)
+	}
+	defer f.Close()
+
+	sheetRows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	if len(sheetRows) == 0 || !slices.Equal(sheetRows[0], []string{"Order Id", "Created At"}) {
+		t.Errorf("header row = %v, want [Order Id Created At]", sheetRows)
+	}
+}
+
+func TestExportCustomHeaders(t *testing.T) {
+	names := []string{"id", "name"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID}
+	data := [][]any{{int32(1), "alice"}, {int32(2), "bob"}}
+
+	tests := []struct {
+		name        string
+		format      string
+		headers     []string
+		expected    string
+		errContains string
+	}{
+		{
+			name:     "csv",
+			format:   FormatCSV,
+			headers:  []string{"CUST_ID", "CUST NAME"},
+			expected: "CUST_ID,CUST NAME\n1,alice\n2,bob\n",
+		},
+		{
+			name:     "tsv",
+			format:   FormatTSV,
+			headers:  []string{"CUST_ID", "CUST NAME"},
+			expected: "CUST_ID\tCUST NAME\n1\talice\n2\tbob\n",
+		},
+		{
+			name:        "csv with too few headers",
+			format:      FormatCSV,
+			headers:     []string{"CUST_ID"},
+			errContains: "--header-from-file has 1 headers but the query returns 2 columns",
+		},
+		{
+			name:        "tsv with too many headers",
+			format:      FormatTSV,
+			headers:     []string{"a", "b", "c"},
+			errContains: "--header-from-file has 3 headers but the query returns 2 columns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			options := ExportOptions{
+				Format:        tt.format,
+				Compression:   "none",
+				OutputPath:    outputPath,
+				Delimiter:     ',',
+				CustomHeaders: tt.headers,
+				// Custom headers are written as given, not transformed
+				HeaderCase: HeaderCaseLower,
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			_, err = exporter.Export(newFakeRows(names, oids, data), options)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Export() error = %v, want %q", err, tt.errContains)
+				}
+				if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+					t.Errorf("no output file should be created when the header count does not match")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output =\n%s\nwant\n%s", content, tt.expected)
+			}
+		})
+	}
+}
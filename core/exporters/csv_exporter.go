@@ -1,57 +1,103 @@
 package exporters
 
 import (
-	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/core/validation"
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/fbz-tec/pgxport/internal/ui"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type csvExporter struct{}
 
 // Export writes query results to a CSV file with buffered I/O.
-func (e *csvExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
+func (e *csvExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 	start := time.Now()
 
-	logger.Debug("Preparing CSV export (delimiter=%q, noHeader=%v, compression=%s)",
-		string(options.Delimiter), options.NoHeader, options.Compression)
+	separator := options.separator()
+
+	rows, err = flattenJSONRows(rows, options, false)
+	if err != nil {
+		return 0, err
+	}
+
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, false)
+	if err != nil {
+		return 0, err
+	}
+	headers, err := HeaderNames(columns, options, false)
+	if err != nil {
+		return 0, err
+	}
+	if headers, err = customHeaders(headers, options); err != nil {
+		return 0, err
+	}
+
+	logger.Debug("Preparing CSV export (delimiter=%q, quote=%s, noHeader=%v, compression=%s)",
+		separator, options.CsvQuoteMode, options.NoHeader, options.Compression)
+
+	skipIndex := -1
+	if options.SkipWhere != nil {
+		if skipIndex, err = options.SkipWhere.columnIndex(columns); err != nil {
+			return 0, err
+		}
+		logger.Debug("Skipping rows where %s", options.SkipWhere)
+	}
+
+	if options.CheckpointEvery > 0 && options.Writer != nil {
+		return 0, fmt.Errorf("checkpoints require a file output, not a writer")
+	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		AppendAt:       options.resumeBytes(),
+		Writer:         options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
 
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
-	writer := csv.NewWriter(writerCloser)
-	writer.Comma = options.Delimiter
+	// A resumed output already starts with the comment and header
+	if options.GeneratedComment && options.Resume == nil {
+		if err := writeLineComment(writerCloser, "# ", options); err != nil {
+			return 0, err
+		}
+	}
+
+	textColumns, err := csvTextColumns(columns, options)
+	if err != nil {
+		return 0, err
+	}
+
+	writer := newRecordWriter(writerCloser, options, len(columns), textColumns)
 	defer writer.Flush()
 
 	// Write headers
 	fields := rows.FieldDescriptions()
 
-	if !options.NoHeader {
-		headers := make([]string, len(fields))
-		for i, fd := range fields {
-			headers[i] = string(fd.Name)
-		}
-
+	if !options.NoHeader && options.Resume == nil {
 		if err := writer.Write(headers); err != nil {
 			return 0, fmt.Errorf("error writing headers: %w", err)
 		}
-		logger.Debug("CSV headers written: %s", strings.Join(headers, string(options.Delimiter)))
+		logger.Debug("CSV headers written: %s", strings.Join(headers, separator))
 	}
 
 	// Write data rows
@@ -64,31 +110,68 @@ func (e *csvExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp.Start()
 	}
 
+	next, stop := csvRecords(rows, fields, options)
+	defer stop()
+
 	rowCount := 0
+	if options.Resume != nil {
+		rowCount = options.Resume.Rows
+		logger.Debug("Resuming CSV export after row %d (byte %d)", options.Resume.Rows, options.Resume.Bytes)
+	}
+	skipped := 0
+	failed := 0 // rows left out by --on-error skip
 	lastLog := time.Now()
 	var fetchTime time.Duration // Track time spent waiting for rows from PostgreSQL
 
-	for rows.Next() {
+	for {
 		fetchStart := time.Now()
+		record, nulls, ok, err := next()
 		fetchTime += time.Since(fetchStart)
-
-		values, err := rows.Values()
 		if err != nil {
-			return rowCount, fmt.Errorf("error reading row: %w", err)
+			return rowCount, err
 		}
-		//format values to strings
-		record := make([]string, len(values))
-		for i, v := range values {
-			record[i] = formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+		if !ok {
+			break
 		}
 
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		if record == nil {
+			failed++
+			continue
+		}
+
+		if skipIndex >= 0 && options.SkipWhere.skips(record[skipIndex]) {
+			skipped++
+			continue
+		}
+
+		applyTextHint(record, textColumns, options.CsvTextHint)
+		if dw, ok := writer.(*delimitedWriter); ok {
+			dw.nulls = nulls
+		}
 		if err := writer.Write(record); err != nil {
-			return 0, fmt.Errorf("error writing row %d: %w", rowCount, err)
+			return rowCount, fmt.Errorf("error writing row %d: %w", rowCount, err)
 		}
 		rowCount++
-		sp.Update(fmt.Sprintf("Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+
+		if options.FlushEvery > 0 && rowCount%options.FlushEvery == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return rowCount, fmt.Errorf("error flushing CSV: %w", err)
+			}
+			if err := output.Flush(writerCloser); err != nil {
+				return rowCount, fmt.Errorf("error flushing output: %w", err)
+			}
+		}
+		if options.CheckpointEvery > 0 && rowCount%options.CheckpointEvery == 0 {
+			if err := writeCSVCheckpoint(writer, writerCloser, rowCount, options); err != nil {
+				return rowCount, err
+			}
+		}
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
 
 		if logger.IsVerbose() && (rowCount%10000 == 0 || time.Since(lastLog) > 2*time.Second) {
 			elapsed := time.Since(start)
@@ -104,6 +187,15 @@ func (e *csvExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 
 	}
 
+	if options.SkipWhere != nil {
+		logger.Debug("%d rows skipped by --skip-where", skipped)
+	}
+	options.logSkippedRows(failed)
+
+	if err := writeCSVTrailer(writer, rowCount, options); err != nil {
+		return rowCount, err
+	}
+
 	logger.Debug("Flushing CSV buffers to disk...")
 	writer.Flush()
 
@@ -131,42 +223,330 @@ func (e *csvExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		}
 	}
 	sp.Stop("Completed!")
+	output.Commit(writerCloser)
 	return rowCount, nil
 }
 
+// writeCSVCheckpoint flushes everything written so far to the output file and
+// records its size and rowCount in the checkpoint file next to it.
+func writeCSVCheckpoint(writer recordWriter, writerCloser io.Writer, rowCount int, options ExportOptions) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV: %w", err)
+	}
+	if err := output.Flush(writerCloser); err != nil {
+		return fmt.Errorf("error flushing output: %w", err)
+	}
+	info, err := os.Stat(options.OutputPath)
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return writeCheckpoint(CheckpointPath(options.OutputPath), Checkpoint{
+		Rows:  rowCount,
+		Bytes: info.Size(),
+		Query: options.CheckpointQuery,
+	})
+}
+
+// csvRecords returns a function yielding the formatted CSV record of each row
+// in order, and a function releasing its resources. With options.Workers > 1
+// the values are formatted by a pool of goroutines. The record is nil for a
+// row left out by OnError skip.
+func csvRecords(rows pgx.Rows, fields []pgconn.FieldDescription, options ExportOptions) (func() ([]string, []bool, bool, error), func()) {
+	if options.Workers > 1 {
+		return parallelCSVRecords(rows, fields, options)
+	}
+
+	reader := newRowReader(rows)
+	row := 0
+	next := func() ([]string, []bool, bool, error) {
+		if !rows.Next() {
+			return nil, nil, false, nil
+		}
+		row++
+		values, err := reader.Values()
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("error reading row: %w", err)
+		}
+		var record []string
+		var nulls []bool
+		skipped, err := options.formatRow(row, func() error {
+			record, nulls = csvRecord(values, fields, options)
+			return nil
+		})
+		if err != nil || skipped {
+			return nil, nil, err == nil, err
+		}
+		return record, nulls, true, nil
+	}
+	return next, func() {}
+}
+
+// csvRecord formats the values of one row as CSV fields. With CsvQuoteEmpty
+// set, it also reports which fields are NULL, since both NULL and an empty
+// string give an empty field; nulls is nil otherwise.
+func csvRecord(values []any, fields []pgconn.FieldDescription, options ExportOptions) (record []string, nulls []bool) {
+	options.trimValues(values, fields)
+	record = make([]string, len(values))
+	if options.CsvQuoteEmpty != "" && options.CsvQuoteEmpty != QuoteEmptyNone {
+		nulls = make([]bool, len(values))
+	}
+	for i, v := range values {
+		if nulls != nil {
+			nulls[i] = v == nil
+		}
+		if text, ok := formatters.SpecialFloatText(v); ok {
+			if replacement, ok := options.CsvSpecialFloats[text]; ok {
+				text = replacement
+			}
+			record[i] = text
+			continue
+		}
+		record[i] = formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+		record[i] = options.truncate(record[i], v, fields[i].DataTypeOID)
+	}
+	return record, nulls
+}
+
 // ExportCopy uses PostgreSQL COPY command for high-performance CSV export.
 // This method is significantly faster than standard Export for large datasets.
-func (e *csvExporter) ExportCopy(conn *pgx.Conn, query string, options ExportOptions) (int, error) {
+func (e *csvExporter) ExportCopy(conn *pgx.Conn, query string, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
 	logger.Debug("Starting PostgreSQL COPY export (noHeader=%v, compression=%s)", options.NoHeader, options.Compression)
 
+	// The query is embedded in COPY (...) TO STDOUT, so it must be a single
+	// read-only SELECT or WITH; COPY itself (including COPY FROM) is rejected
+	if err := validation.ValidateQuery(query); err != nil {
+		return 0, err
+	}
+
+	delimiter, err := copyDelimiter(options.Delimiter)
+	if err != nil {
+		return 0, err
+	}
+
+	// COPY writes the column names itself; custom headers are written by
+	// pgxport instead, once the query is described and the count checked
+	var headers []string
+	if options.CustomHeaders != nil && !options.NoHeader {
+		desc, err := conn.PgConn().Prepare(options.ctx(), "", query, nil)
+		if err != nil {
+			return 0, fmt.Errorf("error describing query: %w", err)
+		}
+		if headers, err = customHeaders(make([]string, len(desc.Fields)), options); err != nil {
+			return 0, err
+		}
+	}
+
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
 
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
-	copySql := fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER %t, DELIMITER '%c')", query, !options.NoHeader, options.Delimiter)
+	if options.GeneratedComment {
+		if err := writeLineComment(writerCloser, "# ", options); err != nil {
+			return 0, err
+		}
+	}
+
+	if headers != nil {
+		headerWriter := csv.NewWriter(writerCloser)
+		headerWriter.Comma = options.Delimiter
+		if err := headerWriter.Write(headers); err != nil {
+			return 0, fmt.Errorf("error writing headers: %w", err)
+		}
+		headerWriter.Flush()
+		if err := headerWriter.Error(); err != nil {
+			return 0, fmt.Errorf("error writing headers: %w", err)
+		}
+	}
 
-	tag, err := conn.PgConn().CopyTo(context.Background(), writerCloser, copySql)
+	copyHeader := !options.NoHeader && headers == nil
+	copySql := fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER %t, DELIMITER %s)", copySubquery(query), copyHeader, delimiter)
+
+	tag, err := conn.PgConn().CopyTo(options.ctx(), writerCloser, copySql)
 	if err != nil {
 		return 0, fmt.Errorf("COPY TO STDOUT failed: %w", err)
 	}
 
 	rowCount := int(tag.RowsAffected())
-	logger.Debug("COPY export completed successfully: %d rows written in %v", rowCount, time.Since(start))
 
+	trailerWriter := csv.NewWriter(writerCloser)
+	trailerWriter.Comma = options.Delimiter
+	if err := writeCSVTrailer(trailerWriter, rowCount, options); err != nil {
+		return rowCount, err
+	}
+	trailerWriter.Flush()
+	if err := trailerWriter.Error(); err != nil {
+		return rowCount, fmt.Errorf("error flushing CSV trailer: %w", err)
+	}
+
+	logger.Debug("COPY export completed successfully: %d rows written to %s in %v", rowCount, output.FinalPath(output.OutputConfig{
+		Path:        options.OutputPath,
+		Compression: options.Compression,
+		Format:      options.Format,
+	}), time.Since(start))
+
+	output.Commit(writerCloser)
 	return rowCount, nil
 
 }
 
+// ValidateCopyDelimiter checks that delimiter can be used with COPY ... (FORMAT csv).
+// COPY only accepts a single-byte delimiter that is neither a line break nor the
+// quote character.
+func ValidateCopyDelimiter(delimiter rune) error {
+	switch {
+	case delimiter >= utf8.RuneSelf:
+		return fmt.Errorf("COPY requires a single-byte delimiter, got %q", delimiter)
+	case delimiter == 0:
+		return fmt.Errorf("COPY delimiter cannot be a NUL byte")
+	case delimiter == '\n' || delimiter == '\r':
+		return fmt.Errorf("COPY delimiter cannot be a line break (CR or LF)")
+	case delimiter == '"':
+		return fmt.Errorf("COPY delimiter cannot be the quote character (\")")
+	}
+	return nil
+}
+
+// copySubquery prepares a validated query for embedding in COPY (...): the
+// trailing semicolon is dropped and the query is put on its own lines so a
+// trailing "-- comment" cannot swallow the closing parenthesis.
+func copySubquery(query string) string {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	return "\n" + query + "\n"
+}
+
+// copyDelimiter returns the delimiter as a SQL string literal for the COPY
+// options. Quotes are doubled, while backslashes, tabs and other control
+// characters use an escape string (E'\t') so the statement does not depend
+// on standard_conforming_strings.
+func copyDelimiter(delimiter rune) (string, error) {
+	if err := ValidateCopyDelimiter(delimiter); err != nil {
+		return "", err
+	}
+
+	switch {
+	case delimiter == '\t':
+		return `E'\t'`, nil
+	case delimiter == '\\':
+		return `E'\\'`, nil
+	case delimiter == '\'':
+		return `''''`, nil
+	case delimiter < 0x20 || delimiter == 0x7f:
+		return fmt.Sprintf(`E'\x%02X'`, delimiter), nil
+	}
+	return "'" + string(delimiter) + "'", nil
+}
+
+// newRecordWriter returns encoding/csv for single-character delimiters with default
+// quoting, and a delimitedWriter for string delimiters, custom quoting modes,
+// quote characters or line terminators. Single-column results also use the
+// delimitedWriter, since encoding/csv writes an empty value as a blank line
+// that CSV readers skip.
+func newRecordWriter(w io.Writer, options ExportOptions, columns int, textColumns []bool) recordWriter {
+	quoteEmpty := options.CsvQuoteEmpty
+	if quoteEmpty == "" {
+		quoteEmpty = QuoteEmptyNone
+	}
+	if columns > 1 && textColumns == nil && options.DelimiterString == "" && quoteEmpty == QuoteEmptyNone &&
+		(options.CsvQuoteMode == "" || options.CsvQuoteMode == QuoteMinimal) &&
+		(options.QuoteChar == 0 || options.QuoteChar == '"') &&
+		(options.LineTerminator == "" || options.LineTerminator == "\n") {
+		writer := csv.NewWriter(w)
+		writer.Comma = options.Delimiter
+		return writer
+	}
+	logger.Debug("Using string delimiter writer (delimiter=%q, quote=%s, quote-char=%q, line-terminator=%q)",
+		options.separator(), options.CsvQuoteMode, options.QuoteChar, options.LineTerminator)
+	writer := newDelimitedWriter(w, options.separator(), options.CsvQuoteMode, options.QuoteChar, options.LineTerminator)
+	writer.forceQuote = textColumns
+	writer.quoteEmpty = quoteEmpty
+	return writer
+}
+
+// csvTextColumns marks the columns listed in options.CsvTextColumns, or
+// returns nil when there are none. Every listed name must be a result column.
+func csvTextColumns(columns []string, options ExportOptions) ([]bool, error) {
+	if len(options.CsvTextColumns) == 0 {
+		return nil, nil
+	}
+	marked := make([]bool, len(columns))
+	for _, name := range options.CsvTextColumns {
+		found := false
+		for i, c := range columns {
+			if c == name {
+				marked[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("--csv-force-text-columns column %q not found in query results", name)
+		}
+	}
+	logger.Debug("Quoting CSV text columns: %s (hint=%s)", strings.Join(options.CsvTextColumns, ", "), options.CsvTextHint)
+	return marked, nil
+}
+
+// applyTextHint rewrites the non-empty values of text columns with the
+// spreadsheet hint. Empty values, which include NULL, are left as they are.
+func applyTextHint(record []string, textColumns []bool, hint string) {
+	if textColumns == nil {
+		return
+	}
+	for i, value := range record {
+		if !textColumns[i] || value == "" {
+			continue
+		}
+		switch hint {
+		case TextHintEquals:
+			record[i] = `="` + strings.ReplaceAll(value, `"`, `""`) + `"`
+		case TextHintTab:
+			record[i] = "\t" + value
+		}
+	}
+}
+
+// writeCSVTrailer appends the optional record count trailer (e.g. "#ROWS=42").
+// The trailer is a single-field row so it stays parseable; readers can skip it
+// by setting csv.Reader.Comment to the first character of the prefix.
+// It is omitted for empty results unless CsvTrailerAlways is set.
+func writeCSVTrailer(writer recordWriter, rowCount int, options ExportOptions) error {
+	if !options.CsvTrailer {
+		return nil
+	}
+	if rowCount == 0 && !options.CsvTrailerAlways {
+		logger.Debug("Skipping CSV trailer for empty result")
+		return nil
+	}
+
+	// The trailer is not a value of the first column, so it is not forced
+	// into quotes, which would hide the prefix from comment-aware readers
+	if dw, ok := writer.(*delimitedWriter); ok {
+		dw.forceQuote = nil
+		dw.nulls = nil
+	}
+
+	trailer := fmt.Sprintf("%s%d", options.CsvTrailerPrefix, rowCount)
+	if err := writer.Write([]string{trailer}); err != nil {
+		return fmt.Errorf("error writing CSV trailer: %w", err)
+	}
+	logger.Debug("CSV trailer written: %s", trailer)
+	return nil
+}
+
 func init() {
 	MustRegister(FormatCSV, func() Exporter { return &csvExporter{} })
 }
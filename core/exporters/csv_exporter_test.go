@@ -1,8 +1,11 @@
 package exporters
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
@@ -11,6 +14,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportCSV(t *testing.T) {
@@ -373,12 +377,13 @@ func TestWriteCopyCSV(t *testing.T) {
 	defer cleanup()
 
 	tests := []struct {
-		name      string
-		query     string
-		delimiter rune
-		wantErr   bool
-		minRows   int
-		checkFunc func(t *testing.T, path string)
+		name        string
+		query       string
+		delimiter   rune
+		compression string
+		wantErr     bool
+		minRows     int
+		checkFunc   func(t *testing.T, path string)
 	}{
 		{
 			name:      "basic COPY export",
@@ -438,6 +443,50 @@ func TestWriteCopyCSV(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:      "COPY with tab delimiter",
+			query:     "SELECT 1 as id, 'test' as name",
+			delimiter: '\t',
+			wantErr:   false,
+			minRows:   1,
+			checkFunc: func(t *testing.T, path string) {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("Failed to read output: %v", err)
+				}
+
+				if string(content) != "id\tname\n1\ttest\n" {
+					t.Errorf("Unexpected tab-separated output: %q", content)
+				}
+			},
+		},
+		{
+			name:        "COPY with gzip compression",
+			query:       "SELECT generate_series(1, 10) as id",
+			delimiter:   ',',
+			compression: "gzip",
+			wantErr:     false,
+			minRows:     10,
+			checkFunc: func(t *testing.T, path string) {
+				f, err := os.Open(path + ".gz")
+				if err != nil {
+					t.Fatalf("Expected compressed file %s.gz: %v", path, err)
+				}
+				defer f.Close()
+
+				gz, err := gzip.NewReader(f)
+				if err != nil {
+					t.Fatalf("Failed to open gzip stream: %v", err)
+				}
+				records, err := csv.NewReader(gz).ReadAll()
+				if err != nil {
+					t.Fatalf("Failed to parse CSV: %v", err)
+				}
+				if len(records) != 11 { // header + 10 rows
+					t.Errorf("Expected 11 records, got %d", len(records))
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -450,10 +499,15 @@ func TestWriteCopyCSV(t *testing.T) {
 				t.Fatalf("Failed to get sql exporter: %v", err)
 			}
 
+			compression := tt.compression
+			if compression == "" {
+				compression = "none"
+			}
+
 			options := ExportOptions{
 				Format:      FormatCSV,
 				Delimiter:   tt.delimiter,
-				Compression: "none",
+				Compression: compression,
 				OutputPath:  outputPath,
 			}
 
@@ -483,6 +537,123 @@ func TestWriteCopyCSV(t *testing.T) {
 	}
 }
 
+func TestCopyDelimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		delimiter   rune
+		want        string
+		errContains string
+	}{
+		{name: "comma", delimiter: ',', want: `','`},
+		{name: "semicolon", delimiter: ';', want: `';'`},
+		{name: "pipe", delimiter: '|', want: `'|'`},
+		{name: "tab", delimiter: '\t', want: `E'\t'`},
+		{name: "single quote", delimiter: '\'', want: `''''`},
+		{name: "backslash", delimiter: '\\', want: `E'\\'`},
+		{name: "unit separator", delimiter: 0x1f, want: `E'\x1F'`},
+		{name: "multi-byte", delimiter: '€', errContains: "single-byte delimiter"},
+		{name: "newline", delimiter: '\n', errContains: "line break"},
+		{name: "double quote", delimiter: '"', errContains: "quote character"},
+		{name: "nul", delimiter: 0, errContains: "NUL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := copyDelimiter(tt.delimiter)
+
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("copyDelimiter(%q) error = %v, want it to contain %q", tt.delimiter, err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copyDelimiter(%q) unexpected error: %v", tt.delimiter, err)
+			}
+			if got != tt.want {
+				t.Errorf("copyDelimiter(%q) = %s, want %s", tt.delimiter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportCopyRejectsMultiByteDelimiter(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.csv")
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	copyExp := exporter.(CopyCapable)
+
+	// The delimiter is checked before the connection is used
+	_, err = copyExp.ExportCopy(nil, "SELECT 1", ExportOptions{
+		Format:      FormatCSV,
+		Delimiter:   '§',
+		Compression: "none",
+		OutputPath:  outputPath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "single-byte delimiter") {
+		t.Fatalf("ExportCopy() error = %v, want single-byte delimiter error", err)
+	}
+	if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+		t.Errorf("no output file should be created for an invalid delimiter")
+	}
+}
+
+func TestExportCopyRejectsCopyQuery(t *testing.T) {
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	copyExp := exporter.(CopyCapable)
+
+	queries := []string{
+		"COPY users FROM STDIN",
+		"COPY (SELECT 1) TO STDOUT",
+		"SELECT * FROM users) TO STDOUT; COPY users FROM '/tmp/x.csv' --",
+		"DELETE FROM users",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.csv")
+
+			// The query is checked before the connection is used
+			_, err := copyExp.ExportCopy(nil, query, ExportOptions{
+				Format:      FormatCSV,
+				Delimiter:   ',',
+				Compression: "none",
+				OutputPath:  outputPath,
+			})
+			if err == nil {
+				t.Fatal("ExportCopy() expected an error for a non read-only query")
+			}
+			if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+				t.Errorf("no output file should be created for a rejected query")
+			}
+		})
+	}
+}
+
+func TestCopySubquery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "\nSELECT 1\n"},
+		{"SELECT 1;", "\nSELECT 1\n"},
+		{"  SELECT 1 ;\n", "\nSELECT 1 \n"},
+		{"SELECT 1 -- trailing comment", "\nSELECT 1 -- trailing comment\n"},
+	}
+
+	for _, tt := range tests {
+		if got := copySubquery(tt.query); got != tt.want {
+			t.Errorf("copySubquery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
 func TestWriteCSVLargeDataset(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping large dataset test in short mode")
@@ -868,6 +1039,110 @@ func TestWriteCopyCSVNoHeader(t *testing.T) {
 	}
 }
 
+func TestWriteCSVTrailer(t *testing.T) {
+	tests := []struct {
+		name          string
+		rowCount      int
+		trailer       bool
+		always        bool
+		prefix        string
+		expectTrailer string
+	}{
+		{
+			name:          "trailer after records",
+			rowCount:      3,
+			trailer:       true,
+			prefix:        "#ROWS=",
+			expectTrailer: "#ROWS=3",
+		},
+		{
+			name:          "custom prefix",
+			rowCount:      2,
+			trailer:       true,
+			prefix:        "#COUNT:",
+			expectTrailer: "#COUNT:2",
+		},
+		{
+			name:     "trailer disabled",
+			rowCount: 3,
+			trailer:  false,
+			prefix:   "#ROWS=",
+		},
+		{
+			name:     "empty result suppresses trailer",
+			rowCount: 0,
+			trailer:  true,
+			prefix:   "#ROWS=",
+		},
+		{
+			name:          "empty result with always",
+			rowCount:      0,
+			trailer:       true,
+			always:        true,
+			prefix:        "#ROWS=",
+			expectTrailer: "#ROWS=0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputPath := filepath.Join(tmpDir, "output.csv")
+
+			data := make([][]any, tt.rowCount)
+			for i := range data {
+				data[i] = []any{int32(i + 1), "name"}
+			}
+			rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, data)
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:           FormatCSV,
+				Delimiter:        ',',
+				Compression:      "none",
+				OutputPath:       outputPath,
+				CsvTrailer:       tt.trailer,
+				CsvTrailerPrefix: tt.prefix,
+				CsvTrailerAlways: tt.always,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			lastLine := lines[len(lines)-1]
+
+			if tt.expectTrailer == "" {
+				if strings.HasPrefix(lastLine, tt.prefix) {
+					t.Errorf("Unexpected trailer line: %q", lastLine)
+				}
+			} else if lastLine != tt.expectTrailer {
+				t.Errorf("Expected trailer %q, got %q", tt.expectTrailer, lastLine)
+			}
+
+			// Output must remain parseable when the trailer is treated as a comment
+			reader := csv.NewReader(strings.NewReader(string(content)))
+			reader.Comment = '#'
+			records, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("Failed to parse CSV with trailer: %v", err)
+			}
+			if len(records) != tt.rowCount+1 { // header + data rows
+				t.Errorf("Expected %d records, got %d", tt.rowCount+1, len(records))
+			}
+		})
+	}
+}
+
 func BenchmarkExportCSV(b *testing.B) {
 	testURL := os.Getenv("DB_TEST_URL")
 	if testURL == "" {
@@ -913,3 +1188,676 @@ func BenchmarkExportCSV(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestWriteCSVStringDelimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		delimiter   string
+		quoteMode   string
+		data        [][]any
+		expected    string
+		errContains string
+	}{
+		{
+			name:      "double pipe delimiter",
+			delimiter: "||",
+			data:      [][]any{{int32(1), "alice"}, {int32(2), "bob"}},
+			expected:  "id||name\n1||alice\n2||bob\n",
+		},
+		{
+			name:      "unit separator delimiter",
+			delimiter: "\x1f",
+			data:      [][]any{{int32(1), "alice"}},
+			expected:  "id\x1fname\n1\x1falice\n",
+		},
+		{
+			name:      "field containing delimiter is quoted",
+			delimiter: "||",
+			data:      [][]any{{int32(1), "a||b"}, {int32(2), "a|b"}},
+			expected:  "id||name\n1||\"a||b\"\n2||a|b\n",
+		},
+		{
+			name:      "field containing quotes and newlines",
+			delimiter: "||",
+			data:      [][]any{{int32(1), "say \"hi\"\nbye"}},
+			expected:  "id||name\n1||\"say \"\"hi\"\"\nbye\"\n",
+		},
+		{
+			name:      "quote all",
+			delimiter: "||",
+			quoteMode: QuoteAll,
+			data:      [][]any{{int32(1), "alice"}},
+			expected:  "\"id\"||\"name\"\n\"1\"||\"alice\"\n",
+		},
+		{
+			name:      "field ending with part of the delimiter is quoted",
+			delimiter: "||",
+			data:      [][]any{{int32(1), "a|"}, {int32(2), "|b"}},
+			expected:  "id||name\n1||\"a|\"\n2|||b\n",
+		},
+		{
+			name:      "quote none",
+			delimiter: "||",
+			quoteMode: QuoteNone,
+			data:      [][]any{{int32(1), "a|b"}},
+			expected:  "id||name\n1||a|b\n",
+		},
+		{
+			name:        "quote none rejects a field containing the delimiter",
+			delimiter:   "||",
+			quoteMode:   QuoteNone,
+			data:        [][]any{{int32(1), "a||b"}},
+			errContains: "cannot be written with --csv-quote none",
+		},
+		{
+			name:        "quote none rejects a line break",
+			delimiter:   ";",
+			quoteMode:   QuoteNone,
+			data:        [][]any{{int32(1), "a\nb"}},
+			errContains: "cannot be written with --csv-quote none",
+		},
+		{
+			name:      "single character delimiter with quote all",
+			delimiter: ";",
+			quoteMode: QuoteAll,
+			data:      [][]any{{int32(1), "alice"}},
+			expected:  "\"id\";\"name\"\n\"1\";\"alice\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputPath := filepath.Join(tmpDir, "output.csv")
+
+			rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, tt.data)
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatCSV,
+				Delimiter:       ',',
+				DelimiterString: tt.delimiter,
+				CsvQuoteMode:    tt.quoteMode,
+				Compression:     "none",
+				OutputPath:      outputPath,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Export() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != len(tt.data) {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, len(tt.data))
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("Output mismatch:\ngot:  %q\nwant: %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteCSVLineTerminatorAndQuoteChar(t *testing.T) {
+	tests := []struct {
+		name           string
+		delimiter      rune
+		lineTerminator string
+		quoteChar      rune
+		quoteMode      string
+		data           [][]any
+		expected       string
+	}{
+		{
+			name:           "CRLF line terminator",
+			delimiter:      ',',
+			lineTerminator: "\r\n",
+			data:           [][]any{{int32(1), "alice"}, {int32(2), "bob"}},
+			expected:       "id,name\r\n1,alice\r\n2,bob\r\n",
+		},
+		{
+			name:      "single quote enclosure",
+			delimiter: ',',
+			quoteChar: '\'',
+			data:      [][]any{{int32(1), "a,b"}, {int32(2), "it's"}, {int32(3), `say "hi"`}},
+			expected:  "id,name\n1,'a,b'\n2,'it''s'\n3,say \"hi\"\n",
+		},
+		{
+			name:      "enclose all fields",
+			delimiter: '\t',
+			quoteChar: '\'',
+			quoteMode: QuoteAll,
+			data:      [][]any{{int32(1), "alice"}},
+			expected:  "'id'\t'name'\n'1'\t'alice'\n",
+		},
+		{
+			name:           "field containing custom terminator is quoted",
+			delimiter:      ',',
+			lineTerminator: ";\n",
+			data:           [][]any{{int32(1), "a;\nb"}},
+			expected:       "id,name;\n1,\"a;\nb\";\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.csv")
+			rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, tt.data)
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:         FormatCSV,
+				Delimiter:      tt.delimiter,
+				LineTerminator: tt.lineTerminator,
+				QuoteChar:      tt.quoteChar,
+				CsvQuoteMode:   tt.quoteMode,
+				Compression:    "none",
+				OutputPath:     outputPath,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("Output mismatch:\ngot:  %q\nwant: %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+// wideCSVRows returns n rows of mixed column types for the worker tests.
+func wideCSVRows(n int) ([]string, []uint32, [][]any) {
+	names := []string{"id", "name", "amount", "created_at", "payload", "flag", "code"}
+	oids := []uint32{pgtype.Int8OID, pgtype.TextOID, pgtype.Float8OID, pgtype.TimestampOID,
+		pgtype.JSONBOID, pgtype.BoolOID, pgtype.BPCharOID}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	data := make([][]any, n)
+	for i := range data {
+		data[i] = []any{
+			int64(i),
+			strings.Repeat("name,\"quoted\" ", i%5+1),
+			float64(i) * 1.25,
+			base.Add(time.Duration(i) * time.Minute),
+			map[string]any{"row": float64(i), "tags": []any{"a", "b"}},
+			i%2 == 0,
+			"AB  ",
+		}
+	}
+	return names, oids, data
+}
+
+func TestExportCSVWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ExportOptions
+	}{
+		{name: "default options", options: ExportOptions{}},
+		{name: "trim and truncate", options: ExportOptions{TrimText: true, MaxFieldLength: 8, TruncateMarker: "~"}},
+		{name: "flush every", options: ExportOptions{FlushEvery: 7, CsvTrailer: true, CsvTrailerPrefix: "#ROWS="}},
+	}
+
+	export := func(t *testing.T, options ExportOptions, workers int) string {
+		t.Helper()
+		names, oids, data := wideCSVRows(500)
+		options.Format = FormatCSV
+		options.Delimiter = ','
+		options.Compression = "none"
+		options.TimeFormat = "yyyy-MM-dd HH:mm:ss"
+		options.OutputPath = filepath.Join(t.TempDir(), "output.csv")
+		options.Workers = workers
+
+		exporter, err := Get(FormatCSV)
+		if err != nil {
+			t.Fatalf("Failed to get csv exporter: %v", err)
+		}
+		rowCount, err := exporter.Export(newFakeRows(names, oids, data), options)
+		if err != nil {
+			t.Fatalf("Export(workers=%d) error = %v", workers, err)
+		}
+		if rowCount != len(data) {
+			t.Errorf("Export(workers=%d) rowCount = %d, want %d", workers, rowCount, len(data))
+		}
+		content, err := os.ReadFile(options.OutputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(content)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := export(t, tt.options, 1)
+			for _, workers := range []int{2, 8} {
+				if got := export(t, tt.options, workers); got != want {
+					t.Errorf("output with %d workers differs from sequential output", workers)
+				}
+			}
+		})
+	}
+}
+
+func TestExportCSVWorkersCancelled(t *testing.T) {
+	names, oids, data := wideCSVRows(1000)
+	rows := newFakeRows(names, oids, data)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rows.onNext = func(pos int) {
+		if pos == 100 {
+			cancel()
+		}
+	}
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	rowCount, err := exporter.Export(rows, ExportOptions{
+		Format:      FormatCSV,
+		Delimiter:   ',',
+		Compression: "none",
+		OutputPath:  filepath.Join(t.TempDir(), "output.csv"),
+		Workers:     4,
+		Context:     ctx,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Export() error = %v, want context.Canceled", err)
+	}
+	if rowCount > 100 {
+		t.Errorf("Export() rowCount = %d, want at most 100", rowCount)
+	}
+}
+
+// BenchmarkExportCSVWorkers compares sequential and parallel formatting of a
+// wide result set. It needs no database.
+func BenchmarkExportCSVWorkers(b *testing.B) {
+	names, oids, row := wideCSVRows(1)
+	// Repeat the columns to get a 70-column table
+	var wideNames []string
+	var wideOIDs []uint32
+	for i := 0; i < 10; i++ {
+		for j, name := range names {
+			wideNames = append(wideNames, fmt.Sprintf("%s_%d", name, i))
+			wideOIDs = append(wideOIDs, oids[j])
+		}
+	}
+	data := make([][]any, 5000)
+	for i := range data {
+		for j := 0; j < 10; j++ {
+			data[i] = append(data[i], row[0]...)
+		}
+	}
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		b.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	outputPath := filepath.Join(b.TempDir(), "bench.csv")
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			options := ExportOptions{
+				Format:      FormatCSV,
+				Delimiter:   ',',
+				Compression: "none",
+				TimeFormat:  "yyyy-MM-dd HH:mm:ss",
+				OutputPath:  outputPath,
+				Workers:     workers,
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := exporter.Export(newFakeRows(wideNames, wideOIDs, data), options); err != nil {
+					b.Fatalf("Export() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCSVTextColumns(t *testing.T) {
+	names := []string{"id", "zip", "phone"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID}
+	data := [][]any{
+		{int32(1), "01234", "0612345678"},
+		{int32(2), nil, `+33 "6"`},
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(*ExportOptions)
+		want        string
+		errContains string
+	}{
+		{
+			name:   "quoted without hint",
+			modify: func(o *ExportOptions) { o.CsvTextColumns = []string{"zip", "phone"} },
+			want:   "id,\"zip\",\"phone\"\n1,\"01234\",\"0612345678\"\n2,,\"+33 \"\"6\"\"\"\n",
+		},
+		{
+			name: "equals hint",
+			modify: func(o *ExportOptions) {
+				o.CsvTextColumns = []string{"zip"}
+				o.CsvTextHint = TextHintEquals
+			},
+			want: "id,\"zip\",phone\n1,\"=\"\"01234\"\"\",0612345678\n2,,\"+33 \"\"6\"\"\"\n",
+		},
+		{
+			name: "equals hint escapes quotes",
+			modify: func(o *ExportOptions) {
+				o.CsvTextColumns = []string{"phone"}
+				o.CsvTextHint = TextHintEquals
+			},
+			want: "id,zip,\"phone\"\n1,01234,\"=\"\"0612345678\"\"\"\n2,,\"=\"\"+33 \"\"\"\"6\"\"\"\"\"\"\"\n",
+		},
+		{
+			name: "tab hint",
+			modify: func(o *ExportOptions) {
+				o.CsvTextColumns = []string{"zip"}
+				o.CsvTextHint = TextHintTab
+			},
+			want: "id,\"zip\",phone\n1,\"\t01234\",0612345678\n2,,\"+33 \"\"6\"\"\"\n",
+		},
+		{
+			name: "custom delimiter and trailer",
+			modify: func(o *ExportOptions) {
+				o.Delimiter = ';'
+				o.CsvTextColumns = []string{"id"}
+				o.CsvTrailer = true
+				o.CsvTrailerPrefix = "#ROWS="
+			},
+			want: "\"id\";zip;phone\n\"1\";01234;0612345678\n\"2\";;\"+33 \"\"6\"\"\"\n#ROWS=2\n",
+		},
+		{
+			name:        "unknown column",
+			modify:      func(o *ExportOptions) { o.CsvTextColumns = []string{"zip", "fax"} },
+			errContains: `column "fax" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := ExportOptions{
+				Format:      FormatCSV,
+				Delimiter:   ',',
+				Compression: "none",
+				OutputPath:  filepath.Join(t.TempDir(), "output.csv"),
+			}
+			tt.modify(&options)
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			_, err = exporter.Export(newFakeRows(names, oids, data), options)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Export() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			content, err := os.ReadFile(options.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVTextColumnsKeepLeadingZeros(t *testing.T) {
+	options := ExportOptions{
+		Format:         FormatCSV,
+		Delimiter:      ',',
+		Compression:    "none",
+		OutputPath:     filepath.Join(t.TempDir(), "output.csv"),
+		CsvTextColumns: []string{"zip"},
+	}
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	rows := newFakeRows([]string{"zip"}, []uint32{pgtype.TextOID}, [][]any{{"01234"}, {"00000"}})
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	content, err := os.ReadFile(options.OutputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := "\"zip\"\n\"01234\"\n\"00000\"\n"; string(content) != want {
+		t.Errorf("output = %q, want %q", content, want)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if records[1][0] != "01234" {
+		t.Errorf("zip = %q, want 01234", records[1][0])
+	}
+}
+
+func TestWriteCSVQuoteEmpty(t *testing.T) {
+	names := []string{"id", "name", "note"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID}
+	data := [][]any{
+		{int32(1), "", nil},
+		{int32(2), nil, ""},
+		{int32(3), "alice", "a, b"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ExportOptions)
+		want   string
+	}{
+		{
+			name:   "default leaves both bare",
+			modify: func(o *ExportOptions) {},
+			want:   "id,name,note\n1,,\n2,,\n3,alice,\"a, b\"\n",
+		},
+		{
+			name:   "none",
+			modify: func(o *ExportOptions) { o.CsvQuoteEmpty = QuoteEmptyNone },
+			want:   "id,name,note\n1,,\n2,,\n3,alice,\"a, b\"\n",
+		},
+		{
+			name:   "empty strings quoted",
+			modify: func(o *ExportOptions) { o.CsvQuoteEmpty = QuoteEmptyStrings },
+			want:   "id,name,note\n1,\"\",\n2,,\"\"\n3,alice,\"a, b\"\n",
+		},
+		{
+			name:   "nulls quoted",
+			modify: func(o *ExportOptions) { o.CsvQuoteEmpty = QuoteEmptyNulls },
+			want:   "id,name,note\n1,,\"\"\n2,\"\",\n3,alice,\"a, b\"\n",
+		},
+		{
+			name: "with workers",
+			modify: func(o *ExportOptions) {
+				o.CsvQuoteEmpty = QuoteEmptyStrings
+				o.Workers = 3
+			},
+			want: "id,name,note\n1,\"\",\n2,,\"\"\n3,alice,\"a, b\"\n",
+		},
+		{
+			name: "with text columns and a trailer",
+			modify: func(o *ExportOptions) {
+				o.CsvQuoteEmpty = QuoteEmptyStrings
+				o.CsvTextColumns = []string{"name"}
+				o.CsvTrailer = true
+				o.CsvTrailerPrefix = "#ROWS="
+			},
+			want: "id,\"name\",note\n1,\"\",\n2,,\"\"\n3,\"alice\",\"a, b\"\n#ROWS=3\n",
+		},
+		{
+			name: "with a string delimiter",
+			modify: func(o *ExportOptions) {
+				o.CsvQuoteEmpty = QuoteEmptyNulls
+				o.DelimiterString = "||"
+			},
+			want: "id||name||note\n1||||\"\"\n2||\"\"||\n3||alice||a, b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := ExportOptions{
+				Format:      FormatCSV,
+				Delimiter:   ',',
+				Compression: "none",
+				OutputPath:  filepath.Join(t.TempDir(), "output.csv"),
+			}
+			tt.modify(&options)
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			content, err := os.ReadFile(options.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVSkipWhere(t *testing.T) {
+	names := []string{"id", "status"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID}
+	data := [][]any{
+		{int32(1), "active"},
+		{int32(2), "deleted"},
+		{int32(3), nil},
+		{int32(4), "deleted"},
+	}
+
+	tests := []struct {
+		name      string
+		filter    RowFilter
+		workers   int
+		trailer   bool
+		wantRows  int
+		want      string
+		errString string
+	}{
+		{
+			name:     "equal",
+			filter:   RowFilter{Column: "status", Value: "deleted"},
+			wantRows: 2,
+			want:     "id,status\n1,active\n3,\n",
+		},
+		{
+			name:     "not equal",
+			filter:   RowFilter{Column: "status", Value: "deleted", Negate: true},
+			wantRows: 2,
+			want:     "id,status\n2,deleted\n4,deleted\n",
+		},
+		{
+			name:     "empty value matches NULL",
+			filter:   RowFilter{Column: "status", Value: ""},
+			wantRows: 3,
+			want:     "id,status\n1,active\n2,deleted\n4,deleted\n",
+		},
+		{
+			name:     "formatted value of a number",
+			filter:   RowFilter{Column: "id", Value: "4"},
+			wantRows: 3,
+			want:     "id,status\n1,active\n2,deleted\n3,\n",
+		},
+		{
+			name:     "workers and trailer count emitted rows",
+			filter:   RowFilter{Column: "status", Value: "deleted"},
+			workers:  2,
+			trailer:  true,
+			wantRows: 2,
+			want:     "id,status\n1,active\n3,\n#ROWS=2\n",
+		},
+		{
+			name:      "unknown column",
+			filter:    RowFilter{Column: "state", Value: "deleted"},
+			errString: `--skip-where column "state" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			options := ExportOptions{
+				Format:           FormatCSV,
+				Delimiter:        ',',
+				Compression:      "none",
+				OutputPath:       filepath.Join(t.TempDir(), "output.csv"),
+				SkipWhere:        &filter,
+				Workers:          tt.workers,
+				CsvTrailer:       tt.trailer,
+				CsvTrailerPrefix: "#ROWS=",
+			}
+
+			exporter, err := Get(FormatCSV)
+			if err != nil {
+				t.Fatalf("Failed to get csv exporter: %v", err)
+			}
+			rowCount, err := exporter.Export(newFakeRows(names, oids, data), options)
+			if tt.errString != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errString) {
+					t.Fatalf("Export() error = %v, want it to contain %q", err, tt.errString)
+				}
+				if _, err := os.Stat(options.OutputPath); !os.IsNotExist(err) {
+					t.Errorf("no output should be created for an unknown column")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if rowCount != tt.wantRows {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, tt.wantRows)
+			}
+
+			content, err := os.ReadFile(options.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
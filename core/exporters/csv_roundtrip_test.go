@@ -0,0 +1,314 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// roundTripValues are text values that break naive delimited output: every
+// separator used below, quotes, line breaks, padding and empty strings.
+var roundTripValues = []any{
+	"plain",
+	"a,b",
+	"semi;colon",
+	"tab\there",
+	"a|", "|b", "||", "a||b",
+	"\x1fus",
+	`say "hi"`,
+	"it's",
+	"line\nbreak",
+	"cr\rhere",
+	"crlf\r\nend",
+	"@@", "a@", "@@\nx",
+	" leading space",
+	"trailing space ",
+	`\.`,
+	"",
+	nil,
+	"ünïcødé €",
+}
+
+// parseDelimited is a reference reader for delimited text with an arbitrary
+// delimiter, quote and line terminator. It follows RFC 4180 (quoted fields,
+// doubled quotes) and rejects anything ambiguous, so it fails where a real
+// reader would misread the file. quote == "" disables quoting.
+func parseDelimited(data, delimiter, quote, terminator string) ([][]string, error) {
+	var records [][]string
+	pos := 0
+	for pos < len(data) {
+		var record []string
+		for {
+			var field string
+			if quote != "" && strings.HasPrefix(data[pos:], quote) {
+				pos += len(quote)
+				var sb strings.Builder
+				for {
+					i := strings.Index(data[pos:], quote)
+					if i < 0 {
+						return nil, fmt.Errorf("record %d: unterminated quoted field", len(records)+1)
+					}
+					sb.WriteString(data[pos : pos+i])
+					pos += i + len(quote)
+					if !strings.HasPrefix(data[pos:], quote) {
+						break
+					}
+					sb.WriteString(quote)
+					pos += len(quote)
+				}
+				field = sb.String()
+			} else {
+				end := len(data)
+				for _, sep := range []string{delimiter, terminator} {
+					if i := strings.Index(data[pos:], sep); i >= 0 && pos+i < end {
+						end = pos + i
+					}
+				}
+				field = data[pos:end]
+				if quote != "" && strings.Contains(field, quote) {
+					return nil, fmt.Errorf("record %d: bare quote in unquoted field %q", len(records)+1, field)
+				}
+				pos = end
+			}
+			record = append(record, field)
+
+			if strings.HasPrefix(data[pos:], delimiter) {
+				pos += len(delimiter)
+				continue
+			}
+			if strings.HasPrefix(data[pos:], terminator) {
+				pos += len(terminator)
+				break
+			}
+			if pos == len(data) {
+				return nil, fmt.Errorf("record %d: missing line terminator", len(records)+1)
+			}
+			return nil, fmt.Errorf("record %d: unexpected text after quoted field at offset %d", len(records)+1, pos)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// assertRoundTrip checks that content parses back to want with the reference
+// reader, and with encoding/csv whenever the layout is one it can read.
+func assertRoundTrip(t *testing.T, content string, options ExportOptions, want [][]string) {
+	t.Helper()
+
+	delimiter := options.separator()
+	terminator := options.LineTerminator
+	if terminator == "" {
+		terminator = "\n"
+	}
+	quote := `"`
+	if options.QuoteChar != 0 {
+		quote = string(options.QuoteChar)
+	}
+	if options.CsvQuoteMode == QuoteNone {
+		quote = ""
+	}
+
+	got, err := parseDelimited(content, delimiter, quote, terminator)
+	if err != nil {
+		t.Fatalf("output is not parseable: %v\n%q", err, content)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	if len([]rune(delimiter)) != 1 || quote != `"` || (terminator != "\n" && terminator != "\r\n") {
+		return
+	}
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = []rune(delimiter)[0]
+	got, err = reader.ReadAll()
+	if err != nil {
+		t.Fatalf("encoding/csv cannot read the output: %v\n%q", err, content)
+	}
+	// encoding/csv turns \r\n inside quoted fields into \n
+	normalized := make([][]string, len(want))
+	for i, record := range want {
+		normalized[i] = make([]string, len(record))
+		for j, field := range record {
+			normalized[i][j] = strings.ReplaceAll(field, "\r\n", "\n")
+		}
+	}
+	if !reflect.DeepEqual(got, normalized) {
+		t.Fatalf("encoding/csv round trip mismatch:\ngot:  %q\nwant: %q", got, normalized)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ExportOptions
+	}{
+		{name: "default", options: ExportOptions{Delimiter: ','}},
+		{name: "semicolon", options: ExportOptions{Delimiter: ';'}},
+		{name: "tab", options: ExportOptions{Delimiter: '\t'}},
+		{name: "pipe", options: ExportOptions{Delimiter: '|'}},
+		{name: "double pipe", options: ExportOptions{Delimiter: ',', DelimiterString: "||"}},
+		{name: "unit separator", options: ExportOptions{Delimiter: 0x1f}},
+		{name: "quote all", options: ExportOptions{Delimiter: ',', CsvQuoteMode: QuoteAll}},
+		{name: "quote all with double pipe", options: ExportOptions{Delimiter: ',', DelimiterString: "||", CsvQuoteMode: QuoteAll}},
+		{name: "single quote", options: ExportOptions{Delimiter: ',', QuoteChar: '\''}},
+		{name: "single quote, quote all", options: ExportOptions{Delimiter: '\t', QuoteChar: '\'', CsvQuoteMode: QuoteAll}},
+		{name: "crlf terminator", options: ExportOptions{Delimiter: ',', LineTerminator: "\r\n"}},
+		{name: "custom terminator", options: ExportOptions{Delimiter: ',', LineTerminator: "@@\n"}},
+		{name: "workers", options: ExportOptions{Delimiter: ';', Workers: 4}},
+	}
+
+	for _, tt := range tests {
+		for _, columns := range []int{1, 3} {
+			t.Run(fmt.Sprintf("%s/columns=%d", tt.name, columns), func(t *testing.T) {
+				names := []string{"plain", "with,comma", `with"quote`}[:columns]
+				oids := []uint32{pgtype.TextOID, pgtype.TextOID, pgtype.TextOID}[:columns]
+
+				// Every value appears in every column
+				var data [][]any
+				for i := range roundTripValues {
+					row := make([]any, columns)
+					for c := range row {
+						row[c] = roundTripValues[(i+c)%len(roundTripValues)]
+					}
+					data = append(data, row)
+				}
+
+				want := [][]string{names}
+				for _, row := range data {
+					record := make([]string, len(row))
+					for c, v := range row {
+						if v != nil {
+							record[c] = v.(string)
+						}
+					}
+					want = append(want, record)
+				}
+
+				options := tt.options
+				options.Format = FormatCSV
+				options.Compression = "none"
+				options.OutputPath = filepath.Join(t.TempDir(), "output.csv")
+
+				exporter, err := Get(FormatCSV)
+				if err != nil {
+					t.Fatalf("Failed to get csv exporter: %v", err)
+				}
+				if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+					t.Fatalf("Export() error = %v", err)
+				}
+				content, err := os.ReadFile(options.OutputPath)
+				if err != nil {
+					t.Fatalf("Failed to read output: %v", err)
+				}
+
+				assertRoundTrip(t, string(content), options, want)
+			})
+		}
+	}
+}
+
+func TestCSVRoundTripQuoteNone(t *testing.T) {
+	// Values without separators or line breaks stay parseable without quoting
+	data := [][]any{{"plain", `say "hi"`}, {"", nil}, {"a|b", " padded "}}
+	options := ExportOptions{
+		Format:          FormatCSV,
+		Delimiter:       ',',
+		DelimiterString: "||",
+		CsvQuoteMode:    QuoteNone,
+		Compression:     "none",
+		OutputPath:      filepath.Join(t.TempDir(), "output.csv"),
+	}
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	names := []string{"a", "b"}
+	if _, err := exporter.Export(newFakeRows(names, []uint32{pgtype.TextOID, pgtype.TextOID}, data), options); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	content, err := os.ReadFile(options.OutputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	assertRoundTrip(t, string(content), options, [][]string{
+		{"a", "b"}, {"plain", `say "hi"`}, {"", ""}, {"a|b", " padded "},
+	})
+
+	// Values that would split the record are rejected instead of written
+	for _, value := range []string{"a||b", "a|", "line\nbreak"} {
+		options.OutputPath = filepath.Join(t.TempDir(), "output.csv")
+		_, err := exporter.Export(newFakeRows(names, []uint32{pgtype.TextOID, pgtype.TextOID}, [][]any{{value, "x"}}), options)
+		if err == nil || !strings.Contains(err.Error(), "--csv-quote none") {
+			t.Errorf("Export(%q) error = %v, want --csv-quote none error", value, err)
+		}
+	}
+}
+
+// parseTSV decodes PostgreSQL text COPY output, mapping \N to nil.
+func parseTSV(t *testing.T, content string) [][]any {
+	t.Helper()
+
+	unescape := strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+	var records [][]any
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, "\n") {
+			t.Fatalf("missing line terminator: %q", line)
+		}
+		var record []any
+		for _, field := range strings.Split(strings.TrimSuffix(line, "\n"), "\t") {
+			if strings.ContainsAny(field, "\r") {
+				t.Fatalf("raw carriage return in field %q", field)
+			}
+			if field == `\N` {
+				record = append(record, nil)
+				continue
+			}
+			record = append(record, unescape.Replace(field))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestTSVRoundTrip(t *testing.T) {
+	names := []string{"a", "b"}
+	var data [][]any
+	for i, v := range roundTripValues {
+		data = append(data, []any{v, roundTripValues[(i+1)%len(roundTripValues)]})
+	}
+
+	options := ExportOptions{
+		Format:      FormatTSV,
+		Compression: "none",
+		OutputPath:  filepath.Join(t.TempDir(), "output.tsv"),
+	}
+	exporter, err := Get(FormatTSV)
+	if err != nil {
+		t.Fatalf("Failed to get tsv exporter: %v", err)
+	}
+	if _, err := exporter.Export(newFakeRows(names, []uint32{pgtype.TextOID, pgtype.TextOID}, data), options); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	content, err := os.ReadFile(options.OutputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	want := [][]any{{"a", "b"}}
+	want = append(want, data...)
+	if got := parseTSV(t, string(content)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\ngot:  %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// csvJob is a fetched row waiting to be formatted.
+type csvJob struct {
+	seq    int
+	values []any
+}
+
+// csvResult is a formatted row. seq restores the query order, since workers
+// finish in any order. record is nil for a row left out by OnError skip.
+type csvResult struct {
+	seq    int
+	record []string
+	nulls  []bool
+}
+
+// parallelCSVRecords formats rows with options.Workers goroutines.
+//
+// pgx rows cannot be read concurrently, so a single goroutine fetches them and
+// numbers them in query order. Workers format the values and the returned
+// next function hands the records back in that order. The stop function must
+// be called before rows is closed: it cancels the pipeline and waits for the
+// fetching goroutine to exit.
+func parallelCSVRecords(rows pgx.Rows, fields []pgconn.FieldDescription, options ExportOptions) (func() ([]string, []bool, bool, error), func()) {
+	workers := options.Workers
+	logger.Debug("Formatting CSV rows with %d workers", workers)
+
+	ctx, cancel := context.WithCancel(options.ctx())
+	jobs := make(chan csvJob, workers*4)
+	results := make(chan csvResult, workers*4)
+	fetched := make(chan struct{})
+	var readErr error
+
+	go func() {
+		defer close(fetched)
+		defer close(jobs)
+		for seq := 0; rows.Next(); seq++ {
+			values, err := rows.Values()
+			if err != nil {
+				readErr = fmt.Errorf("error reading row: %w", err)
+				return
+			}
+			select {
+			case jobs <- csvJob{seq: seq, values: values}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				var record []string
+				var nulls []bool
+				// formatRow only returns errors from format, and csvRecord has none
+				_, _ = options.formatRow(job.seq+1, func() error {
+					record, nulls = csvRecord(job.values, fields, options)
+					return nil
+				})
+				select {
+				case results <- csvResult{seq: job.seq, record: record, nulls: nulls}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]csvResult)
+	nextSeq := 0
+	next := func() ([]string, []bool, bool, error) {
+		for {
+			if result, ok := pending[nextSeq]; ok {
+				delete(pending, nextSeq)
+				nextSeq++
+				return result.record, result.nulls, true, nil
+			}
+			result, ok := <-results
+			if !ok {
+				// Every worker has exited, so the fetching goroutine is done.
+				// A cancelled export also ends here, possibly before the last row.
+				if readErr != nil {
+					return nil, nil, false, readErr
+				}
+				return nil, nil, false, checkCancelled(options.ctx(), nextSeq)
+			}
+			pending[result.seq] = result
+		}
+	}
+
+	stop := func() {
+		cancel()
+		for range results {
+		}
+		<-fetched
+	}
+	return next, stop
+}
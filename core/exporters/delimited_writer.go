@@ -0,0 +1,176 @@
+package exporters
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSV quoting modes
+const (
+	QuoteMinimal = "minimal" // quote only fields that need it (default)
+	QuoteAll     = "all"     // quote every field
+	QuoteNone    = "none"    // never quote; caller guarantees fields are safe
+)
+
+// Quoting of empty CSV fields, which are either NULL or an empty string
+const (
+	QuoteEmptyNone    = "none"  // both bare (default)
+	QuoteEmptyStrings = "empty" // empty strings quoted (""), NULL bare, as in PostgreSQL COPY
+	QuoteEmptyNulls   = "null"  // NULL quoted (""), empty strings bare
+)
+
+// Spreadsheet hints for CSV text columns
+const (
+	TextHintNone   = "none"   // quote only (default)
+	TextHintEquals = "equals" // write ="value", which Excel shows as text
+	TextHintTab    = "tab"    // prefix a tab, which stops Excel from reading a number
+)
+
+// recordWriter is the subset of csv.Writer used by the CSV exporter,
+// allowing encoding/csv to be swapped for the string-delimiter writer.
+type recordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+var _ recordWriter = (*csv.Writer)(nil)
+
+// delimitedWriter writes delimiter-separated records where the delimiter may be
+// any string (e.g. "||" or "\x1f"), which encoding/csv cannot express. It also
+// supports a custom quote character and line terminator.
+// Quoting follows RFC 4180 rules: quotes inside a field are doubled.
+type delimitedWriter struct {
+	w              *bufio.Writer
+	delimiter      string
+	quoteMode      string
+	quote          string
+	lineTerminator string
+	forceQuote     []bool // columns quoted whatever their content
+	quoteEmpty     string // QuoteEmptyStrings or QuoteEmptyNulls quote some empty fields
+	nulls          []bool // NULL fields of the next record, set before each Write
+	err            error
+}
+
+// newDelimitedWriter creates a writer joining fields with delimiter.
+// A zero quote defaults to '"' and an empty lineTerminator to "\n".
+func newDelimitedWriter(w io.Writer, delimiter, quoteMode string, quote rune, lineTerminator string) *delimitedWriter {
+	if quoteMode == "" {
+		quoteMode = QuoteMinimal
+	}
+	if quote == 0 {
+		quote = '"'
+	}
+	if lineTerminator == "" {
+		lineTerminator = "\n"
+	}
+	return &delimitedWriter{
+		w:              bufio.NewWriter(w),
+		delimiter:      delimiter,
+		quoteMode:      quoteMode,
+		quote:          string(quote),
+		lineTerminator: lineTerminator,
+	}
+}
+
+// Write writes a single record followed by the line terminator.
+func (d *delimitedWriter) Write(record []string) error {
+	if d.err != nil {
+		return d.err
+	}
+	// A record made of one empty field would be an empty line, which CSV
+	// readers skip, so it is written as "" to keep the row
+	if len(record) == 1 && record[0] == "" && d.quoteMode != QuoteNone {
+		_, d.err = d.w.WriteString(d.quote + d.quote + d.lineTerminator)
+		return d.err
+	}
+	if d.quoteMode == QuoteNone {
+		// Checked before writing so no partial record is left in the buffer
+		for _, field := range record {
+			if d.breaksRecord(field) {
+				return fmt.Errorf("field %q contains the delimiter or a line break, which cannot be written with --csv-quote none", field)
+			}
+		}
+	}
+	for i, field := range record {
+		if i > 0 {
+			if _, d.err = d.w.WriteString(d.delimiter); d.err != nil {
+				return d.err
+			}
+		}
+		// Forced columns leave empty fields bare so NULL stays distinguishable
+		// from text for readers such as COPY FROM
+		if d.needsQuotes(field) || (field != "" && i < len(d.forceQuote) && d.forceQuote[i]) || d.quotesEmpty(field, i) {
+			_, d.err = d.w.WriteString(d.quote + strings.ReplaceAll(field, d.quote, d.quote+d.quote) + d.quote)
+		} else {
+			_, d.err = d.w.WriteString(field)
+		}
+		if d.err != nil {
+			return d.err
+		}
+	}
+	_, d.err = d.w.WriteString(d.lineTerminator)
+	return d.err
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (d *delimitedWriter) Flush() {
+	if err := d.w.Flush(); err != nil && d.err == nil {
+		d.err = err
+	}
+}
+
+// Error reports any error that occurred during a previous Write or Flush.
+func (d *delimitedWriter) Error() error {
+	return d.err
+}
+
+// quotesEmpty reports whether the empty field i is quoted to tell NULL from
+// an empty string.
+func (d *delimitedWriter) quotesEmpty(field string, i int) bool {
+	if field != "" || i >= len(d.nulls) {
+		return false
+	}
+	switch d.quoteEmpty {
+	case QuoteEmptyStrings:
+		return !d.nulls[i]
+	case QuoteEmptyNulls:
+		return d.nulls[i]
+	}
+	return false
+}
+
+func (d *delimitedWriter) needsQuotes(field string) bool {
+	switch d.quoteMode {
+	case QuoteAll:
+		return true
+	case QuoteNone:
+		return false
+	}
+	if field == "" {
+		return false
+	}
+	if d.breaksRecord(field) || strings.Contains(field, d.quote) || field == `\.` {
+		return true
+	}
+	return field[0] == ' ' || field[0] == '\t'
+}
+
+// breaksRecord reports whether field, written unquoted, would be read back as
+// more than one field or line. Besides a plain delimiter or line break, this
+// catches fields ending with the start of a multi-character separator: with
+// "||", the field "a|" followed by the delimiter reads as "a", "|...".
+func (d *delimitedWriter) breaksRecord(field string) bool {
+	if strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	for _, sep := range []string{d.delimiter, d.lineTerminator} {
+		if strings.Index(field+sep, sep) < len(field) {
+			return true
+		}
+	}
+	return false
+}
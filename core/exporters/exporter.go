@@ -1,11 +1,22 @@
 package exporters
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 const (
 	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
 	FormatJSON     = "json"
 	FormatXML      = "xml"
 	FormatSQL      = "sql"
@@ -14,6 +25,21 @@ const (
 	FormatTemplate = "template"
 )
 
+var (
+	// ErrWrite is matched by errors.Is when the output cannot be created,
+	// written or closed. It is output.ErrWrite.
+	ErrWrite = output.ErrWrite
+	// ErrEmptyResult is returned by callers that require rows, such as
+	// --fail-on-empty, when the query returned none.
+	ErrEmptyResult = errors.New("query returned 0 rows")
+)
+
+// Per-row formatting error handling (--on-error)
+const (
+	OnErrorStop = "stop"
+	OnErrorSkip = "skip"
+)
+
 // ExportOptions holds export configuration
 type ExportOptions struct {
 	Format          string
@@ -27,21 +53,272 @@ type ExportOptions struct {
 	XmlRootElement  string
 	XmlRowElement   string
 	RowPerStatement int
+	// XmlRootAttrs are added to the XML root element (e.g. xmlns:xsi)
+	XmlRootAttrs []xml.Attr
+	// XmlRowCountAttr names a root attribute holding the final row count (uncompressed output only)
+	XmlRowCountAttr string
+	// XmlSanitizeNames replaces characters that are not allowed in XML element names
+	// (false = fail on such a column, root or row name)
+	XmlSanitizeNames bool
+	// XmlNull controls how NULL columns are written in XML: empty (default), xsi-nil or omit
+	XmlNull string
+	// DelimiterString holds a multi-character delimiter (e.g. "||"); overrides Delimiter when set
+	DelimiterString string
+	// CsvQuoteMode controls CSV field quoting: minimal (default), all or none
+	CsvQuoteMode string
+	// QuoteChar encloses quoted CSV fields (0 = '"')
+	QuoteChar rune
+	// CsvQuoteEmpty tells NULL from empty strings in CSV: none (default, both bare),
+	// empty (empty strings written "") or null (NULL written "")
+	CsvQuoteEmpty string
+	// CsvTextColumns names CSV columns that are always quoted so readers keep them as text (e.g. ZIP codes)
+	CsvTextColumns []string
+	// CsvTextHint marks CsvTextColumns values for spreadsheets: none (default), equals (="...") or tab
+	CsvTextHint string
+	// SkipWhere leaves the CSV rows matching a column==value or column!=value filter out of the output (nil = keep all)
+	SkipWhere *RowFilter
+	// OnError controls rows that fail to format: stop (default) fails the export,
+	// skip logs them and leaves them out of the output
+	OnError string
+	// LineTerminator ends each CSV record ("" = "\n")
+	LineTerminator string
+	// CSV trailer line with the record count
+	CsvTrailer       bool   // append a "<prefix><count>" line after all records
+	CsvTrailerPrefix string // trailer prefix, e.g. "#ROWS="
+	CsvTrailerAlways bool   // also write the trailer for empty results
+	// MaxStatementBytes caps the size of a single INSERT statement (0 = unlimited)
+	MaxStatementBytes int
+	// UnquotedIdents writes SQL table and column names without double quotes; each must be
+	// a lowercase identifier that is not a reserved keyword (false = always quoted)
+	UnquotedIdents bool
+	// JsonNumbers controls how numeric and bigint values are written in JSON: number (default) or string
+	JsonNumbers string
+	// JsonSpecialFloats controls how NaN and infinities are written in JSON: null (default) or string
+	JsonSpecialFloats string
+	// JsonFloatFormat controls how real and double precision values are written in JSON: auto (default) or decimal
+	JsonFloatFormat string
+	// JsonKeyBy names a column whose values key a JSON object of rows instead of an array (empty = array)
+	JsonKeyBy string
+	// FlattenJSON names a json or jsonb column replaced in CSV and JSON output by one
+	// "<column>.<key>" column per key of FlattenKeys (empty = off)
+	FlattenJSON string
+	FlattenKeys []string
+	// CustomHeaders replace the column names in the CSV and TSV header row, one per column (nil = column names)
+	CustomHeaders []string
+	// HeaderCase transforms the column names written in CSV and XLSX headers, JSON keys and
+	// XML elements: upper, lower, title, snake or camel (empty = as returned by the query)
+	HeaderCase string
+	// ColumnOrder reorders the result columns: natural (default), alpha or pk-first
+	ColumnOrder string
+	// PrimaryKey lists the primary key columns moved first by pk-first, in key order
+	PrimaryKey []string
+	// Compact writes JSON, XML and YAML rows on a single line each instead of indented (false = pretty)
+	Compact bool
+	// CsvSpecialFloats replaces the CSV text of "NaN", "Infinity" and "-Infinity" (nil = PostgreSQL spelling)
+	CsvSpecialFloats map[string]string
+	// OutputEncoding transcodes text output from UTF-8 (utf-8, latin1, windows-1252)
+	OutputEncoding string
+	// EncodingStrict fails on characters missing from OutputEncoding instead of writing '?'
+	EncodingStrict bool
+	// Atomic writes to "<output>.tmp" and renames it only once the export has succeeded
+	Atomic bool
+	// FlushEvery flushes CSV/XML output to disk every N rows (0 = only at the end)
+	FlushEvery int
+	// CheckpointEvery records the CSV rows and bytes written in "<output>.checkpoint" every N rows (0 = off)
+	CheckpointEvery int
+	// CheckpointQuery is the QueryFingerprint of the exported query, stored in checkpoints
+	CheckpointQuery string
+	// Resume continues a CSV export from a checkpoint: the output is truncated to
+	// Resume.Bytes and appended to without a header, and rows are counted from Resume.Rows.
+	// The query must already skip the first Resume.Rows rows.
+	Resume *Checkpoint
+	// Workers formats CSV rows with N goroutines while rows are fetched (0 or 1 = sequential)
+	Workers int
+	// GeneratedComment writes a provenance comment (version, timestamp, query) at the top of the output
+	GeneratedComment bool
+	// EmitSchema writes a JSON Schema (json) or XSD (xml) describing the columns next to the output
+	EmitSchema bool
+	// SourceQuery is the exported query, used for the generated comment
+	SourceQuery string
+	// SqlSourceComment writes SourceQuery as a "-- Source query:" comment block at the top of SQL output
+	SqlSourceComment bool
+	// XlsxFormats maps column names to Excel number formats (e.g. "#,##0.00")
+	XlsxFormats map[string]string
+	// XlsxTotals appends a bold row with the sum of every numeric column
+	XlsxTotals bool
+	// XlsxSheetTotals writes the totals row on every sheet instead of only the last one
+	XlsxSheetTotals bool
+	// XlsxSheetBy names a column whose values each get their own sheet, created on first use (empty = one sheet)
+	XlsxSheetBy string
+	// XlsxMaxRows fails an XLSX export once it exceeds N rows (0 = unlimited)
+	XlsxMaxRows int
+	// Coalesce maps column names to the default written instead of NULL, converted
+	// to the column type (applied by CoalesceColumns)
+	Coalesce map[string]string
+	// TrimText strips trailing whitespace from text and char(n) values
+	TrimText bool
+	// MaxFieldLength cuts CSV, XLSX and JSON text, binary and JSON values to N characters (0 = unlimited)
+	MaxFieldLength int
+	// TruncateMarker is appended to values cut by MaxFieldLength
+	TruncateMarker string
+	// DedupeColumns controls duplicate column names: warn (default), error or suffix
+	DedupeColumns string
 	// Template mode (dual mode)
 	TemplateFile      string // full mode
 	TemplateHeader    string // streaming header
 	TemplateRow       string // streaming row (required for streaming)
 	TemplateFooter    string // streaming footer
 	TemplateStreaming bool   // enable streaming mode
+	TemplateMaxRows   int    // full mode row limit (0 = unlimited)
+	TemplateStrict    bool   // fail on unknown columns and missing keys
+	TemplateHTML      bool   // parse with html/template (context-aware escaping)
 	ProgressBar       bool   // show progress bar
+	ProgressTotal     int    // expected row count for the progress percentage (0 = unknown)
+	// Context is checked between rows so a cancelled export (timeout, SIGINT)
+	// stops promptly; nil means context.Background()
+	Context context.Context
+	// Writer receives the output instead of a file at OutputPath (nil = write
+	// the file). It is not closed. OutputPath may still name a zip entry.
+	Writer io.Writer
+}
+
+// ctx returns the export context, defaulting to context.Background().
+func (o ExportOptions) ctx() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
 }
 
-// Exporter interface defines export operations
+// WriteCloser returns Writer for output.OutputConfig.Writer, or nil when the
+// output is written to OutputPath. Closing it leaves Writer open.
+func (o ExportOptions) WriteCloser() io.WriteCloser {
+	if o.Writer == nil {
+		return nil
+	}
+	return nopWriteCloser{o.Writer}
+}
+
+// nopWriteCloser adds a no-op Close to a writer owned by the caller.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// checkCancelled returns a wrapped cancellation error once ctx is done, so
+// callers can still report the rows written so far.
+func checkCancelled(ctx context.Context, rowCount int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("export cancelled after %d rows: %w", rowCount, err)
+	}
+	return nil
+}
+
+// closeOutput closes the output of an export and, unless the export already
+// failed, reports a failure to close it through err. Closing flushes buffered
+// and compressed data and publishes --atomic output, so such a failure means
+// the output is incomplete. Exporters defer it right after creating the output.
+func closeOutput(w io.Closer, err *error) {
+	if closeErr := w.Close(); closeErr != nil && *err == nil {
+		*err = fmt.Errorf("error closing output: %w", closeErr)
+	}
+}
+
+// formatRow runs format, which turns the values of the row-th row of the
+// result (1-based) into output. With OnError skip, an error or panic in format
+// is logged and skipped is true; otherwise the error is returned as is.
+// format must not write anything before it has succeeded.
+func (o ExportOptions) formatRow(row int, format func() error) (skipped bool, err error) {
+	if o.OnError != OnErrorSkip {
+		return false, format()
+	}
+	if err := recoverFormat(format); err != nil {
+		logger.Warn("Skipping row %d: %v", row, err)
+		return true, nil
+	}
+	return false, nil
+}
+
+// recoverFormat runs format and turns a panic into an error.
+func recoverFormat(format func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while formatting: %v", r)
+		}
+	}()
+	return format()
+}
+
+// logSkippedRows reports the rows left out by OnError skip.
+func (o ExportOptions) logSkippedRows(skipped int) {
+	if skipped > 0 {
+		logger.Warn("%d rows skipped by --on-error skip", skipped)
+	}
+}
+
+// trimValues strips trailing whitespace from the text values of a row in place
+// when TrimText is set.
+func (o ExportOptions) trimValues(values []any, fields []pgconn.FieldDescription) {
+	if !o.TrimText {
+		return
+	}
+	for i, v := range values {
+		values[i] = formatters.TrimText(v, fields[i].DataTypeOID)
+	}
+}
+
+// truncate cuts the formatted text of v to MaxFieldLength characters when v is
+// a text, binary or JSON value.
+func (o ExportOptions) truncate(text string, v any, oid uint32) string {
+	if o.MaxFieldLength <= 0 || !formatters.Truncatable(v, oid) {
+		return text
+	}
+	return formatters.TruncateText(text, o.MaxFieldLength, o.TruncateMarker)
+}
+
+// separator returns the CSV field separator as a string.
+func (o ExportOptions) separator() string {
+	if o.DelimiterString != "" {
+		return o.DelimiterString
+	}
+	return string(o.Delimiter)
+}
+
+// Exporter writes a query result to options.OutputPath, or to options.Writer.
+// It is the contract for every format, built-in or registered by a third
+// party with Register.
+//
+// Export returns the number of rows written, also on error. An exporter should:
+//   - read column names with ColumnNames(rows.FieldDescriptions(), ...) before
+//     creating the output, and the column types from FieldDescriptions().DataTypeOID
+//   - create the output with output.CreateWriter, passing OutputPath,
+//     Compression, Format, Atomic and WriteCloser(), and close it when done
+//   - call output.Commit on the writer once the export has succeeded, so that
+//     --atomic output is published
+//   - stop when options.Context (nil means context.Background()) is cancelled
+//
+// The exporter does not close rows; the caller does.
 type Exporter interface {
 	Export(rows pgx.Rows, options ExportOptions) (int, error)
 }
 
-// Optional capability interface for exporters that can use PostgreSQL COPY
+// ExportTo exports rows in options.Format to w instead of options.OutputPath,
+// e.g. to a bytes.Buffer or an HTTP response. w is not closed. Outputs that
+// need a file (Atomic, Resume, checkpoints, XmlRowCountAttr) are rejected.
+func ExportTo(w io.Writer, rows pgx.Rows, options ExportOptions) (int, error) {
+	exporter, err := Get(options.Format)
+	if err != nil {
+		return 0, err
+	}
+	options.Writer = w
+	return exporter.Export(rows, options)
+}
+
+// CopyCapable is an optional interface for exporters that can stream the
+// output of PostgreSQL COPY ... TO STDOUT. When --with-copy is set the CLI
+// calls ExportCopy instead of Export, with the query still to be run.
+// Implementations must validate query before embedding it in a COPY statement.
 type CopyCapable interface {
 	ExportCopy(conn *pgx.Conn, query string, options ExportOptions) (int, error)
 }
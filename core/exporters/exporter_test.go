@@ -0,0 +1,1212 @@
+package exporters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportCancelledContext(t *testing.T) {
+	data := [][]any{{int32(1)}, {int32(2)}, {int32(3)}, {int32(4)}, {int32(5)}}
+
+	tests := []struct {
+		name      string
+		format    string
+		streaming bool
+		cancelAt  int // row index at which the context is cancelled (-1 = before export)
+		wantRows  int
+	}{
+		{name: "csv mid-export", format: FormatCSV, cancelAt: 2, wantRows: 2},
+		{name: "json mid-export", format: FormatJSON, cancelAt: 2, wantRows: 2},
+		{name: "xml mid-export", format: FormatXML, cancelAt: 2, wantRows: 2},
+		{name: "yaml mid-export", format: FormatYAML, cancelAt: 2, wantRows: 2},
+		{name: "sql mid-export", format: FormatSQL, cancelAt: 2, wantRows: 2},
+		{name: "xlsx mid-export", format: FormatXLSX, cancelAt: 2, wantRows: 2},
+		{name: "template streaming mid-export", format: FormatTemplate, streaming: true, cancelAt: 2, wantRows: 2},
+		{name: "template full mid-export", format: FormatTemplate, cancelAt: 2, wantRows: 2},
+		{name: "csv cancelled before export", format: FormatCSV, cancelAt: -1, wantRows: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			fullTpl := filepath.Join(tmpDir, "full.tpl")
+			rowTpl := filepath.Join(tmpDir, "row.tpl")
+			if err := os.WriteFile(fullTpl, []byte("{{range .Rows}}{{get . \"id\"}}\n{{end}}"), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+			if err := os.WriteFile(rowTpl, []byte("{{get . \"id\"}}\n"), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAt < 0 {
+				cancel()
+			}
+
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data)
+			rows.onNext = func(pos int) {
+				if pos == tt.cancelAt {
+					cancel()
+				}
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      filepath.Join(tmpDir, "output."+tt.format),
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				Context:         ctx,
+			}
+			if tt.format == FormatTemplate {
+				options.TemplateStreaming = tt.streaming
+				if tt.streaming {
+					options.TemplateRow = rowTpl
+				} else {
+					options.TemplateFile = fullTpl
+				}
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Export() error = %v, want context.Canceled", err)
+			}
+			if rowCount != tt.wantRows {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestExportNilContext(t *testing.T) {
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}})
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:      FormatCSV,
+		Delimiter:   ',',
+		Compression: "none",
+		OutputPath:  filepath.Join(t.TempDir(), "output.csv"),
+	}
+
+	rowCount, err := exporter.Export(rows, options)
+	if err != nil {
+		t.Fatalf("Export() without a context should succeed, got: %v", err)
+	}
+	if rowCount != 1 {
+		t.Errorf("Export() rowCount = %d, want 1", rowCount)
+	}
+}
+
+func TestExportAtomic(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		compression string
+		rowCountTag string
+		cancel      bool
+		wantFile    string // expected output file name (empty = none)
+	}{
+		{name: "csv success", format: FormatCSV, compression: "none", wantFile: "output.csv"},
+		{name: "csv gzip success", format: FormatCSV, compression: "gzip", wantFile: "output.csv.gz"},
+		{name: "json success", format: FormatJSON, compression: "none", wantFile: "output.json"},
+		{name: "xml row count success", format: FormatXML, compression: "none", rowCountTag: "count", wantFile: "output.xml"},
+		{name: "xlsx success", format: FormatXLSX, compression: "none", wantFile: "output.xlsx"},
+		{name: "csv cancelled", format: FormatCSV, compression: "none", cancel: true},
+		{name: "csv gzip cancelled", format: FormatCSV, compression: "gzip", cancel: true},
+		{name: "json cancelled", format: FormatJSON, compression: "none", cancel: true},
+		{name: "yaml cancelled", format: FormatYAML, compression: "none", cancel: true},
+		{name: "sql cancelled", format: FormatSQL, compression: "none", cancel: true},
+		{name: "xml cancelled", format: FormatXML, compression: "none", cancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			data := [][]any{{int32(1)}, {int32(2)}, {int32(3)}}
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data)
+			if tt.cancel {
+				rows.onNext = func(pos int) {
+					if pos == 1 {
+						cancel()
+					}
+				}
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			_, err = exporter.Export(rows, ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     tt.compression,
+				OutputPath:      filepath.Join(tmpDir, "output."+tt.format),
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				XmlRowCountAttr: tt.rowCountTag,
+				Atomic:          true,
+				Context:         ctx,
+			})
+			if tt.cancel != (err != nil) {
+				t.Fatalf("Export() error = %v, cancelled = %v", err, tt.cancel)
+			}
+
+			entries, err := os.ReadDir(tmpDir)
+			if err != nil {
+				t.Fatalf("Failed to read output directory: %v", err)
+			}
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			var want []string
+			if tt.wantFile != "" {
+				want = []string{tt.wantFile}
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("output directory = %v, want %v", files, want)
+			}
+
+			if tt.rowCountTag != "" {
+				content, _ := os.ReadFile(filepath.Join(tmpDir, tt.wantFile))
+				if !strings.Contains(string(content), `count="3"`) {
+					t.Errorf("row count attribute not patched in final file:\n%s", content)
+				}
+			}
+		})
+	}
+}
+
+func TestExportOutputEncoding(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string // byte sequences expected in the output
+	}{
+		{format: FormatCSV, want: []string{"name\ncaf\xe9\n"}},
+		{format: FormatSQL, want: []string{"'caf\xe9'"}},
+		{format: FormatXML, want: []string{`<?xml version="1.0" encoding="ISO-8859-1"?>`, "<name>caf\xe9</name>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"name"}, []uint32{pgtype.TextOID}, [][]any{{"café"}})
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				OutputEncoding:  "latin1",
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got %q", want, content)
+				}
+			}
+			if strings.Contains(string(content), "é") {
+				t.Errorf("output still contains UTF-8 characters: %q", content)
+			}
+		})
+	}
+}
+
+func TestExportOutputEncodingStrict(t *testing.T) {
+	rows := newFakeRows([]string{"price"}, []uint32{pgtype.TextOID}, [][]any{{"12€"}})
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:         FormatCSV,
+		Delimiter:      ',',
+		Compression:    "none",
+		OutputPath:     filepath.Join(t.TempDir(), "output.csv"),
+		OutputEncoding: "latin1",
+		EncodingStrict: true,
+	}
+
+	_, err = exporter.Export(rows, options)
+	if err == nil || !strings.Contains(err.Error(), "not representable in latin1") {
+		t.Fatalf("Export() error = %v, want an encoding error", err)
+	}
+}
+
+func TestExportSpecialFloats(t *testing.T) {
+	data := [][]any{
+		{math.NaN(), pgtype.Numeric{NaN: true, Valid: true}},
+		{math.Inf(1), pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}},
+		{math.Inf(-1), pgtype.Numeric{InfinityModifier: pgtype.NegativeInfinity, Valid: true}},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		modify func(o *ExportOptions)
+		check  func(t *testing.T, path string, content string)
+	}{
+		{
+			name:   "csv postgres spelling",
+			format: FormatCSV,
+			check: func(t *testing.T, _ string, content string) {
+				want := "f,n\nNaN,NaN\nInfinity,Infinity\n-Infinity,-Infinity\n"
+				if content != want {
+					t.Errorf("CSV = %q, want %q", content, want)
+				}
+			},
+		},
+		{
+			name:   "csv custom text",
+			format: FormatCSV,
+			modify: func(o *ExportOptions) {
+				o.CsvSpecialFloats = map[string]string{"NaN": "", "Infinity": "inf", "-Infinity": "-inf"}
+			},
+			check: func(t *testing.T, _ string, content string) {
+				want := "f,n\n,\ninf,inf\n-inf,-inf\n"
+				if content != want {
+					t.Errorf("CSV = %q, want %q", content, want)
+				}
+			},
+		},
+		{
+			name:   "json null",
+			format: FormatJSON,
+			check: func(t *testing.T, _ string, content string) {
+				var parsed []map[string]any
+				if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("JSON should be valid: %v\n%s", err, content)
+				}
+				for i, row := range parsed {
+					if row["f"] != nil || row["n"] != nil {
+						t.Errorf("row %d = %v, want nulls", i, row)
+					}
+				}
+			},
+		},
+		{
+			name:   "json string",
+			format: FormatJSON,
+			modify: func(o *ExportOptions) { o.JsonSpecialFloats = "string" },
+			check: func(t *testing.T, _ string, content string) {
+				var parsed []map[string]any
+				if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("JSON should be valid: %v\n%s", err, content)
+				}
+				want := []string{"NaN", "Infinity", "-Infinity"}
+				for i, row := range parsed {
+					if row["f"] != want[i] || row["n"] != want[i] {
+						t.Errorf("row %d = %v, want %q", i, row, want[i])
+					}
+				}
+			},
+		},
+		{
+			name:   "sql typed literals",
+			format: FormatSQL,
+			check: func(t *testing.T, _ string, content string) {
+				for _, want := range []string{
+					"('NaN'::float8, 'NaN'::numeric)",
+					"('Infinity'::float8, 'Infinity'::numeric)",
+					"('-Infinity'::float8, '-Infinity'::numeric)",
+				} {
+					if !strings.Contains(content, want) {
+						t.Errorf("SQL should contain %s, got:\n%s", want, content)
+					}
+				}
+			},
+		},
+		{
+			name:   "xml postgres spelling",
+			format: FormatXML,
+			check: func(t *testing.T, _ string, content string) {
+				for _, want := range []string{"<f>NaN</f>", "<n>Infinity</n>", "<f>-Infinity</f>"} {
+					if !strings.Contains(content, want) {
+						t.Errorf("XML should contain %s, got:\n%s", want, content)
+					}
+				}
+			},
+		},
+		{
+			name:   "yaml special values",
+			format: FormatYAML,
+			check: func(t *testing.T, _ string, content string) {
+				var parsed []map[string]float64
+				if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("YAML should be valid: %v\n%s", err, content)
+				}
+				if len(parsed) != 3 || !math.IsNaN(parsed[0]["f"]) || !math.IsInf(parsed[1]["n"], 1) || !math.IsInf(parsed[2]["f"], -1) {
+					t.Errorf("unexpected YAML values: %v", parsed)
+				}
+			},
+		},
+		{
+			name:   "xlsx text",
+			format: FormatXLSX,
+			check: func(t *testing.T, path string, _ string) {
+				f, err := excelize.OpenFile(path)
+				if err != nil {
+					t.Fatalf("Failed to open XLSX file: %v", err)
+				}
+				defer f.Close()
+
+				rows, err := f.GetRows("Sheet1")
+				if err != nil {
+					t.Fatalf("Failed to get rows: %v", err)
+				}
+				want := [][]string{{"f", "n"}, {"NaN", "NaN"}, {"Infinity", "Infinity"}, {"-Infinity", "-Infinity"}}
+				if !reflect.DeepEqual(rows, want) {
+					t.Errorf("XLSX rows = %v, want %v", rows, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"f", "n"}, []uint32{pgtype.Float8OID, pgtype.NumericOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			}
+			if tt.modify != nil {
+				tt.modify(&options)
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			tt.check(t, outputPath, string(content))
+		})
+	}
+}
+
+func TestExportMoney(t *testing.T) {
+	data := [][]any{{"$1,234.56"}, {"-$1,234.56"}, {"$0.00"}, {nil}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: FormatCSV, want: []string{"fee\n1234.56\n-1234.56\n0.00\n\"\"\n"}},
+		{format: FormatSQL, want: []string{
+			"('1234.56'::numeric::money)",
+			"('-1234.56'::numeric::money)",
+			"('0.00'::numeric::money)",
+			"(NULL)",
+		}},
+		{format: FormatXML, want: []string{"<fee>1234.56</fee>", "<fee>-1234.56</fee>", "<fee>0.00</fee>"}},
+		{format: FormatJSON, want: []string{`"fee": 1234.56`, `"fee": -1234.56`, `"fee": 0.00`, `"fee": null`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"fee"}, []uint32{formatters.MoneyOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExportBits(t *testing.T) {
+	data := [][]any{
+		{pgtype.Bits{Bytes: []byte{0b10101000}, Len: 6, Valid: true}, pgtype.Bits{Bytes: []byte{0b10100101, 0b10000000}, Len: 9, Valid: true}},
+		{pgtype.Bits{Bytes: []byte{0b00000100}, Len: 6, Valid: true}, pgtype.Bits{Len: 0, Valid: true}},
+		{nil, nil},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: FormatCSV, want: []string{"flags,mask\n101010,101001011\n000001,\n,\n"}},
+		{format: FormatSQL, want: []string{"(B'101010', B'101001011')", "(B'000001', B'')", "(NULL, NULL)"}},
+		{format: FormatXML, want: []string{"<flags>101010</flags>", "<mask>101001011</mask>", "<flags>000001</flags>"}},
+		{format: FormatJSON, want: []string{`"flags": "101010"`, `"mask": "101001011"`, `"mask": ""`, `"flags": null`}},
+		{format: FormatYAML, want: []string{`flags: "101010"`, `mask: "101001011"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"flags", "mask"}, []uint32{pgtype.BitOID, pgtype.VarbitOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExportNetworkTypes(t *testing.T) {
+	data := [][]any{
+		{netip.MustParsePrefix("192.168.1.5/32"), netip.MustParsePrefix("192.168.1.0/24"), net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03}},
+		{netip.MustParsePrefix("2001:db8::1/64"), netip.MustParsePrefix("2001:db8::/32"), nil},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: FormatCSV, want: []string{"host,network,mac\n192.168.1.5,192.168.1.0/24,08:00:2b:01:02:03\n2001:db8::1/64,2001:db8::/32,\n"}},
+		{format: FormatSQL, want: []string{
+			"('192.168.1.5'::inet, '192.168.1.0/24'::cidr, '08:00:2b:01:02:03'::macaddr)",
+			"('2001:db8::1/64'::inet, '2001:db8::/32'::cidr, NULL)",
+		}},
+		{format: FormatXML, want: []string{"<host>192.168.1.5</host>", "<network>2001:db8::/32</network>", "<mac>08:00:2b:01:02:03</mac>"}},
+		{format: FormatJSON, want: []string{`"host": "192.168.1.5"`, `"network": "192.168.1.0/24"`, `"mac": "08:00:2b:01:02:03"`, `"host": "2001:db8::1/64"`}},
+		{format: FormatYAML, want: []string{"host: 192.168.1.5\n", "network: 2001:db8::/32\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"host", "network", "mac"}, []uint32{pgtype.InetOID, pgtype.CIDROID, pgtype.MacaddrOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+// failingJSON is a json value whose encoding panics, like an exotic type
+// breaking the generic formatting path.
+type failingJSON struct{}
+
+func (failingJSON) MarshalJSON() ([]byte, error) { panic("cannot encode value") }
+
+func TestExportOnErrorSkip(t *testing.T) {
+	data := [][]any{
+		{int32(1), map[string]any{"a": 1}},
+		{int32(2), failingJSON{}},
+		{int32(3), nil},
+	}
+
+	tests := []struct {
+		format  string
+		workers int
+		want    []string
+		mustNot []string
+	}{
+		{format: FormatCSV, want: []string{"id,doc\n1,\"{\"\"a\"\":1}\"\n3,\n"}},
+		{format: FormatCSV, workers: 4, want: []string{"id,doc\n1,\"{\"\"a\"\":1}\"\n3,\n"}},
+		{format: FormatTSV, want: []string{"id\tdoc\n1\t{\"a\":1}\n3\t\\N\n"}},
+		{format: FormatJSON, want: []string{`"id": 1`, `"id": 3`}, mustNot: []string{`"id": 2`}},
+		{format: FormatXML, want: []string{"<id>1</id>", "<id>3</id>"}, mustNot: []string{"<id>2</id>"}},
+		{format: FormatSQL, want: []string{"(1, '{\"a\":1}'::jsonb)", "(3, NULL)"}, mustNot: []string{"(2,"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s workers=%d", tt.format, tt.workers), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows([]string{"id", "doc"}, []uint32{pgtype.Int4OID, pgtype.JSONBOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				Workers:         tt.workers,
+				OnError:         OnErrorSkip,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != 2 {
+				t.Errorf("Export() rowCount = %d, want 2", rowCount)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got:\n%s", want, content)
+				}
+			}
+			for _, unwanted := range tt.mustNot {
+				if strings.Contains(string(content), unwanted) {
+					t.Errorf("output should not contain %q, got:\n%s", unwanted, content)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatRow(t *testing.T) {
+	failure := errors.New("bad value")
+
+	tests := []struct {
+		name        string
+		onError     string
+		format      func() error
+		wantSkipped bool
+		wantErr     error
+	}{
+		{name: "stop success", onError: OnErrorStop, format: func() error { return nil }},
+		{name: "stop error", onError: OnErrorStop, format: func() error { return failure }, wantErr: failure},
+		{name: "default is stop", onError: "", format: func() error { return failure }, wantErr: failure},
+		{name: "skip success", onError: OnErrorSkip, format: func() error { return nil }},
+		{name: "skip error", onError: OnErrorSkip, format: func() error { return failure }, wantSkipped: true},
+		{name: "skip panic", onError: OnErrorSkip, format: func() error { panic("boom") }, wantSkipped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipped, err := ExportOptions{OnError: tt.onError}.formatRow(1, tt.format)
+			if skipped != tt.wantSkipped || !errors.Is(err, tt.wantErr) {
+				t.Errorf("formatRow() = %v, %v, want %v, %v", skipped, err, tt.wantSkipped, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportTrimText(t *testing.T) {
+	tests := []struct {
+		format  string
+		trim    bool
+		want    []string
+		mustNot []string
+	}{
+		{format: FormatCSV, trim: true, want: []string{"code,label\nabc,\" x\"\n,y\n"}},
+		{format: FormatCSV, trim: false, want: []string{`abc   ," x  "`}},
+		{format: FormatJSON, trim: true, want: []string{`"code": "abc"`, `"label": " x"`}, mustNot: []string{"abc "}},
+		{format: FormatXML, trim: true, want: []string{"<code>abc</code>", "<label> x</label>"}},
+		{format: FormatSQL, trim: true, want: []string{"('abc', ' x')"}},
+		{format: FormatYAML, trim: true, want: []string{"code: abc\n"}, mustNot: []string{"abc "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s trim=%v", tt.format, tt.trim), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			// 'abc   '::char(6) and a varchar keeping its leading space
+			data := [][]any{{"abc   ", " x  "}, {nil, "y"}}
+			rows := newFakeRows([]string{"code", "label"}, []uint32{pgtype.BPCharOID, pgtype.VarcharOID}, data)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:          tt.format,
+				Delimiter:       ',',
+				Compression:     "none",
+				OutputPath:      outputPath,
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				TrimText:        tt.trim,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %q, got:\n%s", want, content)
+				}
+			}
+			for _, unwanted := range tt.mustNot {
+				if strings.Contains(string(content), unwanted) {
+					t.Errorf("output should not contain %q, got:\n%s", unwanted, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExportMaxFieldLength(t *testing.T) {
+	long := strings.Repeat("x", 50)
+
+	tests := []struct {
+		name   string
+		format string
+		marker string
+		check  func(t *testing.T, path string, content string)
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			marker: "...",
+			check: func(t *testing.T, _ string, content string) {
+				want := "id,body,data,doc,n\n1,xxxxxxxxxx...,0123456789...,\"{\"\"k\"\":\"\"vvvv...\",123456789012345\n"
+				if content != want {
+					t.Errorf("CSV = %q, want %q", content, want)
+				}
+			},
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			marker: "[cut]",
+			check: func(t *testing.T, _ string, content string) {
+				var parsed []map[string]any
+				if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("JSON should be valid: %v\n%s", err, content)
+				}
+				row := parsed[0]
+				if row["body"] != "xxxxxxxxxx[cut]" {
+					t.Errorf("body = %v, want xxxxxxxxxx[cut]", row["body"])
+				}
+				if row["data"] != `\x30313233[cut]` {
+					t.Errorf("data = %v, want the first 10 characters of the hex text", row["data"])
+				}
+				if row["doc"] != `{"k":"vvvv[cut]` {
+					t.Errorf("doc = %v, want the first 10 characters of the serialized JSON", row["doc"])
+				}
+				if row["n"] != float64(123456789012345) {
+					t.Errorf("n = %v, numbers should not be truncated", row["n"])
+				}
+			},
+		},
+		{
+			name:   "xlsx",
+			format: FormatXLSX,
+			marker: "...",
+			check: func(t *testing.T, path string, _ string) {
+				f, err := excelize.OpenFile(path)
+				if err != nil {
+					t.Fatalf("Failed to open XLSX file: %v", err)
+				}
+				defer f.Close()
+
+				rows, err := f.GetRows("Sheet1")
+				if err != nil {
+					t.Fatalf("Failed to get rows: %v", err)
+				}
+				if got := rows[1][1]; got != "xxxxxxxxxx..." {
+					t.Errorf("body cell = %q, want xxxxxxxxxx...", got)
+				}
+				if got := rows[1][3]; got != `{"k":"vvvv...` {
+					t.Errorf("doc cell = %q, want the first 10 characters of the serialized JSON", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows(
+				[]string{"id", "body", "data", "doc", "n"},
+				[]uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.ByteaOID, pgtype.JSONBOID, pgtype.Int8OID},
+				[][]any{{int32(1), long, []byte("01234567890123"), map[string]any{"k": strings.Repeat("v", 20)}, int64(123456789012345)}},
+			)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:         tt.format,
+				Delimiter:      ',',
+				Compression:    "none",
+				OutputPath:     outputPath,
+				MaxFieldLength: 10,
+				TruncateMarker: tt.marker,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			tt.check(t, outputPath, string(content))
+		})
+	}
+}
+
+func TestExportCompact(t *testing.T) {
+	tests := []struct {
+		format  string
+		compact bool
+		want    string
+	}{
+		{
+			format: FormatJSON,
+			want: "[\n" +
+				"  {\n    \"id\": 1,\n    \"name\": \"alice\",\n    \"meta\": {\n      \"a\": 1\n    }\n  },\n" +
+				"  {\n    \"id\": 2,\n    \"name\": null,\n    \"meta\": null\n  }\n" +
+				"]\n",
+		},
+		{
+			format:  FormatJSON,
+			compact: true,
+			want: "[\n" +
+				"{\"id\":1,\"name\":\"alice\",\"meta\":{\"a\":1}},\n" +
+				"{\"id\":2,\"name\":null,\"meta\":null}\n" +
+				"]\n",
+		},
+		{
+			format: FormatXML,
+			want: xml.Header +
+				"<results>\n" +
+				"  <row>\n    <id>1</id>\n    <name>alice</name>\n    <meta>{\"a\":1}</meta>\n  </row>\n" +
+				"  <row>\n    <id>2</id>\n    <name></name>\n    <meta></meta>\n  </row>\n" +
+				"</results>\n",
+		},
+		{
+			format:  FormatXML,
+			compact: true,
+			want: xml.Header +
+				"<results>\n" +
+				"<row><id>1</id><name>alice</name><meta>{\"a\":1}</meta></row>\n" +
+				"<row><id>2</id><name></name><meta></meta></row>\n" +
+				"</results>\n",
+		},
+		{
+			format: FormatYAML,
+			want: "- id: 1\n  name: alice\n  meta:\n    a: 1\n" +
+				"- id: 2\n  name: null\n  meta: null\n",
+		},
+		{
+			format:  FormatYAML,
+			compact: true,
+			want: "- {id: 1, name: alice, meta: {a: 1}}\n" +
+				"- {id: 2, name: null, meta: null}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/compact=%v", tt.format, tt.compact), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows(
+				[]string{"id", "name", "meta"},
+				[]uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.JSONBOID},
+				[][]any{{int32(1), "alice", map[string]any{"a": float64(1)}}, {int32(2), nil, nil}},
+			)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			if _, err := exporter.Export(rows, ExportOptions{
+				Format:         tt.format,
+				Compression:    "none",
+				OutputPath:     outputPath,
+				XmlRootElement: "results",
+				XmlRowElement:  "row",
+				Compact:        tt.compact,
+			}); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output =\n%s\nwant:\n%s", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportCompactWithMeta(t *testing.T) {
+	for _, keyBy := range []string{"", "id"} {
+		t.Run(fmt.Sprintf("keyBy=%q", keyBy), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.json")
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}, {int32(2)}})
+
+			exporter, err := Get(FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get json exporter: %v", err)
+			}
+			if _, err := exporter.Export(rows, ExportOptions{
+				Format:           FormatJSON,
+				Compression:      "none",
+				OutputPath:       outputPath,
+				JsonKeyBy:        keyBy,
+				GeneratedComment: true,
+				SourceQuery:      "SELECT id FROM t",
+				Compact:          true,
+			}); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if !json.Valid(content) {
+				t.Fatalf("output is not valid JSON:\n%s", content)
+			}
+			// Brackets, the _meta entry and the two rows each take one line
+			if lines := strings.Count(string(content), "\n"); lines != 5 {
+				t.Errorf("output has %d lines, want 5:\n%s", lines, content)
+			}
+		})
+	}
+}
+
+func TestExportTo(t *testing.T) {
+	names := []string{"id", "name"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID}
+	data := [][]any{{int32(1), "alice"}, {int32(2), nil}}
+	rowTemplate := filepath.Join(t.TempDir(), "row.tpl")
+	if err := os.WriteFile(rowTemplate, []byte(`{{get . "id"}}={{get . "name"}}`+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	for _, format := range []string{FormatCSV, FormatTSV, FormatJSON, FormatXML, FormatYAML, FormatSQL, FormatTemplate, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			options := ExportOptions{
+				Format:            format,
+				Delimiter:         ',',
+				Compression:       "none",
+				XmlRootElement:    "results",
+				XmlRowElement:     "row",
+				TableName:         "users",
+				TemplateRow:       rowTemplate,
+				TemplateStreaming: true,
+			}
+
+			// The same export written to a file is the reference
+			fileOptions := options
+			fileOptions.OutputPath = filepath.Join(t.TempDir(), "output."+format)
+			exporter, err := Get(format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", format, err)
+			}
+			if _, err := exporter.Export(newFakeRows(names, oids, data), fileOptions); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			want, err := os.ReadFile(fileOptions.OutputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			var buf bytes.Buffer
+			rowCount, err := ExportTo(&buf, newFakeRows(names, oids, data), options)
+			if err != nil {
+				t.Fatalf("ExportTo() error: %v", err)
+			}
+			if rowCount != len(data) {
+				t.Errorf("ExportTo() = %d rows, want %d", rowCount, len(data))
+			}
+
+			if format == FormatXLSX {
+				// Workbooks embed their creation time, so compare the cells
+				f, err := excelize.OpenReader(&buf)
+				if err != nil {
+					t.Fatalf("Failed to open XLSX output: %v", err)
+				}
+				defer f.Close()
+				rows, err := f.GetRows("Sheet1")
+				if err != nil {
+					t.Fatalf("Failed to get rows: %v", err)
+				}
+				if wantRows := [][]string{{"id", "name"}, {"1", "alice"}, {"2"}}; !reflect.DeepEqual(rows, wantRows) {
+					t.Errorf("XLSX rows = %v, want %v", rows, wantRows)
+				}
+				return
+			}
+			if buf.String() != string(want) {
+				t.Errorf("ExportTo() output = %q, want %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestExportToCompressed(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := ExportTo(&buf, newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}}), ExportOptions{
+		Format:      FormatJSON,
+		Compression: "gzip",
+	}); err != nil {
+		t.Fatalf("ExportTo() error: %v", err)
+	}
+
+	reader, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("output is not gzip: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decompress output: %v", err)
+	}
+	if want := "[\n  {\n    \"id\": 1\n  }\n]\n"; string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+// brokenWriter fails every write, like a full disk or a closed connection.
+type brokenWriter struct{}
+
+func (brokenWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestExportToErrWrite(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatTSV, FormatJSON, FormatXML, FormatYAML, FormatSQL, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}, {int32(2)}})
+			_, err := ExportTo(brokenWriter{}, rows, ExportOptions{
+				Format:          format,
+				Delimiter:       ',',
+				Compression:     "none",
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			})
+			if !errors.Is(err, ErrWrite) {
+				t.Errorf("ExportTo() error = %v, want ErrWrite", err)
+			}
+		})
+	}
+
+	t.Run("yaml larger than the output buffer", func(t *testing.T) {
+		value := strings.Repeat("x", 300*1024)
+		rows := newFakeRows([]string{"v"}, []uint32{pgtype.TextOID}, [][]any{{value}, {value}})
+		_, err := ExportTo(brokenWriter{}, rows, ExportOptions{Format: FormatYAML, Compression: "none"})
+		if !errors.Is(err, ErrWrite) {
+			t.Errorf("ExportTo() error = %v, want ErrWrite", err)
+		}
+	})
+
+	t.Run("empty result is not a write error", func(t *testing.T) {
+		if errors.Is(ErrEmptyResult, ErrWrite) {
+			t.Error("ErrEmptyResult should not match ErrWrite")
+		}
+	})
+}
+
+// limitWriter accepts limit bytes, then fails like a disk filling up.
+type limitWriter struct {
+	limit int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("no space left on device")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestExportToErrWriteRowCount(t *testing.T) {
+	// Rows larger than the output buffer reach the writer one by one
+	const rowCount = 10
+	value := strings.Repeat("x", 300*1024)
+	data := make([][]any, rowCount)
+	for i := range data {
+		data[i] = []any{value}
+	}
+
+	// YAML is written in one piece once every row is read, so it is left out
+	for _, format := range []string{FormatCSV, FormatTSV, FormatJSON, FormatXML, FormatSQL} {
+		t.Run(format, func(t *testing.T) {
+			n, err := ExportTo(&limitWriter{limit: 1024 * 1024}, newFakeRows([]string{"v"}, []uint32{pgtype.TextOID}, data), ExportOptions{
+				Format:          format,
+				Delimiter:       ',',
+				Compression:     "none",
+				TableName:       "t",
+				RowPerStatement: 1,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+			})
+			if !errors.Is(err, ErrWrite) {
+				t.Fatalf("ExportTo() error = %v, want ErrWrite", err)
+			}
+			if n == 0 || n >= rowCount {
+				t.Errorf("ExportTo() = %d rows, want the rows written before the failure", n)
+			}
+		})
+	}
+}
+
+func TestExportToErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ExportOptions
+		wantErr string
+	}{
+		{"unknown format", ExportOptions{Format: "parquet"}, "parquet"},
+		{"atomic", ExportOptions{Format: FormatCSV, Delimiter: ',', Atomic: true}, "require a file"},
+		{"checkpoint", ExportOptions{Format: FormatCSV, Delimiter: ',', CheckpointEvery: 10}, "checkpoints require a file"},
+		{"row count attribute", ExportOptions{Format: FormatXML, XmlRootElement: "results", XmlRowElement: "row", XmlRowCountAttr: "count"}, "requires a file output"},
+		{"schema without path", ExportOptions{Format: FormatJSON, EmitSchema: true}, "require an output path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Compression = "none"
+			var buf bytes.Buffer
+			_, err := ExportTo(&buf, newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}}), tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExportTo() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package exporters
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeRows is an in-memory pgx.Rows used by tests that exercise exporter
+// logic without a live database connection.
+type fakeRows struct {
+	fields []pgconn.FieldDescription
+	data   [][]any
+	pos    int
+	err    error
+	closed bool
+	// onNext, when set, is called with the index of each row before it is returned
+	onNext func(pos int)
+}
+
+// newFakeRows builds a fakeRows from column names, their type OIDs and row values.
+func newFakeRows(names []string, oids []uint32, data [][]any) *fakeRows {
+	fields := make([]pgconn.FieldDescription, len(names))
+	for i, name := range names {
+		fields[i] = pgconn.FieldDescription{Name: name, DataTypeOID: oids[i]}
+	}
+	return &fakeRows{fields: fields, data: data, pos: -1}
+}
+
+func (r *fakeRows) Close()                                       { r.closed = true }
+func (r *fakeRows) Err() error                                   { return r.err }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *fakeRows) RawValues() [][]byte                          { return nil }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.pos+1 >= len(r.data) {
+		return false
+	}
+	r.pos++
+	if r.onNext != nil {
+		r.onNext(r.pos)
+	}
+	return true
+}
+
+// Scan round-trips each value through its binary wire format, as a value read
+// from PostgreSQL would be.
+func (r *fakeRows) Scan(dest ...any) error {
+	m := pgtype.NewMap()
+	for i, d := range dest {
+		oid := r.fields[i].DataTypeOID
+		buf, err := m.Encode(oid, pgtype.BinaryFormatCode, r.data[r.pos][i], nil)
+		if err != nil {
+			return err
+		}
+		if buf == nil && r.data[r.pos][i] != nil {
+			buf = []byte{} // empty value, not NULL
+		}
+		if err := m.Scan(oid, pgtype.BinaryFormatCode, buf, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Values() ([]any, error) {
+	return r.data[r.pos], nil
+}
@@ -0,0 +1,156 @@
+package exporters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// flattenedRows replaces a json or jsonb column with one column per key of
+// FlattenKeys, named "<column>.<key>". Rows without the key, and NULL or
+// JSON null values, give NULL.
+//
+// In native mode (JSON output) the new columns keep the json type and hold
+// the key's value as decoded, so numbers, objects and arrays stay JSON. In
+// text mode (CSV output) they are text: strings as-is, other values as
+// compact JSON.
+type flattenedRows struct {
+	pgx.Rows
+	column string
+	index  int // position of the flattened column in the source row
+	keys   []string
+	native bool
+	fields []pgconn.FieldDescription
+	row    int
+}
+
+// flattenJSONRows wraps rows to flatten options.FlattenJSON, or returns rows
+// unchanged when it is not set.
+func flattenJSONRows(rows pgx.Rows, options ExportOptions, native bool) (pgx.Rows, error) {
+	if options.FlattenJSON == "" {
+		return rows, nil
+	}
+
+	source := rows.FieldDescriptions()
+	index := -1
+	for i, fd := range source {
+		if fd.Name == options.FlattenJSON {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("--flatten-json column %q not found in query results", options.FlattenJSON)
+	}
+	oid := source[index].DataTypeOID
+	if oid != pgtype.JSONBOID && oid != pgtype.JSONOID {
+		return nil, fmt.Errorf("--flatten-json column %q is not a json or jsonb column", options.FlattenJSON)
+	}
+
+	keyOID := uint32(pgtype.TextOID)
+	if native {
+		keyOID = oid
+	}
+	fields := make([]pgconn.FieldDescription, 0, len(source)-1+len(options.FlattenKeys))
+	fields = append(fields, source[:index]...)
+	for _, key := range options.FlattenKeys {
+		fields = append(fields, pgconn.FieldDescription{
+			Name:         options.FlattenJSON + "." + key,
+			DataTypeOID:  keyOID,
+			DataTypeSize: -1,
+			TypeModifier: -1,
+		})
+	}
+	fields = append(fields, source[index+1:]...)
+	logger.Debug("Flattening column %q into %d columns", options.FlattenJSON, len(options.FlattenKeys))
+
+	return &flattenedRows{
+		Rows:   rows,
+		column: options.FlattenJSON,
+		index:  index,
+		keys:   options.FlattenKeys,
+		native: native,
+		fields: fields,
+	}, nil
+}
+
+func (r *flattenedRows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.fields
+}
+
+func (r *flattenedRows) Next() bool {
+	if !r.Rows.Next() {
+		return false
+	}
+	r.row++
+	return true
+}
+
+// Scan is not supported: the flattened columns have no wire format, so
+// flattenedRows is always read with Values.
+func (r *flattenedRows) Scan(dest ...any) error {
+	return fmt.Errorf("flattened rows are read with Values")
+}
+
+// Values returns the current row with the flattened column replaced by the
+// values of its keys. Each row gets a new slice, as with pgx.Rows.
+func (r *flattenedRows) Values() ([]any, error) {
+	source, err := r.Rows.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]any
+	switch v := source[r.index].(type) {
+	case nil:
+	case map[string]any:
+		object = v
+	default:
+		return nil, fmt.Errorf("row %d: --flatten-json column %q holds %s, not a JSON object", r.row, r.column, jsonKind(v))
+	}
+
+	values := make([]any, 0, len(r.fields))
+	values = append(values, source[:r.index]...)
+	for _, key := range r.keys {
+		value, err := r.keyValue(object[key])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: --flatten-json key %q: %w", r.row, key, err)
+		}
+		values = append(values, value)
+	}
+	values = append(values, source[r.index+1:]...)
+	return values, nil
+}
+
+// keyValue converts the value of a key for the flattened column.
+func (r *flattenedRows) keyValue(v any) (any, error) {
+	if v == nil || r.native {
+		return v, nil
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// jsonKind names the JSON type of a decoded value that is not an object.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
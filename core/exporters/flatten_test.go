@@ -0,0 +1,187 @@
+package exporters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// flattenTestRows returns rows whose attrs column has a different shape on
+// every row: missing keys, extra keys, nested values, NULL and JSON null.
+func flattenTestRows() *fakeRows {
+	return newFakeRows(
+		[]string{"id", "attrs", "name"},
+		[]uint32{pgtype.Int4OID, pgtype.JSONBOID, pgtype.TextOID},
+		[][]any{
+			{int32(1), map[string]any{"city": "Paris", "zip": "75001"}, "alice"},
+			{int32(2), map[string]any{"city": "Lyon", "floor": float64(3), "extra": map[string]any{"a": true}}, "bob"},
+			{int32(3), nil, "carol"},
+			{int32(4), map[string]any{"zip": float64(12345), "city": nil, "tags": []any{"a", "b"}}, "dave"},
+		},
+	)
+}
+
+func TestExportFlattenJSONCSV(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.csv")
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	rowCount, err := exporter.Export(flattenTestRows(), ExportOptions{
+		Format:      FormatCSV,
+		Compression: "none",
+		OutputPath:  outputPath,
+		Delimiter:   ',',
+		FlattenJSON: "attrs",
+		FlattenKeys: []string{"city", "zip", "floor", "tags", "extra"},
+	})
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if rowCount != 4 {
+		t.Errorf("Export() rowCount = %d, want 4", rowCount)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := "id,attrs.city,attrs.zip,attrs.floor,attrs.tags,attrs.extra,name\n" +
+		"1,Paris,75001,,,,alice\n" +
+		"2,Lyon,,3,,\"{\"\"a\"\":true}\",bob\n" +
+		"3,,,,,,carol\n" +
+		"4,,12345,,\"[\"\"a\"\",\"\"b\"\"]\",,dave\n"
+	if string(content) != want {
+		t.Errorf("output =\n%s\nwant\n%s", content, want)
+	}
+}
+
+func TestExportFlattenJSONWorkers(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.csv")
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to get csv exporter: %v", err)
+	}
+	if _, err := exporter.Export(flattenTestRows(), ExportOptions{
+		Format:      FormatCSV,
+		Compression: "none",
+		OutputPath:  outputPath,
+		Delimiter:   ',',
+		Workers:     4,
+		FlattenJSON: "attrs",
+		FlattenKeys: []string{"city"},
+	}); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := "id,attrs.city,name\n1,Paris,alice\n2,Lyon,bob\n3,,carol\n4,,dave\n"
+	if string(content) != want {
+		t.Errorf("output =\n%s\nwant\n%s", content, want)
+	}
+}
+
+func TestExportFlattenJSONJSON(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+
+	exporter, err := Get(FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to get json exporter: %v", err)
+	}
+	if _, err := exporter.Export(flattenTestRows(), ExportOptions{
+		Format:      FormatJSON,
+		Compression: "none",
+		OutputPath:  outputPath,
+		FlattenJSON: "attrs",
+		FlattenKeys: []string{"city", "zip", "tags", "extra"},
+	}); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var parsed []map[string]any
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+	}
+
+	want := []map[string]any{
+		{"id": float64(1), "attrs.city": "Paris", "attrs.zip": "75001", "attrs.tags": nil, "attrs.extra": nil, "name": "alice"},
+		{"id": float64(2), "attrs.city": "Lyon", "attrs.zip": nil, "attrs.tags": nil, "attrs.extra": map[string]any{"a": true}, "name": "bob"},
+		{"id": float64(3), "attrs.city": nil, "attrs.zip": nil, "attrs.tags": nil, "attrs.extra": nil, "name": "carol"},
+		{"id": float64(4), "attrs.city": nil, "attrs.zip": float64(12345), "attrs.tags": []any{"a", "b"}, "attrs.extra": nil, "name": "dave"},
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("parsed = %v, want %v", parsed, want)
+	}
+	if strings.Contains(string(content), `"attrs":`) {
+		t.Errorf("flattened column should be replaced:\n%s", content)
+	}
+}
+
+func TestExportFlattenJSONErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		column      string
+		oids        []uint32
+		data        [][]any
+		errContains string
+	}{
+		{
+			name:        "unknown column",
+			column:      "missing",
+			oids:        []uint32{pgtype.Int4OID, pgtype.JSONBOID},
+			data:        [][]any{{int32(1), map[string]any{}}},
+			errContains: `--flatten-json column "missing" not found`,
+		},
+		{
+			name:        "not a json column",
+			column:      "attrs",
+			oids:        []uint32{pgtype.Int4OID, pgtype.TextOID},
+			data:        [][]any{{int32(1), "{}"}},
+			errContains: `--flatten-json column "attrs" is not a json or jsonb column`,
+		},
+		{
+			name:        "array value",
+			column:      "attrs",
+			oids:        []uint32{pgtype.Int4OID, pgtype.JSONBOID},
+			data:        [][]any{{int32(1), map[string]any{"city": "Paris"}}, {int32(2), []any{"Lyon"}}},
+			errContains: `row 2: --flatten-json column "attrs" holds an array, not a JSON object`,
+		},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				exporter, err := Get(format)
+				if err != nil {
+					t.Fatalf("Failed to get %s exporter: %v", format, err)
+				}
+				rows := newFakeRows([]string{"id", "attrs"}, tt.oids, tt.data)
+				_, err = exporter.Export(rows, ExportOptions{
+					Format:      format,
+					Compression: "none",
+					OutputPath:  filepath.Join(t.TempDir(), "output."+format),
+					Delimiter:   ',',
+					FlattenJSON: tt.column,
+					FlattenKeys: []string{"city"},
+				})
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Export() error = %v, want it to contain %q", err, tt.errContains)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package exporters
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// generatedInfo describes where an export comes from, written at the top of the
+// output when ExportOptions.GeneratedComment is set.
+type generatedInfo struct {
+	Version     string
+	GeneratedAt string
+	Query       string
+}
+
+// newGeneratedInfo captures the tool version, the current time and the source
+// query collapsed to a single line.
+func newGeneratedInfo(options ExportOptions) generatedInfo {
+	return generatedInfo{
+		Version:     version.AppVersion,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Query:       strings.Join(strings.Fields(options.SourceQuery), " "),
+	}
+}
+
+// lines returns the provenance as human-readable lines.
+func (g generatedInfo) lines() []string {
+	lines := []string{fmt.Sprintf("Generated by pgxport %s at %s", g.Version, g.GeneratedAt)}
+	if g.Query != "" {
+		lines = append(lines, "Query: "+g.Query)
+	}
+	return lines
+}
+
+// writeLineComment writes the provenance as comment lines starting with prefix
+// (e.g. "-- " for SQL, "# " for CSV and YAML).
+func writeLineComment(w io.Writer, prefix string, options ExportOptions) error {
+	var sb strings.Builder
+	for _, line := range newGeneratedInfo(options).lines() {
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("error writing generated comment: %w", err)
+	}
+	return nil
+}
+
+// writeSourceQueryComment writes the source query as a "-- Source query:"
+// block of SQL line comments, one per query line. Blank lines are commented
+// too, and a lone carriage return also starts a new line, as it would end a
+// "--" comment in PostgreSQL.
+func writeSourceQueryComment(w io.Writer, query string) error {
+	query = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(strings.TrimSpace(query))
+
+	var sb strings.Builder
+	sb.WriteString("-- Source query:\n")
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			sb.WriteString("--\n")
+			continue
+		}
+		sb.WriteString("-- ")
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("error writing source query comment: %w", err)
+	}
+	return nil
+}
+
+// xmlComment returns the provenance as XML comment text. "--" is not allowed
+// inside XML comments, so it is broken up.
+func xmlComment(options ExportOptions) string {
+	text := " " + strings.Join(newGeneratedInfo(options).lines(), " | ") + " "
+	for strings.Contains(text, "--") {
+		text = strings.ReplaceAll(text, "--", "- -")
+	}
+	return text
+}
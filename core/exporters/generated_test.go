@@ -0,0 +1,334 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/internal/version"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func TestXMLCommentEscapesDoubleDash(t *testing.T) {
+	comment := xmlComment(ExportOptions{SourceQuery: "SELECT 1 -- note\n---x"})
+
+	if strings.Contains(comment, "--") {
+		t.Errorf("xmlComment() = %q, must not contain \"--\"", comment)
+	}
+	if !strings.Contains(comment, "Query: SELECT 1 - - note - - -x") {
+		t.Errorf("xmlComment() = %q, want collapsed query", comment)
+	}
+}
+
+func TestExportGeneratedComment(t *testing.T) {
+	const query = "SELECT id\n  FROM users"
+	wantQuery := "Query: SELECT id FROM users"
+	wantHeader := "Generated by pgxport " + version.AppVersion + " at "
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, content string)
+	}{
+		{
+			format: FormatCSV,
+			check: func(t *testing.T, content string) {
+				lines := strings.Split(content, "\n")
+				if !strings.HasPrefix(lines[0], "# "+wantHeader) || lines[1] != "# "+wantQuery {
+					t.Errorf("CSV should start with comment lines, got:\n%s", content)
+				}
+
+				reader := csv.NewReader(strings.NewReader(content))
+				reader.Comment = '#'
+				records, err := reader.ReadAll()
+				if err != nil {
+					t.Fatalf("CSV with comments should parse: %v", err)
+				}
+				if len(records) != 2 || records[0][0] != "id" || records[1][0] != "1" {
+					t.Errorf("unexpected records: %v", records)
+				}
+			},
+		},
+		{
+			format: FormatSQL,
+			check: func(t *testing.T, content string) {
+				lines := strings.Split(content, "\n")
+				if !strings.HasPrefix(lines[0], "-- "+wantHeader) || lines[1] != "-- "+wantQuery {
+					t.Errorf("SQL should start with comment lines, got:\n%s", content)
+				}
+				if !strings.Contains(content, `INSERT INTO "users" ("id") VALUES`) {
+					t.Errorf("SQL should still contain the INSERT, got:\n%s", content)
+				}
+			},
+		},
+		{
+			format: FormatYAML,
+			check: func(t *testing.T, content string) {
+				if !strings.HasPrefix(content, "# "+wantHeader) {
+					t.Errorf("YAML should start with a comment, got:\n%s", content)
+				}
+
+				var parsed []map[string]any
+				if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("YAML with comments should parse: %v", err)
+				}
+				if len(parsed) != 1 || parsed[0]["id"] != 1 {
+					t.Errorf("unexpected YAML rows: %v", parsed)
+				}
+			},
+		},
+		{
+			format: FormatXML,
+			check: func(t *testing.T, content string) {
+				lines := strings.Split(content, "\n")
+				if len(lines) < 2 || !strings.HasPrefix(lines[1], "<!-- "+wantHeader) ||
+					!strings.Contains(lines[1], wantQuery+" -->") {
+					t.Errorf("XML should have the comment right after the header, got:\n%s", content)
+				}
+			},
+		},
+		{
+			format: FormatJSON,
+			check: func(t *testing.T, content string) {
+				var parsed []map[string]any
+				if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+					t.Fatalf("JSON with _meta should parse: %v", err)
+				}
+				if len(parsed) != 2 {
+					t.Fatalf("got %d JSON elements, want _meta + 1 row", len(parsed))
+				}
+
+				meta, ok := parsed[0]["_meta"].(map[string]any)
+				if !ok {
+					t.Fatalf("first element should be a _meta object, got %v", parsed[0])
+				}
+				if meta["generator"] != "pgxport" || meta["version"] != version.AppVersion ||
+					meta["query"] != "SELECT id FROM users" || meta["generated_at"] == "" {
+					t.Errorf("unexpected _meta: %v", meta)
+				}
+				if parsed[1]["id"] != float64(1) {
+					t.Errorf("unexpected row: %v", parsed[1])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			rows := newFakeRows(
+				[]string{"id"},
+				[]uint32{pgtype.Int4OID},
+				[][]any{{int32(1)}},
+			)
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			options := ExportOptions{
+				Format:           tt.format,
+				Delimiter:        ',',
+				Compression:      "none",
+				OutputPath:       outputPath,
+				TableName:        "users",
+				RowPerStatement:  1,
+				XmlRootElement:   "results",
+				XmlRowElement:    "row",
+				GeneratedComment: true,
+				SourceQuery:      query,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			tt.check(t, string(content))
+		})
+	}
+}
+
+func TestExportGeneratedCommentJSONNoRows(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, nil)
+
+	exporter, err := Get(FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to get json exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:           FormatJSON,
+		Compression:      "none",
+		OutputPath:       outputPath,
+		GeneratedComment: true,
+		SourceQuery:      "SELECT id FROM users WHERE false",
+	}
+
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	var parsed []map[string]any
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("JSON should parse: %v\n%s", err, content)
+	}
+	if len(parsed) != 1 || parsed[0]["_meta"] == nil {
+		t.Errorf("expected only the _meta element, got %v", parsed)
+	}
+}
+
+func TestExportGeneratedCommentXLSX(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}})
+
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:           FormatXLSX,
+		Compression:      "none",
+		OutputPath:       outputPath,
+		GeneratedComment: true,
+		SourceQuery:      "SELECT id FROM users",
+	}
+
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX file: %v", err)
+	}
+	defer f.Close()
+
+	props, err := f.GetDocProps()
+	if err != nil {
+		t.Fatalf("Failed to read document properties: %v", err)
+	}
+	if props.Creator != "pgxport "+version.AppVersion {
+		t.Errorf("Creator = %q, want pgxport %s", props.Creator, version.AppVersion)
+	}
+	if !strings.Contains(props.Description, "Query: SELECT id FROM users") {
+		t.Errorf("Description = %q, want the source query", props.Description)
+	}
+}
+
+func TestExportSQLSourceComment(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		generated bool
+		expected  string
+	}{
+		{
+			name:  "multi-line query",
+			query: "SELECT id, name\n  FROM users -- active only\n\n  WHERE active\n",
+			expected: "-- Source query:\n-- SELECT id, name\n--   FROM users -- active only\n--\n--   WHERE active\n" +
+				"INSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:  "carriage returns start new comment lines",
+			query: "SELECT id, name\r\nFROM users\rWHERE true",
+			expected: "-- Source query:\n-- SELECT id, name\n-- FROM users\n-- WHERE true\n" +
+				"INSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:     "empty query writes no block",
+			query:    "  ",
+			expected: "INSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.sql")
+			rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, [][]any{{int32(1), "alice"}})
+
+			exporter, err := Get(FormatSQL)
+			if err != nil {
+				t.Fatalf("Failed to get sql exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:           FormatSQL,
+				TableName:        "users",
+				Compression:      "none",
+				RowPerStatement:  1,
+				OutputPath:       outputPath,
+				SourceQuery:      tt.query,
+				SqlSourceComment: true,
+			}
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output =\n%s\nwant\n%s", content, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportSQLSourceCommentAfterGeneratedComment(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.sql")
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}, {int32(2)}})
+
+	exporter, err := Get(FormatSQL)
+	if err != nil {
+		t.Fatalf("Failed to get sql exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:           FormatSQL,
+		TableName:        "users",
+		Compression:      "none",
+		RowPerStatement:  1,
+		OutputPath:       outputPath,
+		SourceQuery:      "SELECT id\nFROM users",
+		GeneratedComment: true,
+		SqlSourceComment: true,
+	}
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if !strings.HasPrefix(lines[0], "-- Generated by pgxport ") {
+		t.Errorf("the generated comment should come first, got:\n%s", content)
+	}
+
+	block := strings.Index(string(content), "-- Source query:\n-- SELECT id\n-- FROM users\n")
+	firstInsert := strings.Index(string(content), "INSERT INTO")
+	if block < 0 || firstInsert < 0 || block > firstInsert {
+		t.Errorf("the source query block should precede the first INSERT, got:\n%s", content)
+	}
+	for _, line := range lines[:len(lines)-1] {
+		if !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "INSERT INTO") && !strings.HasPrefix(line, "\t(") {
+			t.Errorf("unexpected line %q in:\n%s", line, content)
+		}
+	}
+	if got := strings.Count(string(content), "INSERT INTO"); got != 2 {
+		t.Errorf("expected 2 INSERT statements, got %d", got)
+	}
+}
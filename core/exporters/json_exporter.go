@@ -1,11 +1,15 @@
 package exporters
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/elliotchance/orderedmap/v3"
 	"github.com/fbz-tec/pgxport/core/encoders"
+	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/core/output"
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/fbz-tec/pgxport/internal/ui"
@@ -14,32 +18,87 @@ import (
 
 type jsonExporter struct{}
 
-// Export writes query results to a JSON file with buffered I/O.
-func (e *jsonExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
+// Export writes query results to a JSON file with buffered I/O: an array of
+// row objects or, with JsonKeyBy, an object of row objects keyed by the text
+// of that column. Keyed rows are still streamed; only the keys seen so far
+// are kept, to reject duplicates. Rows are indented unless Compact is set,
+// which writes each row on a single line.
+func (e *jsonExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 	start := time.Now()
-	logger.Debug("Preparing JSON export (indent=2 spaces, compression=%s)", options.Compression)
+	logger.Debug("Preparing JSON export (compact=%v, compression=%s)", options.Compact, options.Compression)
+
+	rows, err = flattenJSONRows(rows, options, true)
+	if err != nil {
+		return 0, err
+	}
+
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, true)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := HeaderNames(columns, options, true)
+	if err != nil {
+		return 0, err
+	}
+
+	keyIndex := -1
+	if options.JsonKeyBy != "" {
+		for i, c := range columns {
+			if c == options.JsonKeyBy {
+				keyIndex = i
+				break
+			}
+		}
+		if keyIndex < 0 {
+			return 0, fmt.Errorf("--json-key-by column %q not found in query results", options.JsonKeyBy)
+		}
+		logger.Debug("Keying JSON rows by column %q", options.JsonKeyBy)
+	}
+	keyed := keyIndex >= 0
+	docStart, docEnd := "[\n", "\n]\n"
+	if keyed {
+		docStart, docEnd = "{\n", "\n}\n"
+	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
 		Path:        options.OutputPath,
 		Compression: options.Compression,
 		Format:      options.Format,
+		Atomic:      options.Atomic,
+		Writer:      options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
 	// Write opening bracket
-	if _, err := writerCloser.Write([]byte("[\n")); err != nil {
-		return 0, fmt.Errorf("error writing start of JSON array: %w", err)
+	if _, err := writerCloser.Write([]byte(docStart)); err != nil {
+		return 0, fmt.Errorf("error writing start of JSON document: %w", err)
+	}
+
+	// Keys already written, including "_meta" when present
+	seen := make(map[string]bool)
+
+	if options.GeneratedComment {
+		if err := writeJSONMeta(writerCloser, options, keyed); err != nil {
+			return 0, err
+		}
+		seen[jsonMetaKey] = true
 	}
 
 	// Get column descriptions
 	fields := rows.FieldDescriptions()
 
 	// Create ordered JSON encoder
-	orderedEncoder := encoders.NewOrderedJsonEncoder(options.TimeFormat, options.TimeZone)
+	orderedEncoder := encoders.NewOrderedJsonEncoder(options.TimeFormat, options.TimeZone, options.JsonNumbers, options.JsonSpecialFloats).
+		WithTruncation(options.MaxFieldLength, options.TruncateMarker).
+		WithCompact(options.Compact).
+		WithFloatFormat(options.JsonFloatFormat)
+
+	// Columns are the same on every row, so one map is refilled in place
+	rowData := orderedmap.NewOrderedMap[string, encoders.DataParams]()
 
 	rowCount := 0
 	logger.Debug("Starting to write JSON objects...")
@@ -51,45 +110,69 @@ func (e *jsonExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp.Start()
 	}
 
+	failed := 0 // rows left out by --on-error skip
+	reader := newRowReader(rows)
 	for rows.Next() {
-		values, err := rows.Values()
-		if err != nil {
-			return rowCount, fmt.Errorf("error reading row: %w", err)
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
 		}
 
-		// Write comma separator for subsequent entries
-		if rowCount > 0 {
-			if _, err := writerCloser.Write([]byte(",\n")); err != nil {
-				return rowCount, fmt.Errorf("error writing comma for row %d: %w", rowCount, err)
-			}
+		values, err := reader.Values()
+		if err != nil {
+			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
-
-		rowData := orderedmap.NewOrderedMap[string, encoders.DataParams]()
+		options.trimValues(values, fields)
 
 		for i, fd := range fields {
-			rowData.Set(fd.Name, encoders.DataParams{
+			rowData.Set(keys[i], encoders.DataParams{
 				Value:     values[i],
 				ValueType: fd.DataTypeOID,
 			})
 		}
-		// Encode with preserved order
-		jsonBytes, err := orderedEncoder.EncodeRow(rowData)
-		if err != nil {
-			return rowCount, fmt.Errorf("error encoding JSON for row %d: %w", rowCount, err)
+
+		// With --on-error skip the row is encoded before anything is written,
+		// so a failing row leaves no partial object behind
+		var encoded []byte
+		if options.OnError == OnErrorSkip {
+			skipped, _ := options.formatRow(rowCount+failed+1, func() error {
+				encoded, err = orderedEncoder.EncodeRow(rowData)
+				return err
+			})
+			if skipped {
+				failed++
+				continue
+			}
+		}
+
+		// Write comma separator for subsequent entries (the _meta object counts as one)
+		if rowCount > 0 || options.GeneratedComment {
+			if _, err := writerCloser.Write([]byte(",\n")); err != nil {
+				return rowCount, fmt.Errorf("error writing comma for row %d: %w", rowCount, err)
+			}
 		}
 
 		// Write with indentation
-		if _, err := writerCloser.Write([]byte("  ")); err != nil {
-			return rowCount, fmt.Errorf("error writing indentation for row %d: %w", rowCount, err)
+		if !options.Compact {
+			if _, err := writerCloser.Write([]byte("  ")); err != nil {
+				return rowCount, fmt.Errorf("error writing indentation for row %d: %w", rowCount, err)
+			}
+		}
+		if keyed {
+			if err := writeJSONKey(writerCloser, values[keyIndex], fields[keyIndex].DataTypeOID, options, seen); err != nil {
+				return rowCount, fmt.Errorf("row %d: %w", rowCount+1, err)
+			}
 		}
-		if _, err := writerCloser.Write(jsonBytes); err != nil {
+		// Encode with preserved order
+		if encoded != nil {
+			if _, err := writerCloser.Write(encoded); err != nil {
+				return rowCount, fmt.Errorf("error writing JSON object for row %d: %w", rowCount, err)
+			}
+		} else if err := orderedEncoder.WriteRow(writerCloser, rowData); err != nil {
 			return rowCount, fmt.Errorf("error writing JSON object for row %d: %w", rowCount, err)
 		}
 
 		rowCount++
-		sp.Update(fmt.Sprintf("Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
 
 		if rowCount%10000 == 0 {
 			logger.Debug("%d JSON objects written...", rowCount)
@@ -99,18 +182,99 @@ func (e *jsonExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
+	options.logSkippedRows(failed)
 
 	// Write closing bracket
-	if _, err := writerCloser.Write([]byte("\n]\n")); err != nil {
-		return rowCount, fmt.Errorf("error writing end of JSON array: %w", err)
+	if _, err := writerCloser.Write([]byte(docEnd)); err != nil {
+		return rowCount, fmt.Errorf("error writing end of JSON document: %w", err)
+	}
+
+	if options.EmitSchema {
+		if err := writeSchema(keys, fields, options); err != nil {
+			return rowCount, err
+		}
 	}
 	sp.Stop("Completed!")
 
 	logger.Debug("JSON export completed successfully: %d rows written in %v", rowCount, time.Since(start))
 
+	output.Commit(writerCloser)
 	return rowCount, nil
 }
 
+// jsonMetaKey holds the provenance written with GeneratedComment.
+const jsonMetaKey = "_meta"
+
+// writeJSONMeta writes the provenance as the first array element:
+// {"_meta": {"generator": "pgxport", "version": ..., "generated_at": ..., "query": ...}}
+// or, when keyed, as the first "_meta" member of the object.
+func writeJSONMeta(w io.Writer, options ExportOptions, keyed bool) error {
+	info := newGeneratedInfo(options)
+	fields := map[string]string{
+		"generator":    "pgxport",
+		"version":      info.Version,
+		"generated_at": info.GeneratedAt,
+		"query":        info.Query,
+	}
+	var meta any = map[string]map[string]string{jsonMetaKey: fields}
+	indent, colon := "  ", ": "
+	if options.Compact {
+		indent, colon = "", ":"
+	}
+	prefix := indent
+	if keyed {
+		meta = fields
+		prefix = indent + `"` + jsonMetaKey + `"` + colon
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if !options.Compact {
+		enc.SetIndent("  ", "  ")
+	}
+	if err := enc.Encode(meta); err != nil {
+		return fmt.Errorf("error encoding JSON metadata: %w", err)
+	}
+
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return fmt.Errorf("error writing JSON metadata: %w", err)
+	}
+	if _, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); err != nil {
+		return fmt.Errorf("error writing JSON metadata: %w", err)
+	}
+	return nil
+}
+
+// writeJSONKey writes `"<key>": ` for a keyed row, without the space when
+// compact. The key is the value's text as in CSV output; NULL and keys
+// already in seen are rejected.
+func writeJSONKey(w io.Writer, value any, oid uint32, options ExportOptions, seen map[string]bool) error {
+	if value == nil {
+		return fmt.Errorf("--json-key-by column %q is NULL", options.JsonKeyBy)
+	}
+	key := formatters.FormatCSVValue(value, oid, options.TimeFormat, options.TimeZone)
+	if seen[key] {
+		return fmt.Errorf("duplicate --json-key-by value %q in column %q", key, options.JsonKeyBy)
+	}
+	seen[key] = true
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(key); err != nil {
+		return fmt.Errorf("error encoding JSON key: %w", err)
+	}
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline
+	if options.Compact {
+		buf.WriteByte(':')
+	} else {
+		buf.WriteString(": ")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
 func init() {
 	MustRegister(FormatJSON, func() Exporter { return &jsonExporter{} })
 }
@@ -1,15 +1,20 @@
 package exporters
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportJSON(t *testing.T) {
@@ -569,3 +574,397 @@ func BenchmarkExportJSON(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+// BenchmarkExportJSONRows measures a 100k-row export without a database.
+// Run with -benchmem to compare allocations per export.
+func BenchmarkExportJSONRows(b *testing.B) {
+	names := []string{"id", "name", "email", "score", "active", "created_at", "payload"}
+	oids := []uint32{pgtype.Int8OID, pgtype.TextOID, pgtype.TextOID, pgtype.Float8OID,
+		pgtype.BoolOID, pgtype.TimestampOID, pgtype.JSONBOID}
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	data := make([][]any, 100000)
+	for i := range data {
+		data[i] = []any{int64(i), "Jane Doe", "jane@example.com", float64(i) / 3, i%2 == 0, created,
+			map[string]any{"plan": "pro"}}
+	}
+
+	exporter, err := Get(FormatJSON)
+	if err != nil {
+		b.Fatalf("Failed to get json exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:      FormatJSON,
+		Compression: "none",
+		TimeFormat:  "yyyy-MM-dd HH:mm:ss",
+		OutputPath:  filepath.Join(b.TempDir(), "bench.json"),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+			b.Fatalf("Export() error = %v", err)
+		}
+	}
+}
+
+func TestWriteJSONNumbers(t *testing.T) {
+	var bigNumeric pgtype.Numeric
+	if err := bigNumeric.Scan("12345678901234567890.123456789012345678"); err != nil {
+		t.Fatalf("Failed to build numeric: %v", err)
+	}
+	var scaledNumeric pgtype.Numeric
+	if err := scaledNumeric.Scan("12.50"); err != nil {
+		t.Fatalf("Failed to build numeric: %v", err)
+	}
+
+	names := []string{"amount", "price", "big_id", "payload", "ratio", "fee"}
+	oids := []uint32{pgtype.NumericOID, pgtype.NumericOID, pgtype.Int8OID, pgtype.ByteaOID, pgtype.Float8OID, formatters.MoneyOID}
+	data := [][]any{{bigNumeric, scaledNumeric, int64(9007199254740993), []byte{0xde, 0xad, 0x00}, 0.5, "-$1,234.50"}}
+
+	tests := []struct {
+		name     string
+		mode     string
+		expected []string
+	}{
+		{
+			name: "number mode keeps exact digits",
+			mode: "number",
+			expected: []string{
+				`"amount": 12345678901234567890.123456789012345678`,
+				`"price": 12.50`,
+				`"big_id": 9007199254740993`,
+				`"payload": "\\xdead00"`,
+				`"ratio": 0.5`,
+				`"fee": -1234.50`,
+			},
+		},
+		{
+			name: "default mode is number",
+			mode: "",
+			expected: []string{
+				`"amount": 12345678901234567890.123456789012345678`,
+				`"big_id": 9007199254740993`,
+			},
+		},
+		{
+			name: "string mode",
+			mode: "string",
+			expected: []string{
+				`"amount": "12345678901234567890.123456789012345678"`,
+				`"price": "12.50"`,
+				`"big_id": "9007199254740993"`,
+				`"payload": "\\xdead00"`,
+				`"ratio": 0.5`,
+				`"fee": "-1234.50"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.json")
+			rows := newFakeRows(names, oids, data)
+
+			exporter, err := Get(FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get json exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:      FormatJSON,
+				Compression: "none",
+				OutputPath:  outputPath,
+				JsonNumbers: tt.mode,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			var parsed []map[string]any
+			if err := json.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+			}
+
+			for _, want := range tt.expected {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %s, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJSONFloatFormat(t *testing.T) {
+	names := []string{"tiny", "small", "huge", "big", "single", "plain"}
+	oids := []uint32{pgtype.Float8OID, pgtype.Float8OID, pgtype.Float8OID, pgtype.Float8OID, pgtype.Float4OID, pgtype.Float8OID}
+	data := [][]any{{1e-7, 1.5e-10, 1e21, 1.2345678901234567e38, float32(2.5e-8), 0.1}}
+
+	tests := []struct {
+		name     string
+		mode     string
+		expected []string
+	}{
+		{
+			name: "auto mode keeps exponents",
+			mode: "auto",
+			expected: []string{
+				`"tiny": 1e-7`,
+				`"huge": 1e+21`,
+				`"plain": 0.1`,
+			},
+		},
+		{
+			name: "decimal mode",
+			mode: "decimal",
+			expected: []string{
+				`"tiny": 0.0000001`,
+				`"small": 0.00000000015`,
+				`"huge": 1000000000000000000000`,
+				`"big": 123456789012345670000000000000000000000`,
+				`"single": 0.000000025`,
+				`"plain": 0.1`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.json")
+
+			exporter, err := Get(FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get json exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatJSON,
+				Compression:     "none",
+				OutputPath:      outputPath,
+				JsonFloatFormat: tt.mode,
+			}
+			if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("output should contain %s, got:\n%s", want, content)
+				}
+			}
+
+			// Every value must parse back to the exported float
+			var parsed []map[string]json.Number
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.UseNumber()
+			if err := decoder.Decode(&parsed); err != nil {
+				t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+			}
+			for i, name := range names {
+				text := parsed[0][name].String()
+				if tt.mode == "decimal" && strings.ContainsAny(text, "eE") {
+					t.Errorf("%s = %s, want no exponent", name, text)
+				}
+				bitSize := 64
+				if oids[i] == pgtype.Float4OID {
+					bitSize = 32
+				}
+				got, err := strconv.ParseFloat(text, bitSize)
+				if err != nil {
+					t.Fatalf("%s = %s is not a number: %v", name, text, err)
+				}
+				want := data[0][i]
+				if f, ok := want.(float32); ok {
+					if float32(got) != f {
+						t.Errorf("%s = %s, does not round-trip to %v", name, text, f)
+					}
+				} else if got != want.(float64) {
+					t.Errorf("%s = %s, does not round-trip to %v", name, text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJSONKeysAndReuse(t *testing.T) {
+	names := []string{"id", `say "hi"`, "tab\there", "ctrl\x01", "<b>&</b>"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID, pgtype.TextOID, pgtype.JSONBOID}
+	data := [][]any{
+		{int32(1), "a", "b", "c", map[string]any{"k": "<v>"}},
+		{int32(2), "d", nil, "f", map[string]any{"k": "w"}},
+	}
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+
+	exporter, err := Get(FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to get json exporter: %v", err)
+	}
+	if _, err := exporter.Export(newFakeRows(names, oids, data), ExportOptions{
+		Format:      FormatJSON,
+		Compression: "none",
+		OutputPath:  outputPath,
+	}); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var parsed []map[string]any
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+	}
+
+	want := []map[string]any{
+		{"id": float64(1), `say "hi"`: "a", "tab\there": "b", "ctrl\x01": "c", "<b>&</b>": map[string]any{"k": "<v>"}},
+		{"id": float64(2), `say "hi"`: "d", "tab\there": nil, "ctrl\x01": "f", "<b>&</b>": map[string]any{"k": "w"}},
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("parsed = %v, want %v", parsed, want)
+	}
+	if !strings.Contains(string(content), `"<b>&</b>": {`) {
+		t.Errorf("keys should not be HTML-escaped:\n%s", content)
+	}
+}
+
+func TestWriteJSONKeyBy(t *testing.T) {
+	names := []string{"id", "code", "name"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID}
+
+	tests := []struct {
+		name        string
+		keyBy       string
+		data        [][]any
+		generated   bool
+		want        string
+		errContains string
+	}{
+		{
+			name:  "keyed by integer",
+			keyBy: "id",
+			data:  [][]any{{int32(1), "fr", "France"}, {int32(2), "de", "Germany"}},
+			want: "{\n" +
+				"  \"1\": {\n    \"id\": 1,\n    \"code\": \"fr\",\n    \"name\": \"France\"\n  },\n" +
+				"  \"2\": {\n    \"id\": 2,\n    \"code\": \"de\",\n    \"name\": \"Germany\"\n  }\n" +
+				"}\n",
+		},
+		{
+			name:  "text keys are escaped but not HTML-escaped",
+			keyBy: "code",
+			data:  [][]any{{int32(1), `a"<b>`, "x"}},
+			want:  "{\n  \"a\\\"<b>\": {\n    \"id\": 1,\n    \"code\": \"a\\\"<b>\",\n    \"name\": \"x\"\n  }\n}\n",
+		},
+		{
+			name:  "no rows",
+			keyBy: "id",
+			want:  "{\n\n}\n",
+		},
+		{
+			name:        "duplicate key",
+			keyBy:       "code",
+			data:        [][]any{{int32(1), "fr", "France"}, {int32(2), "fr", "French Guiana"}},
+			errContains: `duplicate --json-key-by value "fr"`,
+		},
+		{
+			name:        "NULL key",
+			keyBy:       "code",
+			data:        [][]any{{int32(1), nil, "Nowhere"}},
+			errContains: "is NULL",
+		},
+		{
+			name:        "unknown column",
+			keyBy:       "missing",
+			data:        [][]any{{int32(1), "fr", "France"}},
+			errContains: `column "missing" not found`,
+		},
+		{
+			name:        "metadata key cannot be reused",
+			keyBy:       "code",
+			generated:   true,
+			data:        [][]any{{int32(1), "_meta", "x"}},
+			errContains: `duplicate --json-key-by value "_meta"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.json")
+			exporter, err := Get(FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get json exporter: %v", err)
+			}
+			_, err = exporter.Export(newFakeRows(names, oids, tt.data), ExportOptions{
+				Format:           FormatJSON,
+				Compression:      "none",
+				OutputPath:       outputPath,
+				JsonKeyBy:        tt.keyBy,
+				GeneratedComment: tt.generated,
+			})
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Export() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output =\n%s\nwant:\n%s", content, tt.want)
+			}
+			var parsed map[string]map[string]any
+			if err := json.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+			}
+		})
+	}
+}
+
+func TestWriteJSONKeyByWithMeta(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+	exporter, err := Get(FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to get json exporter: %v", err)
+	}
+	_, err = exporter.Export(newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(7)}}), ExportOptions{
+		Format:           FormatJSON,
+		Compression:      "none",
+		OutputPath:       outputPath,
+		JsonKeyBy:        "id",
+		GeneratedComment: true,
+		SourceQuery:      "SELECT 7 AS id",
+	})
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var parsed map[string]map[string]any
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+	}
+	if parsed["_meta"]["query"] != "SELECT 7 AS id" || parsed["7"]["id"] != float64(7) {
+		t.Errorf("parsed = %v, want _meta and row 7", parsed)
+	}
+	if !strings.HasPrefix(string(content), "{\n  \"_meta\": {\n    \"") {
+		t.Errorf("_meta should be the first member:\n%s", content)
+	}
+}
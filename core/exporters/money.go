@@ -0,0 +1,60 @@
+package exporters
+
+import (
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5"
+)
+
+// exactMoneyRows rewrites money values, printed by PostgreSQL with the
+// separators and currency symbol of lc_monetary, as plain decimals. The
+// number of fraction digits is read once from the session, so exporters no
+// longer have to guess which separator is the decimal point.
+type exactMoneyRows struct {
+	pgx.Rows
+	fracDigits int
+	columns    []int // positions of the money columns
+}
+
+// ExactMoney wraps rows to convert the values of their money columns to plain
+// decimals with fracDigits fraction digits, the frac_digits of the session's
+// lc_monetary (see db.PgStore.MoneyFractionDigits). rows is returned
+// unchanged when it has no money column or when fracDigits is negative,
+// meaning unknown.
+func ExactMoney(rows pgx.Rows, fracDigits int) pgx.Rows {
+	if fracDigits < 0 {
+		return rows
+	}
+	var columns []int
+	for i, fd := range rows.FieldDescriptions() {
+		if fd.DataTypeOID == formatters.MoneyOID {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) == 0 {
+		return rows
+	}
+	return &exactMoneyRows{Rows: rows, fracDigits: fracDigits, columns: columns}
+}
+
+// Scan is not supported: exactMoneyRows is always read with Values.
+func (r *exactMoneyRows) Scan(dest ...any) error {
+	return fmt.Errorf("money rows are read with Values")
+}
+
+// Values returns the current row with its money values as plain decimals.
+func (r *exactMoneyRows) Values() ([]any, error) {
+	values, err := r.Rows.Values()
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range r.columns {
+		if s, ok := values[i].(string); ok {
+			if text, ok := formatters.MoneyTextDigits(s, r.fracDigits); ok {
+				values[i] = text
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,115 @@
+package exporters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestExactMoney(t *testing.T) {
+	names := []string{"id", "price"}
+	oids := []uint32{pgtype.Int4OID, formatters.MoneyOID}
+
+	tests := []struct {
+		name       string
+		fracDigits int
+		data       [][]any
+		want       []any
+	}{
+		{"two fraction digits", 2, [][]any{{int32(1), "$1,234.56"}}, []any{int32(1), "1234.56"}},
+		{"no fraction digits", 0, [][]any{{int32(1), "￥1,235"}}, []any{int32(1), "1235"}},
+		{"three fraction digits", 3, [][]any{{int32(1), "KD 1,235"}}, []any{int32(1), "1.235"}},
+		{"null", 2, [][]any{{int32(1), nil}}, []any{int32(1), nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := ExactMoney(newFakeRows(names, oids, tt.data), tt.fracDigits)
+			if !rows.Next() {
+				t.Fatal("Next() = false, want a row")
+			}
+			values, err := rows.Values()
+			if err != nil {
+				t.Fatalf("Values() error: %v", err)
+			}
+			for i, v := range values {
+				if v != tt.want[i] {
+					t.Errorf("column %s = %#v, want %#v", names[i], v, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExactMoneyUnchanged(t *testing.T) {
+	noMoney := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, nil)
+	if rows := ExactMoney(noMoney, 2); rows != noMoney {
+		t.Error("ExactMoney() without money columns should return rows unchanged")
+	}
+	unknown := newFakeRows([]string{"price"}, []uint32{formatters.MoneyOID}, nil)
+	if rows := ExactMoney(unknown, -1); rows != unknown {
+		t.Error("ExactMoney() with unknown fraction digits should return rows unchanged")
+	}
+}
+
+func TestExportExactMoney(t *testing.T) {
+	names := []string{"item", "price"}
+	oids := []uint32{pgtype.TextOID, formatters.MoneyOID}
+	data := [][]any{{"dinar", "KD 1,235"}, {"refund", "-KD 0,500"}}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "csv",
+			format:   FormatCSV,
+			expected: "item,price\ndinar,1.235\nrefund,-0.500\n",
+		},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			expected: "[\n{\"item\":\"dinar\",\"price\":1.235},\n{\"item\":\"refund\",\"price\":-0.500}\n]\n",
+		},
+		{
+			name:     "sql",
+			format:   FormatSQL,
+			expected: "INSERT INTO \"prices\" (\"item\", \"price\") VALUES\n\t('dinar', '1.235'::numeric::money);\nINSERT INTO \"prices\" (\"item\", \"price\") VALUES\n\t('refund', '-0.500'::numeric::money);\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			options := ExportOptions{
+				Format:          tt.format,
+				Compression:     "none",
+				OutputPath:      outputPath,
+				Delimiter:       ',',
+				TableName:       "prices",
+				RowPerStatement: 1,
+				Compact:         true,
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get %s exporter: %v", tt.format, err)
+			}
+			if _, err := exporter.Export(ExactMoney(newFakeRows(names, oids, data), 3), options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			got, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("output = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
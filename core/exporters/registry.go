@@ -7,14 +7,25 @@ import (
 )
 
 // Factory is a function type that creates a new Exporter instance.
+// It is called once per export, so exporters may keep per-export state.
 type Factory func() Exporter
 
 var registry = map[string]Factory{}
 
 // Register registers a new exporter format with its factory function.
-// Returns an error if the format is already registered.
+// Format names are case-insensitive. Returns an error if the name is empty,
+// the factory is nil or the format is already registered.
+//
+// Custom formats are usually registered from an init function, so importing
+// the package is enough to make the format available to --format.
 func Register(format string, factory Factory) error {
-	format = strings.ToLower(strings.TrimSpace(format))
+	format = normalizeFormat(format)
+	if format == "" {
+		return fmt.Errorf("exporter: format name cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("exporter: format %q has a nil factory", format)
+	}
 	if _, exists := registry[format]; exists {
 		return fmt.Errorf("exporter: format %q already registered", format)
 	}
@@ -25,7 +36,7 @@ func Register(format string, factory Factory) error {
 // Get retrieves an exporter instance for the specified format.
 // Returns an error if the format is not registered.
 func Get(format string) (Exporter, error) {
-	factory, ok := registry[format]
+	factory, ok := registry[normalizeFormat(format)]
 	if !ok {
 		return nil, fmt.Errorf("unsupported format: %q (available: %s)",
 			format, strings.Join(List(), ", "))
@@ -50,3 +61,8 @@ func MustRegister(format string, factory Factory) {
 		panic(err)
 	}
 }
+
+// normalizeFormat returns the registry key of a format name.
+func normalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimSpace(format))
+}
@@ -0,0 +1,114 @@
+package exporters
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRegistryBuiltinFormats(t *testing.T) {
+	builtin := []string{
+		FormatCSV,
+		FormatJSON,
+		FormatSQL,
+		FormatTemplate,
+		FormatTSV,
+		FormatXLSX,
+		FormatXML,
+		FormatYAML,
+	}
+
+	formats := List()
+
+	if !slices.IsSorted(formats) {
+		t.Errorf("List() should return formats in alphabetical order, got %v", formats)
+	}
+
+	for _, f := range builtin {
+		if !slices.Contains(formats, f) {
+			t.Errorf("List() missing built-in format %q", f)
+		}
+
+		exp, err := Get(f)
+		if err != nil {
+			t.Errorf("Get(%q) unexpected error: %v", f, err)
+			continue
+		}
+		if exp == nil {
+			t.Errorf("Get(%q) returned nil exporter", f)
+		}
+	}
+}
+
+func TestRegistryGetUnknownFormat(t *testing.T) {
+	_, err := Get("bogus")
+	if err == nil {
+		t.Fatal("Get() expected error for unknown format, got nil")
+	}
+
+	for _, f := range List() {
+		if !strings.Contains(err.Error(), f) {
+			t.Errorf("Get() error %q should list available format %q", err.Error(), f)
+		}
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	const name = "registry-test"
+	defer delete(registry, name)
+
+	factory := func() Exporter { return &csvExporter{} }
+
+	if err := Register("  REGISTRY-TEST ", factory); err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+
+	if !slices.Contains(List(), name) {
+		t.Errorf("List() should contain newly registered format %q", name)
+	}
+
+	if _, err := Get(name); err != nil {
+		t.Errorf("Get(%q) unexpected error: %v", name, err)
+	}
+
+	if _, err := Get(" Registry-Test"); err != nil {
+		t.Errorf("Get() should ignore case and surrounding spaces, got %v", err)
+	}
+
+	if err := Register(name, factory); err == nil {
+		t.Error("Register() expected error for duplicate format, got nil")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustRegister() expected panic for duplicate format")
+		}
+	}()
+	MustRegister(name, factory)
+}
+
+func TestRegistryRegisterInvalid(t *testing.T) {
+	factory := func() Exporter { return &csvExporter{} }
+
+	tests := []struct {
+		name        string
+		format      string
+		factory     Factory
+		errContains string
+	}{
+		{name: "empty name", format: "  ", factory: factory, errContains: "cannot be empty"},
+		{name: "nil factory", format: "registry-nil", factory: nil, errContains: "nil factory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.format, tt.factory)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Fatalf("Register() error = %v, want it to contain %q", err, tt.errContains)
+			}
+			if slices.Contains(List(), strings.TrimSpace(tt.format)) {
+				t.Errorf("List() should not contain rejected format %q", tt.format)
+			}
+		})
+	}
+}
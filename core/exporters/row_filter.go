@@ -0,0 +1,58 @@
+package exporters
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RowFilter skips the rows whose formatted value of Column equals Value, or
+// differs from it when Negate is set. It is a client-side convenience for
+// CSV; a WHERE clause in the query is always cheaper.
+type RowFilter struct {
+	Column string
+	Value  string
+	Negate bool // column!=value: skip the rows whose value differs
+}
+
+// ParseRowFilter parses a "column==value" or "column!=value" expression.
+// The column name is trimmed; the value is compared as written, so
+// "status==" matches empty strings and NULL.
+func ParseRowFilter(expr string) (*RowFilter, error) {
+	column, value, found := strings.Cut(expr, "==")
+	negate := false
+	if !found {
+		if column, value, found = strings.Cut(expr, "!="); !found {
+			return nil, fmt.Errorf("expected column==value or column!=value, got %q", expr)
+		}
+		negate = true
+	}
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return nil, fmt.Errorf("missing column name in %q", expr)
+	}
+	return &RowFilter{Column: column, Value: value, Negate: negate}, nil
+}
+
+// String returns the filter as an expression.
+func (f *RowFilter) String() string {
+	if f.Negate {
+		return f.Column + "!=" + f.Value
+	}
+	return f.Column + "==" + f.Value
+}
+
+// columnIndex returns the position of the filtered column in columns.
+func (f *RowFilter) columnIndex(columns []string) (int, error) {
+	index := slices.Index(columns, f.Column)
+	if index < 0 {
+		return 0, fmt.Errorf("--skip-where column %q not found in query results", f.Column)
+	}
+	return index, nil
+}
+
+// skips reports whether the row holding value in the filtered column is left
+// out of the output.
+func (f *RowFilter) skips(value string) bool {
+	return (value == f.Value) != f.Negate
+}
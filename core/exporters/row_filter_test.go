@@ -0,0 +1,70 @@
+package exporters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRowFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		want        *RowFilter
+		errContains string
+	}{
+		{
+			name: "equal",
+			expr: "status==deleted",
+			want: &RowFilter{Column: "status", Value: "deleted"},
+		},
+		{
+			name: "not equal",
+			expr: "status!=active",
+			want: &RowFilter{Column: "status", Value: "active", Negate: true},
+		},
+		{
+			name: "column trimmed, value kept",
+			expr: " status == deleted",
+			want: &RowFilter{Column: "status", Value: " deleted"},
+		},
+		{
+			name: "empty value",
+			expr: "note==",
+			want: &RowFilter{Column: "note", Value: ""},
+		},
+		{
+			name: "value containing an operator",
+			expr: "expr==a!=b",
+			want: &RowFilter{Column: "expr", Value: "a!=b"},
+		},
+		{
+			name:        "single equals",
+			expr:        "status=deleted",
+			errContains: "expected column==value or column!=value",
+		},
+		{
+			name:        "missing column",
+			expr:        "==deleted",
+			errContains: "missing column name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRowFilter(tt.expr)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("ParseRowFilter() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRowFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRowFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package exporters
+
+import (
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// rowReader reads the values of each row into a slice reused across rows.
+//
+// rows.Values allocates a new slice per row and decodes every value through a
+// freshly planned codec. When every column has one of the common types below,
+// rowReader scans the row instead into typed destinations kept for the whole
+// export; pgx plans that scan once. The values keep the Go types rows.Values
+// returns (int32, string, ...), so OID-based formatting is unchanged. Other
+// result sets fall back to rows.Values.
+//
+// The returned slice is only valid until the next call, so rowReader must not
+// be used by exporters that keep rows (SQL batches, XLSX, full templates).
+type rowReader struct {
+	rows   pgx.Rows
+	dests  []any // scan destinations, nil when falling back to rows.Values
+	values []any
+}
+
+// newRowReader returns a reader for rows, using the scan fast path when all
+// columns have a supported type. Flattened, coalesced and exact money rows
+// cannot be scanned and are always read with Values.
+func newRowReader(rows pgx.Rows) *rowReader {
+	switch rows.(type) {
+	case *flattenedRows, *coalescedRows, *exactMoneyRows:
+		return &rowReader{rows: rows}
+	}
+	fields := rows.FieldDescriptions()
+	dests := make([]any, len(fields))
+	for i, fd := range fields {
+		if dests[i] = scanDest(fd.DataTypeOID); dests[i] == nil {
+			logger.Debug("Column %q (OID %d) has no scan fast path, reading rows with Values()", fd.Name, fd.DataTypeOID)
+			return &rowReader{rows: rows}
+		}
+	}
+	return &rowReader{rows: rows, dests: dests, values: make([]any, len(fields))}
+}
+
+// scanDest returns a reusable scan destination for a column type, or nil when
+// the type is read with rows.Values.
+func scanDest(oid uint32) any {
+	switch oid {
+	case pgtype.Int2OID:
+		return &pgtype.Int2{}
+	case pgtype.Int4OID:
+		return &pgtype.Int4{}
+	case pgtype.Int8OID:
+		return &pgtype.Int8{}
+	case pgtype.Float4OID:
+		return &pgtype.Float4{}
+	case pgtype.Float8OID:
+		return &pgtype.Float8{}
+	case pgtype.BoolOID:
+		return &pgtype.Bool{}
+	case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID:
+		return &pgtype.Text{}
+	}
+	return nil
+}
+
+// Values returns the values of the current row.
+func (r *rowReader) Values() ([]any, error) {
+	if r.dests == nil {
+		return r.rows.Values()
+	}
+	if err := r.rows.Scan(r.dests...); err != nil {
+		return nil, err
+	}
+	for i, dest := range r.dests {
+		r.values[i] = destValue(dest)
+	}
+	return r.values, nil
+}
+
+// destValue returns the value of a scan destination as rows.Values would,
+// with nil for NULL.
+func destValue(dest any) any {
+	switch d := dest.(type) {
+	case *pgtype.Int2:
+		if d.Valid {
+			return d.Int16
+		}
+	case *pgtype.Int4:
+		if d.Valid {
+			return d.Int32
+		}
+	case *pgtype.Int8:
+		if d.Valid {
+			return d.Int64
+		}
+	case *pgtype.Float4:
+		if d.Valid {
+			return d.Float32
+		}
+	case *pgtype.Float8:
+		if d.Valid {
+			return d.Float64
+		}
+	case *pgtype.Bool:
+		if d.Valid {
+			return d.Bool
+		}
+	case *pgtype.Text:
+		if d.Valid {
+			return d.String
+		}
+	}
+	return nil
+}
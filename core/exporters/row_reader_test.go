@@ -0,0 +1,119 @@
+package exporters
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRowReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		oids     []uint32
+		data     [][]any
+		wantFast bool
+	}{
+		{
+			name:     "common types",
+			oids:     []uint32{pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.Float4OID, pgtype.Float8OID, pgtype.BoolOID, pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID},
+			data:     [][]any{{int16(-2), int32(40000), int64(1) << 40, float32(1.5), 2.25, true, "héllo", "v", "AB  ", "pg_class"}},
+			wantFast: true,
+		},
+		{
+			name:     "nulls",
+			oids:     []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.BoolOID},
+			data:     [][]any{{nil, nil, nil}, {int32(7), "", false}},
+			wantFast: true,
+		},
+		{
+			name:     "other type falls back to Values",
+			oids:     []uint32{pgtype.Int4OID, pgtype.TimestampOID},
+			data:     [][]any{{int32(1), "2024-01-01"}},
+			wantFast: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make([]string, len(tt.oids))
+			for i := range names {
+				names[i] = "c"
+			}
+			rows := newFakeRows(names, tt.oids, tt.data)
+			reader := newRowReader(rows)
+			if fast := reader.dests != nil; fast != tt.wantFast {
+				t.Fatalf("fast path = %v, want %v", fast, tt.wantFast)
+			}
+
+			for i := 0; rows.Next(); i++ {
+				values, err := reader.Values()
+				if err != nil {
+					t.Fatalf("Values() error = %v", err)
+				}
+				if !reflect.DeepEqual(values, tt.data[i]) {
+					t.Errorf("row %d = %#v, want %#v", i, values, tt.data[i])
+				}
+			}
+		})
+	}
+}
+
+// decodedRow keeps benchmark results alive so the compiler cannot drop them.
+var decodedRow []any
+
+// BenchmarkRowDecode compares how rows.Values decodes a row (a codec lookup
+// per value and a new slice per row) with the scan fast path of rowReader
+// (plans resolved once, destinations and slice reused).
+func BenchmarkRowDecode(b *testing.B) {
+	m := pgtype.NewMap()
+	oids := []uint32{pgtype.Int4OID, pgtype.Int8OID, pgtype.TextOID, pgtype.Float8OID, pgtype.BoolOID, pgtype.VarcharOID}
+	row := []any{int32(42), int64(1) << 40, "jane@example.com", 3.14, true, "active"}
+
+	raw := make([][]byte, len(oids))
+	for i, oid := range oids {
+		buf, err := m.Encode(oid, pgtype.BinaryFormatCode, row[i], nil)
+		if err != nil {
+			b.Fatalf("Encode() error = %v", err)
+		}
+		raw[i] = buf
+	}
+
+	b.Run("Values", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			values := make([]any, 0, len(oids))
+			for j, oid := range oids {
+				dt, _ := m.TypeForOID(oid)
+				v, err := dt.Codec.DecodeValue(m, oid, pgtype.BinaryFormatCode, raw[j])
+				if err != nil {
+					b.Fatal(err)
+				}
+				values = append(values, v)
+			}
+			decodedRow = values
+		}
+	})
+
+	b.Run("Scan", func(b *testing.B) {
+		dests := make([]any, len(oids))
+		plans := make([]pgtype.ScanPlan, len(oids))
+		for j, oid := range oids {
+			dests[j] = scanDest(oid)
+			plans[j] = m.PlanScan(oid, pgtype.BinaryFormatCode, dests[j])
+		}
+		values := make([]any, len(oids))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := range oids {
+				if err := plans[j].Scan(raw[j], dests[j]); err != nil {
+					b.Fatal(err)
+				}
+				values[j] = destValue(dests[j])
+			}
+			decodedRow = values
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package exporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/encoders"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the generated schemas.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaPath returns the schema file written next to outputPath with
+// EmitSchema: "users.json" -> "users.schema.json", "users.xml.gz" -> "users.xsd".
+func SchemaPath(outputPath, format string) string {
+	outputPath = output.TrimCompressionExtension(outputPath)
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	if format == FormatXML {
+		return base + ".xsd"
+	}
+	return base + ".schema.json"
+}
+
+// writeSchema writes the schema describing an export of columns to
+// SchemaPath. Columns are typed from their OIDs the way the exporter writes
+// them, and are always nullable since a result does not tell which ones are.
+func writeSchema(columns []string, fields []pgconn.FieldDescription, options ExportOptions) error {
+	if options.OutputPath == "" {
+		return fmt.Errorf("schema files require an output path")
+	}
+	var data []byte
+	var err error
+	switch options.Format {
+	case FormatJSON:
+		data, err = jsonSchemaDocument(columns, fields, options)
+	case FormatXML:
+		data = xsdDocument(columns, fields, options)
+	default:
+		return fmt.Errorf("schema files are not supported for format %s", options.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	path := SchemaPath(options.OutputPath, options.Format)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing schema file: %w", err)
+	}
+	logger.Debug("Schema written to %s", path)
+	return nil
+}
+
+// jsonSchema is the subset of JSON Schema used to describe JSON exports.
+type jsonSchema struct {
+	Dialect              string            `json:"$schema,omitempty"`
+	Type                 any               `json:"type,omitempty"`
+	Properties           *schemaProperties `json:"properties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	AdditionalProperties any               `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema       `json:"items,omitempty"`
+	AnyOf                []*jsonSchema     `json:"anyOf,omitempty"`
+}
+
+// schemaProperty is a named member of a JSON Schema "properties" object.
+type schemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+// schemaProperties keeps properties in column order, which a Go map would sort.
+type schemaProperties []schemaProperty
+
+// MarshalJSON writes the properties as an object in their original order.
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(prop.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonSchemaDocument describes the array of row objects written by the JSON
+// exporter, or the object of rows keyed by JsonKeyBy, including the "_meta"
+// entry of GeneratedComment.
+func jsonSchemaDocument(columns []string, fields []pgconn.FieldDescription, options ExportOptions) ([]byte, error) {
+	props := make(schemaProperties, len(columns))
+	for i, c := range columns {
+		props[i] = schemaProperty{name: c, schema: jsonColumnSchema(fields[i].DataTypeOID, options)}
+	}
+	row := &jsonSchema{
+		Type:                 "object",
+		Properties:           &props,
+		Required:             columns,
+		AdditionalProperties: false,
+	}
+
+	var doc *jsonSchema
+	if options.JsonKeyBy != "" {
+		doc = &jsonSchema{Type: "object", AdditionalProperties: row}
+		if options.GeneratedComment {
+			doc.Properties = &schemaProperties{{name: jsonMetaKey, schema: &jsonSchema{Type: "object"}}}
+		}
+	} else {
+		items := row
+		if options.GeneratedComment {
+			meta := &jsonSchema{
+				Type:                 "object",
+				Properties:           &schemaProperties{{name: jsonMetaKey, schema: &jsonSchema{Type: "object"}}},
+				Required:             []string{jsonMetaKey},
+				AdditionalProperties: false,
+			}
+			items = &jsonSchema{AnyOf: []*jsonSchema{row, meta}}
+		}
+		doc = &jsonSchema{Type: "array", Items: items}
+	}
+	doc.Dialect = jsonSchemaDialect
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding JSON schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// jsonColumnSchema returns the JSON types a column of type oid is written
+// as. JSON, array and other types without a fixed JSON form are unconstrained.
+func jsonColumnSchema(oid uint32, options ExportOptions) *jsonSchema {
+	numbers := "number"
+	if options.JsonNumbers == encoders.JSONNumbersString {
+		numbers = "string"
+	}
+	// NaN and infinities are written as null or, on request, as strings
+	specials := "null"
+	if options.JsonSpecialFloats == encoders.JSONSpecialFloatsString {
+		specials = "string"
+	}
+
+	var types []string
+	switch oid {
+	case pgtype.BoolOID:
+		types = []string{"boolean"}
+	case pgtype.Int2OID, pgtype.Int4OID:
+		types = []string{"integer"}
+	case pgtype.Int8OID:
+		if numbers == "string" {
+			types = []string{"string"}
+		} else {
+			types = []string{"integer"}
+		}
+	case pgtype.Float4OID, pgtype.Float8OID:
+		types = []string{"number", specials}
+	case pgtype.NumericOID:
+		types = []string{numbers, specials}
+	case formatters.MoneyOID:
+		types = []string{numbers}
+	case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID, pgtype.UUIDOID,
+		pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID, pgtype.TimeOID, pgtype.TimetzOID,
+		pgtype.IntervalOID, pgtype.ByteaOID, pgtype.BitOID, pgtype.VarbitOID,
+		pgtype.InetOID, pgtype.CIDROID, pgtype.MacaddrOID, pgtype.Macaddr8OID:
+		types = []string{"string"}
+	default:
+		return &jsonSchema{}
+	}
+
+	var unique []string
+	for _, t := range append(types, "null") {
+		if !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	return &jsonSchema{Type: unique}
+}
+
+// xsdType is the type of a column in an XSD: a built-in type, or a union of
+// members declared by name in the schema.
+type xsdType struct {
+	name    string
+	members string
+}
+
+var (
+	xsdString  = xsdType{name: "xs:string"}
+	xsdBoolean = xsdType{name: "nullable-boolean", members: "xs:boolean empty"}
+	xsdShort   = xsdType{name: "nullable-short", members: "xs:short empty"}
+	xsdInt     = xsdType{name: "nullable-int", members: "xs:int empty"}
+	xsdLong    = xsdType{name: "nullable-long", members: "xs:long empty"}
+	xsdDouble  = xsdType{name: "nullable-double", members: "xs:double special-float empty"}
+	xsdDecimal = xsdType{name: "nullable-decimal", members: "xs:decimal empty"}
+)
+
+// xsdColumnType returns the type of a column of type oid as written by
+// FormatXMLValue. NULL is an empty element by default, so every non-text
+// type also accepts the empty string. Dates and times follow --time-format
+// and are plain strings.
+func xsdColumnType(oid uint32) xsdType {
+	switch oid {
+	case pgtype.BoolOID:
+		return xsdBoolean
+	case pgtype.Int2OID:
+		return xsdShort
+	case pgtype.Int4OID:
+		return xsdInt
+	case pgtype.Int8OID:
+		return xsdLong
+	case pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		return xsdDouble
+	case formatters.MoneyOID:
+		return xsdDecimal
+	default:
+		return xsdString
+	}
+}
+
+// xsdDocument describes the XML written by the XML exporter: the root
+// element, any number of row elements and one element per column.
+func xsdDocument(columns []string, fields []pgconn.FieldDescription, options ExportOptions) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + "\n")
+	fmt.Fprintf(&b, `  <xs:element name="%s">`+"\n", xmlAttrValue(options.XmlRootElement))
+	b.WriteString("    <xs:complexType>\n      <xs:sequence>\n")
+	fmt.Fprintf(&b, `        <xs:element name="%s" minOccurs="0" maxOccurs="unbounded">`+"\n", xmlAttrValue(options.XmlRowElement))
+	b.WriteString("          <xs:complexType>\n            <xs:sequence>\n")
+
+	// NULL columns are nil or missing elements with those modes
+	nullAttr := ""
+	switch options.XmlNull {
+	case XmlNullXsiNil:
+		nullAttr = ` nillable="true"`
+	case XmlNullOmit:
+		nullAttr = ` minOccurs="0"`
+	}
+
+	var used []xsdType
+	for i, c := range columns {
+		t := xsdColumnType(fields[i].DataTypeOID)
+		fmt.Fprintf(&b, `              <xs:element name="%s" type="%s"%s/>`+"\n", xmlAttrValue(c), t.name, nullAttr)
+		if t.members != "" && !slices.Contains(used, t) {
+			used = append(used, t)
+		}
+	}
+
+	b.WriteString("            </xs:sequence>\n          </xs:complexType>\n        </xs:element>\n")
+	b.WriteString("      </xs:sequence>\n")
+	// Root attributes (--xml-root-attr, --xml-row-count-attr) are not constrained
+	b.WriteString(`      <xs:anyAttribute processContents="skip"/>` + "\n")
+	b.WriteString("    </xs:complexType>\n  </xs:element>\n")
+
+	if len(used) > 0 {
+		b.WriteString(`  <xs:simpleType name="empty">` + "\n")
+		b.WriteString(`    <xs:restriction base="xs:string"><xs:maxLength value="0"/></xs:restriction>` + "\n")
+		b.WriteString("  </xs:simpleType>\n")
+	}
+	if slices.Contains(used, xsdDouble) {
+		b.WriteString(`  <xs:simpleType name="special-float">` + "\n")
+		b.WriteString(`    <xs:restriction base="xs:string">` + "\n")
+		for _, v := range []string{"NaN", "Infinity", "-Infinity"} {
+			fmt.Fprintf(&b, `      <xs:enumeration value="%s"/>`+"\n", v)
+		}
+		b.WriteString("    </xs:restriction>\n  </xs:simpleType>\n")
+	}
+	for _, t := range used {
+		fmt.Fprintf(&b, `  <xs:simpleType name="%s">`+"\n", t.name)
+		fmt.Fprintf(&b, `    <xs:union memberTypes="%s"/>`+"\n", t.members)
+		b.WriteString("  </xs:simpleType>\n")
+	}
+
+	b.WriteString("</xs:schema>\n")
+	return []byte(b.String())
+}
+
+// xmlAttrValue escapes s for a double-quoted attribute value.
+func xmlAttrValue(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
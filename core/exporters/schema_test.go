@@ -0,0 +1,406 @@
+package exporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSchemaPath(t *testing.T) {
+	tests := []struct {
+		output string
+		format string
+		want   string
+	}{
+		{"users.json", FormatJSON, "users.schema.json"},
+		{"/data/users.xml", FormatXML, "/data/users.xsd"},
+		{"out/users", FormatJSON, "out/users.schema.json"},
+		{"report.v2.xml", FormatXML, "report.v2.xsd"},
+		{"users.json.gz", FormatJSON, "users.schema.json"},
+		{"users.xml.zst", FormatXML, "users.xsd"},
+	}
+
+	for _, tt := range tests {
+		if got := SchemaPath(tt.output, tt.format); got != tt.want {
+			t.Errorf("SchemaPath(%q, %q) = %q, want %q", tt.output, tt.format, got, tt.want)
+		}
+	}
+}
+
+// schemaTestRows returns a result covering every type mapped by the schemas,
+// including NULLs, NaN and infinities.
+func schemaTestRows() *fakeRows {
+	names := []string{"id", "total", "name", "active", "score", "price", "balance", "payload", "created", "small"}
+	oids := []uint32{
+		pgtype.Int4OID, pgtype.Int8OID, pgtype.TextOID, pgtype.BoolOID, pgtype.Float8OID,
+		pgtype.NumericOID, 790, pgtype.JSONBOID, pgtype.TimestampOID, pgtype.Int2OID,
+	}
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	data := [][]any{
+		{int32(1), int64(9007199254740993), "alice", true, 1.5,
+			pgtype.Numeric{Int: big.NewInt(12345), Exp: -2, Valid: true}, "$1,234.50", map[string]any{"a": 1}, created, int16(7)},
+		{int32(2), nil, "", false, math.NaN(),
+			pgtype.Numeric{NaN: true, Valid: true}, "-$3.00", []any{1, "two"}, created, int16(-7)},
+		{int32(3), int64(-1), nil, nil, math.Inf(-1),
+			pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}, nil, nil, nil, nil},
+	}
+	return newFakeRows(names, oids, data)
+}
+
+func TestJSONSchemaValidatesExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ExportOptions
+	}{
+		{name: "default", options: ExportOptions{}},
+		{name: "numbers and special floats as strings", options: ExportOptions{JsonNumbers: "string", JsonSpecialFloats: "string"}},
+		{name: "generated comment", options: ExportOptions{GeneratedComment: true, SourceQuery: "SELECT 1"}},
+		{name: "keyed", options: ExportOptions{JsonKeyBy: "id"}},
+		{name: "keyed with generated comment", options: ExportOptions{JsonKeyBy: "id", GeneratedComment: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.Format = FormatJSON
+			options.Compression = "none"
+			options.EmitSchema = true
+			options.OutputPath = filepath.Join(t.TempDir(), "users.json")
+
+			exporter, err := Get(FormatJSON)
+			if err != nil {
+				t.Fatalf("Failed to get json exporter: %v", err)
+			}
+			if _, err := exporter.Export(schemaTestRows(), options); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			schema := decodeJSONFile(t, SchemaPath(options.OutputPath, FormatJSON))
+			if schema.(map[string]any)["$schema"] != jsonSchemaDialect {
+				t.Errorf("$schema = %v, want %s", schema.(map[string]any)["$schema"], jsonSchemaDialect)
+			}
+			data := decodeJSONFile(t, options.OutputPath)
+			if err := validateJSONSchema(schema, data, "$"); err != nil {
+				t.Fatalf("export does not match its schema: %v", err)
+			}
+
+			// The schema is not trivially permissive
+			if err := validateJSONSchema(schema, []any{map[string]any{"id": "1"}}, "$"); err == nil {
+				t.Error("schema accepts a row with a missing column and a string id")
+			}
+		})
+	}
+}
+
+func TestJSONSchemaColumnOrder(t *testing.T) {
+	schema, err := jsonSchemaDocument([]string{"zeta", "alpha"}, newFakeRows([]string{"zeta", "alpha"},
+		[]uint32{pgtype.TextOID, pgtype.Int4OID}, nil).FieldDescriptions(), ExportOptions{})
+	if err != nil {
+		t.Fatalf("jsonSchemaDocument() error = %v", err)
+	}
+	zeta := bytes.Index(schema, []byte(`"zeta"`))
+	alpha := bytes.Index(schema, []byte(`"alpha"`))
+	if zeta < 0 || alpha < 0 || zeta > alpha {
+		t.Errorf("properties are not in column order:\n%s", schema)
+	}
+}
+
+func TestXSDValidatesExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ExportOptions
+	}{
+		{name: "default", options: ExportOptions{XmlRootElement: "results", XmlRowElement: "row"}},
+		{name: "custom elements and root attributes", options: ExportOptions{
+			XmlRootElement:  "users",
+			XmlRowElement:   "user",
+			XmlRootAttrs:    []xml.Attr{{Name: xml.Name{Local: "source"}, Value: "crm"}},
+			XmlRowCountAttr: "count",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.Format = FormatXML
+			options.Compression = "none"
+			options.EmitSchema = true
+			options.OutputPath = filepath.Join(t.TempDir(), "users.xml")
+
+			exporter, err := Get(FormatXML)
+			if err != nil {
+				t.Fatalf("Failed to get xml exporter: %v", err)
+			}
+			if _, err := exporter.Export(schemaTestRows(), options); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			schema := decodeXMLFile(t, SchemaPath(options.OutputPath, FormatXML))
+			data := decodeXMLFile(t, options.OutputPath)
+			if err := validateXSD(schema, data); err != nil {
+				t.Fatalf("export does not match its schema: %v", err)
+			}
+
+			// The schema is not trivially permissive
+			data.Children[0].Children[0].Content = "one"
+			if err := validateXSD(schema, data); err == nil {
+				t.Error("schema accepts a non-integer id")
+			}
+		})
+	}
+}
+
+func decodeJSONFile(t *testing.T, path string) any {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("%s is not valid JSON: %v", path, err)
+	}
+	return v
+}
+
+// validateJSONSchema checks v against the JSON Schema keywords used by the
+// generated schemas: type, properties, required, additionalProperties, items
+// and anyOf.
+func validateJSONSchema(schema, v any, path string) error {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: schema is not an object", path)
+	}
+
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		var errs []string
+		for _, sub := range anyOf {
+			err := validateJSONSchema(sub, v, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no anyOf schema matches: %s", path, strings.Join(errs, "; "))
+	}
+
+	if typ, ok := s["type"]; ok {
+		var types []string
+		switch typ := typ.(type) {
+		case string:
+			types = []string{typ}
+		case []any:
+			for _, t := range typ {
+				types = append(types, t.(string))
+			}
+		}
+		if !slices.Contains(types, jsonTypeOf(v)) && !(jsonTypeOf(v) == "integer" && slices.Contains(types, "number")) {
+			return fmt.Errorf("%s: %v is %s, want %v", path, v, jsonTypeOf(v), types)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := s["properties"].(map[string]any)
+		if required, ok := s["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+		for name, value := range v {
+			if sub, ok := props[name]; ok {
+				if err := validateJSONSchema(sub, value, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+			case map[string]any:
+				if err := validateJSONSchema(extra, value, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				if err := validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTypeOf returns the JSON Schema type of a value decoded with UseNumber.
+func jsonTypeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		if !strings.ContainsAny(v.String(), ".eE") {
+			return "integer" // beyond int64
+		}
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// xmlNode is a generic XML element.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func (n xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func decodeXMLFile(t *testing.T, path string) xmlNode {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var n xmlNode
+	if err := xml.Unmarshal(content, &n); err != nil {
+		t.Fatalf("%s is not valid XML: %v", path, err)
+	}
+	return n
+}
+
+// validateXSD checks a document against the element structure and simple
+// types used by the generated XSDs.
+func validateXSD(schema, doc xmlNode) error {
+	types := make(map[string]xmlNode)
+	var root xmlNode
+	for _, c := range schema.Children {
+		switch c.XMLName.Local {
+		case "simpleType":
+			types[c.attr("name")] = c
+		case "element":
+			root = c
+		}
+	}
+
+	if doc.XMLName.Local != root.attr("name") {
+		return fmt.Errorf("root element is <%s>, want <%s>", doc.XMLName.Local, root.attr("name"))
+	}
+	rowDecl := root.Children[0].Children[0].Children[0]
+	columns := rowDecl.Children[0].Children[0].Children
+
+	for i, row := range doc.Children {
+		if row.XMLName.Local != rowDecl.attr("name") {
+			return fmt.Errorf("row %d is <%s>, want <%s>", i+1, row.XMLName.Local, rowDecl.attr("name"))
+		}
+		if strings.TrimSpace(row.Content) != "" {
+			return fmt.Errorf("row %d has text %q outside its columns", i+1, strings.TrimSpace(row.Content))
+		}
+		if len(row.Children) != len(columns) {
+			return fmt.Errorf("row %d has %d columns, want %d", i+1, len(row.Children), len(columns))
+		}
+		for j, col := range row.Children {
+			if col.XMLName.Local != columns[j].attr("name") {
+				return fmt.Errorf("row %d column %d is <%s>, want <%s>", i+1, j+1, col.XMLName.Local, columns[j].attr("name"))
+			}
+			if !xsdValid(types, columns[j].attr("type"), col.Content) {
+				return fmt.Errorf("row %d: <%s> value %q is not a valid %s", i+1, col.XMLName.Local, col.Content, columns[j].attr("type"))
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	xsdDoubleLexical  = regexp.MustCompile(`^([+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|INF|-INF|NaN)$`)
+	xsdDecimalLexical = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+)
+
+// xsdValid reports whether value is valid for the built-in or named type.
+func xsdValid(types map[string]xmlNode, typ, value string) bool {
+	switch typ {
+	case "xs:string":
+		return true
+	case "xs:boolean":
+		return slices.Contains([]string{"true", "false", "1", "0"}, value)
+	case "xs:short", "xs:int", "xs:long":
+		bits := map[string]int{"xs:short": 16, "xs:int": 32, "xs:long": 64}[typ]
+		_, err := strconv.ParseInt(value, 10, bits)
+		return err == nil
+	case "xs:double":
+		return xsdDoubleLexical.MatchString(value)
+	case "xs:decimal":
+		return xsdDecimalLexical.MatchString(value)
+	}
+
+	decl, ok := types[typ]
+	if !ok || len(decl.Children) == 0 {
+		return false
+	}
+	def := decl.Children[0]
+	switch def.XMLName.Local {
+	case "union":
+		for _, member := range strings.Fields(def.attr("memberTypes")) {
+			if xsdValid(types, member, value) {
+				return true
+			}
+		}
+		return false
+	case "restriction":
+		if !xsdValid(types, def.attr("base"), value) {
+			return false
+		}
+		var enum []string
+		for _, facet := range def.Children {
+			switch facet.XMLName.Local {
+			case "maxLength":
+				if n, _ := strconv.Atoi(facet.attr("value")); len(value) > n {
+					return false
+				}
+			case "enumeration":
+				enum = append(enum, facet.attr("value"))
+			}
+		}
+		return enum == nil || slices.Contains(enum, value)
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package exporters
+
+import "github.com/jackc/pgx/v5"
+
+// SplitRows hands out the rows of a result in parts of at most limit rows, so
+// a single query can be exported to several files: an Export call on it ends
+// once the part is full, and NextPart moves on to the following part.
+//
+// To know whether another part follows a full one, NextPart reads the next
+// row ahead; Next then returns it first. Closing is left to the caller, who
+// owns the underlying rows.
+type SplitRows struct {
+	pgx.Rows
+	limit   int
+	count   int  // rows returned in the current part
+	started bool // the first part has begun
+	pending bool // the underlying rows hold a row read ahead by NextPart
+	done    bool // the underlying rows are exhausted
+}
+
+// NewSplitRows wraps rows to split them every limit rows.
+func NewSplitRows(rows pgx.Rows, limit int) *SplitRows {
+	return &SplitRows{Rows: rows, limit: limit}
+}
+
+// NextPart starts the next part and reports whether there is one. The first
+// call always starts a part, even for an empty result, so the export writes
+// at least one file; later calls only do when rows remain.
+func (r *SplitRows) NextPart() bool {
+	if !r.started {
+		r.started = true
+		return true
+	}
+	if r.done || r.count < r.limit {
+		return false
+	}
+	if !r.Rows.Next() {
+		r.done = true
+		return false
+	}
+	r.pending = true
+	r.count = 0
+	return true
+}
+
+// Next advances to the next row of the current part.
+func (r *SplitRows) Next() bool {
+	if r.done || r.count >= r.limit {
+		return false
+	}
+	if r.pending {
+		r.pending = false
+	} else if !r.Rows.Next() {
+		r.done = true
+		return false
+	}
+	r.count++
+	return true
+}
+
+// Close does nothing: the rows stay open for the following parts.
+func (r *SplitRows) Close() {}
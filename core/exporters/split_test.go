@@ -0,0 +1,144 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSplitRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  int
+		limit int
+		want  []int
+	}{
+		{"last part partial", 7, 3, []int{3, 3, 1}},
+		{"exact multiple", 6, 3, []int{3, 3}},
+		{"single part", 2, 5, []int{2}},
+		{"empty result", 0, 3, []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([][]any, tt.rows)
+			for i := range data {
+				data[i] = []any{int32(i + 1)}
+			}
+			split := NewSplitRows(newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data), tt.limit)
+
+			var got []int
+			next := int32(1)
+			for split.NextPart() {
+				n := 0
+				for split.Next() {
+					values, err := split.Values()
+					if err != nil {
+						t.Fatalf("Values() error: %v", err)
+					}
+					if values[0] != next {
+						t.Fatalf("row %d = %v, want %d", next, values[0], next)
+					}
+					next++
+					n++
+				}
+				got = append(got, n)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("parts = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("parts = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSplitRowsExportIndex(t *testing.T) {
+	data := make([][]any, 7)
+	for i := range data {
+		data[i] = []any{int32(i + 1), "user" + strconv.Itoa(i+1)}
+	}
+	rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, data)
+	split := NewSplitRows(rows, 3)
+
+	exporter, err := Get(FormatCSV)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	splitter := output.NewSplitter(output.PartTemplate(filepath.Join(dir, "users.csv")))
+	for split.NextPart() {
+		options := ExportOptions{
+			Format:      FormatCSV,
+			Delimiter:   ',',
+			Compression: "none",
+			OutputPath:  splitter.NextPath(),
+		}
+		n, err := exporter.Export(split, options)
+		if err != nil {
+			t.Fatalf("Export() error: %v", err)
+		}
+		splitter.Add(options.OutputPath, n)
+	}
+
+	indexPath := filepath.Join(dir, "index.csv")
+	if err := splitter.WriteIndex(indexPath); err != nil {
+		t.Fatalf("WriteIndex() error: %v", err)
+	}
+	index := readCSVFile(t, indexPath)
+	if len(index) != 4 {
+		t.Fatalf("index has %d records, want header + 3 parts:\n%v", len(index), index)
+	}
+
+	for _, entry := range index[1:] {
+		file := entry[1]
+		first, _ := strconv.Atoi(entry[2])
+		last, _ := strconv.Atoi(entry[3])
+		count, _ := strconv.Atoi(entry[4])
+
+		records := readCSVFile(t, filepath.Join(dir, file))
+		if len(records)-1 != count {
+			t.Errorf("%s has %d rows, index says %d", file, len(records)-1, count)
+		}
+		if last-first+1 != count {
+			t.Errorf("%s range %d-%d does not match %d rows", file, first, last, count)
+		}
+		for i, record := range records[1:] {
+			if want := strconv.Itoa(first + i); record[0] != want {
+				t.Errorf("%s row %d id = %s, want %s", file, i+1, record[0], want)
+			}
+		}
+	}
+
+	wantFiles := []string{"users_part001.csv", "users_part002.csv", "users_part003.csv"}
+	for i, want := range wantFiles {
+		if index[i+1][1] != want {
+			t.Errorf("index part %d file = %q, want %q", i+1, index[i+1][1], want)
+		}
+	}
+}
+
+func readCSVFile(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	return records
+}
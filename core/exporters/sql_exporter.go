@@ -16,34 +16,57 @@ import (
 type sqlExporter struct{}
 
 // Export writes query results as SQL INSERT statements.
-func (e *sqlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
+func (e *sqlExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
-	logger.Debug("Preparing SQL export (table=%s, compression=%s, rows-per-statement=%d)",
-		options.TableName, options.Compression, options.RowPerStatement)
+	logger.Debug("Preparing SQL export (table=%s, compression=%s, rows-per-statement=%d, max-statement-bytes=%d)",
+		options.TableName, options.Compression, options.RowPerStatement, options.MaxStatementBytes)
+
+	names, err := ColumnNames(rows.FieldDescriptions(), options, true)
+	if err != nil {
+		return 0, err
+	}
+	table, columns, err := sqlIdentifiers(options.TableName, names, options.UnquotedIdents)
+	if err != nil {
+		return 0, err
+	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
 	})
 	if err != nil {
 		return 0, err
 	}
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
-	fields := rows.FieldDescriptions()
-	columns := make([]string, len(fields))
-	for i, fd := range fields {
-		columns[i] = formatters.QuoteIdent(fd.Name)
+	if options.GeneratedComment {
+		if err := writeLineComment(writerCloser, "-- ", options); err != nil {
+			return 0, err
+		}
+	}
+	if options.SqlSourceComment && strings.TrimSpace(options.SourceQuery) != "" {
+		if err := writeSourceQueryComment(writerCloser, options.SourceQuery); err != nil {
+			return 0, err
+		}
 	}
+
+	fields := rows.FieldDescriptions()
 	size := len(columns)
+	headerSize := len(insertHeader(table, columns))
 
 	logger.Debug("Starting to write SQL INSERT statements...")
 
 	var rowCount int
 	var statementCount int
+	failed := 0 // rows left out by --on-error skip
 	batchInsertValues := make([][]string, 0, options.RowPerStatement)
+	batchBytes := headerSize
 
 	var sp *ui.Spinner
 
@@ -52,31 +75,59 @@ func (e *sqlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp.Start()
 	}
 	for rows.Next() {
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
 		values, err := rows.Values()
 		if err != nil {
-			return 0, fmt.Errorf("error reading row: %w", err)
+			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
+		options.trimValues(values, fields)
 
 		record := make([]string, size)
 
 		//format values
-		for i, val := range values {
-			record[i] = formatters.FormatSQLValue(val, fields[i].DataTypeOID)
+		skipped, err := options.formatRow(rowCount+failed+1, func() error {
+			for i, val := range values {
+				record[i] = formatters.FormatSQLValue(val, fields[i].DataTypeOID)
+			}
+			return nil
+		})
+		if err != nil {
+			return rowCount, err
+		}
+		if skipped {
+			failed++
+			continue
 		}
 
 		rowCount++
-		sp.Update(fmt.Sprintf("Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
+
+		// Flush the pending batch first if this row would push it past the byte limit
+		recordBytes := valueRowSize(record)
+		if options.MaxStatementBytes > 0 && len(batchInsertValues) > 0 &&
+			batchBytes+recordBytes > options.MaxStatementBytes {
+			if err := e.writeBatchInsert(writerCloser, table, columns, batchInsertValues); err != nil {
+				return rowCount, fmt.Errorf("error writing batch statement %d: %w", statementCount+1, err)
+			}
+			statementCount++
+			batchInsertValues = batchInsertValues[:0]
+			batchBytes = headerSize
+		}
+
 		batchInsertValues = append(batchInsertValues, record)
+		batchBytes += recordBytes
 
 		// Write batch when full
 		if len(batchInsertValues) == options.RowPerStatement {
-			if err := e.writeBatchInsert(writerCloser, options.TableName, columns, batchInsertValues); err != nil {
-				return 0, fmt.Errorf("error writing batch statement %d: %w", statementCount+1, err)
+			if err := e.writeBatchInsert(writerCloser, table, columns, batchInsertValues); err != nil {
+				return rowCount, fmt.Errorf("error writing batch statement %d: %w", statementCount+1, err)
 			}
 			statementCount++
 			batchInsertValues = batchInsertValues[:0]
+			batchBytes = headerSize
 
 			if statementCount%1000 == 0 {
 				logger.Debug("%d rows processed (%d INSERT statements written)...", rowCount, statementCount)
@@ -86,8 +137,8 @@ func (e *sqlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 
 	// Write remaining rows as final batch
 	if len(batchInsertValues) > 0 {
-		if err := e.writeBatchInsert(writerCloser, options.TableName, columns, batchInsertValues); err != nil {
-			return 0, fmt.Errorf("error writing final batch statement: %w", err)
+		if err := e.writeBatchInsert(writerCloser, table, columns, batchInsertValues); err != nil {
+			return rowCount, fmt.Errorf("error writing final batch statement: %w", err)
 		}
 		statementCount++
 	}
@@ -95,10 +146,12 @@ func (e *sqlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
+	options.logSkippedRows(failed)
 
 	logger.Debug("SQL export completed successfully: %d rows written in %d INSERT statements (%v)",
 		rowCount, statementCount, time.Since(start))
 	sp.Stop("Completed!")
+	output.Commit(writerCloser)
 	return rowCount, nil
 }
 
@@ -111,8 +164,7 @@ func (e *sqlExporter) writeBatchInsert(writer io.Writer, table string, columns [
 	var stmt strings.Builder
 
 	// Write INSERT header
-	stmt.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES\n",
-		formatters.QuoteIdent(table), strings.Join(columns, ", ")))
+	stmt.WriteString(insertHeader(table, columns))
 
 	// Write value rows
 	for i, record := range rows {
@@ -127,6 +179,52 @@ func (e *sqlExporter) writeBatchInsert(writer io.Writer, table string, columns [
 	return err
 }
 
+// insertHeader returns the "INSERT INTO ... VALUES" line shared by every statement.
+// table and columns are already written as identifiers.
+func insertHeader(table string, columns []string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", table, strings.Join(columns, ", "))
+}
+
+// sqlIdentifiers returns the table and column names as written in INSERT
+// statements: double-quoted, or bare when unquoted is set, in which case a
+// name that PostgreSQL would not read back unchanged fails the export.
+func sqlIdentifiers(table string, names []string, unquoted bool) (string, []string, error) {
+	columns := make([]string, len(names))
+	if !unquoted {
+		for i, name := range names {
+			columns[i] = formatters.QuoteIdent(name)
+		}
+		return formatters.QuoteIdent(table), columns, nil
+	}
+
+	bareTable, err := formatters.BareIdent(table)
+	if err != nil {
+		return "", nil, fmt.Errorf("--no-quote-identifiers: table %w", err)
+	}
+	for i, name := range names {
+		if strings.Contains(name, ".") {
+			return "", nil, fmt.Errorf("--no-quote-identifiers: column %q contains a dot", name)
+		}
+		if columns[i], err = formatters.BareIdent(name); err != nil {
+			return "", nil, fmt.Errorf("--no-quote-identifiers: column %w", err)
+		}
+	}
+	return bareTable, columns, nil
+}
+
+// valueRowSize returns the number of bytes a record occupies in a VALUES list,
+// including the leading tab, parentheses, separator and newline.
+func valueRowSize(record []string) int {
+	size := len("\t()") + len(",\n")
+	for i, v := range record {
+		if i > 0 {
+			size += len(", ")
+		}
+		size += len(v)
+	}
+	return size
+}
+
 func init() {
 	MustRegister(FormatSQL, func() Exporter { return &sqlExporter{} })
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportSQL(t *testing.T) {
@@ -994,6 +995,273 @@ func TestWriteSQLBatchInsertLargeDataset(t *testing.T) {
 	t.Logf("Output file size with batch inserts: %d bytes", info.Size())
 }
 
+func TestWriteSQLMaxStatementBytes(t *testing.T) {
+	wide := strings.Repeat("x", 1000)
+
+	tests := []struct {
+		name            string
+		rowCount        int
+		insertBatch     int
+		maxBytes        int
+		expectedInserts int
+	}{
+		{
+			name:            "no byte limit keeps row batching",
+			rowCount:        10,
+			insertBatch:     5,
+			maxBytes:        0,
+			expectedInserts: 2,
+		},
+		{
+			name:            "byte limit splits before row batch is full",
+			rowCount:        10,
+			insertBatch:     10,
+			maxBytes:        2500,
+			expectedInserts: 5, // header + 2 wide rows fit, a third does not
+		},
+		{
+			name:            "row batch still applies when under byte limit",
+			rowCount:        10,
+			insertBatch:     2,
+			maxBytes:        1_000_000,
+			expectedInserts: 5,
+		},
+		{
+			name:            "single row larger than limit is written alone",
+			rowCount:        3,
+			insertBatch:     10,
+			maxBytes:        100,
+			expectedInserts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputPath := filepath.Join(tmpDir, "output.sql")
+
+			data := make([][]any, tt.rowCount)
+			for i := range data {
+				data[i] = []any{int32(i + 1), wide}
+			}
+			rows := newFakeRows([]string{"id", "payload"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, data)
+
+			exporter, err := Get(FormatSQL)
+			if err != nil {
+				t.Fatalf("Failed to get sql exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:            FormatSQL,
+				TableName:         "wide_rows",
+				Compression:       "none",
+				RowPerStatement:   tt.insertBatch,
+				MaxStatementBytes: tt.maxBytes,
+				OutputPath:        outputPath,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != tt.rowCount {
+				t.Errorf("Expected %d rows, got %d", tt.rowCount, rowCount)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			contentStr := string(content)
+
+			statements := strings.SplitAfter(contentStr, ";\n")
+			statements = statements[:len(statements)-1]
+			if len(statements) != tt.expectedInserts {
+				t.Errorf("Expected %d INSERT statements, got %d", tt.expectedInserts, len(statements))
+			}
+
+			for i, stmt := range statements {
+				if tt.maxBytes > 0 && len(stmt) > tt.maxBytes && strings.Count(stmt, "\t(") > 1 {
+					t.Errorf("Statement %d is %d bytes with several rows, limit is %d", i+1, len(stmt), tt.maxBytes)
+				}
+			}
+
+			if got := strings.Count(contentStr, "\t("); got != tt.rowCount {
+				t.Errorf("Expected %d value rows, got %d", tt.rowCount, got)
+			}
+		})
+	}
+}
+
+func TestWriteSQLArraysReload(t *testing.T) {
+	conn, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	setup := `
+		CREATE TEMP TABLE array_source (id int4, tags text[], nums int4[], big int8[], stamps timestamptz[], ids uuid[]);
+		CREATE TEMP TABLE array_reload (LIKE array_source);
+		INSERT INTO array_source VALUES
+			(1, ARRAY['a,b', 'say "hi"', 'O''Brien', E'back\\slash', 'NULL', '', NULL, '{x}', ' padded '],
+			    ARRAY[1, NULL, -3], ARRAY[9007199254740993], ARRAY['2024-01-15 10:30:00.123456+02'::timestamptz],
+			    ARRAY['12345678-1234-5678-1234-567812345678'::uuid]),
+			(2, '{}', '{}', NULL, '{}', '{}')`
+	if _, err := conn.Exec(ctx, setup); err != nil {
+		t.Fatalf("Failed to create test tables: %v", err)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT * FROM array_source ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	outputPath := filepath.Join(t.TempDir(), "arrays.sql")
+	exporter, err := Get(FormatSQL)
+	if err != nil {
+		t.Fatalf("Failed to get sql exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:          FormatSQL,
+		TableName:       "array_reload",
+		Compression:     "none",
+		RowPerStatement: 1,
+		OutputPath:      outputPath,
+	}
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if _, err := conn.Exec(ctx, string(content)); err != nil {
+		t.Fatalf("Failed to reload exported SQL: %v\n%s", err, content)
+	}
+
+	var diff int
+	err = conn.QueryRow(ctx, `
+		SELECT count(*) FROM (
+			(SELECT * FROM array_source EXCEPT ALL SELECT * FROM array_reload)
+			UNION ALL
+			(SELECT * FROM array_reload EXCEPT ALL SELECT * FROM array_source)
+		) d`).Scan(&diff)
+	if err != nil {
+		t.Fatalf("Failed to compare tables: %v", err)
+	}
+	if diff != 0 {
+		t.Errorf("%d rows differ after reloading:\n%s", diff, content)
+	}
+}
+
+func TestWriteSQLUnquotedIdents(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		columns     []string
+		unquoted    bool
+		expected    string
+		errContains string
+	}{
+		{
+			name:     "quoted by default",
+			table:    "users",
+			columns:  []string{"id", "name"},
+			expected: "INSERT INTO \"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:     "quoted schema-qualified table",
+			table:    "public.users",
+			columns:  []string{"id", "name"},
+			expected: "INSERT INTO \"public\".\"users\" (\"id\", \"name\") VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:     "unquoted",
+			table:    "users",
+			columns:  []string{"id", "name"},
+			unquoted: true,
+			expected: "INSERT INTO users (id, name) VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:     "unquoted schema-qualified table",
+			table:    "public.users",
+			columns:  []string{"user_id", "name$2"},
+			unquoted: true,
+			expected: "INSERT INTO public.users (user_id, name$2) VALUES\n\t(1, 'alice');\n",
+		},
+		{
+			name:        "unquoted uppercase table",
+			table:       "public.Users",
+			columns:     []string{"id", "name"},
+			unquoted:    true,
+			errContains: `table "public.Users" cannot be written without quotes: "Users" contains 'U'`,
+		},
+		{
+			name:        "unquoted column with a space",
+			table:       "users",
+			columns:     []string{"id", "full name"},
+			unquoted:    true,
+			errContains: `column "full name" cannot be written without quotes`,
+		},
+		{
+			name:        "unquoted reserved keyword column",
+			table:       "users",
+			columns:     []string{"id", "order"},
+			unquoted:    true,
+			errContains: `"order" is a reserved keyword`,
+		},
+		{
+			name:        "unquoted column with a dot",
+			table:       "users",
+			columns:     []string{"id", "a.b"},
+			unquoted:    true,
+			errContains: `column "a.b" contains a dot`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.sql")
+			rows := newFakeRows(tt.columns, []uint32{pgtype.Int4OID, pgtype.TextOID}, [][]any{{int32(1), "alice"}})
+
+			exporter, err := Get(FormatSQL)
+			if err != nil {
+				t.Fatalf("Failed to get sql exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatSQL,
+				TableName:       tt.table,
+				Compression:     "none",
+				RowPerStatement: 1,
+				OutputPath:      outputPath,
+				UnquotedIdents:  tt.unquoted,
+			}
+
+			_, err = exporter.Export(rows, options)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Export() error = %v, want containing %q", err, tt.errContains)
+				}
+				if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+					t.Error("Output file should not be created when an identifier is rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output =\n%s\nwant\n%s", content, tt.expected)
+			}
+		})
+	}
+}
+
 func BenchmarkWriteSQLBatchComparison(b *testing.B) {
 	testURL := os.Getenv("DB_TEST_URL")
 	if testURL == "" {
@@ -3,6 +3,8 @@ package exporters
 import (
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
+	"io"
 	"os"
 	"strings"
 	"text/template"
@@ -31,7 +33,11 @@ func (e *templateExporter) Export(rows pgx.Rows, options ExportOptions) (int, er
 }
 
 // full mode (load all rows)
-func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (int, error) {
+//
+// Every row is kept in memory until the template runs, so this mode is meant
+// for small and medium result sets. TemplateMaxRows turns an unexpectedly
+// large result into an error instead of an out-of-memory crash.
+func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
 	logger.Debug("Preparing TEMPLATE (full mode) export (compression=%s)", options.Compression)
@@ -41,17 +47,15 @@ func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (int
 		return 0, fmt.Errorf("error reading template file: %w", err)
 	}
 
-	tpl, err := template.New("pgxport-template").
-		Funcs(defaultTemplateFuncs()).
-		Parse(string(tplBytes))
+	tpl, err := parseTemplate("pgxport-template", string(tplBytes), defaultTemplateFuncs(options.TemplateStrict), options)
 	if err != nil {
 		return 0, fmt.Errorf("error parsing template: %w", err)
 	}
 
 	fields := rows.FieldDescriptions()
-	keys := make([]string, len(fields))
-	for i, f := range fields {
-		keys[i] = string(f.Name)
+	keys, err := ColumnNames(fields, options, true)
+	if err != nil {
+		return 0, err
 	}
 
 	allRows := []*orderedmap.OrderedMap[string, interface{}]{}
@@ -65,18 +69,26 @@ func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (int
 	}
 
 	for rows.Next() {
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		if options.TemplateMaxRows > 0 && rowCount >= options.TemplateMaxRows {
+			return rowCount, fmt.Errorf("result exceeds --tpl-max-rows (%d): full-mode templates keep every row in memory, use streaming mode (--tpl-row) for large results",
+				options.TemplateMaxRows)
+		}
+
 		vals, err := rows.Values()
 		if err != nil {
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
+		options.trimValues(vals, fields)
 
 		rowMap := buildRow(keys, vals, fields, options)
 		allRows = append(allRows, rowMap)
 
 		rowCount++
-		sp.Update(fmt.Sprintf("[1/2] Exporting rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("[1/2] Exporting rows...", rowCount, options.ProgressTotal, time.Since(start)))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -86,15 +98,19 @@ func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (int
 	sp.Stop("Completed!")
 
 	writer, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
 	})
 
 	if err != nil {
 		return rowCount, err
 	}
-	defer writer.Close()
+	defer closeOutput(writer, &err)
 
 	data := map[string]interface{}{
 		"Rows":        allRows,
@@ -117,46 +133,52 @@ func (e *templateExporter) exportFull(rows pgx.Rows, options ExportOptions) (int
 	sp2.Stop("Completed!")
 
 	logger.Debug("TEMPLATE full export completed: %d rows in %.2fs", rowCount, time.Since(start).Seconds())
+	output.Commit(writer)
 	return rowCount, nil
 }
 
 // Streaming mode
-func (e *templateExporter) exportStreaming(rows pgx.Rows, options ExportOptions) (int, error) {
+func (e *templateExporter) exportStreaming(rows pgx.Rows, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
 	logger.Debug("Preparing TEMPLATE (streaming mode) export (compression=%s)", options.Compression)
 
-	funcs := defaultTemplateFuncs()
+	funcs := defaultTemplateFuncs(options.TemplateStrict)
 
-	tplHeader, err := loadTemplateIfExists(options.TemplateHeader, false, funcs)
+	tplHeader, err := loadTemplateIfExists(options.TemplateHeader, false, funcs, options)
 	if err != nil {
 		return 0, err
 	}
-	tplRow, err := loadTemplateIfExists(options.TemplateRow, true, funcs)
+	tplRow, err := loadTemplateIfExists(options.TemplateRow, true, funcs, options)
 	if err != nil {
 		return 0, err
 	}
-	tplFooter, err := loadTemplateIfExists(options.TemplateFooter, false, funcs)
+	tplFooter, err := loadTemplateIfExists(options.TemplateFooter, false, funcs, options)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := ColumnNames(rows.FieldDescriptions(), options, true)
 	if err != nil {
 		return 0, err
 	}
 
 	writer, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
-	defer writer.Close()
+	defer closeOutput(writer, &err)
 
 	fields := rows.FieldDescriptions()
-	keys := make([]string, len(fields))
-	for i, f := range fields {
-		keys[i] = string(f.Name)
-	}
 
 	generatedAt := time.Now().Format(time.RFC3339)
 
@@ -180,10 +202,15 @@ func (e *templateExporter) exportStreaming(rows pgx.Rows, options ExportOptions)
 
 	// Stream row-by-row
 	for rows.Next() {
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
 		vals, err := rows.Values()
 		if err != nil {
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
+		options.trimValues(vals, fields)
 
 		rowMap := buildRow(keys, vals, fields, options)
 
@@ -193,9 +220,7 @@ func (e *templateExporter) exportStreaming(rows pgx.Rows, options ExportOptions)
 		}
 
 		rowCount++
-		sp.Update(fmt.Sprintf("Exporting rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("Exporting rows...", rowCount, options.ProgressTotal, time.Since(start)))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -215,11 +240,16 @@ func (e *templateExporter) exportStreaming(rows pgx.Rows, options ExportOptions)
 
 	sp.Stop("Completed!")
 	logger.Debug("TEMPLATE streaming export completed: %d rows in %.2fs", rowCount, time.Since(start).Seconds())
+	output.Commit(writer)
 	return rowCount, nil
 }
 
 // utilities for template exporter
-func defaultTemplateFuncs() template.FuncMap {
+//
+// The helpers only transform values passed to them: none of them touch the
+// filesystem, environment or network, so templates stay side-effect free.
+// In strict mode, get fails on unknown columns instead of returning nil.
+func defaultTemplateFuncs(strict bool) template.FuncMap {
 	return template.FuncMap{
 		"upper":     strings.ToUpper,
 		"lower":     strings.ToLower,
@@ -263,14 +293,42 @@ func defaultTemplateFuncs() template.FuncMap {
 			return a / b
 		},
 		// Helper function to access orderedmap values in templates
-		"get": func(m *orderedmap.OrderedMap[string, interface{}], key string) interface{} {
-			val, _ := m.Get(key)
-			return val
+		"get": func(m *orderedmap.OrderedMap[string, interface{}], key string) (interface{}, error) {
+			val, ok := m.Get(key)
+			if !ok && strict {
+				return nil, fmt.Errorf("unknown column %q", key)
+			}
+			return val, nil
 		},
 	}
 }
 
-func loadTemplateIfExists(path string, required bool, funcs template.FuncMap) (*template.Template, error) {
+// missingKeyOption makes map lookups such as {{.Cuont}} fail in strict mode
+// instead of rendering "<no value>".
+func missingKeyOption(strict bool) string {
+	if strict {
+		return "missingkey=error"
+	}
+	return "missingkey=default"
+}
+
+// templateRenderer is satisfied by both text/template and html/template.
+type templateRenderer interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseTemplate parses text with html/template when TemplateHTML is set, so
+// values are escaped according to their HTML context (element, attribute,
+// URL, script), and with text/template otherwise.
+func parseTemplate(name, text string, funcs template.FuncMap, options ExportOptions) (templateRenderer, error) {
+	missingKey := missingKeyOption(options.TemplateStrict)
+	if options.TemplateHTML {
+		return htmltemplate.New(name).Funcs(funcs).Option(missingKey).Parse(text)
+	}
+	return template.New(name).Funcs(funcs).Option(missingKey).Parse(text)
+}
+
+func loadTemplateIfExists(path string, required bool, funcs template.FuncMap, options ExportOptions) (templateRenderer, error) {
 	if strings.TrimSpace(path) == "" {
 		if required {
 			return nil, fmt.Errorf("template file path is empty")
@@ -281,7 +339,7 @@ func loadTemplateIfExists(path string, required bool, funcs template.FuncMap) (*
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template file %q: %w", path, err)
 	}
-	tpl, err := template.New(path).Funcs(funcs).Parse(string(b))
+	tpl, err := parseTemplate(path, string(b), funcs, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template %q: %w", path, err)
 	}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportTemplateFull(t *testing.T) {
@@ -332,3 +334,251 @@ Title: {{title (get . "name")}}
 		t.Error("Expected title case transformation")
 	}
 }
+
+func TestExportTemplateFullMaxRows(t *testing.T) {
+	data := [][]any{{int32(1)}, {int32(2)}, {int32(3)}}
+
+	tests := []struct {
+		name     string
+		maxRows  int
+		wantErr  bool
+		wantRows int
+	}{
+		{name: "unlimited", maxRows: 0, wantRows: 3},
+		{name: "limit equals row count", maxRows: 3, wantRows: 3},
+		{name: "limit exceeded", maxRows: 2, wantErr: true, wantRows: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tplPath := filepath.Join(tmpDir, "full.tpl")
+			outPath := filepath.Join(tmpDir, "out.txt")
+			if err := os.WriteFile(tplPath, []byte(`{{.Count}}`), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data)
+
+			exporter, err := Get(FormatTemplate)
+			if err != nil {
+				t.Fatalf("Failed to get template exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatTemplate,
+				OutputPath:      outPath,
+				Compression:     "none",
+				TemplateFile:    tplPath,
+				TemplateMaxRows: tt.maxRows,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+
+			if rowCount != tt.wantRows {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, tt.wantRows)
+			}
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "exceeds --tpl-max-rows (2)") {
+					t.Fatalf("Export() error = %v, want --tpl-max-rows error", err)
+				}
+				if _, statErr := os.Stat(outPath); statErr == nil {
+					t.Error("Export() should not create the output file when the limit is exceeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExportTemplateStrict(t *testing.T) {
+	tests := []struct {
+		name      string
+		streaming bool
+		template  string
+		strict    bool
+		wantErr   string
+		want      string
+	}{
+		{
+			name:      "streaming unknown column lenient",
+			streaming: true,
+			template:  `{{get . "nmae"}}|`,
+			want:      "<no value>|",
+		},
+		{
+			name:      "streaming unknown column strict",
+			streaming: true,
+			template:  `{{get . "nmae"}}|`,
+			strict:    true,
+			wantErr:   `unknown column "nmae"`,
+		},
+		{
+			name:      "streaming known column strict",
+			streaming: true,
+			template:  `{{get . "name"}}|`,
+			strict:    true,
+			want:      "Alice|",
+		},
+		{
+			name:     "full missing key lenient",
+			template: `{{.Cuont}}`,
+			want:     "<no value>",
+		},
+		{
+			name:     "full missing key strict",
+			template: `{{.Cuont}}`,
+			strict:   true,
+			wantErr:  `map has no entry for key "Cuont"`,
+		},
+		{
+			name:     "full unknown column strict",
+			template: `{{range .Rows}}{{get . "nmae"}}{{end}}`,
+			strict:   true,
+			wantErr:  `unknown column "nmae"`,
+		},
+		{
+			name:     "full known keys strict",
+			template: `{{.Count}}:{{range .Rows}}{{get . "name"}}{{end}}`,
+			strict:   true,
+			want:     "1:Alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tplPath := filepath.Join(tmpDir, "test.tpl")
+			outPath := filepath.Join(tmpDir, "out.txt")
+			if err := os.WriteFile(tplPath, []byte(tt.template), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			rows := newFakeRows([]string{"name"}, []uint32{pgtype.TextOID}, [][]any{{"Alice"}})
+
+			exporter, err := Get(FormatTemplate)
+			if err != nil {
+				t.Fatalf("Failed to get template exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:            FormatTemplate,
+				OutputPath:        outPath,
+				Compression:       "none",
+				TemplateStreaming: tt.streaming,
+				TemplateStrict:    tt.strict,
+			}
+			if tt.streaming {
+				options.TemplateRow = tplPath
+			} else {
+				options.TemplateFile = tplPath
+			}
+
+			_, err = exporter.Export(rows, options)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Export() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportTemplateHTML(t *testing.T) {
+	const payload = `<script>alert("x")</script>`
+
+	tests := []struct {
+		name      string
+		streaming bool
+		html      bool
+		template  string
+		want      string
+	}{
+		{
+			name:      "streaming text mode keeps markup",
+			streaming: true,
+			template:  `<td>{{get . "name"}}</td>`,
+			want:      `<td><script>alert("x")</script></td>`,
+		},
+		{
+			name:      "streaming html mode escapes markup",
+			streaming: true,
+			html:      true,
+			template:  `<td>{{get . "name"}}</td>`,
+			want:      `<td>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</td>`,
+		},
+		{
+			name:     "full text mode keeps markup",
+			template: `{{range .Rows}}<td>{{get . "name"}}</td>{{end}}`,
+			want:     `<td><script>alert("x")</script></td>`,
+		},
+		{
+			name:     "full html mode escapes markup",
+			html:     true,
+			template: `{{range .Rows}}<td>{{get . "name"}}</td>{{end}}`,
+			want:     `<td>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</td>`,
+		},
+		{
+			name:     "full html mode escapes attributes",
+			html:     true,
+			template: `{{range .Rows}}<a title="{{get . "name"}}">{{upper "ok"}}</a>{{end}}`,
+			want:     `<a title="&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;">OK</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tplPath := filepath.Join(tmpDir, "test.tpl")
+			outPath := filepath.Join(tmpDir, "out.html")
+			if err := os.WriteFile(tplPath, []byte(tt.template), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			rows := newFakeRows([]string{"name"}, []uint32{pgtype.TextOID}, [][]any{{payload}})
+
+			exporter, err := Get(FormatTemplate)
+			if err != nil {
+				t.Fatalf("Failed to get template exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:            FormatTemplate,
+				OutputPath:        outPath,
+				Compression:       "none",
+				TemplateStreaming: tt.streaming,
+				TemplateHTML:      tt.html,
+			}
+			if tt.streaming {
+				options.TemplateRow = tplPath
+			} else {
+				options.TemplateFile = tplPath
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
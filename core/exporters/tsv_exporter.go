@@ -0,0 +1,246 @@
+package exporters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/fbz-tec/pgxport/core/validation"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/ui"
+	"github.com/jackc/pgx/v5"
+)
+
+// tsvNull is how PostgreSQL's text COPY format writes NULL.
+const tsvNull = `\N`
+
+// tsvEscaper escapes the characters that are special in PostgreSQL's text COPY format.
+var tsvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+type tsvExporter struct{}
+
+// Export writes query results as tab-separated values in PostgreSQL's text
+// COPY format: fields are never quoted, tabs, line breaks and backslashes are
+// escaped and NULL is written as \N.
+func (e *tsvExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
+	start := time.Now()
+
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, false)
+	if err != nil {
+		return 0, err
+	}
+	headers, err := customHeaders(columns, options)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("Preparing TSV export (noHeader=%v, compression=%s)", options.NoHeader, options.Compression)
+
+	writerCloser, err := output.CreateWriter(output.OutputConfig{
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer closeOutput(writerCloser, &err)
+
+	if options.GeneratedComment {
+		if err := writeLineComment(writerCloser, "# ", options); err != nil {
+			return 0, err
+		}
+	}
+
+	writer := bufio.NewWriter(writerCloser)
+
+	if !options.NoHeader {
+		if err := writeTSVHeader(writer, headers); err != nil {
+			return 0, err
+		}
+	}
+
+	var sp *ui.Spinner
+
+	if options.ProgressBar {
+		sp = ui.NewSpinner()
+		sp.Start()
+	}
+
+	fields := rows.FieldDescriptions()
+	reader := newRowReader(rows)
+	record := make([]string, len(fields))
+	rowCount := 0
+	failed := 0 // rows left out by --on-error skip
+
+	for rows.Next() {
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		values, err := reader.Values()
+		if err != nil {
+			return rowCount, fmt.Errorf("error reading row %d: %w", rowCount+1, err)
+		}
+		options.trimValues(values, fields)
+
+		skipped, err := options.formatRow(rowCount+failed+1, func() error {
+			for i, v := range values {
+				if v == nil {
+					record[i] = tsvNull
+					continue
+				}
+				record[i] = tsvEscaper.Replace(formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone))
+			}
+			return nil
+		})
+		if err != nil {
+			return rowCount, err
+		}
+		if skipped {
+			failed++
+			continue
+		}
+
+		if err := writeTSVLine(writer, record); err != nil {
+			return rowCount, fmt.Errorf("error writing row %d: %w", rowCount+1, err)
+		}
+		rowCount++
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
+
+		if rowCount%10000 == 0 {
+			logger.Debug("%d TSV rows written...", rowCount)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return rowCount, fmt.Errorf("error iterating rows: %w", err)
+	}
+	options.logSkippedRows(failed)
+
+	if err := writer.Flush(); err != nil {
+		return rowCount, fmt.Errorf("error flushing TSV: %w", err)
+	}
+
+	sp.Stop("Completed!")
+	logger.Debug("TSV export completed: %d rows written in %v", rowCount, time.Since(start))
+
+	output.Commit(writerCloser)
+	return rowCount, nil
+}
+
+// ExportCopy streams the result of COPY ... TO STDOUT WITH (FORMAT text),
+// PostgreSQL's native tab-delimited format. Text COPY has no header option
+// on every server version, so the header is written by pgxport from the
+// column names of the described query.
+func (e *tsvExporter) ExportCopy(conn *pgx.Conn, query string, options ExportOptions) (n int, err error) {
+	start := time.Now()
+	logger.Debug("Starting PostgreSQL COPY TSV export (noHeader=%v, compression=%s)", options.NoHeader, options.Compression)
+
+	// The query is embedded in COPY (...) TO STDOUT, so it must be a single
+	// read-only SELECT or WITH; COPY itself (including COPY FROM) is rejected
+	if err := validation.ValidateQuery(query); err != nil {
+		return 0, err
+	}
+
+	// Describe the query before creating the output so an invalid query
+	// leaves no file behind
+	var columns []string
+	if !options.NoHeader {
+		desc, err := conn.PgConn().Prepare(options.ctx(), "", query, nil)
+		if err != nil {
+			return 0, fmt.Errorf("error describing query: %w", err)
+		}
+		columns, err = ColumnNames(desc.Fields, options, false)
+		if err != nil {
+			return 0, err
+		}
+		if columns, err = customHeaders(columns, options); err != nil {
+			return 0, err
+		}
+	}
+
+	writerCloser, err := output.CreateWriter(output.OutputConfig{
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer closeOutput(writerCloser, &err)
+
+	if options.GeneratedComment {
+		if err := writeLineComment(writerCloser, "# ", options); err != nil {
+			return 0, err
+		}
+	}
+
+	if !options.NoHeader {
+		if err := writeTSVHeader(writerCloser, columns); err != nil {
+			return 0, err
+		}
+	}
+
+	copySql := fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT text)", copySubquery(query))
+
+	tag, err := conn.PgConn().CopyTo(options.ctx(), writerCloser, copySql)
+	if err != nil {
+		return 0, fmt.Errorf("COPY TO STDOUT failed: %w", err)
+	}
+
+	rowCount := int(tag.RowsAffected())
+
+	logger.Debug("COPY TSV export completed successfully: %d rows written to %s in %v", rowCount, output.FinalPath(output.OutputConfig{
+		Path:        options.OutputPath,
+		Compression: options.Compression,
+		Format:      options.Format,
+	}), time.Since(start))
+
+	output.Commit(writerCloser)
+	return rowCount, nil
+}
+
+// writeTSVHeader writes the escaped column names as the first line.
+func writeTSVHeader(w io.Writer, columns []string) error {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = tsvEscaper.Replace(c)
+	}
+	if err := writeTSVLine(w, header); err != nil {
+		return fmt.Errorf("error writing headers: %w", err)
+	}
+	logger.Debug("TSV headers written: %s", strings.Join(columns, ", "))
+	return nil
+}
+
+// writeTSVLine writes already escaped fields joined by tabs.
+func writeTSVLine(w io.Writer, fields []string) error {
+	_, err := io.WriteString(w, strings.Join(fields, "\t")+"\n")
+	return err
+}
+
+func init() {
+	MustRegister(FormatTSV, func() Exporter { return &tsvExporter{} })
+}
@@ -0,0 +1,210 @@
+package exporters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestExportTSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  []string
+		oids     []uint32
+		data     [][]any
+		noHeader bool
+		want     string
+	}{
+		{
+			name:    "header and rows",
+			columns: []string{"id", "name"},
+			oids:    []uint32{pgtype.Int4OID, pgtype.TextOID},
+			data:    [][]any{{int32(1), "Alice"}, {int32(2), "Bob"}},
+			want:    "id\tname\n1\tAlice\n2\tBob\n",
+		},
+		{
+			name:     "no header",
+			columns:  []string{"id", "name"},
+			oids:     []uint32{pgtype.Int4OID, pgtype.TextOID},
+			data:     [][]any{{int32(1), "Alice"}},
+			noHeader: true,
+			want:     "1\tAlice\n",
+		},
+		{
+			name:    "NULL and empty string",
+			columns: []string{"id", "note"},
+			oids:    []uint32{pgtype.Int4OID, pgtype.TextOID},
+			data:    [][]any{{nil, ""}, {int32(3), nil}},
+			want:    "id\tnote\n\\N\t\n3\t\\N\n",
+		},
+		{
+			name:    "special characters are escaped",
+			columns: []string{"text"},
+			oids:    []uint32{pgtype.TextOID},
+			data:    [][]any{{"a\tb"}, {"line1\nline2\r"}, {`C:\temp`}, {`\N`}},
+			want:    "text\na\\tb\nline1\\nline2\\r\nC:\\\\temp\n\\\\N\n",
+		},
+		{
+			name:    "column names are escaped",
+			columns: []string{"a\tb"},
+			oids:    []uint32{pgtype.Int4OID},
+			data:    [][]any{{int32(1)}},
+			want:    "a\\tb\n1\n",
+		},
+		{
+			name:    "no rows",
+			columns: []string{"id"},
+			oids:    []uint32{pgtype.Int4OID},
+			data:    nil,
+			want:    "id\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.tsv")
+
+			exporter, err := Get(FormatTSV)
+			if err != nil {
+				t.Fatalf("Failed to get tsv exporter: %v", err)
+			}
+
+			rowCount, err := exporter.Export(newFakeRows(tt.columns, tt.oids, tt.data), ExportOptions{
+				Format:      FormatTSV,
+				Compression: "none",
+				NoHeader:    tt.noHeader,
+				OutputPath:  outputPath,
+			})
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if rowCount != len(tt.data) {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, len(tt.data))
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCopyTSV(t *testing.T) {
+	conn, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	query := `SELECT * FROM (VALUES
+		(1, 'Alice', 'tab' || chr(9) || 'here'),
+		(2, NULL, 'line' || chr(10) || 'break'),
+		(3, 'back\slash', '')
+	) AS t(id, name, note)`
+
+	tests := []struct {
+		name     string
+		noHeader bool
+		want     string
+	}{
+		{
+			name: "with header",
+			want: "id\tname\tnote\n1\tAlice\ttab\\there\n2\t\\N\tline\\nbreak\n3\tback\\\\slash\t\n",
+		},
+		{
+			name:     "without header",
+			noHeader: true,
+			want:     "1\tAlice\ttab\\there\n2\t\\N\tline\\nbreak\n3\tback\\\\slash\t\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.tsv")
+
+			exporter, err := Get(FormatTSV)
+			if err != nil {
+				t.Fatalf("Failed to get tsv exporter: %v", err)
+			}
+			copyExp, ok := exporter.(CopyCapable)
+			if !ok {
+				t.Fatalf("Copy mode is not supported by the tsv exporter")
+			}
+
+			rowCount, err := copyExp.ExportCopy(conn, query, ExportOptions{
+				Format:      FormatTSV,
+				Compression: "none",
+				NoHeader:    tt.noHeader,
+				OutputPath:  outputPath,
+			})
+			if err != nil {
+				t.Fatalf("ExportCopy() error = %v", err)
+			}
+			if rowCount != 3 {
+				t.Errorf("ExportCopy() rowCount = %d, want 3", rowCount)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCopyTSVInvalidQueryLeavesNoFile(t *testing.T) {
+	conn, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	outputPath := filepath.Join(t.TempDir(), "output.tsv")
+	exporter, err := Get(FormatTSV)
+	if err != nil {
+		t.Fatalf("Failed to get tsv exporter: %v", err)
+	}
+
+	_, err = exporter.(CopyCapable).ExportCopy(conn, "SELECT * FROM missing_table_for_tsv", ExportOptions{
+		Format:      FormatTSV,
+		Compression: "none",
+		OutputPath:  outputPath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "error describing query") {
+		t.Fatalf("ExportCopy() error = %v, want describe error", err)
+	}
+	if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+		t.Errorf("no output file should be created for an invalid query")
+	}
+}
+
+func TestExportCopyTSVRejectsCopyQuery(t *testing.T) {
+	exporter, err := Get(FormatTSV)
+	if err != nil {
+		t.Fatalf("Failed to get tsv exporter: %v", err)
+	}
+	copyExp := exporter.(CopyCapable)
+
+	for _, query := range []string{"COPY users FROM STDIN", "DELETE FROM users"} {
+		t.Run(query, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.tsv")
+
+			// The query is checked before the connection is used
+			_, err := copyExp.ExportCopy(nil, query, ExportOptions{
+				Format:      FormatTSV,
+				Compression: "none",
+				OutputPath:  outputPath,
+			})
+			if err == nil {
+				t.Fatal("ExportCopy() expected an error for a non read-only query")
+			}
+			if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+				t.Errorf("no output file should be created for a rejected query")
+			}
+		})
+	}
+}
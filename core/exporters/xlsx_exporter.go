@@ -2,6 +2,9 @@ package exporters
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
@@ -9,21 +12,35 @@ import (
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/fbz-tec/pgxport/internal/ui"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/xuri/excelize/v2"
 )
 
 type xlsxExporter struct{}
 
+// xlsxMaxRows is the maximum number of rows in an XLSX sheet.
+// It is a variable so tests can exercise the sheet overflow.
+var xlsxMaxRows = 1_048_576
+
 // Export writes query results to an Excel XLSX file.
 // Automatically creates multiple sheets if the row count exceeds Excel's maximum (1,048,576 rows per sheet).
-func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
-
-	const maxRows = 1_048_576 // Maximum rows in an XLSX sheet
+// With XlsxSheetBy, each value of the column gets its own sheets, created when the value is first seen.
+func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
 
 	logger.Debug("Preparing XLSX export (compression=%s)", options.Compression)
 
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, false)
+	if err != nil {
+		return 0, err
+	}
+	headers, err := HeaderNames(columns, options, false)
+	if err != nil {
+		return 0, err
+	}
+
 	// Create new Excel file
 	f := excelize.NewFile()
 	defer func() {
@@ -32,16 +49,22 @@ func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		}
 	}()
 
+	if options.GeneratedComment {
+		info := newGeneratedInfo(options)
+		if err := f.SetDocProps(&excelize.DocProperties{
+			Creator:     "pgxport " + info.Version,
+			Created:     info.GeneratedAt,
+			Description: strings.Join(info.lines(), "\n"),
+		}); err != nil {
+			return 0, fmt.Errorf("error setting workbook properties: %w", err)
+		}
+	}
+
 	// Remove default sheet to avoid duplication
 	f.DeleteSheet("Sheet1")
 
 	fields := rows.FieldDescriptions()
 
-	columns := make([]string, len(fields))
-	for i, fd := range fields {
-		columns[i] = string(fd.Name)
-	}
-
 	// Create style for headers if present
 	var headerStyleID int
 	if !options.NoHeader {
@@ -58,10 +81,56 @@ func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		}
 	}
 
+	columnStyles, err := columnNumberFormats(f, columns, options.XlsxFormats)
+	if err != nil {
+		return 0, err
+	}
+
+	// Keep one row free on each sheet for the totals row
+	lastRow := xlsxMaxRows
+	var totalStyles []int
+	if options.XlsxTotals {
+		lastRow--
+		totalStyles, err = totalsRowStyles(f, columns, options.XlsxFormats)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	sheetBy := -1
+	if options.XlsxSheetBy != "" {
+		for i, col := range columns {
+			if col == options.XlsxSheetBy {
+				sheetBy = i
+				break
+			}
+		}
+		if sheetBy < 0 {
+			return 0, fmt.Errorf("--xlsx-sheet-by column %q not found in query results", options.XlsxSheetBy)
+		}
+		logger.Debug("Writing one sheet per value of column %q", options.XlsxSheetBy)
+	}
+
+	book := &xlsxWorkbook{
+		f:             f,
+		headers:       headers,
+		noHeader:      options.NoHeader,
+		headerStyleID: headerStyleID,
+		names:         make(map[string]bool),
+	}
+	newGroup := func(name string) *xlsxSheet {
+		group := &xlsxSheet{name: name}
+		if options.XlsxTotals {
+			group.totals = newXlsxTotals(fields)
+		}
+		return group
+	}
+
 	// Write data rows
 	logger.Debug("Starting to write XLSX rows...")
 
 	rowCount := 0
+	failed := 0 // rows left out by --on-error skip
 	lastLog := time.Now()
 
 	var sp *ui.Spinner
@@ -71,56 +140,103 @@ func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp.Start()
 	}
 
-	var sw *excelize.StreamWriter
-	var err error
-	var currentRow int
-	sheetIndex := 1
-
-	sw, currentRow, err = initSheet(columns, options.NoHeader, headerStyleID, f, sheetIndex)
-	if err != nil {
-		return 0, err
+	// groups holds the sheets in the order they were created; without
+	// XlsxSheetBy there is a single group, created before the first row so
+	// that an empty result still has a sheet
+	var groups []*xlsxSheet
+	byValue := make(map[xlsxSheetKey]*xlsxSheet)
+	if sheetBy < 0 {
+		group := newGroup("")
+		if err := book.nextSheet(group); err != nil {
+			return 0, err
+		}
+		groups = append(groups, group)
 	}
 
 	for rows.Next() {
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		if options.XlsxMaxRows > 0 && rowCount >= options.XlsxMaxRows {
+			return rowCount, fmt.Errorf("result exceeds --xlsx-max-rows (%d): the workbook is only written once every row has been read, use csv for large results",
+				options.XlsxMaxRows)
+		}
+
 		values, err := rows.Values()
 
 		if err != nil {
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
+		options.trimValues(values, fields)
 
 		//format values for excel
 		excelValues := make([]interface{}, len(values))
-		for i, v := range values {
-			excelValues[i] = formatters.FormatXLSXValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+		skipped, err := options.formatRow(rowCount+failed+1, func() error {
+			for i, v := range values {
+				excelValues[i] = formatters.FormatXLSXValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+				if text, ok := excelValues[i].(string); ok {
+					excelValues[i] = options.truncate(text, v, fields[i].DataTypeOID)
+				}
+				if styleID := columnStyles[i]; styleID != 0 {
+					excelValues[i] = excelize.Cell{StyleID: styleID, Value: excelValues[i]}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return rowCount, err
+		}
+		if skipped {
+			failed++
+			continue
 		}
 
-		if currentRow > maxRows {
-
-			if err := sw.Flush(); err != nil {
-				return rowCount, fmt.Errorf("error flushing sheet %d: %w", sheetIndex, err)
+		var group *xlsxSheet
+		if sheetBy < 0 {
+			group = groups[0]
+		} else {
+			key := sheetKey(values[sheetBy], fields[sheetBy].DataTypeOID, options)
+			if group = byValue[key]; group == nil {
+				group = newGroup(book.uniqueName(sheetName(key)))
+				if err := book.nextSheet(group); err != nil {
+					return rowCount, err
+				}
+				byValue[key] = group
+				groups = append(groups, group)
 			}
+		}
 
-			sheetIndex++
-			logger.Debug("Created new sheet Sheet%d (row limit reached)", sheetIndex)
+		if group.row > lastRow {
 
-			sw, currentRow, err = initSheet(columns, options.NoHeader, headerStyleID, f, sheetIndex)
-			if err != nil {
-				return 0, err
+			if group.totals != nil && options.XlsxSheetTotals {
+				if err := group.totals.writeRow(group.sw, group.row, totalStyles); err != nil {
+					return rowCount, err
+				}
+				group.totals.reset()
+			}
+
+			if err := group.sw.Flush(); err != nil {
+				return rowCount, fmt.Errorf("error flushing sheet %s: %w", group.sheet, err)
 			}
 
+			if err := book.nextSheet(group); err != nil {
+				return rowCount, err
+			}
+			logger.Debug("Created new sheet %s (row limit reached)", group.sheet)
 		}
 
-		cell, _ := excelize.CoordinatesToCellName(1, currentRow)
-		if err := sw.SetRow(cell, excelValues); err != nil {
-			return rowCount, fmt.Errorf("error writing row %d: %w", currentRow, err)
+		cell, _ := excelize.CoordinatesToCellName(1, group.row)
+		if err := group.sw.SetRow(cell, excelValues); err != nil {
+			return rowCount, fmt.Errorf("error writing row %d: %w", group.row, err)
 		}
 
+		group.totals.add(values)
+
 		rowCount++
-		currentRow++
+		group.row++
 
-		sp.Update(fmt.Sprintf("Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
 
 		// Log progress every 10000 rows
 		if rowCount%10000 == 0 {
@@ -133,22 +249,53 @@ func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
+	options.logSkippedRows(failed)
 
-	// Flush stream writer
-	if err := sw.Flush(); err != nil {
-		return rowCount, fmt.Errorf("error flushing stream: %w", err)
+	// An empty result split by value has no group yet
+	if len(groups) == 0 {
+		group := newGroup("")
+		if err := book.nextSheet(group); err != nil {
+			return rowCount, err
+		}
+		groups = append(groups, group)
+	}
+	if sheetBy >= 0 {
+		logger.Debug("XLSX rows split into %d sheet groups", len(groups))
+	}
+
+	// excelize keeps its default sheet until another one exists, so it is
+	// only removed here when no group uses the name
+	if !book.names["sheet1"] {
+		if err := f.DeleteSheet("Sheet1"); err != nil {
+			return rowCount, fmt.Errorf("error removing default sheet: %w", err)
+		}
+	}
+
+	for _, group := range groups {
+		if group.totals != nil {
+			if err := group.totals.writeRow(group.sw, group.row, totalStyles); err != nil {
+				return rowCount, err
+			}
+		}
+
+		// Flush stream writer
+		if err := group.sw.Flush(); err != nil {
+			return rowCount, fmt.Errorf("error flushing stream: %w", err)
+		}
 	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
 		Path:        options.OutputPath,
 		Compression: options.Compression,
 		Format:      options.Format,
+		Atomic:      options.Atomic,
+		Writer:      options.WriteCloser(),
 	})
 
 	if err != nil {
 		return rowCount, err
 	}
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
 	if err := f.Write(writerCloser); err != nil {
 		return rowCount, fmt.Errorf("error writing Excel file: %w", err)
@@ -159,14 +306,287 @@ func (e *xlsxExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		rowCount, elapsed.Seconds(), float64(rowCount)/elapsed.Seconds())
 
 	sp.Stop("Completed!")
+	output.Commit(writerCloser)
 	return rowCount, nil
 }
 
+// columnNumberFormats creates a style for each column with a custom number
+// format (e.g. "#,##0.00") and returns the style IDs by column position.
+// Columns without a format get 0 and keep the default formatting.
+func columnNumberFormats(f *excelize.File, columns []string, formats map[string]string) ([]int, error) {
+	styles := make([]int, len(columns))
+	if len(formats) == 0 {
+		return styles, nil
+	}
+
+	matched := make(map[string]bool, len(formats))
+	for i, col := range columns {
+		numFmt, ok := formats[col]
+		if !ok {
+			continue
+		}
+		styleID, err := f.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+		if err != nil {
+			return nil, fmt.Errorf("invalid number format %q for column %s: %w", numFmt, col, err)
+		}
+		styles[i] = styleID
+		matched[col] = true
+		logger.Debug("XLSX number format for column %s: %s", col, numFmt)
+	}
+
+	for col := range formats {
+		if !matched[col] {
+			logger.Warn("--xlsx-format column %s is not in the result", col)
+		}
+	}
+
+	return styles, nil
+}
+
+// totalsRowStyles creates the bold styles of the totals row, keeping the
+// custom number format of each column if one was given.
+func totalsRowStyles(f *excelize.File, columns []string, formats map[string]string) ([]int, error) {
+	boldID, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, fmt.Errorf("error creating totals style: %w", err)
+	}
+
+	styles := make([]int, len(columns))
+	for i, col := range columns {
+		styles[i] = boldID
+		if numFmt, ok := formats[col]; ok {
+			styleID, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &numFmt})
+			if err != nil {
+				return nil, fmt.Errorf("invalid number format %q for column %s: %w", numFmt, col, err)
+			}
+			styles[i] = styleID
+		}
+	}
+	return styles, nil
+}
+
+// xlsxTotals keeps the running sums of the numeric columns for the totals row.
+// Integer columns are summed exactly; a column switches to float64 as soon as
+// it holds a float or numeric value.
+type xlsxTotals struct {
+	numeric []bool
+	isFloat []bool
+	ints    []int64
+	floats  []float64
+}
+
+func newXlsxTotals(fields []pgconn.FieldDescription) *xlsxTotals {
+	t := &xlsxTotals{
+		numeric: make([]bool, len(fields)),
+		isFloat: make([]bool, len(fields)),
+		ints:    make([]int64, len(fields)),
+		floats:  make([]float64, len(fields)),
+	}
+	for i, fd := range fields {
+		switch fd.DataTypeOID {
+		case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID,
+			pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID, formatters.MoneyOID:
+			t.numeric[i] = true
+		}
+	}
+	return t
+}
+
+// add accumulates one row. NULL, NaN and infinite values are skipped.
+func (t *xlsxTotals) add(values []interface{}) {
+	if t == nil {
+		return
+	}
+	for i, v := range values {
+		if i >= len(t.numeric) || !t.numeric[i] {
+			continue
+		}
+		switch n := v.(type) {
+		case int16:
+			t.ints[i] += int64(n)
+		case int32:
+			t.ints[i] += int64(n)
+		case int64:
+			t.ints[i] += n
+		case float32:
+			if math.IsNaN(float64(n)) || math.IsInf(float64(n), 0) {
+				continue
+			}
+			t.floats[i] += float64(n)
+			t.isFloat[i] = true
+		case float64:
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				continue
+			}
+			t.floats[i] += n
+			t.isFloat[i] = true
+		case string: // money
+			text, ok := formatters.MoneyText(n)
+			if !ok {
+				continue
+			}
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				continue
+			}
+			t.floats[i] += f
+			t.isFloat[i] = true
+		case pgtype.Numeric:
+			f, err := n.Float64Value()
+			if err != nil || !f.Valid || math.IsNaN(f.Float64) || math.IsInf(f.Float64, 0) {
+				continue
+			}
+			t.floats[i] += f.Float64
+			t.isFloat[i] = true
+		}
+	}
+}
+
+// reset clears the sums, used when each sheet gets its own totals row.
+func (t *xlsxTotals) reset() {
+	for i := range t.numeric {
+		t.ints[i] = 0
+		t.floats[i] = 0
+		t.isFloat[i] = false
+	}
+}
+
+// writeRow writes the totals at row; non-numeric columns are left blank.
+func (t *xlsxTotals) writeRow(sw *excelize.StreamWriter, row int, styles []int) error {
+	cells := make([]interface{}, len(t.numeric))
+	for i := range t.numeric {
+		var value interface{}
+		if t.numeric[i] {
+			if t.isFloat[i] {
+				value = t.floats[i] + float64(t.ints[i])
+			} else {
+				value = t.ints[i]
+			}
+		}
+		cells[i] = excelize.Cell{StyleID: styles[i], Value: value}
+	}
+
+	cell, _ := excelize.CoordinatesToCellName(1, row)
+	if err := sw.SetRow(cell, cells); err != nil {
+		return fmt.Errorf("error writing totals row: %w", err)
+	}
+	logger.Debug("XLSX totals row written at row %d", row)
+	return nil
+}
+
+// xlsxSheetNameMax is the maximum length of an Excel sheet name.
+const xlsxSheetNameMax = 31
+
+// xlsxSheet is a group of sheets receiving rows: the whole result, or with
+// XlsxSheetBy the rows of one value. A group continues on a new sheet when
+// its current sheet is full.
+type xlsxSheet struct {
+	name   string // first sheet name, empty for Sheet1, Sheet2, ...
+	count  int    // sheets created for the group
+	sheet  string // current sheet name
+	sw     *excelize.StreamWriter
+	row    int // next row of the current sheet
+	totals *xlsxTotals
+}
+
+// xlsxSheetKey identifies the group of an XlsxSheetBy value. NULL is kept
+// apart from the text "NULL".
+type xlsxSheetKey struct {
+	null bool
+	text string
+}
+
+// xlsxWorkbook creates the sheets of an export and keeps their names unique.
+type xlsxWorkbook struct {
+	f             *excelize.File
+	headers       []string
+	noHeader      bool
+	headerStyleID int
+	names         map[string]bool // sheet names in use, lower-cased as Excel compares them
+}
+
+// nextSheet starts the next sheet of group: its first one, or the one after
+// an overflow. Overflow sheets of a named group get a " (2)", " (3)", ...
+// suffix.
+func (b *xlsxWorkbook) nextSheet(group *xlsxSheet) error {
+	group.count++
+	name := fmt.Sprintf("Sheet%d", group.count)
+	if group.name != "" {
+		name = group.name
+		if group.count > 1 {
+			name = b.uniqueName(withSuffix(group.name, group.count))
+		}
+	}
+	b.names[strings.ToLower(name)] = true
+
+	sw, row, err := initSheet(b.headers, b.noHeader, b.headerStyleID, b.f, name)
+	if err != nil {
+		return err
+	}
+	group.sheet, group.sw, group.row = name, sw, row
+	return nil
+}
+
+// uniqueName returns name, or name with a " (2)", " (3)", ... suffix when
+// another sheet already has it.
+func (b *xlsxWorkbook) uniqueName(name string) string {
+	candidate := name
+	for n := 2; b.names[strings.ToLower(candidate)]; n++ {
+		candidate = withSuffix(name, n)
+	}
+	return candidate
+}
+
+// sheetKey returns the group of an XlsxSheetBy value, formatted as in CSV.
+func sheetKey(v any, oid uint32, options ExportOptions) xlsxSheetKey {
+	if v == nil {
+		return xlsxSheetKey{null: true}
+	}
+	return xlsxSheetKey{text: formatters.FormatCSVValue(v, oid, options.TimeFormat, options.TimeZone)}
+}
+
+// sheetName turns a value into a valid Excel sheet name: the characters
+// : \ / ? * [ ] become '_', leading and trailing apostrophes are removed and
+// the name is cut to 31 characters. NULL gives "NULL", an empty value "(blank)".
+func sheetName(key xlsxSheetKey) string {
+	if key.null {
+		return "NULL"
+	}
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, key.text)
+	name = strings.Trim(name, "'")
+	if runes := []rune(name); len(runes) > xlsxSheetNameMax {
+		name = strings.TrimRight(string(runes[:xlsxSheetNameMax]), "'")
+	}
+	if strings.TrimSpace(name) == "" {
+		return "(blank)"
+	}
+	// Excel reserves History for its change tracking sheet
+	if strings.EqualFold(name, "History") {
+		return name + "_"
+	}
+	return name
+}
+
+// withSuffix appends " (n)" to name, cutting name so the result still fits
+// in a sheet name.
+func withSuffix(name string, n int) string {
+	suffix := fmt.Sprintf(" (%d)", n)
+	runes := []rune(name)
+	if max := xlsxSheetNameMax - len(suffix); len(runes) > max {
+		runes = runes[:max]
+	}
+	return string(runes) + suffix
+}
+
 // initSheet initializes a new Excel sheet with optional headers.
 // Returns a stream writer, the starting row number, and an error if initialization fails.
-func initSheet(columns []string, noHeader bool, headerStyleID int, f *excelize.File, sheetIndex int) (*excelize.StreamWriter, int, error) {
+func initSheet(columns []string, noHeader bool, headerStyleID int, f *excelize.File, sheetName string) (*excelize.StreamWriter, int, error) {
 
-	sheetName := fmt.Sprintf("Sheet%d", sheetIndex)
 	currentRow := 1
 	if _, err := f.NewSheet(sheetName); err != nil {
 		return nil, currentRow, fmt.Errorf("failed to create new sheet: %w", err)
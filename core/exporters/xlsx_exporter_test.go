@@ -2,13 +2,17 @@ package exporters
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -620,3 +624,471 @@ func BenchmarkExportXLSX(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestExportXLSXNumberFormats(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+	rows := newFakeRows(
+		[]string{"id", "amount", "rate"},
+		[]uint32{pgtype.Int4OID, pgtype.Float8OID, pgtype.Float8OID},
+		[][]any{{int32(1), 1234.5, 0.0425}, {int32(2), 99.99, 0.1}},
+	)
+
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:      FormatXLSX,
+		Compression: "none",
+		OutputPath:  outputPath,
+		XlsxFormats: map[string]string{"amount": "#,##0.00", "rate": "0.0000%"},
+	}
+
+	if _, err := exporter.Export(rows, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX file: %v", err)
+	}
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	expected := map[string]string{
+		"B2": "#,##0.00",
+		"B3": "#,##0.00",
+		"C2": "0.0000%",
+		"C3": "0.0000%",
+	}
+	for cell, want := range expected {
+		styleID, err := f.GetCellStyle(sheet, cell)
+		if err != nil {
+			t.Fatalf("GetCellStyle(%s) error: %v", cell, err)
+		}
+		style, err := f.GetStyle(styleID)
+		if err != nil {
+			t.Fatalf("GetStyle(%d) error: %v", styleID, err)
+		}
+		if style.CustomNumFmt == nil || *style.CustomNumFmt != want {
+			t.Errorf("%s number format = %v, want %q", cell, style.CustomNumFmt, want)
+		}
+	}
+
+	// Unlisted columns keep the default style
+	if styleID, _ := f.GetCellStyle(sheet, "A2"); styleID != 0 {
+		t.Errorf("A2 style = %d, want default style 0", styleID)
+	}
+
+	// Values are still written as numbers
+	if value, _ := f.GetCellValue(sheet, "B2", excelize.Options{RawCellValue: true}); value != "1234.5" {
+		t.Errorf("B2 raw value = %q, want 1234.5", value)
+	}
+	if value, _ := f.GetCellValue(sheet, "B2"); value != "1,234.50" {
+		t.Errorf("B2 formatted value = %q, want 1,234.50", value)
+	}
+}
+
+func TestExportXLSXTotals(t *testing.T) {
+	data := [][]any{
+		{int32(1), "a", int64(10), 1.5},
+		{int32(2), "b", int64(20), nil},
+		{int32(3), "c", nil, 2.25},
+		{int32(4), "d", int64(30), 0.25},
+		{int32(5), "e", int64(40), 1.0},
+	}
+
+	tests := []struct {
+		name        string
+		maxRows     int
+		perSheet    bool
+		wantTotals  map[string][]string // sheet -> totals row (raw values)
+		wantNoTotal []string            // sheets without a totals row
+	}{
+		{
+			name:       "single sheet",
+			wantTotals: map[string][]string{"Sheet1": {"15", "", "100", "5"}},
+		},
+		{
+			// 3 rows per sheet: header + 2 data rows, the last row is kept for totals
+			name:        "overflow totals on last sheet only",
+			maxRows:     4,
+			wantTotals:  map[string][]string{"Sheet3": {"15", "", "100", "5"}},
+			wantNoTotal: []string{"Sheet1", "Sheet2"},
+		},
+		{
+			name:     "overflow totals per sheet",
+			maxRows:  4,
+			perSheet: true,
+			wantTotals: map[string][]string{
+				"Sheet1": {"3", "", "30", "1.5"},
+				"Sheet2": {"7", "", "30", "2.5"},
+				"Sheet3": {"5", "", "40", "1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxRows > 0 {
+				defer func(prev int) { xlsxMaxRows = prev }(xlsxMaxRows)
+				xlsxMaxRows = tt.maxRows
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+			rows := newFakeRows(
+				[]string{"id", "name", "qty", "price"},
+				[]uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.Int8OID, pgtype.Float8OID},
+				data,
+			)
+
+			exporter, err := Get(FormatXLSX)
+			if err != nil {
+				t.Fatalf("Failed to get xlsx exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatXLSX,
+				Compression:     "none",
+				OutputPath:      outputPath,
+				XlsxTotals:      true,
+				XlsxSheetTotals: tt.perSheet,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != len(data) {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, len(data))
+			}
+
+			f, err := excelize.OpenFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to open XLSX file: %v", err)
+			}
+			defer f.Close()
+
+			for sheet, want := range tt.wantTotals {
+				sheetRows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+				if err != nil {
+					t.Fatalf("GetRows(%s) error: %v", sheet, err)
+				}
+				last := sheetRows[len(sheetRows)-1]
+				for len(last) < len(want) {
+					last = append(last, "")
+				}
+				if !slices.Equal(last, want) {
+					t.Errorf("%s totals row = %v, want %v", sheet, last, want)
+				}
+
+				cell, _ := excelize.CoordinatesToCellName(1, len(sheetRows))
+				styleID, _ := f.GetCellStyle(sheet, cell)
+				style, err := f.GetStyle(styleID)
+				if err != nil || style.Font == nil || !style.Font.Bold {
+					t.Errorf("%s totals cell %s should be bold", sheet, cell)
+				}
+			}
+
+			for _, sheet := range tt.wantNoTotal {
+				sheetRows, err := f.GetRows(sheet)
+				if err != nil {
+					t.Fatalf("GetRows(%s) error: %v", sheet, err)
+				}
+				if len(sheetRows) != 3 {
+					t.Errorf("%s has %d rows, want header + 2 data rows", sheet, len(sheetRows))
+				}
+			}
+		})
+	}
+}
+
+func TestExportXLSXMaxRows(t *testing.T) {
+	data := [][]any{{int32(1)}, {int32(2)}, {int32(3)}}
+
+	tests := []struct {
+		name     string
+		maxRows  int
+		wantErr  bool
+		wantRows int
+	}{
+		{name: "unlimited", maxRows: 0, wantRows: 3},
+		{name: "exactly at limit", maxRows: 3, wantRows: 3},
+		{name: "over limit", maxRows: 2, wantErr: true, wantRows: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data)
+
+			exporter, err := Get(FormatXLSX)
+			if err != nil {
+				t.Fatalf("Failed to get xlsx exporter: %v", err)
+			}
+			rowCount, err := exporter.Export(rows, ExportOptions{
+				Format:      FormatXLSX,
+				Compression: "none",
+				OutputPath:  outputPath,
+				XlsxMaxRows: tt.maxRows,
+			})
+
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "exceeds --xlsx-max-rows (2)") {
+					t.Fatalf("Export() error = %v, want --xlsx-max-rows error", err)
+				}
+				if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+					t.Errorf("no output file should be created when the limit is exceeded")
+				}
+			} else if err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+			if rowCount != tt.wantRows {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, tt.wantRows)
+			}
+		})
+	}
+}
+
+// BenchmarkExportXLSXMemory reports the peak heap used by an XLSX export.
+// excelize keeps each sheet in memory up to 16 MiB and spills the rest to a
+// temporary file, so the peak grows much more slowly than the row count.
+func BenchmarkExportXLSXMemory(b *testing.B) {
+	names := []string{"id", "name", "email", "amount", "created_at"}
+	oids := []uint32{pgtype.Int8OID, pgtype.TextOID, pgtype.TextOID, pgtype.Float8OID, pgtype.TimestampOID}
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		b.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+
+	for _, n := range []int{50_000, 200_000} {
+		data := make([][]any, n)
+		for i := range data {
+			data[i] = []any{int64(i), "Jane Doe", "jane@example.com", float64(i) * 1.5, created}
+		}
+
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			options := ExportOptions{
+				Format:      FormatXLSX,
+				Compression: "none",
+				TimeFormat:  "yyyy-MM-dd HH:mm:ss",
+				OutputPath:  filepath.Join(b.TempDir(), "bench.xlsx"),
+			}
+
+			// The row data is measured in the baseline, so only the export's own heap is reported
+			var baseline, peak uint64
+			var ms runtime.MemStats
+			sample := func() {
+				runtime.ReadMemStats(&ms)
+				peak = max(peak, ms.HeapInuse-baseline)
+			}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&ms)
+				baseline = ms.HeapInuse
+				rows := newFakeRows(names, oids, data)
+				rows.onNext = func(pos int) {
+					if pos%10_000 == 0 {
+						sample()
+					}
+				}
+				if _, err := exporter.Export(rows, options); err != nil {
+					b.Fatalf("Export() error = %v", err)
+				}
+				sample()
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MiB")
+		})
+	}
+}
+
+func TestExportXLSXSheetBy(t *testing.T) {
+	names := []string{"id", "category", "qty"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.Int8OID}
+	data := [][]any{
+		{int32(1), "books", int64(10)},
+		{int32(2), "games", int64(20)},
+		{int32(3), "books", int64(30)},
+		{int32(4), nil, int64(40)},
+		{int32(5), "books", int64(50)},
+	}
+
+	tests := []struct {
+		name       string
+		maxRows    int
+		totals     bool
+		wantSheets []string
+		wantRows   map[string][][]string // sheet -> rows including the header
+	}{
+		{
+			name:       "one sheet per value in first seen order",
+			wantSheets: []string{"books", "games", "NULL"},
+			wantRows: map[string][][]string{
+				"books": {{"id", "category", "qty"}, {"1", "books", "10"}, {"3", "books", "30"}, {"5", "books", "50"}},
+				"games": {{"id", "category", "qty"}, {"2", "games", "20"}},
+				"NULL":  {{"id", "category", "qty"}, {"4", "", "40"}},
+			},
+		},
+		{
+			// 3 rows per sheet: header + 2 data rows
+			name:       "overflow continues the value on a numbered sheet",
+			maxRows:    3,
+			wantSheets: []string{"books", "games", "NULL", "books (2)"},
+			wantRows: map[string][][]string{
+				"books":     {{"id", "category", "qty"}, {"1", "books", "10"}, {"3", "books", "30"}},
+				"books (2)": {{"id", "category", "qty"}, {"5", "books", "50"}},
+			},
+		},
+		{
+			name:       "totals per value",
+			totals:     true,
+			wantSheets: []string{"books", "games", "NULL"},
+			wantRows: map[string][][]string{
+				"books": {{"id", "category", "qty"}, {"1", "books", "10"}, {"3", "books", "30"}, {"5", "books", "50"}, {"9", "", "90"}},
+				"games": {{"id", "category", "qty"}, {"2", "games", "20"}, {"2", "", "20"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxRows > 0 {
+				defer func(prev int) { xlsxMaxRows = prev }(xlsxMaxRows)
+				xlsxMaxRows = tt.maxRows
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+			exporter, err := Get(FormatXLSX)
+			if err != nil {
+				t.Fatalf("Failed to get xlsx exporter: %v", err)
+			}
+			rowCount, err := exporter.Export(newFakeRows(names, oids, data), ExportOptions{
+				Format:      FormatXLSX,
+				Compression: "none",
+				OutputPath:  outputPath,
+				XlsxSheetBy: "category",
+				XlsxTotals:  tt.totals,
+			})
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != len(data) {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, len(data))
+			}
+
+			f, err := excelize.OpenFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to open XLSX file: %v", err)
+			}
+			defer f.Close()
+
+			if got := f.GetSheetList(); !slices.Equal(got, tt.wantSheets) {
+				t.Errorf("sheets = %v, want %v", got, tt.wantSheets)
+			}
+			for sheet, want := range tt.wantRows {
+				got, err := f.GetRows(sheet)
+				if err != nil {
+					t.Fatalf("GetRows(%s) error: %v", sheet, err)
+				}
+				if len(got) != len(want) {
+					t.Fatalf("%s rows = %v, want %v", sheet, got, want)
+				}
+				for i := range want {
+					row := got[i]
+					for len(row) < len(want[i]) {
+						row = append(row, "")
+					}
+					if !slices.Equal(row, want[i]) {
+						t.Errorf("%s row %d = %v, want %v", sheet, i+1, row, want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExportXLSXSheetByErrors(t *testing.T) {
+	exporter, err := Get(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Failed to get xlsx exporter: %v", err)
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}})
+		_, err := exporter.Export(rows, ExportOptions{
+			Format:      FormatXLSX,
+			Compression: "none",
+			OutputPath:  filepath.Join(t.TempDir(), "output.xlsx"),
+			XlsxSheetBy: "category",
+		})
+		if err == nil || !strings.Contains(err.Error(), `--xlsx-sheet-by column "category" not found`) {
+			t.Fatalf("Export() error = %v, want column not found", err)
+		}
+	})
+
+	t.Run("empty result keeps one sheet", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "output.xlsx")
+		rows := newFakeRows([]string{"category"}, []uint32{pgtype.TextOID}, nil)
+		if _, err := exporter.Export(rows, ExportOptions{
+			Format:      FormatXLSX,
+			Compression: "none",
+			OutputPath:  outputPath,
+			XlsxSheetBy: "category",
+		}); err != nil {
+			t.Fatalf("Export() error: %v", err)
+		}
+		f, err := excelize.OpenFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to open XLSX file: %v", err)
+		}
+		defer f.Close()
+		if got := f.GetSheetList(); !slices.Equal(got, []string{"Sheet1"}) {
+			t.Errorf("sheets = %v, want [Sheet1]", got)
+		}
+	})
+}
+
+func TestSheetName(t *testing.T) {
+	tests := []struct {
+		name string
+		key  xlsxSheetKey
+		want string
+	}{
+		{name: "plain", key: xlsxSheetKey{text: "books"}, want: "books"},
+		{name: "NULL", key: xlsxSheetKey{null: true}, want: "NULL"},
+		{name: "empty", key: xlsxSheetKey{text: ""}, want: "(blank)"},
+		{name: "spaces", key: xlsxSheetKey{text: "   "}, want: "(blank)"},
+		{name: "invalid characters", key: xlsxSheetKey{text: "a/b\\c:d?e*f[g]"}, want: "a_b_c_d_e_f_g_"},
+		{name: "apostrophes at the ends", key: xlsxSheetKey{text: "'quoted'"}, want: "quoted"},
+		{name: "reserved name", key: xlsxSheetKey{text: "history"}, want: "history_"},
+		{name: "cut to 31 characters", key: xlsxSheetKey{text: strings.Repeat("é", 40)}, want: strings.Repeat("é", 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sheetName(tt.key); got != tt.want {
+				t.Errorf("sheetName(%+v) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXWorkbookUniqueName(t *testing.T) {
+	book := &xlsxWorkbook{names: map[string]bool{"books": true, "books (2)": true}}
+	if got := book.uniqueName("Books"); got != "Books (3)" {
+		t.Errorf("uniqueName(Books) = %q, want %q", got, "Books (3)")
+	}
+	if got := book.uniqueName("games"); got != "games" {
+		t.Errorf("uniqueName(games) = %q, want %q", got, "games")
+	}
+
+	long := strings.Repeat("x", 31)
+	book.names[long] = true
+	want := strings.Repeat("x", 27) + " (2)"
+	if got := book.uniqueName(long); got != want {
+		t.Errorf("uniqueName(31 characters) = %q, want %q", got, want)
+	}
+}
@@ -1,9 +1,17 @@
 package exporters
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/core/output"
@@ -14,45 +22,118 @@ import (
 
 type xmlExporter struct{}
 
-// Export writes query results to an XML file with buffered I/O.
-func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
+// XML NULL handling modes
+const (
+	XmlNullEmpty  = "empty"   // empty element, like an empty string (default)
+	XmlNullXsiNil = "xsi-nil" // empty element with xsi:nil="true"
+	XmlNullOmit   = "omit"    // no element
+)
+
+// xsiNamespace is the XML Schema instance namespace, which defines xsi:nil.
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// Export writes query results to an XML file with buffered I/O. Elements are
+// indented unless Compact is set, which writes each row on a single line.
+// NULL columns are written according to XmlNull; xsi-nil declares the xsi
+// namespace on the root element unless XmlRootAttrs already does.
+func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 
 	start := time.Now()
-	logger.Debug("Preparing XML export (indent=2 spaces, compression=%s)", options.Compression)
+	logger.Debug("Preparing XML export (compact=%v, compression=%s)", options.Compact, options.Compression)
+
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, false)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := HeaderNames(columns, options, false)
+	if err != nil {
+		return 0, err
+	}
+	if keys, err = xmlElementNames(keys, &options); err != nil {
+		return 0, err
+	}
+
+	// The row count is patched into the file once all rows are written
+	if options.XmlRowCountAttr != "" && options.Writer != nil {
+		return 0, fmt.Errorf("XML row count attribute requires a file output, not a writer")
+	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
-		Path:        options.OutputPath,
-		Compression: options.Compression,
-		Format:      options.Format,
+		Path:           options.OutputPath,
+		Compression:    options.Compression,
+		Format:         options.Format,
+		Encoding:       options.OutputEncoding,
+		EncodingStrict: options.EncodingStrict,
+		Atomic:         options.Atomic,
+		Writer:         options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
-	// Encode to XML with indentation
+	// Encode to XML with indentation, or with only a line break between rows when compact
 	encoder := xml.NewEncoder(writerCloser)
-	encoder.Indent("", "  ")
+	if !options.Compact {
+		encoder.Indent("", "  ")
+	}
+	lineBreak := func() error {
+		if !options.Compact {
+			return nil
+		}
+		return encoder.EncodeToken(xml.CharData("\n"))
+	}
 
-	// Write XML header
-	if _, err := writerCloser.Write([]byte(xml.Header)); err != nil {
+	// Write XML header, declaring the output encoding when it is not UTF-8
+	header := xml.Header
+	if label := output.EncodingLabel(options.OutputEncoding); label != "UTF-8" {
+		header = fmt.Sprintf("<?xml version=\"1.0\" encoding=\"%s\"?>\n", label)
+	}
+	if _, err := writerCloser.Write([]byte(header)); err != nil {
 		return 0, fmt.Errorf("error writing XML header: %w", err)
 	}
 
 	logger.Debug("XML header written")
 
+	if options.GeneratedComment {
+		if err := encoder.EncodeToken(xml.Comment(xmlComment(options))); err != nil {
+			return 0, fmt.Errorf("error writing generated comment: %w", err)
+		}
+		if err := lineBreak(); err != nil {
+			return 0, fmt.Errorf("error writing generated comment: %w", err)
+		}
+	}
+
 	// get fields names
 	fields := rows.FieldDescriptions()
-	keys := make([]string, len(fields))
-	for i, fd := range fields {
-		keys[i] = string(fd.Name)
+
+	if options.XmlRowCountAttr != "" && !strings.EqualFold(options.Compression, output.None) {
+		return 0, fmt.Errorf("XML row count attribute requires uncompressed output")
 	}
 
-	startResults := xml.StartElement{Name: xml.Name{Local: options.XmlRootElement}}
+	startResults := xml.StartElement{
+		Name: xml.Name{Local: options.XmlRootElement},
+		Attr: append([]xml.Attr(nil), options.XmlRootAttrs...),
+	}
+	if options.XmlNull == XmlNullXsiNil && !slices.ContainsFunc(startResults.Attr, func(a xml.Attr) bool {
+		return a.Name.Space == "" && a.Name.Local == "xmlns:xsi"
+	}) {
+		startResults.Attr = append(startResults.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace})
+	}
+	if options.XmlRowCountAttr != "" {
+		// Placeholder rewritten with the real count once all rows are written
+		startResults.Attr = append(startResults.Attr, xml.Attr{
+			Name:  xml.Name{Local: options.XmlRowCountAttr},
+			Value: rowCountPlaceholder,
+		})
+	}
 	if err := encoder.EncodeToken(startResults); err != nil {
 		return 0, fmt.Errorf("error starting <%s>: %w", options.XmlRootElement, err)
 	}
+	if err := lineBreak(); err != nil {
+		return 0, fmt.Errorf("error starting <%s>: %w", options.XmlRootElement, err)
+	}
 
 	rowCount := 0
 
@@ -65,10 +146,33 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp.Start()
 	}
 
+	failed := 0 // rows left out by --on-error skip
+	texts := make([]string, len(fields))
+	reader := newRowReader(rows)
 	for rows.Next() {
-		values, err := rows.Values()
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		values, err := reader.Values()
+		if err != nil {
+			return rowCount, fmt.Errorf("error reading row: %w", err)
+		}
+		options.trimValues(values, fields)
+
+		// Every value is formatted before the row is written
+		skipped, err := options.formatRow(rowCount+failed+1, func() error {
+			for i, v := range values {
+				texts[i] = formatters.FormatXMLValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+			}
+			return nil
+		})
 		if err != nil {
-			return 0, fmt.Errorf("error reading row: %w", err)
+			return rowCount, err
+		}
+		if skipped {
+			failed++
+			continue
 		}
 
 		startRow := xml.StartElement{Name: xml.Name{Local: options.XmlRowElement}}
@@ -79,7 +183,20 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 
 		for i, field := range keys {
 			elem := xml.StartElement{Name: xml.Name{Local: field}}
-			val := formatters.FormatXMLValue(values[i], fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+			if values[i] == nil && options.XmlNull == XmlNullOmit {
+				continue
+			}
+			if values[i] == nil && options.XmlNull == XmlNullXsiNil {
+				elem.Attr = []xml.Attr{{Name: xml.Name{Local: "xsi:nil"}, Value: "true"}}
+				if err := encoder.EncodeToken(elem); err != nil {
+					return rowCount, fmt.Errorf("error opening <%s>: %w", field, err)
+				}
+				if err := encoder.EncodeToken(xml.EndElement{Name: elem.Name}); err != nil {
+					return rowCount, fmt.Errorf("error closing </%s>: %w", field, err)
+				}
+				continue
+			}
+			val := texts[i]
 			if val == "" {
 				if err := encoder.EncodeToken(xml.StartElement{Name: elem.Name}); err != nil {
 					return rowCount, fmt.Errorf("error opening <%s>: %w", field, err)
@@ -94,6 +211,10 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 				if err := encoder.EncodeToken(elem); err != nil {
 					return rowCount, fmt.Errorf("error opening <%s>: %w", field, err)
 				}
+				// The raw value bypasses the encoder, so its buffered start tag goes first
+				if err := encoder.Flush(); err != nil {
+					return rowCount, fmt.Errorf("error flushing XML encoder: %w", err)
+				}
 				if _, err := writerCloser.Write([]byte(val)); err != nil {
 					return rowCount, fmt.Errorf("error writing raw value for <%s>: %w", field, err)
 				}
@@ -112,11 +233,22 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		if err := encoder.EncodeToken(xml.EndElement{Name: startRow.Name}); err != nil {
 			return rowCount, fmt.Errorf("error closing </%s>: %w", options.XmlRowElement, err)
 		}
+		if err := lineBreak(); err != nil {
+			return rowCount, fmt.Errorf("error closing </%s>: %w", options.XmlRowElement, err)
+		}
 
 		rowCount++
-		sp.Update(fmt.Sprintf("Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+
+		if options.FlushEvery > 0 && rowCount%options.FlushEvery == 0 {
+			if err := encoder.Flush(); err != nil {
+				return rowCount, fmt.Errorf("error flushing XML encoder: %w", err)
+			}
+			if err := output.Flush(writerCloser); err != nil {
+				return rowCount, fmt.Errorf("error flushing output: %w", err)
+			}
+		}
+
+		sp.Update(ui.ProgressMessage("Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
 
 		if rowCount%10000 == 0 {
 			logger.Debug("%d XML rows written...", rowCount)
@@ -127,18 +259,36 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
+	options.logSkippedRows(failed)
 
 	if err := encoder.EncodeToken(xml.EndElement{Name: startResults.Name}); err != nil {
-		return 0, fmt.Errorf("error ending </%s>: %w", options.XmlRootElement, err)
+		return rowCount, fmt.Errorf("error ending </%s>: %w", options.XmlRootElement, err)
 	}
 
 	if err := encoder.Flush(); err != nil {
-		return 0, fmt.Errorf("error flushing XML encoder: %w", err)
+		return rowCount, fmt.Errorf("error flushing XML encoder: %w", err)
 	}
 
 	// Add final newline
 	if _, err := writerCloser.Write([]byte("\n")); err != nil {
-		return 0, fmt.Errorf("error writing final newline: %w", err)
+		return rowCount, fmt.Errorf("error writing final newline: %w", err)
+	}
+
+	if options.EmitSchema {
+		if err := writeSchema(keys, fields, options); err != nil {
+			return rowCount, err
+		}
+	}
+
+	output.Commit(writerCloser)
+
+	if options.XmlRowCountAttr != "" {
+		if err := writerCloser.Close(); err != nil {
+			return rowCount, fmt.Errorf("error closing XML output: %w", err)
+		}
+		if err := patchRowCountAttr(options.OutputPath, options.XmlRowCountAttr, rowCount); err != nil {
+			return rowCount, err
+		}
 	}
 
 	logger.Debug("XML export completed successfully: %d rows written in %v", rowCount, time.Since(start))
@@ -147,6 +297,114 @@ func (e *xmlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	return rowCount, nil
 }
 
+// rowCountPlaceholder reserves room for any int64 row count in the root element.
+var rowCountPlaceholder = strings.Repeat("0", 20)
+
+// patchRowCountAttr rewrites the row count placeholder of the root element in
+// place. The count is written inside the quotes and the remaining width is
+// padded with spaces after the closing quote, which keeps the XML valid
+// without shifting the rest of the file.
+func patchRowCountAttr(path, attr string, rowCount int) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("error reopening XML output: %w", err)
+	}
+	defer file.Close()
+
+	// The root element is at the top of the file; its start tag is bounded by
+	// the header, root name and attributes.
+	head := make([]byte, 64*1024)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error reading XML output: %w", err)
+	}
+	head = head[:n]
+
+	placeholder := []byte(attr + `="` + rowCountPlaceholder + `"`)
+	offset := bytes.Index(head, placeholder)
+	if offset < 0 {
+		return fmt.Errorf("row count placeholder not found in XML output")
+	}
+
+	value := attr + `="` + strconv.Itoa(rowCount) + `"`
+	value += strings.Repeat(" ", len(placeholder)-len(value))
+
+	if _, err := file.WriteAt([]byte(value), int64(offset)); err != nil {
+		return fmt.Errorf("error writing XML row count: %w", err)
+	}
+	return nil
+}
+
 func init() {
 	MustRegister(FormatXML, func() Exporter { return &xmlExporter{} })
 }
+
+// xmlNCName matches XML element names without a namespace prefix: a letter
+// or underscore, then letters, digits, underscores, hyphens and dots.
+var xmlNCName = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_.\-]*$`)
+
+// ValidXMLName reports whether name can be used as an XML element name.
+func ValidXMLName(name string) bool {
+	return xmlNCName.MatchString(name)
+}
+
+// SanitizeXMLName turns name into a valid XML element name: characters that
+// are not allowed become underscores, and a name that does not start with a
+// letter or underscore gets one in front, so "2024 total" becomes
+// "_2024_total".
+func SanitizeXMLName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+		default:
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// xmlElementNames checks the root, row and column element names, or replaces
+// their invalid characters when options.XmlSanitizeNames is set. The root and
+// row names are updated in options.
+func xmlElementNames(columns []string, options *ExportOptions) ([]string, error) {
+	hint := " (use --xml-sanitize-names to replace invalid characters)"
+	tags := []struct {
+		flag string
+		name *string
+	}{
+		{"--xml-root-tag", &options.XmlRootElement},
+		{"--xml-row-tag", &options.XmlRowElement},
+	}
+	for _, tag := range tags {
+		if ValidXMLName(*tag.name) {
+			continue
+		}
+		if !options.XmlSanitizeNames {
+			return nil, fmt.Errorf("%s %q is not a valid XML element name%s", tag.flag, *tag.name, hint)
+		}
+		*tag.name = SanitizeXMLName(*tag.name)
+	}
+
+	names := make([]string, len(columns))
+	for i, name := range columns {
+		if ValidXMLName(name) {
+			names[i] = name
+			continue
+		}
+		if !options.XmlSanitizeNames {
+			return nil, fmt.Errorf("column %q is not a valid XML element name%s", name, hint)
+		}
+		names[i] = SanitizeXMLName(name)
+		logger.Debug("XML element name %q written as <%s>", name, names[i])
+	}
+	return names, nil
+}
@@ -3,6 +3,7 @@ package exporters
 import (
 	"context"
 	"encoding/xml"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportXML(t *testing.T) {
@@ -710,3 +712,475 @@ func BenchmarkExportXML(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestExportFlushEvery(t *testing.T) {
+	tests := []struct {
+		format     string
+		flushEvery int
+	}{
+		{FormatXML, 1},
+		{FormatXML, 2},
+		{FormatCSV, 1},
+		{FormatCSV, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/every_%d", tt.format, tt.flushEvery), func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+
+			data := make([][]any, 5)
+			for i := range data {
+				data[i] = []any{int32(i + 1), fmt.Sprintf("name-%d", i+1)}
+			}
+			rows := newFakeRows([]string{"id", "name"}, []uint32{pgtype.Int4OID, pgtype.TextOID}, data)
+
+			// Before returning row pos, every row already written and covered by
+			// a flush boundary must be readable from disk.
+			rows.onNext = func(pos int) {
+				flushed := (pos / tt.flushEvery) * tt.flushEvery
+				if flushed == 0 {
+					return
+				}
+				content, err := os.ReadFile(outputPath)
+				if err != nil {
+					t.Errorf("row %d: failed to read partial output: %v", pos, err)
+					return
+				}
+				want := fmt.Sprintf("name-%d", flushed)
+				if !strings.Contains(string(content), want) {
+					t.Errorf("row %d: partial output should contain %q, got %q", pos, want, content)
+				}
+			}
+
+			exporter, err := Get(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to get exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:         tt.format,
+				Delimiter:      ',',
+				Compression:    "none",
+				OutputPath:     outputPath,
+				XmlRootElement: "results",
+				XmlRowElement:  "row",
+				FlushEvery:     tt.flushEvery,
+			}
+
+			rowCount, err := exporter.Export(rows, options)
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if rowCount != len(data) {
+				t.Errorf("Export() rowCount = %d, want %d", rowCount, len(data))
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if tt.format == FormatXML {
+				var parsed struct {
+					Rows []struct {
+						ID   int    `xml:"id"`
+						Name string `xml:"name"`
+					} `xml:"row"`
+				}
+				if err := xml.Unmarshal(content, &parsed); err != nil {
+					t.Fatalf("final XML is invalid: %v", err)
+				}
+				if len(parsed.Rows) != len(data) {
+					t.Errorf("final XML has %d rows, want %d", len(parsed.Rows), len(data))
+				}
+			} else if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != len(data)+1 {
+				t.Errorf("final CSV has %d lines, want %d", len(lines), len(data)+1)
+			}
+		})
+	}
+}
+
+func TestWriteXMLRootAttributes(t *testing.T) {
+	tests := []struct {
+		name      string
+		rowCount  int
+		attrs     []xml.Attr
+		countAttr string
+	}{
+		{
+			name:     "schema attributes",
+			rowCount: 2,
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "xmlns:xsi"}, Value: "http://www.w3.org/2001/XMLSchema-instance"},
+				{Name: xml.Name{Local: "xsi:noNamespaceSchemaLocation"}, Value: "results.xsd"},
+			},
+		},
+		{
+			name:      "row count attribute",
+			rowCount:  3,
+			attrs:     []xml.Attr{{Name: xml.Name{Local: "source"}, Value: `a&b "quoted"`}},
+			countAttr: "count",
+		},
+		{
+			name:      "row count attribute on empty result",
+			rowCount:  0,
+			countAttr: "total",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.xml")
+
+			data := make([][]any, tt.rowCount)
+			for i := range data {
+				data[i] = []any{int32(i + 1)}
+			}
+			rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, data)
+
+			exporter, err := Get(FormatXML)
+			if err != nil {
+				t.Fatalf("Failed to get xml exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:          FormatXML,
+				Compression:     "none",
+				OutputPath:      outputPath,
+				XmlRootElement:  "results",
+				XmlRowElement:   "row",
+				XmlRootAttrs:    tt.attrs,
+				XmlRowCountAttr: tt.countAttr,
+			}
+
+			if _, err := exporter.Export(rows, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+
+			// Read the root start element and its raw attributes
+			decoder := xml.NewDecoder(strings.NewReader(string(content)))
+			var root xml.StartElement
+			for {
+				tok, err := decoder.RawToken()
+				if err != nil {
+					t.Fatalf("Failed to parse XML: %v\n%s", err, content)
+				}
+				if se, ok := tok.(xml.StartElement); ok {
+					root = se
+					break
+				}
+			}
+
+			got := make(map[string]string)
+			for _, a := range root.Attr {
+				key := a.Name.Local
+				if a.Name.Space != "" {
+					key = a.Name.Space + ":" + a.Name.Local
+				}
+				got[key] = a.Value
+			}
+
+			for _, want := range tt.attrs {
+				if got[want.Name.Local] != want.Value {
+					t.Errorf("root attribute %s = %q, want %q", want.Name.Local, got[want.Name.Local], want.Value)
+				}
+			}
+			if tt.countAttr != "" {
+				if want := fmt.Sprint(tt.rowCount); got[tt.countAttr] != want {
+					t.Errorf("root attribute %s = %q, want %q", tt.countAttr, got[tt.countAttr], want)
+				}
+			}
+
+			// The whole document must still be well-formed
+			var parsed struct {
+				Rows []struct {
+					ID int `xml:"id"`
+				} `xml:"row"`
+			}
+			if err := xml.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("XML is not well-formed: %v", err)
+			}
+			if len(parsed.Rows) != tt.rowCount {
+				t.Errorf("parsed %d rows, want %d", len(parsed.Rows), tt.rowCount)
+			}
+		})
+	}
+}
+
+func TestWriteXMLRowCountAttrRequiresUncompressed(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.xml")
+	rows := newFakeRows([]string{"id"}, []uint32{pgtype.Int4OID}, [][]any{{int32(1)}})
+
+	exporter, err := Get(FormatXML)
+	if err != nil {
+		t.Fatalf("Failed to get xml exporter: %v", err)
+	}
+	options := ExportOptions{
+		Format:          FormatXML,
+		Compression:     "gzip",
+		OutputPath:      outputPath,
+		XmlRootElement:  "results",
+		XmlRowElement:   "row",
+		XmlRowCountAttr: "count",
+	}
+
+	if _, err := exporter.Export(rows, options); err == nil {
+		t.Error("Export() expected error for row count attribute with compression, got nil")
+	}
+}
+
+func TestWriteXMLNull(t *testing.T) {
+	names := []string{"id", "name", "score"}
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.Int4OID}
+	data := [][]any{
+		{int32(1), nil, nil},
+		{int32(2), "", int32(7)},
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		attrs     []xml.Attr
+		wantRoot  string
+		wantRow1  string
+		wantXSD   string
+		wantCount int // name elements in the output
+	}{
+		{
+			name:      "default is empty",
+			mode:      "",
+			wantRoot:  "<results>",
+			wantRow1:  "<row>\n    <id>1</id>\n    <name></name>\n    <score></score>\n  </row>",
+			wantXSD:   `<xs:element name="score" type="nullable-int"/>`,
+			wantCount: 2,
+		},
+		{
+			name:      "empty",
+			mode:      XmlNullEmpty,
+			wantRoot:  "<results>",
+			wantRow1:  "<row>\n    <id>1</id>\n    <name></name>\n    <score></score>\n  </row>",
+			wantXSD:   `<xs:element name="score" type="nullable-int"/>`,
+			wantCount: 2,
+		},
+		{
+			name:      "xsi nil",
+			mode:      XmlNullXsiNil,
+			wantRoot:  `<results xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`,
+			wantRow1:  "<row>\n    <id>1</id>\n    <name xsi:nil=\"true\"></name>\n    <score xsi:nil=\"true\"></score>\n  </row>",
+			wantXSD:   `<xs:element name="score" type="nullable-int" nillable="true"/>`,
+			wantCount: 2,
+		},
+		{
+			name:      "xsi nil with namespace already declared",
+			mode:      XmlNullXsiNil,
+			attrs:     []xml.Attr{{Name: xml.Name{Local: "xmlns:xsi"}, Value: "http://www.w3.org/2001/XMLSchema-instance"}},
+			wantRoot:  `<results xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`,
+			wantRow1:  "<row>\n    <id>1</id>\n    <name xsi:nil=\"true\"></name>\n    <score xsi:nil=\"true\"></score>\n  </row>",
+			wantXSD:   `<xs:element name="score" type="nullable-int" nillable="true"/>`,
+			wantCount: 2,
+		},
+		{
+			name:      "omit",
+			mode:      XmlNullOmit,
+			wantRoot:  "<results>",
+			wantRow1:  "<row>\n    <id>1</id>\n  </row>",
+			wantXSD:   `<xs:element name="score" type="nullable-int" minOccurs="0"/>`,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.xml")
+
+			exporter, err := Get(FormatXML)
+			if err != nil {
+				t.Fatalf("Failed to get xml exporter: %v", err)
+			}
+			options := ExportOptions{
+				Format:         FormatXML,
+				Compression:    "none",
+				OutputPath:     outputPath,
+				XmlRootElement: "results",
+				XmlRowElement:  "row",
+				XmlRootAttrs:   tt.attrs,
+				XmlNull:        tt.mode,
+				EmitSchema:     true,
+			}
+			if _, err := exporter.Export(newFakeRows(names, oids, data), options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			out := string(content)
+			if !strings.Contains(out, tt.wantRoot+"\n") {
+				t.Errorf("root element should be %s, got:\n%s", tt.wantRoot, out)
+			}
+			if strings.Count(out, "xmlns:xsi=") > 1 {
+				t.Errorf("xsi namespace declared more than once:\n%s", out)
+			}
+			if !strings.Contains(out, tt.wantRow1) {
+				t.Errorf("first row should be\n%s\ngot:\n%s", tt.wantRow1, out)
+			}
+			// An empty string is never affected by the NULL mode
+			if !strings.Contains(out, "<id>2</id>\n    <name></name>\n    <score>7</score>") {
+				t.Errorf("second row should keep its empty name, got:\n%s", out)
+			}
+			if got := strings.Count(out, "<name"); got != tt.wantCount {
+				t.Errorf("found %d name elements, want %d", got, tt.wantCount)
+			}
+			var parsed struct {
+				Rows []struct {
+					ID int `xml:"id"`
+				} `xml:"row"`
+			}
+			if err := xml.Unmarshal(content, &parsed); err != nil || len(parsed.Rows) != 2 {
+				t.Errorf("output is not valid XML with 2 rows: %v", err)
+			}
+
+			xsd, err := os.ReadFile(SchemaPath(outputPath, FormatXML))
+			if err != nil {
+				t.Fatalf("Failed to read schema: %v", err)
+			}
+			if !strings.Contains(string(xsd), tt.wantXSD) {
+				t.Errorf("schema should contain %s, got:\n%s", tt.wantXSD, xsd)
+			}
+		})
+	}
+}
+
+func TestSanitizeXMLName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"total", "total"},
+		{"user-name", "user-name"},
+		{"2024 total", "_2024_total"},
+		{"order total", "order_total"},
+		{"-1", "_-1"},
+		{".hidden", "_.hidden"},
+		{"?column?", "_column_"},
+		{"ns:name", "ns_name"},
+		{"élève", "élève"},
+		{"", "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := SanitizeXMLName(tt.input)
+			if got != tt.want {
+				t.Errorf("SanitizeXMLName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if !ValidXMLName(got) {
+				t.Errorf("SanitizeXMLName(%q) = %q, which is not a valid XML name", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestWriteXMLElementNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		columns     []string
+		root        string
+		row         string
+		sanitize    bool
+		want        string
+		errContains string
+	}{
+		{
+			name:    "valid names",
+			columns: []string{"id", "user-name"},
+			root:    "results",
+			row:     "row",
+			want:    "<results>\n<row><id>1</id><user-name>a</user-name></row>\n</results>",
+		},
+		{
+			name:        "numeric-leading column",
+			columns:     []string{"id", "2024_total"},
+			root:        "results",
+			row:         "row",
+			errContains: `column "2024_total" is not a valid XML element name (use --xml-sanitize-names`,
+		},
+		{
+			name:        "column with a space",
+			columns:     []string{"id", "order total"},
+			root:        "results",
+			row:         "row",
+			errContains: `column "order total" is not a valid XML element name`,
+		},
+		{
+			name:        "invalid root tag",
+			columns:     []string{"id", "name"},
+			root:        "my results",
+			row:         "row",
+			errContains: `--xml-root-tag "my results" is not a valid XML element name`,
+		},
+		{
+			name:        "invalid row tag",
+			columns:     []string{"id", "name"},
+			root:        "results",
+			row:         "1row",
+			errContains: `--xml-row-tag "1row" is not a valid XML element name`,
+		},
+		{
+			name:     "sanitized",
+			columns:  []string{"2024 total", "order total"},
+			root:     "my results",
+			row:      "1row",
+			sanitize: true,
+			want:     "<my_results>\n<_1row><_2024_total>1</_2024_total><order_total>a</order_total></_1row>\n</my_results>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.xml")
+			rows := newFakeRows(tt.columns, []uint32{pgtype.Int4OID, pgtype.TextOID}, [][]any{{int32(1), "a"}})
+
+			exporter, err := Get(FormatXML)
+			if err != nil {
+				t.Fatalf("Failed to get xml exporter: %v", err)
+			}
+			_, err = exporter.Export(rows, ExportOptions{
+				Format:           FormatXML,
+				Compression:      "none",
+				OutputPath:       outputPath,
+				XmlRootElement:   tt.root,
+				XmlRowElement:    tt.row,
+				XmlSanitizeNames: tt.sanitize,
+				Compact:          true,
+			})
+
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Export() error = %v, want it to contain %q", err, tt.errContains)
+				}
+				if _, statErr := os.Stat(outputPath); statErr == nil {
+					t.Error("Export() should not create the output file for an invalid name")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if !strings.Contains(string(content), tt.want) {
+				t.Errorf("output should contain %s, got:\n%s", tt.want, content)
+			}
+			if err := xml.Unmarshal(content, new(struct{})); err != nil {
+				t.Errorf("output is not well-formed XML: %v\n%s", err, content)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package exporters
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/elliotchance/orderedmap/v3"
@@ -16,22 +17,38 @@ import (
 type yamlExporter struct{}
 
 // Export writes query results to a YAML file.
-func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error) {
+func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (n int, err error) {
 	start := time.Now()
-	logger.Debug("Preparing YAML export (compression=%s)", options.Compression)
+	logger.Debug("Preparing YAML export (compact=%v, compression=%s)", options.Compact, options.Compression)
+
+	columns, err := ColumnNames(rows.FieldDescriptions(), options, true)
+	if err != nil {
+		return 0, err
+	}
 
 	writerCloser, err := output.CreateWriter(output.OutputConfig{
 		Path:        options.OutputPath,
 		Compression: options.Compression,
 		Format:      options.Format,
+		Atomic:      options.Atomic,
+		Writer:      options.WriteCloser(),
 	})
 
 	if err != nil {
 		return 0, err
 	}
-	defer writerCloser.Close()
+	defer closeOutput(writerCloser, &err)
 
-	enc := yaml.NewEncoder(writerCloser)
+	if options.GeneratedComment {
+		if err := writeLineComment(writerCloser, "# ", options); err != nil {
+			return 0, err
+		}
+	}
+
+	// The YAML encoder reports write failures as text, so keep the original
+	// error to return it
+	out := &writeErrorRecorder{Writer: writerCloser}
+	enc := yaml.NewEncoder(out)
 	enc.SetIndent(2)
 	defer enc.Close()
 
@@ -52,32 +69,49 @@ func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 		sp = ui.NewSpinner()
 		sp.Start()
 	}
+	failed := 0 // rows left out by --on-error skip
+	reader := newRowReader(rows)
 	for rows.Next() {
-		values, err := rows.Values()
+		if err := checkCancelled(options.ctx(), rowCount); err != nil {
+			return rowCount, err
+		}
+
+		values, err := reader.Values()
 		if err != nil {
 			return rowCount, fmt.Errorf("error reading row %d: %w", rowCount+1, err)
 		}
+		options.trimValues(values, fields)
 
 		rowData := orderedmap.NewOrderedMap[string, encoders.DataParams]()
 
 		for i, fd := range fields {
-			rowData.Set(fd.Name, encoders.DataParams{
+			rowData.Set(columns[i], encoders.DataParams{
 				Value:     values[i],
 				ValueType: fd.DataTypeOID,
 			})
 		}
 
-		rowNode, err := rowEncoder.EncodeRow(rowData)
+		var rowNode *yaml.Node
+		skipped, err := options.formatRow(rowCount+failed+1, func() error {
+			rowNode, err = rowEncoder.EncodeRow(rowData)
+			return err
+		})
 		if err != nil {
 			return rowCount, fmt.Errorf("error encoding YAML row %d: %w", rowCount+1, err)
 		}
+		if skipped {
+			failed++
+			continue
+		}
+		if options.Compact {
+			// One "- {col: value, ...}" line per row
+			rowNode.Style = yaml.FlowStyle
+		}
 
 		// Add to sequence
 		rootSeq.Content = append(rootSeq.Content, rowNode)
 		rowCount++
-		sp.Update(fmt.Sprintf("[1/2] Processing rows... %d rows [%ds]",
-			rowCount,
-			int(time.Since(start).Seconds())))
+		sp.Update(ui.ProgressMessage("[1/2] Processing rows...", rowCount, options.ProgressTotal, time.Since(start)))
 
 		if rowCount%10000 == 0 {
 			logger.Debug("%d YAML rows processed...", rowCount)
@@ -87,6 +121,7 @@ func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
+	options.logSkippedRows(failed)
 
 	sp.Stop("Completed!")
 
@@ -98,6 +133,9 @@ func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	sp2.Update("[2/2] Writing output...")
 	// Encode final YAML sequence
 	if err := enc.Encode(rootSeq); err != nil {
+		if out.err != nil {
+			err = out.err
+		}
 		return rowCount, fmt.Errorf("error writing YAML: %w", err)
 	}
 	sp2.Stop("Completed!")
@@ -105,9 +143,24 @@ func (e *yamlExporter) Export(rows pgx.Rows, options ExportOptions) (int, error)
 	logger.Debug("YAML export completed: %d rows written in %v",
 		rowCount, time.Since(start))
 
+	output.Commit(writerCloser)
 	return rowCount, nil
 }
 
 func init() {
 	MustRegister(FormatYAML, func() Exporter { return &yamlExporter{} })
 }
+
+// writeErrorRecorder remembers the first error of the underlying writer.
+type writeErrorRecorder struct {
+	io.Writer
+	err error
+}
+
+func (w *writeErrorRecorder) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
@@ -4,22 +4,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// MoneyOID is the OID of the PostgreSQL money type, which pgtype does not define.
+// pgx returns money values as text formatted with the server's lc_monetary.
+const MoneyOID uint32 = 790
+
+// GoLayoutPrefix marks a time format that is already a Go reference layout
+// (e.g. "go:2006-01-02T15:04:05Z07:00"); such a layout is used as-is.
+const GoLayoutPrefix = "go:"
+
+// timeFormatReplacer maps the user time format tokens to Go layout elements.
+// At each position the first matching token in argument order wins, so longer
+// tokens must come before their prefixes (yyyy/yy, SSSSSS/SSS/S, xxx/xx).
 var timeFormatReplacer = strings.NewReplacer(
 	"yyyy", "2006",
 	"yy", "06",
 	"MM", "01",
 	"dd", "02",
 	"HH", "15",
+	"hh", "03", // 12-hour clock
 	"mm", "04",
 	"ss", "05",
+	"SSSSSS", "000000", // Microseconds
 	"SSS", "000", // Milliseconds
 	"S", "0", // Deciseconds
+	"xxx", "-07:00", // Zone offset with colon
+	"xx", "-0700", // Zone offset without colon
+	"a", "PM", // AM/PM marker
 )
 
 // formatValue is kept for backward compatibility (not used in new code)
@@ -69,6 +92,24 @@ func formatValueByOID(val interface{}, valueType uint32, userTimefmt string, tim
 			return t.In(loc).Format(layout)
 		}
 
+	case pgtype.TimeOID:
+		if t, ok := val.(pgtype.Time); ok {
+			if !t.Valid {
+				return nil
+			}
+			layout := ConvertUserTimeFormat(extractUserTimeFormat(userTimefmt, false))
+			return timeOfDay(t).Format(layout)
+		}
+
+	case pgtype.TimetzOID:
+		// pgx has no timetz codec and returns the PostgreSQL text form
+		if str, ok := val.(string); ok {
+			if t, err := parseTimetz(str); err == nil {
+				layout, loc := UserTimeZoneFormat(extractUserTimeFormat(userTimefmt, true), timeZone)
+				return t.In(loc).Format(layout)
+			}
+		}
+
 	case pgtype.UUIDOID:
 		if uuid, ok := val.([16]byte); ok {
 			return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
@@ -79,6 +120,19 @@ func formatValueByOID(val interface{}, valueType uint32, userTimefmt string, tim
 			return string(bytes)
 		}
 
+	case pgtype.BitOID, pgtype.VarbitOID:
+		if bits, ok := val.(pgtype.Bits); ok {
+			if !bits.Valid {
+				return nil
+			}
+			return BitString(bits)
+		}
+
+	case pgtype.InetOID, pgtype.CIDROID, pgtype.MacaddrOID, pgtype.Macaddr8OID:
+		if text, ok := NetworkText(val, valueType); ok {
+			return text
+		}
+
 	case pgtype.NumericOID:
 		if num, ok := val.(pgtype.Numeric); ok {
 			if !num.Valid {
@@ -106,12 +160,267 @@ func formatValueByOID(val interface{}, valueType uint32, userTimefmt string, tim
 	case pgtype.JSONBOID, pgtype.JSONOID:
 		// Return as-is for JSON export, will be marshaled for CSV/XML/YAML
 		return val
+
+	case MoneyOID:
+		if str, ok := val.(string); ok {
+			if text, ok := MoneyText(str); ok {
+				return text
+			}
+		}
 	}
 
 	// Return value as-is for generic types
 	return val
 }
 
+// TrimText strips trailing whitespace from text, varchar, char(n) and name
+// values, such as the padding PostgreSQL adds to fixed-width char(n) columns.
+// Other types and NULL values are returned unchanged.
+func TrimText(val interface{}, valueType uint32) interface{} {
+	switch valueType {
+	case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID:
+		if s, ok := val.(string); ok {
+			return strings.TrimRightFunc(s, unicode.IsSpace)
+		}
+	}
+	return val
+}
+
+// CoalesceValue converts the text of a --coalesce default to the Go value
+// rows.Values returns for the column type, so the default is formatted like
+// a value read from the database: numbers and booleans stay unquoted in SQL
+// and JSON. Text and other types keep the text as is.
+func CoalesceValue(text string, valueType uint32) (interface{}, error) {
+	switch valueType {
+	case pgtype.Int2OID:
+		n, err := strconv.ParseInt(text, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid smallint", text)
+		}
+		return int16(n), nil
+	case pgtype.Int4OID:
+		n, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid integer", text)
+		}
+		return int32(n), nil
+	case pgtype.Int8OID:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid bigint", text)
+		}
+		return n, nil
+	case pgtype.Float4OID:
+		f, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid real", text)
+		}
+		return float32(f), nil
+	case pgtype.Float8OID:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid double precision", text)
+		}
+		return f, nil
+	case pgtype.NumericOID:
+		var num pgtype.Numeric
+		if err := num.Scan(text); err != nil || !num.Valid {
+			return nil, fmt.Errorf("%q is not a valid numeric", text)
+		}
+		return num, nil
+	case pgtype.BoolOID:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid boolean", text)
+		}
+		return b, nil
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		var v interface{}
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, fmt.Errorf("%q is not valid JSON", text)
+		}
+		return v, nil
+	}
+	return text, nil
+}
+
+// Truncatable reports whether --max-field-length applies to a value: text,
+// binary, JSON and array values. Numbers, money, dates and NULL are never cut.
+func Truncatable(val interface{}, valueType uint32) bool {
+	if valueType == MoneyOID {
+		return false
+	}
+	switch val.(type) {
+	case string, []byte, map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// TruncateText cuts s to limit characters and appends marker when s is longer.
+// A limit of 0 or less leaves s unchanged.
+func TruncateText(s string, limit int, marker string) string {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	end := 0
+	for n := 0; n < limit; n++ {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	return s[:end] + marker
+}
+
+// MoneyText converts a money value as printed by PostgreSQL (e.g. "$1,234.56",
+// "-$0.50", "1.234,56 €") into a plain decimal such as "1234.56", without
+// currency symbol or thousands separators. The decimal separator is the last
+// '.' or ',' unless exactly three digits follow it, in which case it groups
+// thousands. Use MoneyTextDigits when the number of fraction digits is known.
+// Text that is already a plain decimal (e.g. "1.234") is kept as is:
+// PostgreSQL always prints a currency symbol, so it does not come from the
+// server. ok is false when the text holds no digits.
+func MoneyText(s string) (text string, ok bool) {
+	digits, decimalPos, negative := moneyDigits(s)
+	if digits == "" {
+		return "", false
+	}
+
+	fracDigits := 0
+	if decimalPos >= 0 && (plainDecimal(s) || len(digits)-decimalPos != 3) {
+		fracDigits = len(digits) - decimalPos
+	}
+	return moneyDecimal(digits, fracDigits, negative), true
+}
+
+// MoneyTextDigits converts a money value like MoneyText, knowing that it has
+// fracDigits fraction digits. PostgreSQL prints every money value with the
+// frac_digits of lc_monetary, so the last fracDigits digits are the fraction
+// and no separator has to be guessed: "¥1,235" with 0 digits is "1235",
+// "KD 1,235" with 3 digits is "1.235". A negative fracDigits means unknown
+// and falls back to MoneyText. ok is false when the text holds no digits.
+func MoneyTextDigits(s string, fracDigits int) (text string, ok bool) {
+	if fracDigits < 0 {
+		return MoneyText(s)
+	}
+	digits, _, negative := moneyDigits(s)
+	if digits == "" {
+		return "", false
+	}
+	if n := fracDigits + 1 - len(digits); n > 0 {
+		digits = strings.Repeat("0", n) + digits
+	}
+	return moneyDecimal(digits, fracDigits, negative), true
+}
+
+// moneyDigits returns the digits of a money text, the number of digits before
+// its last '.' or ',' (-1 when there is none) and whether it is negative,
+// written with a minus sign or in parentheses.
+func moneyDigits(s string) (digits string, decimalPos int, negative bool) {
+	negative = strings.Contains(s, "-") ||
+		(strings.HasPrefix(strings.TrimSpace(s), "(") && strings.HasSuffix(strings.TrimSpace(s), ")"))
+
+	var b strings.Builder
+	decimalPos = -1
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == ',':
+			decimalPos = b.Len()
+		}
+	}
+	return b.String(), decimalPos, negative
+}
+
+// plainDecimal reports whether s is a decimal with a '.' separator and no
+// currency symbol or grouping, e.g. "-1234.5".
+func plainDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	intPart, fracPart, found := strings.Cut(s, ".")
+	if intPart == "" || (found && fracPart == "") {
+		return false
+	}
+	return strings.Trim(intPart, "0123456789") == "" && strings.Trim(fracPart, "0123456789") == ""
+}
+
+// moneyDecimal writes digits as a decimal whose last fracDigits digits are the
+// fraction, without leading zeros. Zero is never negative.
+func moneyDecimal(digits string, fracDigits int, negative bool) string {
+	intPart, fracPart := digits[:len(digits)-fracDigits], digits[len(digits)-fracDigits:]
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	text := intPart
+	if fracPart != "" {
+		text += "." + fracPart
+	}
+	if negative && strings.Trim(digits, "0") != "" {
+		text = "-" + text
+	}
+	return text
+}
+
+// SpecialFloatText returns the PostgreSQL spelling of a non-finite float or
+// numeric value: "NaN", "Infinity" or "-Infinity". ok is false for finite
+// values and for anything that is not a float or numeric.
+func SpecialFloatText(val interface{}) (text string, ok bool) {
+	var f float64
+	switch v := val.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case pgtype.Numeric:
+		switch {
+		case !v.Valid:
+			return "", false
+		case v.NaN:
+			return "NaN", true
+		case v.InfinityModifier == pgtype.Infinity:
+			return "Infinity", true
+		case v.InfinityModifier == pgtype.NegativeInfinity:
+			return "-Infinity", true
+		}
+		return "", false
+	default:
+		return "", false
+	}
+
+	switch {
+	case math.IsNaN(f):
+		return "NaN", true
+	case math.IsInf(f, 1):
+		return "Infinity", true
+	case math.IsInf(f, -1):
+		return "-Infinity", true
+	}
+	return "", false
+}
+
+// formatFloat writes finite floats with 15 significant digits and NaN or
+// infinities with their PostgreSQL spelling, which COPY FROM reads back.
+func formatFloat(f float64) string {
+	if text, ok := SpecialFloatText(f); ok {
+		return text
+	}
+	return fmt.Sprintf("%.15g", f)
+}
+
+// specialFloatCast returns the type used to cast a NaN or infinity in SQL.
+func specialFloatCast(valueType uint32) string {
+	switch valueType {
+	case pgtype.Float4OID:
+		return "float4"
+	case pgtype.NumericOID:
+		return "numeric"
+	default:
+		return "float8"
+	}
+}
+
 // FormatJSONValue formats a PostgreSQL value for JSON export.
 // Handles type-specific conversions including dates, timestamps, UUIDs, and numeric types.
 func FormatJSONValue(val interface{}, valueType uint32, userTimefmt string, timeZone string) interface{} {
@@ -133,10 +442,10 @@ func FormatCSVValue(val interface{}, valueType uint32, userTimefmt string, timeZ
 		return v
 
 	case float64:
-		return fmt.Sprintf("%.15g", v)
+		return formatFloat(v)
 
 	case float32:
-		return fmt.Sprintf("%.15g", v)
+		return formatFloat(float64(v))
 
 	case []interface{}:
 		if len(v) == 0 {
@@ -176,10 +485,10 @@ func FormatXMLValue(val interface{}, valueType uint32, userTimefmt string, timeZ
 		return v
 
 	case float64:
-		return fmt.Sprintf("%.15g", v)
+		return formatFloat(v)
 
 	case float32:
-		return fmt.Sprintf("%.15g", v)
+		return formatFloat(float64(v))
 
 	case []interface{}:
 		if len(v) == 0 {
@@ -217,6 +526,11 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 		return "NULL"
 	}
 
+	// NaN and infinities are not numeric literals; write them as typed strings
+	if text, ok := SpecialFloatText(val); ok {
+		return fmt.Sprintf("'%s'::%s", text, specialFloatCast(valueType))
+	}
+
 	switch valueType {
 	case pgtype.DateOID:
 		if t, ok := val.(time.Time); ok {
@@ -233,6 +547,19 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 			return fmt.Sprintf("'%s'::timestamptz", t.Format("2006-01-02 15:04:05.000-07"))
 		}
 
+	case pgtype.TimeOID:
+		if t, ok := val.(pgtype.Time); ok {
+			if !t.Valid {
+				return "NULL"
+			}
+			return fmt.Sprintf("'%s'::time", timeOfDay(t).Format("15:04:05.999999"))
+		}
+
+	case pgtype.TimetzOID:
+		if str, ok := val.(string); ok {
+			return fmt.Sprintf("'%s'::timetz", strings.ReplaceAll(str, "'", "''"))
+		}
+
 	case pgtype.UUIDOID:
 		if uuid, ok := val.([16]byte); ok {
 			return fmt.Sprintf("'%x-%x-%x-%x-%x'::uuid", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
@@ -244,6 +571,19 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 			return fmt.Sprintf("'%s'::bytea", escaped)
 		}
 
+	case pgtype.BitOID, pgtype.VarbitOID:
+		if bits, ok := val.(pgtype.Bits); ok {
+			if !bits.Valid {
+				return "NULL"
+			}
+			return fmt.Sprintf("B'%s'", BitString(bits))
+		}
+
+	case pgtype.InetOID, pgtype.CIDROID, pgtype.MacaddrOID, pgtype.Macaddr8OID:
+		if text, ok := NetworkText(val, valueType); ok {
+			return fmt.Sprintf("'%s'::%s", text, networkTypes[valueType])
+		}
+
 	case pgtype.BoolOID:
 		if b, ok := val.(bool); ok {
 			if b {
@@ -289,6 +629,14 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 			return "'{}'::json"
 		}
 		return fmt.Sprintf("'%s'::json", string(jsonStr))
+
+	case MoneyOID:
+		// Going through numeric keeps the literal independent of lc_monetary
+		if str, ok := val.(string); ok {
+			if text, ok := MoneyText(str); ok {
+				return fmt.Sprintf("'%s'::numeric::money", text)
+			}
+		}
 	}
 
 	// Generic SQL value formatting
@@ -300,14 +648,7 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 		return fmt.Sprintf("%.15g", val)
 
 	case []interface{}:
-		if len(v) == 0 {
-			return "'{}'"
-		}
-		elems := make([]string, len(v))
-		for i, elem := range v {
-			elems[i] = fmt.Sprintf("%v", elem)
-		}
-		return fmt.Sprintf("'{%s}'", strings.Join(elems, ","))
+		return sqlArrayLiteral(v, valueType)
 
 	default:
 		str := fmt.Sprintf("%v", val)
@@ -316,10 +657,113 @@ func FormatSQLValue(val interface{}, valueType uint32) string {
 	}
 }
 
+// arrayTypes encodes array values in PostgreSQL text format. A pgtype.Map
+// caches encode plans and is not safe for concurrent use, hence the mutex.
+var arrayTypes = struct {
+	sync.Mutex
+	m *pgtype.Map
+}{m: pgtype.NewMap()}
+
+// sqlArrayLiteral writes an array as a string literal cast to the array type,
+// e.g. '{"a,b","say \"hi\"",NULL}'::text[] or '{1,2,3}'::int4[]. The elements
+// are encoded like PostgreSQL's own array output, so commas, quotes,
+// backslashes and NULL reload unchanged. Arrays whose element type is not
+// known, such as record[], are written without a cast and with every element
+// quoted, leaving PostgreSQL to coerce the literal to the target column.
+func sqlArrayLiteral(elems []interface{}, arrayOID uint32) string {
+	arrayTypes.Lock()
+	elemType, known := arrayElementType(arrayOID)
+	var text []byte
+	var err error
+	if known {
+		text, err = arrayTypes.m.Encode(arrayOID, pgtype.TextFormatCode, elems, nil)
+	}
+	arrayTypes.Unlock()
+	if known && err == nil {
+		return fmt.Sprintf("'%s'::%s[]", strings.ReplaceAll(string(text), "'", "''"), elemType)
+	}
+
+	quoted := make([]string, len(elems))
+	for i, elem := range elems {
+		switch e := elem.(type) {
+		case nil:
+			quoted[i] = "NULL"
+		case []interface{}:
+			quoted[i] = quoteArrayElement(recordText(e))
+		default:
+			quoted[i] = quoteArrayElement(fmt.Sprintf("%v", e))
+		}
+	}
+	return fmt.Sprintf("'{%s}'", strings.ReplaceAll(strings.Join(quoted, ","), "'", "''"))
+}
+
+// arrayElementType returns the SQL name of the element type of a known array
+// type. record[] has no usable cast, since anonymous records cannot be input.
+// It must be called with arrayTypes locked.
+func arrayElementType(arrayOID uint32) (string, bool) {
+	if arrayOID == pgtype.RecordArrayOID {
+		return "", false
+	}
+	typ, ok := arrayTypes.m.TypeForOID(arrayOID)
+	if !ok {
+		return "", false
+	}
+	codec, ok := typ.Codec.(*pgtype.ArrayCodec)
+	if !ok || codec.ElementType == nil {
+		return "", false
+	}
+	if codec.ElementType.Name == "char" {
+		// Unquoted, char means bpchar(1)
+		return `"char"`, true
+	}
+	return codec.ElementType.Name, true
+}
+
+// recordText returns the text form of a record, e.g. (1,"a b",) for
+// ROW(1, 'a b', NULL): NULL fields are left empty and fields holding
+// delimiters, quotes, backslashes or spaces are double-quoted.
+func recordText(fields []interface{}) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		if field == nil {
+			continue
+		}
+		text := fmt.Sprintf("%v", field)
+		if text == "" || strings.ContainsAny(text, "(),\"\\ \t\n") {
+			text = quoteArrayElement(text)
+		}
+		parts[i] = text
+	}
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// quoteArrayElement double-quotes an array or record element, escaping
+// backslashes and double quotes.
+func quoteArrayElement(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
 // FormatXLSXValue formats a PostgreSQL value for Excel XLSX export.
 // Preserves native types (dates, times) for Excel compatibility and converts complex types to JSON strings.
 func FormatXLSXValue(value interface{}, oid uint32, timeFormat, timeZone string) interface{} {
 
+	// Excel has no NaN or infinity, so they are written as text
+	if text, ok := SpecialFloatText(value); ok {
+		return text
+	}
+
+	// Money is written as a number so it can be summed and formatted in Excel
+	if oid == MoneyOID {
+		if str, ok := value.(string); ok {
+			if text, ok := MoneyText(str); ok {
+				if f, err := strconv.ParseFloat(text, 64); err == nil {
+					return f
+				}
+			}
+		}
+	}
+
 	if pgtype.DateOID == oid || pgtype.TimestampOID == oid || pgtype.TimestamptzOID == oid {
 		return value
 	}
@@ -372,6 +816,65 @@ func FormatTemplateValue(val interface{}, oid uint32, userTimefmt string, timeZo
 	return base
 }
 
+// BitString returns a bit or varbit value as a string of 0 and 1 digits
+// (e.g. "101010"), the way PostgreSQL prints it.
+func BitString(bits pgtype.Bits) string {
+	var b strings.Builder
+	b.Grow(int(bits.Len))
+	for i := int32(0); i < bits.Len; i++ {
+		if bits.Bytes[i/8]&(0x80>>(i%8)) != 0 {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// networkTypes names the network address types for SQL casts.
+var networkTypes = map[uint32]string{
+	pgtype.InetOID:     "inet",
+	pgtype.CIDROID:     "cidr",
+	pgtype.MacaddrOID:  "macaddr",
+	pgtype.Macaddr8OID: "macaddr8",
+}
+
+// NetworkText returns an inet, cidr, macaddr or macaddr8 value in the text
+// form PostgreSQL prints: inet omits the prefix length of a single host
+// ("192.168.1.5", "::1"), cidr always has it ("192.168.1.0/24") and MAC
+// addresses are lower-case, colon-separated ("08:00:2b:01:02:03").
+// ok is false when val is not a network address.
+func NetworkText(val interface{}, valueType uint32) (text string, ok bool) {
+	switch v := val.(type) {
+	case netip.Prefix:
+		if !v.IsValid() {
+			return "", false
+		}
+		if valueType == pgtype.InetOID && v.Bits() == v.Addr().BitLen() {
+			return v.Addr().String(), true
+		}
+		return v.String(), true
+	case net.HardwareAddr:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// NumericText returns the exact decimal representation of a numeric value
+// (e.g. "12345678901234567890.0001"), without the precision loss of float64.
+// ok is false for NULL, NaN and infinite values, which have no JSON number form.
+func NumericText(num pgtype.Numeric) (text string, ok bool) {
+	if !num.Valid || num.NaN || num.InfinityModifier != pgtype.Finite {
+		return "", false
+	}
+	v, err := num.Value()
+	if err != nil {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
 // QuoteIdent quotes a PostgreSQL identifier (table or column name).
 // Handles schema-qualified names (e.g., "schema"."table") and escapes double quotes.
 func QuoteIdent(s string) string {
@@ -382,6 +885,67 @@ func QuoteIdent(s string) string {
 	return strings.Join(parts, ".")
 }
 
+// maxIdentLength is the longest identifier PostgreSQL keeps (NAMEDATALEN - 1);
+// longer names are silently truncated.
+const maxIdentLength = 63
+
+// reservedKeywords are the PostgreSQL keywords that cannot be used as a bare
+// table or column name.
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "both": true, "case": true, "cast": true,
+	"check": true, "collate": true, "column": true, "constraint": true, "create": true,
+	"current_catalog": true, "current_date": true, "current_role": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+	"group": true, "having": true, "in": true, "initially": true, "intersect": true, "into": true,
+	"lateral": true, "leading": true, "limit": true, "localtime": true, "localtimestamp": true,
+	"not": true, "null": true, "offset": true, "on": true, "only": true, "or": true, "order": true,
+	"placing": true, "primary": true, "references": true, "returning": true, "select": true,
+	"session_user": true, "some": true, "symmetric": true, "system_user": true, "table": true,
+	"then": true, "to": true, "trailing": true, "true": true, "union": true, "unique": true,
+	"user": true, "using": true, "variadic": true, "when": true, "where": true, "window": true,
+	"with": true,
+}
+
+// BareIdent returns a PostgreSQL identifier (table or column name) unquoted.
+// Handles schema-qualified names: every part must be a lowercase identifier
+// that PostgreSQL reads back unchanged without quotes (letters a-z, digits,
+// _ and $, not starting with a digit, at most 63 bytes, not a reserved keyword).
+func BareIdent(s string) (string, error) {
+	for _, part := range strings.Split(s, ".") {
+		if err := checkBareIdent(part); err != nil {
+			return "", fmt.Errorf("%q cannot be written without quotes: %w", s, err)
+		}
+	}
+	return s, nil
+}
+
+func checkBareIdent(part string) error {
+	if part == "" {
+		return fmt.Errorf("empty name")
+	}
+	if len(part) > maxIdentLength {
+		return fmt.Errorf("%q is longer than %d bytes", part, maxIdentLength)
+	}
+	for i, r := range part {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case (r >= '0' && r <= '9') || r == '$':
+			if i == 0 {
+				return fmt.Errorf("%q starts with %q", part, r)
+			}
+		default:
+			return fmt.Errorf("%q contains %q (only lowercase letters, digits, _ and $ are allowed)", part, r)
+		}
+	}
+	if reservedKeywords[part] {
+		return fmt.Errorf("%q is a reserved keyword", part)
+	}
+	return nil
+}
+
 // UserTimeZoneFormat converts a user time format string to Go time layout and loads the timezone.
 // Returns the Go time layout format and the timezone location.
 // Falls back to local timezone if the provided timezone is invalid.
@@ -405,7 +969,11 @@ func UserTimeZoneFormat(userTimefmt string, timeZone string) (string, *time.Loca
 
 // ConvertUserTimeFormat converts a user-friendly time format (e.g., "yyyy-MM-dd HH:mm:ss")
 // to Go's time layout format (e.g., "2006-01-02 15:04:05").
+// A format starting with GoLayoutPrefix is returned without the prefix and unconverted.
 func ConvertUserTimeFormat(userTimefmt string) string {
+	if layout, ok := strings.CutPrefix(userTimefmt, GoLayoutPrefix); ok {
+		return layout
+	}
 	return timeFormatReplacer.Replace(userTimefmt)
 }
 
@@ -413,10 +981,105 @@ func ConvertUserTimeFormat(userTimefmt string) string {
 // For example, "yyyy-MM-dd HH:mm:ss" becomes "yyyy-MM-dd".
 // This ensures DATE columns are exported without time components.
 func extractUserDateFormat(userFmt string) string {
-	dateTokens := []string{"yyyy", "yy", "MM", "dd"}
+	if layout, ok := strings.CutPrefix(userFmt, GoLayoutPrefix); ok {
+		return GoLayoutPrefix + cutAfterLast(layout, goDateElements)
+	}
+	return cutAfterLast(userFmt, []string{"yyyy", "yy", "MM", "dd"})
+}
+
+// defaultTimeOfDayFormat formats time and timetz values when the time format
+// has no time elements (e.g. "yyyy-MM-dd").
+const defaultTimeOfDayFormat = "HH:mm:ss"
+
+// extractUserTimeFormat extracts the time-of-day portion of a datetime format
+// string for time and timetz columns: "yyyy-MM-dd HH:mm:ss" becomes
+// "HH:mm:ss". A trailing zone offset is kept only when keepZone is set.
+func extractUserTimeFormat(userFmt string, keepZone bool) string {
+	if layout, ok := strings.CutPrefix(userFmt, GoLayoutPrefix); ok {
+		part := cutBeforeFirst(layout, []string{"15", "03"})
+		if part == "" {
+			return defaultTimeOfDayFormat
+		}
+		if !keepZone {
+			part = cutAfterLast(part, goClockElements)
+		}
+		return GoLayoutPrefix + part
+	}
+
+	part := cutBeforeFirst(userFmt, []string{"HH", "hh"})
+	if part == "" {
+		return defaultTimeOfDayFormat
+	}
+	if !keepZone {
+		part = cutAfterLast(part, []string{"HH", "hh", "mm", "ss", "S", "a"})
+	}
+	return part
+}
+
+// goClockElements are the time-of-day elements of a Go reference layout.
+var goClockElements = []string{"15", "03", "04", "05", "PM", "pm", ".000000000", ".000000", ".000", ".999999999", ".999999", ".999"}
+
+// cutBeforeFirst trims s before the first occurrence of any of tokens, or
+// returns "" when s contains none of them.
+func cutBeforeFirst(s string, tokens []string) string {
+	first := -1
+	for _, tok := range tokens {
+		if idx := strings.Index(s, tok); idx != -1 && (first == -1 || idx < first) {
+			first = idx
+		}
+	}
+	if first == -1 {
+		return ""
+	}
+	return strings.TrimSpace(s[first:])
+}
+
+// timeOfDay returns a time value as a time.Time on January 1st of year 0, UTC.
+func timeOfDay(t pgtype.Time) time.Time {
+	return time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(t.Microseconds) * time.Microsecond)
+}
+
+// parseTimetz parses the PostgreSQL text form of a timetz value, such as
+// "13:45:00+02" or "08:30:00.5-05:30", as that time of day today in its own
+// offset, so it can be converted to another time zone.
+func parseTimetz(s string) (time.Time, error) {
+	sign := strings.LastIndexAny(s, "+-")
+	if sign <= 0 {
+		return time.Time{}, fmt.Errorf("invalid timetz %q", s)
+	}
+	clock, err := time.Parse("15:04:05.999999", s[:sign])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timetz %q: %w", s, err)
+	}
+
+	offset := 0
+	for i, part := range strings.Split(s[sign+1:], ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || i > 2 {
+			return time.Time{}, fmt.Errorf("invalid timetz offset in %q", s)
+		}
+		offset += n * []int{3600, 60, 1}[i]
+	}
+	if s[sign] == '-' {
+		offset = -offset
+	}
+
+	zone := time.FixedZone("", offset)
+	today := time.Now().In(zone)
+	return time.Date(today.Year(), today.Month(), today.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), zone), nil
+}
+
+// goDateElements are the date elements of a Go reference layout. "06" is
+// also found inside "2006", which ends at the same position.
+var goDateElements = []string{"2006", "06", "January", "Jan", "01", "Monday", "Mon", "02", "_2", "002"}
+
+// cutAfterLast trims s after the last occurrence of any of tokens.
+// s is returned unchanged when it contains none of them.
+func cutAfterLast(s string, tokens []string) string {
 	last := -1
-	for _, tok := range dateTokens {
-		idx := strings.LastIndex(userFmt, tok)
+	for _, tok := range tokens {
+		idx := strings.LastIndex(s, tok)
 		if idx != -1 {
 			end := idx + len(tok)
 			if end > last {
@@ -427,7 +1090,7 @@ func extractUserDateFormat(userFmt string) string {
 
 	if last == -1 {
 		// No date tokens found, return original
-		return userFmt
+		return s
 	}
-	return strings.TrimSpace(userFmt[:last])
+	return strings.TrimSpace(s[:last])
 }
@@ -1,7 +1,11 @@
 package formatters
 
 import (
+	"math"
 	"math/big"
+	"net"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,6 +58,26 @@ func TestConvertUserTimeFormat(t *testing.T) {
 			input:    "HH:mm:ss",
 			expected: "15:04:05",
 		},
+		{
+			name:     "Microseconds and zone offset",
+			input:    "yyyy-MM-ddTHH:mm:ss.SSSSSSxxx",
+			expected: "2006-01-02T15:04:05.000000-07:00",
+		},
+		{
+			name:     "Zone offset without colon",
+			input:    "yyyyMMddHHmmssxx",
+			expected: "20060102150405-0700",
+		},
+		{
+			name:     "12-hour clock with AM/PM marker",
+			input:    "MM/dd/yyyy hh:mm a",
+			expected: "01/02/2006 03:04 PM",
+		},
+		{
+			name:     "Go layout used verbatim",
+			input:    "go:2006-01-02T15:04:05Z07:00 MST",
+			expected: "2006-01-02T15:04:05Z07:00 MST",
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,6 +126,21 @@ func TestExtractUserDateFormat(t *testing.T) {
 			input:    "HH:mm",
 			expected: "HH:mm",
 		},
+		{
+			name:     "Go layout",
+			input:    "go:2006-01-02T15:04:05Z07:00",
+			expected: "go:2006-01-02",
+		},
+		{
+			name:     "Go layout with month and weekday names",
+			input:    "go:Mon, 02 Jan 2006 15:04:05 MST",
+			expected: "go:Mon, 02 Jan 2006",
+		},
+		{
+			name:     "Go layout with two-digit year",
+			input:    "go:02/01/06 03:04PM",
+			expected: "go:02/01/06",
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,6 +153,61 @@ func TestExtractUserDateFormat(t *testing.T) {
 	}
 }
 
+func TestFormatCSVValueFractionAndZoneTokens(t *testing.T) {
+	ts := time.Date(2024, 3, 7, 21, 5, 3, 123456789, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   string
+		timeZone string
+		want     string
+	}{
+		{"microseconds with offset", "yyyy-MM-ddTHH:mm:ss.SSSSSSxxx", "Europe/Paris", "2024-03-07T22:05:03.123456+01:00"},
+		{"milliseconds with UTC offset", "yyyy-MM-ddTHH:mm:ss.SSSxxx", "UTC", "2024-03-07T21:05:03.123+00:00"},
+		{"compact offset", "yyyy-MM-dd HH:mm xx", "Asia/Kolkata", "2024-03-08 02:35 +0530"},
+		{"12-hour clock", "hh:mm a", "Europe/Paris", "10:05 PM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatCSVValue(ts, pgtype.TimestamptzOID, tt.format, tt.timeZone)
+			if got != tt.want {
+				t.Errorf("FormatCSVValue(%q, %q) = %q, want %q", tt.format, tt.timeZone, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoLayoutMatchesTokenFormat(t *testing.T) {
+	ts := time.Date(2024, 3, 7, 9, 5, 3, 456000000, time.UTC)
+
+	tests := []struct {
+		name  string
+		token string
+		goFmt string
+	}{
+		{"ISO 8601 with milliseconds", "yyyy-MM-ddTHH:mm:ss.SSS", "2006-01-02T15:04:05.000"},
+		{"European", "dd/MM/yyyy HH:mm", "02/01/2006 15:04"},
+		{"short year", "yy-MM-dd HH:mm:ss.S", "06-01-02 15:04:05.0"},
+	}
+
+	oids := []uint32{pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, oid := range oids {
+				for _, zone := range []string{"", "Asia/Tokyo"} {
+					want := FormatCSVValue(ts, oid, tt.token, zone)
+					got := FormatCSVValue(ts, oid, GoLayoutPrefix+tt.goFmt, zone)
+					if got != want {
+						t.Errorf("oid %d, zone %q: Go layout gave %q, token format gave %q", oid, zone, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestFormatValue(t *testing.T) {
 	testTime := time.Date(2024, 3, 15, 14, 30, 45, 123000000, time.UTC)
 	layout := "2006-01-02 15:04:05"
@@ -186,6 +280,166 @@ func TestFormatValue(t *testing.T) {
 	}
 }
 
+func TestBitString(t *testing.T) {
+	tests := []struct {
+		name     string
+		bits     pgtype.Bits
+		expected string
+	}{
+		{name: "fixed width", bits: pgtype.Bits{Bytes: []byte{0b10101000}, Len: 6, Valid: true}, expected: "101010"},
+		{name: "leading zeros", bits: pgtype.Bits{Bytes: []byte{0b00000100}, Len: 6, Valid: true}, expected: "000001"},
+		{name: "full byte", bits: pgtype.Bits{Bytes: []byte{0xff}, Len: 8, Valid: true}, expected: "11111111"},
+		{name: "spans two bytes", bits: pgtype.Bits{Bytes: []byte{0b10100101, 0b10000000}, Len: 9, Valid: true}, expected: "101001011"},
+		{name: "empty varbit", bits: pgtype.Bits{Valid: true}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BitString(tt.bits); got != tt.expected {
+				t.Errorf("BitString() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNetworkText(t *testing.T) {
+	tests := []struct {
+		name      string
+		val       interface{}
+		valueType uint32
+		expected  string
+		ok        bool
+	}{
+		{name: "inet host", val: netip.MustParsePrefix("192.168.1.5/32"), valueType: pgtype.InetOID, expected: "192.168.1.5", ok: true},
+		{name: "inet with netmask", val: netip.MustParsePrefix("192.168.1.5/24"), valueType: pgtype.InetOID, expected: "192.168.1.5/24", ok: true},
+		{name: "inet IPv6 host", val: netip.MustParsePrefix("2001:db8::1/128"), valueType: pgtype.InetOID, expected: "2001:db8::1", ok: true},
+		{name: "inet IPv6 with netmask", val: netip.MustParsePrefix("fe80::1/64"), valueType: pgtype.InetOID, expected: "fe80::1/64", ok: true},
+		{name: "cidr network", val: netip.MustParsePrefix("192.168.1.0/24"), valueType: pgtype.CIDROID, expected: "192.168.1.0/24", ok: true},
+		{name: "cidr single host keeps length", val: netip.MustParsePrefix("10.0.0.1/32"), valueType: pgtype.CIDROID, expected: "10.0.0.1/32", ok: true},
+		{name: "cidr IPv6", val: netip.MustParsePrefix("2001:db8::/32"), valueType: pgtype.CIDROID, expected: "2001:db8::/32", ok: true},
+		{name: "macaddr", val: net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03}, valueType: pgtype.MacaddrOID, expected: "08:00:2b:01:02:03", ok: true},
+		{name: "macaddr8", val: net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03, 0x04, 0x05}, valueType: pgtype.Macaddr8OID, expected: "08:00:2b:01:02:03:04:05", ok: true},
+		{name: "not an address", val: "192.168.1.5", valueType: pgtype.InetOID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NetworkText(tt.val, tt.valueType)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("NetworkText() = %q, %v, want %q, %v", got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExtractUserTimeFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		keepZone bool
+		expected string
+	}{
+		{name: "datetime format", input: "yyyy-MM-dd HH:mm:ss", expected: "HH:mm:ss"},
+		{name: "12-hour clock", input: "dd/MM/yyyy hh:mm a", expected: "hh:mm a"},
+		{name: "fraction kept", input: "yyyy-MM-dd HH:mm:ss.SSS", expected: "HH:mm:ss.SSS"},
+		{name: "zone dropped", input: "yyyy-MM-ddTHH:mm:ssxxx", expected: "HH:mm:ss"},
+		{name: "zone kept", input: "yyyy-MM-ddTHH:mm:ssxxx", keepZone: true, expected: "HH:mm:ssxxx"},
+		{name: "date only falls back", input: "yyyy-MM-dd", expected: "HH:mm:ss"},
+		{name: "Go layout", input: "go:2006-01-02T15:04:05Z07:00", expected: "go:15:04:05"},
+		{name: "Go layout zone kept", input: "go:2006-01-02T15:04:05Z07:00", keepZone: true, expected: "go:15:04:05Z07:00"},
+		{name: "Go date layout falls back", input: "go:02 Jan 2006", expected: "HH:mm:ss"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractUserTimeFormat(tt.input, tt.keepZone); got != tt.expected {
+				t.Errorf("extractUserTimeFormat(%q, %v) = %q, want %q", tt.input, tt.keepZone, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatTimeOfDay(t *testing.T) {
+	afternoon := pgtype.Time{Microseconds: (13*3600 + 45*60) * 1e6, Valid: true}
+
+	tests := []struct {
+		name      string
+		val       interface{}
+		valueType uint32
+		timefmt   string
+		timezone  string
+		expected  string
+	}{
+		{
+			name:      "time with the default format",
+			val:       afternoon,
+			valueType: pgtype.TimeOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ss",
+			expected:  "13:45:00",
+		},
+		{
+			name:      "time with a 12-hour format",
+			val:       afternoon,
+			valueType: pgtype.TimeOID,
+			timefmt:   "dd/MM/yyyy hh:mm a",
+			expected:  "01:45 PM",
+		},
+		{
+			name:      "time ignores the time zone",
+			val:       afternoon,
+			valueType: pgtype.TimeOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ssxxx",
+			timezone:  "Asia/Tokyo",
+			expected:  "13:45:00",
+		},
+		{
+			name:      "invalid time is empty",
+			val:       pgtype.Time{},
+			valueType: pgtype.TimeOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ss",
+			expected:  "",
+		},
+		{
+			name:      "timetz in UTC",
+			val:       "13:45:00+02",
+			valueType: pgtype.TimetzOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ss",
+			timezone:  "UTC",
+			expected:  "11:45:00",
+		},
+		{
+			name:      "timetz with offset and fraction",
+			val:       "08:30:00.5-05:30",
+			valueType: pgtype.TimetzOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ss.SSSxxx",
+			timezone:  "Asia/Tokyo",
+			expected:  "23:00:00.500+09:00",
+		},
+		{
+			name:      "unparsable timetz kept",
+			val:       "24:00:00+00",
+			valueType: pgtype.TimetzOID,
+			timefmt:   "yyyy-MM-dd HH:mm:ss",
+			timezone:  "UTC",
+			expected:  "24:00:00+00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCSVValue(tt.val, tt.valueType, tt.timefmt, tt.timezone); got != tt.expected {
+				t.Errorf("FormatCSVValue() = %q, want %q", got, tt.expected)
+			}
+			// JSON, YAML and XML share the same formatting
+			if tt.expected != "" {
+				if got := FormatJSONValue(tt.val, tt.valueType, tt.timefmt, tt.timezone); got != tt.expected {
+					t.Errorf("FormatJSONValue() = %v, want %q", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
 func TestFormatValueByOID(t *testing.T) {
 	testDate := time.Date(2021, 9, 25, 0, 0, 0, 0, time.UTC)
 	testTimestamp := time.Date(2024, 3, 15, 14, 30, 45, 123000000, time.UTC)
@@ -384,6 +638,24 @@ func TestFormatCSVValue(t *testing.T) {
 			timezone:  "",
 			expected:  "test string",
 		},
+		{
+			name:      "float NaN",
+			val:       math.NaN(),
+			valueType: pgtype.Float8OID,
+			expected:  "NaN",
+		},
+		{
+			name:      "float positive infinity",
+			val:       float32(math.Inf(1)),
+			valueType: pgtype.Float4OID,
+			expected:  "Infinity",
+		},
+		{
+			name:      "numeric negative infinity",
+			val:       pgtype.Numeric{InfinityModifier: pgtype.NegativeInfinity, Valid: true},
+			valueType: pgtype.NumericOID,
+			expected:  "-Infinity",
+		},
 	}
 
 	for _, tt := range tests {
@@ -494,6 +766,18 @@ func TestFormatXMLValue(t *testing.T) {
 			timezone:  "",
 			expected:  "1250.75",
 		},
+		{
+			name:      "float NaN",
+			val:       math.NaN(),
+			valueType: pgtype.Float8OID,
+			expected:  "NaN",
+		},
+		{
+			name:      "float negative infinity",
+			val:       math.Inf(-1),
+			valueType: pgtype.Float8OID,
+			expected:  "-Infinity",
+		},
 	}
 
 	for _, tt := range tests {
@@ -535,6 +819,54 @@ func TestFormatSQLValue(t *testing.T) {
 			valueType: pgtype.TimestampOID,
 			expected:  "'2024-03-15 14:30:45.123'::timestamp",
 		},
+		{
+			name:      "Time with cast",
+			value:     pgtype.Time{Microseconds: (13*3600 + 45*60) * 1e6, Valid: true},
+			valueType: pgtype.TimeOID,
+			expected:  "'13:45:00'::time",
+		},
+		{
+			name:      "Time with microseconds",
+			value:     pgtype.Time{Microseconds: (13*3600+45*60)*1e6 + 250, Valid: true},
+			valueType: pgtype.TimeOID,
+			expected:  "'13:45:00.00025'::time",
+		},
+		{
+			name:      "Bit string",
+			value:     pgtype.Bits{Bytes: []byte{0b10101000}, Len: 6, Valid: true},
+			valueType: pgtype.BitOID,
+			expected:  "B'101010'",
+		},
+		{
+			name:      "Varbit string",
+			value:     pgtype.Bits{Bytes: []byte{0b10100101, 0b10000000}, Len: 9, Valid: true},
+			valueType: pgtype.VarbitOID,
+			expected:  "B'101001011'",
+		},
+		{
+			name:      "Inet host",
+			value:     netip.MustParsePrefix("192.168.1.5/32"),
+			valueType: pgtype.InetOID,
+			expected:  "'192.168.1.5'::inet",
+		},
+		{
+			name:      "Cidr IPv6",
+			value:     netip.MustParsePrefix("2001:db8::/32"),
+			valueType: pgtype.CIDROID,
+			expected:  "'2001:db8::/32'::cidr",
+		},
+		{
+			name:      "Macaddr",
+			value:     net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03},
+			valueType: pgtype.MacaddrOID,
+			expected:  "'08:00:2b:01:02:03'::macaddr",
+		},
+		{
+			name:      "Timetz with cast",
+			value:     "13:45:00+02",
+			valueType: pgtype.TimetzOID,
+			expected:  "'13:45:00+02'::timetz",
+		},
 		{
 			name:      "Timestamptz with cast",
 			value:     testTimestamp,
@@ -629,13 +961,73 @@ func TestFormatSQLValue(t *testing.T) {
 			name:      "array value",
 			value:     []interface{}{1, 2, 3},
 			valueType: pgtype.Int4ArrayOID,
-			expected:  "'{1,2,3}'",
+			expected:  "'{1,2,3}'::int4[]",
 		},
 		{
 			name:      "empty array",
 			value:     []interface{}{},
 			valueType: pgtype.Int4ArrayOID,
-			expected:  "'{}'",
+			expected:  "'{}'::int4[]",
+		},
+		{
+			name:      "integer array with NULL",
+			value:     []interface{}{int64(-1), nil, int64(9007199254740993)},
+			valueType: pgtype.Int8ArrayOID,
+			expected:  "'{-1,NULL,9007199254740993}'::int8[]",
+		},
+		{
+			name:      "text array with commas, quotes and NULL",
+			value:     []interface{}{"a,b", `say "hi"`, "O'Brien", `C:\tmp`, "NULL", "", nil},
+			valueType: pgtype.TextArrayOID,
+			expected:  `'{"a,b","say \"hi\"",O''Brien,"C:\\tmp","NULL","",NULL}'::text[]`,
+		},
+		{
+			name:      "timestamptz array",
+			value:     []interface{}{time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+			valueType: pgtype.TimestamptzArrayOID,
+			expected:  "'{2024-01-15 10:30:00Z}'::timestamptz[]",
+		},
+		{
+			name:      "uuid array",
+			value:     []interface{}{[16]byte{0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78}},
+			valueType: pgtype.UUIDArrayOID,
+			expected:  "'{12345678-1234-5678-1234-567812345678}'::uuid[]",
+		},
+		{
+			name:      "record array",
+			value:     []interface{}{[]interface{}{int32(1), "a b"}, []interface{}{int32(2), nil}},
+			valueType: pgtype.RecordArrayOID,
+			expected:  `'{"(1,\"a b\")","(2,)"}'`,
+		},
+		{
+			name:      "array of unknown type",
+			value:     []interface{}{"x,y", "z"},
+			valueType: 99999,
+			expected:  `'{"x,y","z"}'`,
+		},
+		{
+			name:      "float8 NaN",
+			value:     math.NaN(),
+			valueType: pgtype.Float8OID,
+			expected:  "'NaN'::float8",
+		},
+		{
+			name:      "float8 infinity",
+			value:     math.Inf(1),
+			valueType: pgtype.Float8OID,
+			expected:  "'Infinity'::float8",
+		},
+		{
+			name:      "float4 negative infinity",
+			value:     float32(math.Inf(-1)),
+			valueType: pgtype.Float4OID,
+			expected:  "'-Infinity'::float4",
+		},
+		{
+			name:      "numeric NaN",
+			value:     pgtype.Numeric{NaN: true, Valid: true},
+			valueType: pgtype.NumericOID,
+			expected:  "'NaN'::numeric",
 		},
 	}
 
@@ -695,6 +1087,60 @@ func TestFormatValueWithDifferentTimezones(t *testing.T) {
 	}
 }
 
+func TestNumericText(t *testing.T) {
+	tests := []struct {
+		name     string
+		val      pgtype.Numeric
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "high precision value",
+			val:      pgtype.Numeric{Int: mustBigInt("123456789012345678901234567890"), Exp: -10, Valid: true},
+			expected: "12345678901234567890.1234567890",
+			ok:       true,
+		},
+		{
+			name:     "trailing zeros preserved",
+			val:      pgtype.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true},
+			expected: "12.50",
+			ok:       true,
+		},
+		{
+			name: "null",
+			val:  pgtype.Numeric{Valid: false},
+		},
+		{
+			name: "NaN",
+			val:  pgtype.Numeric{NaN: true, Valid: true},
+		},
+		{
+			name: "infinity",
+			val:  pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := NumericText(tt.val)
+			if ok != tt.ok {
+				t.Fatalf("NumericText() ok = %v, want %v", ok, tt.ok)
+			}
+			if result != tt.expected {
+				t.Errorf("NumericText() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func mustBigInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big.Int literal: " + s)
+	}
+	return n
+}
+
 func TestQuoteIdent(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -748,6 +1194,45 @@ func TestQuoteIdent(t *testing.T) {
 	}
 }
 
+func TestBareIdent(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		errContains string
+	}{
+		{name: "simple identifier", input: "users"},
+		{name: "schema.table", input: "public.users"},
+		{name: "underscore, digits and dollar", input: "_order_items2$"},
+		{name: "uppercase", input: "Users", errContains: `"Users" contains 'U'`},
+		{name: "space", input: "user name", errContains: `"user name" contains ' '`},
+		{name: "hyphen", input: "table-name", errContains: `contains '-'`},
+		{name: "leading digit", input: "1users", errContains: `"1users" starts with '1'`},
+		{name: "reserved keyword", input: "public.user", errContains: `"user" is a reserved keyword`},
+		{name: "empty string", input: "", errContains: "empty name"},
+		{name: "empty schema", input: ".users", errContains: "empty name"},
+		{name: "too long", input: strings.Repeat("a", 64), errContains: "longer than 63 bytes"},
+		{name: "non-ASCII letter", input: "café", errContains: `contains 'é'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BareIdent(tt.input)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("BareIdent(%q) error = %v, want containing %q", tt.input, err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BareIdent(%q) error: %v", tt.input, err)
+			}
+			if result != tt.input {
+				t.Errorf("BareIdent(%q) = %q, want %q", tt.input, result, tt.input)
+			}
+		})
+	}
+}
+
 func TestUserTimeZoneFormat(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -923,3 +1408,242 @@ func BenchmarkQuoteIdent(b *testing.B) {
 		}
 	})
 }
+
+func TestSpecialFloatText(t *testing.T) {
+	tests := []struct {
+		name     string
+		val      interface{}
+		wantText string
+		wantOK   bool
+	}{
+		{"float64 NaN", math.NaN(), "NaN", true},
+		{"float64 +Inf", math.Inf(1), "Infinity", true},
+		{"float64 -Inf", math.Inf(-1), "-Infinity", true},
+		{"float32 NaN", float32(math.NaN()), "NaN", true},
+		{"numeric NaN", pgtype.Numeric{NaN: true, Valid: true}, "NaN", true},
+		{"numeric +Inf", pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}, "Infinity", true},
+		{"numeric NULL", pgtype.Numeric{}, "", false},
+		{"finite float", 1.5, "", false},
+		{"finite numeric", pgtype.Numeric{Int: big.NewInt(15), Exp: -1, Valid: true}, "", false},
+		{"text NaN is not a float", "NaN", "", false},
+		{"nil", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := SpecialFloatText(tt.val)
+			if text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("SpecialFloatText(%v) = (%q, %v), want (%q, %v)", tt.val, text, ok, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limit  int
+		marker string
+		want   string
+	}{
+		{"shorter than limit", "abc", 5, "...", "abc"},
+		{"exactly at limit", "abcde", 5, "...", "abcde"},
+		{"cut at limit", "abcdefgh", 5, "...", "abcde..."},
+		{"custom marker", "abcdefgh", 3, " [cut]", "abc [cut]"},
+		{"empty marker", "abcdefgh", 3, "", "abc"},
+		{"counts characters not bytes", "éàüöç€", 4, "…", "éàüö…"},
+		{"disabled", "abcdefgh", 0, "...", "abcdefgh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateText(tt.input, tt.limit, tt.marker); got != tt.want {
+				t.Errorf("TruncateText(%q, %d) = %q, want %q", tt.input, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimText(t *testing.T) {
+	tests := []struct {
+		name      string
+		val       interface{}
+		valueType uint32
+		want      interface{}
+	}{
+		{"char(6) padding", "abc   ", pgtype.BPCharOID, "abc"},
+		{"text trailing tab and newline", "abc\t\n", pgtype.TextOID, "abc"},
+		{"varchar leading spaces kept", "  abc  ", pgtype.VarcharOID, "  abc"},
+		{"name", "users ", pgtype.NameOID, "users"},
+		{"only spaces", "   ", pgtype.BPCharOID, ""},
+		{"NULL", nil, pgtype.BPCharOID, nil},
+		{"json untouched", "{} ", pgtype.JSONOID, "{} "},
+		{"non-text type untouched", int32(1), pgtype.Int4OID, int32(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrimText(tt.val, tt.valueType); got != tt.want {
+				t.Errorf("TrimText(%q) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoalesceValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		valueType   uint32
+		want        interface{}
+		errContains string
+	}{
+		{name: "empty text", text: "", valueType: pgtype.TextOID, want: ""},
+		{name: "varchar", text: "n/a", valueType: pgtype.VarcharOID, want: "n/a"},
+		{name: "smallint", text: "-3", valueType: pgtype.Int2OID, want: int16(-3)},
+		{name: "integer", text: "0", valueType: pgtype.Int4OID, want: int32(0)},
+		{name: "bigint", text: "9000000000", valueType: pgtype.Int8OID, want: int64(9000000000)},
+		{name: "real", text: "1.5", valueType: pgtype.Float4OID, want: float32(1.5)},
+		{name: "double precision", text: "2.25", valueType: pgtype.Float8OID, want: 2.25},
+		{name: "boolean", text: "false", valueType: pgtype.BoolOID, want: false},
+		{name: "date kept as text", text: "1970-01-01", valueType: pgtype.DateOID, want: "1970-01-01"},
+		{name: "integer out of range", text: "70000", valueType: pgtype.Int2OID, errContains: `"70000" is not a valid smallint`},
+		{name: "empty integer", text: "", valueType: pgtype.Int4OID, errContains: `"" is not a valid integer`},
+		{name: "invalid boolean", text: "maybe", valueType: pgtype.BoolOID, errContains: `"maybe" is not a valid boolean`},
+		{name: "invalid numeric", text: "1,5", valueType: pgtype.NumericOID, errContains: `"1,5" is not a valid numeric`},
+		{name: "invalid json", text: "{", valueType: pgtype.JSONBOID, errContains: `"{" is not valid JSON`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CoalesceValue(tt.text, tt.valueType)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("CoalesceValue(%q) error = %v, want %q", tt.text, err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CoalesceValue(%q) unexpected error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("CoalesceValue(%q) = %#v, want %#v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("numeric", func(t *testing.T) {
+		got, err := CoalesceValue("12.50", pgtype.NumericOID)
+		if err != nil {
+			t.Fatalf("CoalesceValue() unexpected error: %v", err)
+		}
+		if sql := FormatSQLValue(got, pgtype.NumericOID); sql != "12.5" {
+			t.Errorf("FormatSQLValue(CoalesceValue(\"12.50\")) = %q, want %q", sql, "12.5")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		got, err := CoalesceValue(`{"tags":[]}`, pgtype.JSONBOID)
+		if err != nil {
+			t.Fatalf("CoalesceValue() unexpected error: %v", err)
+		}
+		if sql := FormatSQLValue(got, pgtype.JSONBOID); sql != `'{"tags":[]}'::jsonb` {
+			t.Errorf("FormatSQLValue(CoalesceValue()) = %q, want %q", sql, `'{"tags":[]}'::jsonb`)
+		}
+	})
+}
+
+func TestMoneyText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantText string
+		wantOK   bool
+	}{
+		{"en_US positive", "$1,234.56", "1234.56", true},
+		{"en_US negative", "-$1,234.56", "-1234.56", true},
+		{"parenthesized negative", "($5.00)", "-5.00", true},
+		{"zero", "$0.00", "0.00", true},
+		{"negative zero stays zero", "-$0.00", "0.00", true},
+		{"large value", "$92,233,720,368,547,758.07", "92233720368547758.07", true},
+		{"de_DE", "1.234,56 €", "1234.56", true},
+		{"fr_FR with spaces", "-1 234,56 €", "-1234.56", true},
+		{"no fraction digits", "¥1,235", "1235", true},
+		{"plain decimal", "12.5", "12.5", true},
+		{"plain decimal with three fraction digits", "1.234", "1.234", true},
+		{"negative plain decimal", "-0.050", "-0.050", true},
+		{"no digits", "$", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := MoneyText(tt.input)
+			if text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("MoneyText(%q) = (%q, %v), want (%q, %v)", tt.input, text, ok, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMoneyTextDigits(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		fracDigits int
+		wantText   string
+		wantOK     bool
+	}{
+		{"en_US", "$1,234.56", 2, "1234.56", true},
+		{"en_US negative", "-$1,234.56", 2, "-1234.56", true},
+		{"ja_JP", "￥1,235", 0, "1235", true},
+		{"three fraction digits", "KD 1,235", 3, "1.235", true},
+		{"three fraction digits with grouping", "KD 1.234,567", 3, "1234.567", true},
+		{"de_DE", "1.234,56 €", 2, "1234.56", true},
+		{"parenthesized negative", "($5.00)", 2, "-5.00", true},
+		{"negative zero stays zero", "-$0.00", 2, "0.00", true},
+		{"fewer digits than fraction", "$5", 2, "0.05", true},
+		{"unknown digits falls back to MoneyText", "$1,234.56", -1, "1234.56", true},
+		{"no digits", "$", 2, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := MoneyTextDigits(tt.input, tt.fracDigits)
+			if text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("MoneyTextDigits(%q, %d) = (%q, %v), want (%q, %v)", tt.input, tt.fracDigits, text, ok, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatMoneyValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		wantCSV string
+		wantSQL string
+		wantXLS interface{}
+	}{
+		{"positive", "$1,234.56", "1234.56", "'1234.56'::numeric::money", 1234.56},
+		{"negative", "-$99.99", "-99.99", "'-99.99'::numeric::money", -99.99},
+		{"zero", "$0.00", "0.00", "'0.00'::numeric::money", 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCSVValue(tt.val, MoneyOID, "", ""); got != tt.wantCSV {
+				t.Errorf("FormatCSVValue(%q) = %q, want %q", tt.val, got, tt.wantCSV)
+			}
+			if got := FormatXMLValue(tt.val, MoneyOID, "", ""); got != tt.wantCSV {
+				t.Errorf("FormatXMLValue(%q) = %q, want %q", tt.val, got, tt.wantCSV)
+			}
+			if got := FormatSQLValue(tt.val, MoneyOID); got != tt.wantSQL {
+				t.Errorf("FormatSQLValue(%q) = %q, want %q", tt.val, got, tt.wantSQL)
+			}
+			if got := FormatXLSXValue(tt.val, MoneyOID, "", ""); got != tt.wantXLS {
+				t.Errorf("FormatXLSXValue(%q) = %v, want %v", tt.val, got, tt.wantXLS)
+			}
+		})
+	}
+}
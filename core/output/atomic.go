@@ -0,0 +1,115 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// atomicSuffix is appended to the final path to name the temporary file of an
+// atomic output.
+const atomicSuffix = ".tmp"
+
+// atomicFile writes to a temporary file next to the final path and moves it
+// into place on Close once the output has been committed. An uncommitted
+// output, or one that hit a write error, is removed instead, so readers never
+// see a partial file.
+type atomicFile struct {
+	file      *os.File
+	path      string // final path
+	committed bool
+	failed    bool
+}
+
+// createFile creates the output file at path, or its temporary file when
+// atomic is set. An existing named pipe is opened for writing instead.
+func createFile(path string, atomic bool) (io.WriteCloser, error) {
+	if IsFIFO(path) {
+		if atomic {
+			return nil, fmt.Errorf("atomic output is not supported for named pipe %s", path)
+		}
+		// Opening blocks until a reader opens the other end
+		logger.Debug("Writing to named pipe: %s", path)
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	if !atomic {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+
+	tmp := path + atomicSuffix
+	logger.Debug("Writing atomically through temporary file: %s", tmp)
+	file, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{file: file, path: path}, nil
+}
+
+// Write implements io.Writer and remembers failures so Close discards the output.
+func (a *atomicFile) Write(p []byte) (int, error) {
+	n, err := a.file.Write(p)
+	if err != nil {
+		a.failed = true
+	}
+	return n, err
+}
+
+// Commit marks the output as complete.
+func (a *atomicFile) Commit() {
+	a.committed = true
+}
+
+// Close renames the temporary file to the final path when the output was
+// committed without write errors, and removes it otherwise.
+func (a *atomicFile) Close() error {
+	tmp := a.file.Name()
+
+	if !a.committed || a.failed {
+		a.file.Close()
+		logger.Debug("Discarding incomplete output: %s", tmp)
+		if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing temporary file: %w", err)
+		}
+		return nil
+	}
+
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error syncing temporary file: %w", err)
+	}
+	if err := a.file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error closing temporary file: %w", err)
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error moving output into place: %w", err)
+	}
+	logger.Debug("Moved %s to %s", tmp, a.path)
+	return nil
+}
+
+// committer is implemented by writers that take part in atomic output.
+type committer interface {
+	Commit()
+}
+
+// Commit marks the output behind w as complete, so closing an atomic output
+// moves it to its final path. Exporters call it once everything has been
+// written; it has no effect on non-atomic outputs.
+func Commit(w io.Writer) {
+	if c, ok := w.(committer); ok {
+		c.Commit()
+	}
+}
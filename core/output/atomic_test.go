@@ -0,0 +1,133 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateOutputWriter_Atomic(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression string
+		commit      bool
+	}{
+		{"committed plain file", "none", true},
+		{"committed gzip", "gzip", true},
+		{"committed zip", "zip", true},
+		{"committed zstd", "zstd", true},
+		{"committed lz4", "lz4", true},
+		{"uncommitted plain file", "none", false},
+		{"uncommitted gzip", "gzip", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := OutputConfig{
+				Path:        filepath.Join(t.TempDir(), "test.csv"),
+				Compression: tt.compression,
+				Format:      "csv",
+				Atomic:      true,
+			}
+			final := FinalPath(cfg)
+
+			writer, err := CreateWriter(cfg)
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			if _, err := writer.Write([]byte("id,name\n1,alice\n")); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			if _, err := os.Stat(final); !os.IsNotExist(err) {
+				t.Errorf("final file %s should not exist before Close", final)
+			}
+			if _, err := os.Stat(final + atomicSuffix); err != nil {
+				t.Errorf("temporary file should exist while writing: %v", err)
+			}
+
+			if tt.commit {
+				Commit(writer)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if _, err := os.Stat(final + atomicSuffix); !os.IsNotExist(err) {
+				t.Errorf("temporary file should be gone after Close")
+			}
+			_, statErr := os.Stat(final)
+			if tt.commit && statErr != nil {
+				t.Errorf("final file should exist after a committed Close: %v", statErr)
+			}
+			if !tt.commit && !os.IsNotExist(statErr) {
+				t.Errorf("final file should not exist after an uncommitted Close")
+			}
+		})
+	}
+}
+
+func TestAtomicFile_WriteErrorLeavesNoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.csv")
+
+	wc, err := createFile(path, true)
+	if err != nil {
+		t.Fatalf("createFile() error = %v", err)
+	}
+	if _, err := wc.Write([]byte("partial")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// Close the file behind the writer's back so the next write fails
+	wc.(*atomicFile).file.Close()
+	if _, err := wc.Write([]byte(" row")); err == nil {
+		t.Fatal("Write() expected an error on a closed file")
+	}
+
+	Commit(wc)
+	if err := wc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, p := range []string{path, path + atomicSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist after a failed write", p)
+		}
+	}
+}
+
+func TestCreateOutputWriter_AtomicReplacesExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.csv")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write existing file: %v", err)
+	}
+
+	writer, err := CreateWriter(OutputConfig{Path: path, Compression: "none", Format: "csv", Atomic: true})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	writer.Write([]byte("new\n"))
+
+	// An abandoned export keeps the previous file intact
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "old\n" {
+		t.Errorf("content = %q, want previous file kept", content)
+	}
+
+	writer, err = CreateWriter(OutputConfig{Path: path, Compression: "none", Format: "csv", Atomic: true})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	writer.Write([]byte("new\n"))
+	Commit(writer)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	content, _ = os.ReadFile(path)
+	if string(content) != "new\n" {
+		t.Errorf("content = %q, want %q", content, "new\n")
+	}
+}
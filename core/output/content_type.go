@@ -0,0 +1,43 @@
+package output
+
+import "strings"
+
+// formatContentTypes maps export formats to their media type.
+var formatContentTypes = map[string]string{
+	"csv":      "text/csv",
+	"tsv":      "text/tab-separated-values",
+	"json":     "application/json",
+	"xml":      "application/xml",
+	"yaml":     "application/x-yaml",
+	"sql":      "application/sql",
+	"xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"template": "text/plain",
+}
+
+// ContentType returns the media type of an output of format compressed with
+// compression, and the content encoding to declare with it, for HTTP
+// responses and object storage metadata.
+//
+// gzip and zstd are content encodings: the type stays the format's and the
+// encoding names the compression, as for a "users.json.gz" served with
+// Content-Encoding: gzip. zip and lz4 files have a type of their own and no
+// encoding. Formats without a known type are application/octet-stream.
+func ContentType(format, compression string) (contentType, contentEncoding string) {
+	contentType, ok := formatContentTypes[strings.ToLower(strings.TrimSpace(format))]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+
+	switch strings.ToLower(strings.TrimSpace(compression)) {
+	case GZIP:
+		return contentType, "gzip"
+	case ZSTD:
+		return contentType, "zstd"
+	case ZIP:
+		return "application/zip", ""
+	case LZ4:
+		return "application/x-lz4", ""
+	default:
+		return contentType, ""
+	}
+}
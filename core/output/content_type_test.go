@@ -0,0 +1,41 @@
+package output
+
+import "testing"
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		format       string
+		compression  string
+		wantType     string
+		wantEncoding string
+	}{
+		{"csv", "none", "text/csv", ""},
+		{"tsv", "none", "text/tab-separated-values", ""},
+		{"json", "none", "application/json", ""},
+		{"xml", "none", "application/xml", ""},
+		{"yaml", "none", "application/x-yaml", ""},
+		{"sql", "none", "application/sql", ""},
+		{"xlsx", "none", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ""},
+		{"template", "none", "text/plain", ""},
+		{"markdown", "none", "application/octet-stream", ""},
+		{"csv", "", "text/csv", ""},
+		{"csv", "gzip", "text/csv", "gzip"},
+		{"json", "gzip", "application/json", "gzip"},
+		{"xlsx", "gzip", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "gzip"},
+		{"xml", "zstd", "application/xml", "zstd"},
+		{"csv", "zip", "application/zip", ""},
+		{"json", "zip", "application/zip", ""},
+		{"csv", "lz4", "application/x-lz4", ""},
+		{" JSON ", " GZIP ", "application/json", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.compression, func(t *testing.T) {
+			gotType, gotEncoding := ContentType(tt.format, tt.compression)
+			if gotType != tt.wantType || gotEncoding != tt.wantEncoding {
+				t.Errorf("ContentType(%q, %q) = (%q, %q), want (%q, %q)",
+					tt.format, tt.compression, gotType, gotEncoding, tt.wantType, tt.wantEncoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+const (
+	UTF8        = "utf-8"
+	Latin1      = "latin1"
+	Windows1252 = "windows-1252"
+)
+
+// encodingAliases maps accepted --output-encoding spellings to their canonical name.
+var encodingAliases = map[string]string{
+	"utf-8":        UTF8,
+	"utf8":         UTF8,
+	"latin1":       Latin1,
+	"latin-1":      Latin1,
+	"iso-8859-1":   Latin1,
+	"iso8859-1":    Latin1,
+	"windows-1252": Windows1252,
+	"cp1252":       Windows1252,
+}
+
+// charmaps holds the single-byte character sets behind each non-UTF-8 encoding.
+var charmaps = map[string]*charmap.Charmap{
+	Latin1:      charmap.ISO8859_1,
+	Windows1252: charmap.Windows1252,
+}
+
+// NormalizeEncoding returns the canonical name of an output encoding
+// (utf-8, latin1 or windows-1252). An empty name means UTF-8.
+func NormalizeEncoding(name string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return UTF8, nil
+	}
+	if canonical, ok := encodingAliases[key]; ok {
+		return canonical, nil
+	}
+	return "", fmt.Errorf("unsupported output encoding %q (use utf-8, latin1 or windows-1252)", name)
+}
+
+// EncodingLabel returns the IANA name of an encoding, as used in an XML declaration.
+func EncodingLabel(name string) string {
+	canonical, err := NormalizeEncoding(name)
+	if err != nil {
+		return name
+	}
+	switch canonical {
+	case Latin1:
+		return "ISO-8859-1"
+	case Windows1252:
+		return "windows-1252"
+	default:
+		return "UTF-8"
+	}
+}
+
+// encodingWriteCloser transcodes UTF-8 input into a single-byte charset
+// before it reaches the underlying (possibly compressed) writer.
+type encodingWriteCloser struct {
+	writer     *transform.Writer
+	underlying io.WriteCloser
+	name       string
+}
+
+// newEncodingWriter wraps wc so that everything written is converted from
+// UTF-8 to the given encoding. Characters missing from the target charset are
+// replaced with '?', or make Write fail when strict is set.
+// UTF-8 output is returned unchanged.
+func newEncodingWriter(wc io.WriteCloser, name string, strict bool) (io.WriteCloser, error) {
+	canonical, err := NormalizeEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if canonical == UTF8 {
+		return wc, nil
+	}
+
+	cm := charmaps[canonical]
+	var t transform.Transformer = cm.NewEncoder()
+	if !strict {
+		t = transform.Chain(runes.Map(func(r rune) rune {
+			if _, ok := cm.EncodeRune(r); !ok {
+				return '?'
+			}
+			return r
+		}), t)
+	}
+
+	logger.Debug("Transcoding output to %s (strict=%v)", canonical, strict)
+	return &encodingWriteCloser{
+		writer:     transform.NewWriter(wc, t),
+		underlying: wc,
+		name:       canonical,
+	}, nil
+}
+
+// Write implements io.Writer.
+func (e *encodingWriteCloser) Write(p []byte) (int, error) {
+	n, err := e.writer.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("character not representable in %s: %w", e.name, err)
+	}
+	return n, nil
+}
+
+// Flush flushes the underlying writer. Complete characters are transcoded on
+// every Write, so only a partial trailing rune can remain buffered.
+func (e *encodingWriteCloser) Flush() error {
+	return Flush(e.underlying)
+}
+
+// Commit marks the underlying output as complete.
+func (e *encodingWriteCloser) Commit() {
+	Commit(e.underlying)
+}
+
+// Close flushes the transcoder and closes the underlying writer.
+func (e *encodingWriteCloser) Close() error {
+	if err := e.writer.Close(); err != nil {
+		e.underlying.Close() // Attempt to close even if the transcoder fails
+		return fmt.Errorf("character not representable in %s: %w", e.name, err)
+	}
+	return e.underlying.Close()
+}
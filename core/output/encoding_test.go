@@ -0,0 +1,175 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeEncoding(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", UTF8, false},
+		{"UTF8", UTF8, false},
+		{"latin1", Latin1, false},
+		{" ISO-8859-1 ", Latin1, false},
+		{"cp1252", Windows1252, false},
+		{"Windows-1252", Windows1252, false},
+		{"ebcdic", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeEncoding(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeEncoding(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeEncoding(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOutputWriter_Encoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    string
+		strict      bool
+		input       string
+		want        []byte
+		errContains string
+	}{
+		{
+			name:     "utf-8 unchanged",
+			encoding: "utf-8",
+			input:    "café,€\n",
+			want:     []byte("café,€\n"),
+		},
+		{
+			name:     "latin1 accented characters",
+			encoding: "latin1",
+			input:    "café,naïve,Ångström\n",
+			want:     []byte("caf\xe9,na\xefve,\xc5ngstr\xf6m\n"),
+		},
+		{
+			name:     "windows-1252 euro sign",
+			encoding: "windows-1252",
+			input:    "prix,12€\n",
+			want:     []byte("prix,12\x80\n"),
+		},
+		{
+			name:     "latin1 replaces unsupported characters",
+			encoding: "latin1",
+			input:    "12€ 東京\n",
+			want:     []byte("12? ??\n"),
+		},
+		{
+			name:        "latin1 strict rejects unsupported characters",
+			encoding:    "latin1",
+			strict:      true,
+			input:       "12€\n",
+			errContains: "not representable in latin1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.csv")
+			writer, err := CreateWriter(OutputConfig{
+				Path:           path,
+				Compression:    "none",
+				Format:         "csv",
+				Encoding:       tt.encoding,
+				EncodingStrict: tt.strict,
+			})
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+
+			// Write byte by byte so multi-byte characters are split across writes
+			var writeErr error
+			for i := 0; i < len(tt.input) && writeErr == nil; i++ {
+				_, writeErr = writer.Write([]byte{tt.input[i]})
+			}
+			closeErr := writer.Close()
+
+			if tt.errContains != "" {
+				err := writeErr
+				if err == nil {
+					err = closeErr
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if writeErr != nil || closeErr != nil {
+				t.Fatalf("unexpected error: write=%v close=%v", writeErr, closeErr)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if !bytes.Equal(content, tt.want) {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOutputWriter_EncodingWithGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.csv")
+	writer, err := CreateWriter(OutputConfig{
+		Path:        path,
+		Compression: "gzip",
+		Format:      "csv",
+		Encoding:    "latin1",
+	})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	if _, err := writer.Write([]byte("déjà vu\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatalf("Failed to open compressed file: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	content, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress: %v", err)
+	}
+	if want := []byte("d\xe9j\xe0 vu\n"); !bytes.Equal(content, want) {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestCreateOutputWriter_InvalidEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.csv")
+	_, err := CreateWriter(OutputConfig{Path: path, Compression: "none", Format: "csv", Encoding: "ebcdic"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported output encoding") {
+		t.Fatalf("CreateWriter() error = %v, want unsupported encoding", err)
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Errorf("no file should be created for an invalid encoding")
+	}
+}
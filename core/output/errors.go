@@ -0,0 +1,55 @@
+package output
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrWrite is matched by errors.Is for failures to create, write or close the
+// output, e.g. a missing directory, a full disk or a closed HTTP connection.
+var ErrWrite = errors.New("unable to write output")
+
+// writeError tags an I/O failure of the output with ErrWrite, keeping its
+// message. errors.As still reaches the underlying error (e.g. *fs.PathError).
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string   { return e.err.Error() }
+func (e *writeError) Unwrap() []error { return []error{ErrWrite, e.err} }
+
+// tagWriteError returns err tagged with ErrWrite, or nil when err is nil.
+func tagWriteError(err error) error {
+	if err == nil || errors.Is(err, ErrWrite) {
+		return err
+	}
+	return &writeError{err: err}
+}
+
+// destination is the file, or OutputConfig.Writer, beneath the compression
+// and encoding layers. Its errors are tagged with ErrWrite, so they can be
+// told apart from formatting errors once exporters have wrapped them. Closing
+// it again is a no-op, so an output closed early (e.g. to patch the XML row
+// count) can still be closed by a deferred call.
+type destination struct {
+	io.WriteCloser
+	closed bool
+}
+
+func (d *destination) Write(p []byte) (int, error) {
+	n, err := d.WriteCloser.Write(p)
+	return n, tagWriteError(err)
+}
+
+func (d *destination) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	return tagWriteError(d.WriteCloser.Close())
+}
+
+// Commit marks the underlying output as complete.
+func (d *destination) Commit() {
+	Commit(d.WriteCloser)
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// makeFIFO creates a named pipe in a temporary directory, skipping the test
+// where named pipes are not available.
+func makeFIFO(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are not files on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "export.fifo")
+	if out, err := exec.Command("mkfifo", path).CombinedOutput(); err != nil {
+		t.Skipf("mkfifo unavailable: %v %s", err, out)
+	}
+	return path
+}
+
+// readFIFO reads everything written to the named pipe at path in the
+// background and returns a channel receiving the data once the writer closes.
+func readFIFO(t *testing.T, path string) <-chan []byte {
+	t.Helper()
+	done := make(chan []byte, 1)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Errorf("opening fifo for reading: %v", err)
+			done <- nil
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Errorf("reading fifo: %v", err)
+		}
+		done <- data
+	}()
+	return done
+}
+
+func TestIsFIFO(t *testing.T) {
+	fifo := makeFIFO(t)
+	regular := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(regular, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"named pipe", fifo, true},
+		{"regular file", regular, false},
+		{"directory", filepath.Dir(regular), false},
+		{"missing file", filepath.Join(t.TempDir(), "missing.csv"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFIFO(tt.path); got != tt.want {
+				t.Errorf("IsFIFO(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateWriter_FIFO(t *testing.T) {
+	const content = "id,name\n1,alice\n2,bob\n"
+
+	for _, compression := range []string{"none", "gzip"} {
+		t.Run(compression, func(t *testing.T) {
+			path := makeFIFO(t)
+			cfg := OutputConfig{Path: path, Compression: compression, Format: "csv"}
+			if got := FinalPath(cfg); got != path {
+				t.Errorf("FinalPath() = %q, want the pipe path %q", got, path)
+			}
+
+			received := readFIFO(t, path)
+			writer, err := CreateWriter(cfg)
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			if _, err := writer.Write([]byte(content)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			data := <-received
+			if compression == "gzip" {
+				zr, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("pipe did not receive a gzip stream: %v", err)
+				}
+				if data, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("decompressing: %v", err)
+				}
+			}
+			if string(data) != content {
+				t.Errorf("reader received %q, want %q", data, content)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+				t.Errorf("the named pipe should be left in place, stat = %v, %v", info, err)
+			}
+			if _, err := os.Stat(path + ".gz"); !os.IsNotExist(err) {
+				t.Errorf("no file with a compression extension should be created, got err = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateWriter_FIFOAtomic(t *testing.T) {
+	path := makeFIFO(t)
+	_, err := CreateWriter(OutputConfig{Path: path, Compression: "none", Atomic: true})
+	if err == nil || !strings.Contains(err.Error(), "atomic output is not supported for named pipe") {
+		t.Fatalf("CreateWriter() error = %v, want atomic named pipe error", err)
+	}
+	if _, err := os.Stat(path + atomicSuffix); !os.IsNotExist(err) {
+		t.Errorf("no temporary file should be created, got err = %v", err)
+	}
+}
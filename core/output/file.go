@@ -8,12 +8,47 @@ import (
 	"github.com/fbz-tec/pgxport/internal/logger"
 )
 
-func newFileWriter(path string) (io.WriteCloser, error) {
+// IsFIFO reports whether path is an existing named pipe (FIFO). Output to a
+// FIFO streams to the process reading it: the pipe is opened as is, keeps its
+// name without a compression extension and is never renamed or removed.
+func IsFIFO(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+func newFileWriter(file io.WriteCloser, path string) io.WriteCloser {
 	logger.Debug("Creating uncompressed output file: %s", path)
-	file, err := os.Create(path)
+	// Using 256KB buffer provides optimal throughput for large exports
+	return newBufferedWriteCloser(file, 256*1024)
+}
+
+// newAppendWriter reopens the file at path, drops everything after the first
+// size bytes (e.g. a record cut short by an interrupted export) and appends
+// to it.
+func newAppendWriter(path string, size int64) (io.WriteCloser, error) {
+	logger.Debug("Appending to output file %s after byte %d", path, size)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
 	if err != nil {
-		return nil, fmt.Errorf("error creating file: %w", err)
+		return nil, fmt.Errorf("error opening file: %w", tagWriteError(err))
 	}
-	// Using 256KB buffer provides optimal throughput for large exports
-	return newBufferedWriteCloser(file, 256*1024), nil
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error opening file: %w", tagWriteError(err))
+	}
+	if info.Size() < size {
+		file.Close()
+		return nil, fmt.Errorf("output %s has %d bytes, expected at least %d", path, info.Size(), size)
+	}
+
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error truncating file: %w", tagWriteError(err))
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error seeking file: %w", tagWriteError(err))
+	}
+	return newBufferedWriteCloser(&destination{WriteCloser: file}, 256*1024), nil
 }
@@ -2,27 +2,21 @@ package output
 
 import (
 	"compress/gzip"
-	"fmt"
 	"io"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/internal/logger"
 )
 
-func newGzipWriter(path string) (io.WriteCloser, error) {
+// newGzipWriter compresses to a single gzip member, closed by Close. Flush
+// emits a sync flush inside that member, so periodic flushes do not start new
+// members.
+func newGzipWriter(file io.WriteCloser, path string) io.WriteCloser {
 	start := time.Now()
-	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
-		path += ".gz"
-	}
 	logger.Debug("Creating gzip-compressed output file: %s", path)
-	file, err := os.Create(path)
-	if err != nil {
-		return nil, fmt.Errorf("error creating file: %w", err)
-	}
 	gzipWriter := gzip.NewWriter(file)
 	return &compositeWriteCloser{
+		file:   file,
 		Writer: gzipWriter,
 		closeFunc: func() error {
 			logger.Debug("Finalizing gzip compression for: %s", path)
@@ -36,5 +30,5 @@ func newGzipWriter(path string) (io.WriteCloser, error) {
 			logger.Debug("GZIP file closed successfully in %v", time.Since(start))
 			return err
 		},
-	}, nil
+	}
 }
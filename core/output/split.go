@@ -0,0 +1,130 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PartPlaceholder is replaced by the part number in the path of an output
+// split into several files, e.g. "users_{part}.csv".
+const PartPlaceholder = "{part}"
+
+// Part is one file of an output split every N rows. Rows are numbered from 1
+// across the whole export; an empty part has FirstRow and LastRow 0.
+type Part struct {
+	Number   int    `json:"part"`
+	File     string `json:"file"`
+	FirstRow int    `json:"first_row"`
+	LastRow  int    `json:"last_row"`
+	Rows     int    `json:"rows"`
+}
+
+// PartTemplate returns path with a {part} placeholder. A path without one
+// gets "_part{part}" before its format and compression extensions:
+// "users.csv.gz" becomes "users_part{part}.csv.gz".
+func PartTemplate(path string) string {
+	if strings.Contains(path, PartPlaceholder) {
+		return path
+	}
+	base, suffix := splitCompressionExtension(path)
+	ext := filepath.Ext(base)
+	if ext == filepath.Base(base) {
+		// A dot file such as ".csv" has no extension to keep
+		ext = ""
+	}
+	return strings.TrimSuffix(base, ext) + "_part" + PartPlaceholder + ext + suffix
+}
+
+// PartPath returns the path of part n (from 1) of template, with the number
+// zero-padded to three digits so the parts sort in order.
+func PartPath(template string, n int) string {
+	return strings.ReplaceAll(template, PartPlaceholder, fmt.Sprintf("%03d", n))
+}
+
+// Splitter names the files of an output split every N rows and records each
+// finished part for the index file.
+type Splitter struct {
+	template string
+	parts    []Part
+	rows     int // rows written in the parts recorded so far
+}
+
+// NewSplitter returns a splitter for the parts of template, a path holding
+// a {part} placeholder (see PartTemplate).
+func NewSplitter(template string) *Splitter {
+	return &Splitter{template: template}
+}
+
+// NextPath returns the path of the part to write next.
+func (s *Splitter) NextPath() string {
+	return PartPath(s.template, len(s.parts)+1)
+}
+
+// Add records the next part: file is the path actually written, including
+// any compression extension, and rows the number of rows written to it.
+func (s *Splitter) Add(file string, rows int) {
+	part := Part{Number: len(s.parts) + 1, File: file, Rows: rows}
+	if rows > 0 {
+		part.FirstRow = s.rows + 1
+		part.LastRow = s.rows + rows
+	}
+	s.rows += rows
+	s.parts = append(s.parts, part)
+}
+
+// Parts returns the parts recorded so far, in order.
+func (s *Splitter) Parts() []Part {
+	return s.parts
+}
+
+// WriteIndex writes the recorded parts to an index file at path: a JSON array
+// when path ends with .json, CSV with a part,file,first_row,last_row,rows
+// header otherwise. Parts in the directory of the index are listed by file
+// name so the index and its parts can be moved together.
+func (s *Splitter) WriteIndex(path string) (err error) {
+	dir := filepath.Clean(filepath.Dir(path))
+	parts := make([]Part, len(s.parts))
+	for i, part := range s.parts {
+		if filepath.Clean(filepath.Dir(part.File)) == dir {
+			part.File = filepath.Base(part.File)
+		}
+		parts[i] = part
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating index file: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("error closing index file: %w", closeErr)
+		}
+	}()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(parts); err != nil {
+			return fmt.Errorf("error writing index file: %w", err)
+		}
+		return nil
+	}
+
+	w := csv.NewWriter(f)
+	records := [][]string{{"part", "file", "first_row", "last_row", "rows"}}
+	for _, part := range parts {
+		records = append(records, []string{
+			strconv.Itoa(part.Number), part.File,
+			strconv.Itoa(part.FirstRow), strconv.Itoa(part.LastRow), strconv.Itoa(part.Rows),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("error writing index file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPartTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"plain file", "users.csv", "users_part{part}.csv"},
+		{"compressed file", "out/users.csv.gz", "out/users_part{part}.csv.gz"},
+		{"zstd file", "users.json.zst", "users_part{part}.json.zst"},
+		{"explicit placeholder", "users-{part}.csv", "users-{part}.csv"},
+		{"no extension", "users", "users_part{part}"},
+		{"dot file", ".csv", ".csv_part{part}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PartTemplate(tt.path); got != tt.want {
+				t.Errorf("PartTemplate(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	tests := []struct {
+		template string
+		n        int
+		want     string
+	}{
+		{"users_part{part}.csv", 1, "users_part001.csv"},
+		{"users_part{part}.csv", 42, "users_part042.csv"},
+		{"users_part{part}.csv", 1234, "users_part1234.csv"},
+		{"{part}/users_{part}.csv", 2, "002/users_002.csv"},
+	}
+
+	for _, tt := range tests {
+		if got := PartPath(tt.template, tt.n); got != tt.want {
+			t.Errorf("PartPath(%q, %d) = %q, want %q", tt.template, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSplitterParts(t *testing.T) {
+	s := NewSplitter("users_part{part}.csv")
+	for _, rows := range []int{3, 3, 1} {
+		path := s.NextPath()
+		s.Add(path, rows)
+	}
+
+	want := []Part{
+		{Number: 1, File: "users_part001.csv", FirstRow: 1, LastRow: 3, Rows: 3},
+		{Number: 2, File: "users_part002.csv", FirstRow: 4, LastRow: 6, Rows: 3},
+		{Number: 3, File: "users_part003.csv", FirstRow: 7, LastRow: 7, Rows: 1},
+	}
+	got := s.Parts()
+	if len(got) != len(want) {
+		t.Fatalf("Parts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestSplitterEmptyPart(t *testing.T) {
+	s := NewSplitter("users_part{part}.csv")
+	s.Add(s.NextPath(), 0)
+
+	want := Part{Number: 1, File: "users_part001.csv"}
+	if got := s.Parts(); len(got) != 1 || got[0] != want {
+		t.Errorf("Parts() = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestSplitterWriteIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		index string
+		want  string
+	}{
+		{
+			name:  "csv index",
+			index: "index.csv",
+			want: "part,file,first_row,last_row,rows\n" +
+				"1,users_part001.csv.gz,1,3,3\n" +
+				"2,users_part002.csv.gz,4,6,3\n" +
+				"3,users_part003.csv.gz,7,7,1\n",
+		},
+		{
+			name:  "txt index defaults to csv",
+			index: "index.txt",
+			want: "part,file,first_row,last_row,rows\n" +
+				"1,users_part001.csv.gz,1,3,3\n" +
+				"2,users_part002.csv.gz,4,6,3\n" +
+				"3,users_part003.csv.gz,7,7,1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewSplitter(filepath.Join(dir, "users_part{part}.csv.gz"))
+			for _, rows := range []int{3, 3, 1} {
+				s.Add(s.NextPath(), rows)
+			}
+
+			path := filepath.Join(dir, tt.index)
+			if err := s.WriteIndex(path); err != nil {
+				t.Fatalf("WriteIndex() error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading index: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("index =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitterWriteIndexJSON(t *testing.T) {
+	dir := t.TempDir()
+	partsDir := filepath.Join(dir, "parts")
+	s := NewSplitter(filepath.Join(partsDir, "users_part{part}.csv"))
+	for _, rows := range []int{2, 2, 1} {
+		s.Add(s.NextPath(), rows)
+	}
+
+	path := filepath.Join(dir, "index.json")
+	if err := s.WriteIndex(path); err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading index: %v", err)
+	}
+
+	var got []Part
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("index is not valid JSON: %v\n%s", err, data)
+	}
+	// Parts outside the directory of the index keep their full path
+	want := []Part{
+		{Number: 1, File: filepath.Join(partsDir, "users_part001.csv"), FirstRow: 1, LastRow: 2, Rows: 2},
+		{Number: 2, File: filepath.Join(partsDir, "users_part002.csv"), FirstRow: 3, LastRow: 4, Rows: 2},
+		{Number: 3, File: filepath.Join(partsDir, "users_part003.csv"), FirstRow: 5, LastRow: 5, Rows: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("index = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestSplitterWriteIndexError(t *testing.T) {
+	s := NewSplitter("users_part{part}.csv")
+	s.Add(s.NextPath(), 1)
+
+	err := s.WriteIndex(filepath.Join(t.TempDir(), "missing", "index.csv"))
+	if err == nil {
+		t.Fatal("WriteIndex() expected error for a missing directory")
+	}
+}
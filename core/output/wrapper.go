@@ -21,6 +21,11 @@ func (bwc *bufferedWriteCloser) Close() error {
 	return bwc.underlying.Close()
 }
 
+// Commit marks the underlying output as complete.
+func (bwc *bufferedWriteCloser) Commit() {
+	Commit(bwc.underlying)
+}
+
 // newBufferedWriteCloser creates a buffered writer with specified buffer size
 func newBufferedWriteCloser(wc io.WriteCloser, size int) io.WriteCloser {
 	return &bufferedWriteCloser{
@@ -29,11 +34,36 @@ func newBufferedWriteCloser(wc io.WriteCloser, size int) io.WriteCloser {
 	}
 }
 
+// flusher is implemented by writers that buffer data (bufio, gzip, zstd, lz4).
+type flusher interface {
+	Flush() error
+}
+
+// Flush pushes data buffered by w, including any pending compressed block,
+// to the output file. Writers without buffering are left untouched.
+func Flush(w io.Writer) error {
+	if f, ok := w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
 type compositeWriteCloser struct {
 	io.Writer
+	file      io.Writer // output file beneath the compressor
 	closeFunc func() error
 }
 
+// Commit marks the output file as complete.
+func (c *compositeWriteCloser) Commit() {
+	Commit(c.file)
+}
+
+// Flush flushes the wrapped writer when it supports flushing.
+func (c *compositeWriteCloser) Flush() error {
+	return Flush(c.Writer)
+}
+
 // Close implements io.WriteCloser.
 func (c *compositeWriteCloser) Close() error {
 	if c.closeFunc == nil {
@@ -3,7 +3,10 @@ package output
 import (
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
 )
 
 const (
@@ -19,24 +22,168 @@ type OutputConfig struct {
 	Path        string
 	Compression string
 	Format      string
+	// Encoding transcodes the output from UTF-8 (utf-8, latin1, windows-1252)
+	Encoding string
+	// EncodingStrict fails on characters missing from Encoding instead of writing '?'
+	EncodingStrict bool
+	// Atomic writes to "<final path>.tmp" and renames it on Close once the
+	// output is committed with Commit; otherwise the temporary file is removed
+	Atomic bool
+	// AppendAt reopens an existing uncompressed file, truncated to AppendAt
+	// bytes, and appends to it instead of creating a new file (0 = create)
+	AppendAt int64
+	// Writer receives the output instead of a file at Path, still compressed
+	// and transcoded as configured. Closing the output closes Writer. Path
+	// then only names the entry of a zip archive.
+	Writer io.WriteCloser
 }
 
 // CreateWriter creates a new writer based on the output configuration.
-// Supports various compression formats: none, gzip, zip, zstd, lz4.
+// Supports various compression formats: none, gzip, zip, zstd, lz4, and
+// transcodes the output when a non-UTF-8 encoding is set.
 // Returns an error if the compression type is unsupported or file creation fails.
 func CreateWriter(cfg OutputConfig) (io.WriteCloser, error) {
-	switch strings.ToLower(strings.TrimSpace(cfg.Compression)) {
+	// Check the encoding first so no file is created for an invalid name
+	if _, err := NormalizeEncoding(cfg.Encoding); err != nil {
+		return nil, err
+	}
+
+	wc, err := createCompressedWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := newEncodingWriter(wc, cfg.Encoding, cfg.EncodingStrict)
+	if err != nil {
+		wc.Close()
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// createCompressedWriter opens the output file, or takes cfg.Writer, with the
+// configured compression.
+func createCompressedWriter(cfg OutputConfig) (io.WriteCloser, error) {
+	compression := strings.ToLower(strings.TrimSpace(cfg.Compression))
+	switch compression {
+	case None, GZIP, ZIP, ZSTD, LZ4:
+	default:
+		return nil, fmt.Errorf("unsupported compression type %q", cfg.Compression)
+	}
+
+	if cfg.Writer != nil && (cfg.Atomic || cfg.AppendAt > 0) {
+		return nil, fmt.Errorf("atomic and appended outputs require a file, not a writer")
+	}
+	if cfg.AppendAt > 0 {
+		if compression != None {
+			// A compressed stream cannot be cut at a byte offset of its content
+			return nil, fmt.Errorf("appending to an existing output requires compression none, got %s", compression)
+		}
+		if cfg.Atomic {
+			return nil, fmt.Errorf("appending to an existing output cannot be atomic")
+		}
+		return newAppendWriter(cfg.Path, cfg.AppendAt)
+	}
+
+	path := FinalPath(cfg)
+	file := cfg.Writer
+	if file == nil {
+		var err error
+		if file, err = createFile(path, cfg.Atomic); err != nil {
+			return nil, fmt.Errorf("error creating file: %w", tagWriteError(err))
+		}
+	} else {
+		logger.Debug("Writing output to the supplied writer")
+	}
+	file = &destination{WriteCloser: file}
+
+	var wc io.WriteCloser
+	var err error
+	switch compression {
 	case None:
-		return newFileWriter(cfg.Path)
+		wc = newFileWriter(file, path)
 	case GZIP:
-		return newGzipWriter(cfg.Path)
+		wc = newGzipWriter(file, path)
 	case ZIP:
-		return newZipWriter(cfg.Path, cfg.Format)
+		wc, err = newZipWriter(file, path, cfg.Format)
 	case ZSTD:
-		return newZstdWriter(cfg.Path)
+		wc, err = newZstdWriter(file, path)
 	case LZ4:
-		return newLz4Writer(cfg.Path)
-	default:
-		return nil, fmt.Errorf("unsupported compression type %q", cfg.Compression)
+		wc = newLz4Writer(file, path)
+	}
+	if err != nil {
+		return nil, err // the constructors close file on error
+	}
+	return wc, nil
+}
+
+// compressionExtensions maps each compression to the extension of its files.
+var compressionExtensions = map[string]string{
+	GZIP: ".gz",
+	ZIP:  ".zip",
+	ZSTD: ".zst",
+	LZ4:  ".lz4",
+}
+
+// FinalPath returns the path of the file CreateWriter writes for cfg. Without
+// compression this is cfg.Path. A compressed output always ends with the
+// compression extension, after the format extension:
+//   - a compression extension already ending the path is kept when it matches
+//     and replaced otherwise ("out.json.gz" stays, with zstd it becomes "out.json.zst")
+//   - a name without extension gets the format extension ("out" -> "out.json.gz")
+//   - zip replaces the format extension instead ("out.json" -> "out.zip"), since
+//     the entry inside the archive keeps it
+//
+// A path naming an existing named pipe is returned as is, whatever the
+// compression: the compressed stream is written to the pipe.
+func FinalPath(cfg OutputConfig) string {
+	ext, ok := compressionExtensions[strings.ToLower(strings.TrimSpace(cfg.Compression))]
+	if !ok || IsFIFO(cfg.Path) {
+		return cfg.Path
+	}
+
+	base, suffix := splitCompressionExtension(cfg.Path)
+	if !strings.EqualFold(suffix, ext) {
+		suffix = ext
+	} else if ext == ".zip" {
+		// An explicit archive name is used as is ("out.json.zip")
+		return cfg.Path
+	}
+
+	if ext == ".zip" {
+		return strings.TrimSuffix(base, filepath.Ext(base)) + suffix
+	}
+	if filepath.Ext(base) == "" {
+		base += formatExtension(cfg.Format)
+	}
+	return base + suffix
+}
+
+// TrimCompressionExtension removes a compression extension (.gz, .zip, .zst,
+// .lz4) from the end of path.
+func TrimCompressionExtension(path string) string {
+	base, _ := splitCompressionExtension(path)
+	return base
+}
+
+// splitCompressionExtension splits path into its name and a trailing
+// compression extension, matched case-insensitively and returned as written.
+func splitCompressionExtension(path string) (string, string) {
+	ext := filepath.Ext(path)
+	for _, known := range compressionExtensions {
+		if strings.EqualFold(ext, known) {
+			return strings.TrimSuffix(path, ext), ext
+		}
+	}
+	return path, ""
+}
+
+// formatExtension returns the extension of files of format, or "" for
+// templates, whose output can be any kind of text.
+func formatExtension(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || format == "template" {
+		return ""
 	}
+	return "." + format
 }
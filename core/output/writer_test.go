@@ -4,7 +4,10 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -334,8 +337,8 @@ func TestCreateOutputWriter_ZIP(t *testing.T) {
 		t.Fatalf("Close() error = %v", err)
 	}
 
-	// Verify .zip extension was added
-	expectedPath := fixExtension(testPath, ".zip")
+	// Verify .zip replaced the format extension
+	expectedPath := filepath.Join(tmpDir, "test.zip")
 	if _, err := os.Stat(expectedPath); os.IsNotExist(err) {
 		t.Errorf("Expected file %s does not exist", expectedPath)
 	}
@@ -425,6 +428,71 @@ func TestCreateOutputWriter_InvalidCompression(t *testing.T) {
 	}
 }
 
+// failingWriter fails every write, like a full disk or a closed connection.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+func (failingWriter) Close() error              { return nil }
+
+func TestCreateWriterErrWrite(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := CreateWriter(OutputConfig{
+			Format:      "csv",
+			Compression: None,
+			Path:        filepath.Join(t.TempDir(), "missing", "test.csv"),
+		})
+		if !errors.Is(err, ErrWrite) {
+			t.Fatalf("CreateWriter() error = %v, want ErrWrite", err)
+		}
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("CreateWriter() error = %v, want a *fs.PathError underneath", err)
+		}
+	})
+
+	for _, compression := range []string{None, GZIP, ZSTD} {
+		t.Run("failing writer "+compression, func(t *testing.T) {
+			w, err := CreateWriter(OutputConfig{
+				Format:      "csv",
+				Compression: compression,
+				Path:        "test.csv",
+				Writer:      failingWriter{},
+			})
+			if err != nil {
+				t.Fatalf("CreateWriter() error: %v", err)
+			}
+			_, err = w.Write([]byte("id\n1\n"))
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+			if !errors.Is(err, ErrWrite) {
+				t.Errorf("write error = %v, want ErrWrite", err)
+			}
+			if err != nil && err.Error() == ErrWrite.Error() {
+				t.Errorf("write error should keep its message, got %q", err)
+			}
+		})
+	}
+
+	t.Run("formatting errors are not write errors", func(t *testing.T) {
+		var buf bufferCloser
+		w, err := CreateWriter(OutputConfig{
+			Format:         "csv",
+			Compression:    None,
+			Encoding:       "latin1",
+			EncodingStrict: true,
+			Writer:         &buf,
+		})
+		if err != nil {
+			t.Fatalf("CreateWriter() error: %v", err)
+		}
+		defer w.Close()
+		if _, err := w.Write([]byte("€")); err == nil || errors.Is(err, ErrWrite) {
+			t.Errorf("encoding error = %v, want a non-ErrWrite error", err)
+		}
+	})
+}
+
 func TestCreateOutputWriter_CompressionCaseInsensitive(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -705,24 +773,167 @@ func TestCreateOutputWriter_FilePermissions(t *testing.T) {
 	}
 }
 
-func TestFixExtension(t *testing.T) {
+func TestFinalPathMatrix(t *testing.T) {
+	// Every combination of output name, format and compression ends with the
+	// format extension (or the zip archive) and exactly one compression extension
 	tests := []struct {
-		name  string
-		input string
-		ext   string
-		want  string
+		path   string
+		format string
+		want   map[string]string // compression -> final name
 	}{
-		{"no extension", "data", ".zip", "data.zip"},
-		{"no extension zip", "data.csv", ".zip", "data.zip"},
-		{"already correct zip", "data.csv.zip", ".zip", "data.csv.zip"},
-		{"different compression extension", "data.txt", ".bz2", "data.bz2"},
+		{"out.json", "json", map[string]string{
+			"none": "out.json", "gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.zip",
+		}},
+		{"out", "json", map[string]string{
+			"none": "out", "gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.zip",
+		}},
+		{"out.json.gz", "json", map[string]string{
+			"none": "out.json.gz", "gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.zip",
+		}},
+		{"out.gz", "json", map[string]string{
+			"none": "out.gz", "gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.zip",
+		}},
+		{"out.json.zst", "json", map[string]string{
+			"gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.zip",
+		}},
+		{"out.json.zip", "json", map[string]string{
+			"gzip": "out.json.gz", "zstd": "out.json.zst", "lz4": "out.json.lz4", "zip": "out.json.zip",
+		}},
+		{"OUT.CSV.GZ", "csv", map[string]string{
+			"gzip": "OUT.CSV.GZ", "zstd": "OUT.CSV.zst",
+		}},
+		{"report.csv", "json", map[string]string{
+			"gzip": "report.csv.gz", "zip": "report.zip",
+		}},
+		{"page", "template", map[string]string{
+			"gzip": "page.gz", "zip": "page.zip",
+		}},
+		{"page.html", "template", map[string]string{
+			"gzip": "page.html.gz", "zip": "page.zip",
+		}},
+	}
+
+	for _, tt := range tests {
+		for compression, want := range tt.want {
+			t.Run(fmt.Sprintf("%s/%s/%s", tt.path, tt.format, compression), func(t *testing.T) {
+				dir := t.TempDir()
+				cfg := OutputConfig{Path: filepath.Join(dir, tt.path), Compression: compression, Format: tt.format}
+				want := filepath.Join(dir, want)
+
+				if got := FinalPath(cfg); got != want {
+					t.Fatalf("FinalPath() = %q, want %q", got, want)
+				}
+
+				// CreateWriter writes exactly that file and nothing else
+				writer, err := CreateWriter(cfg)
+				if err != nil {
+					t.Fatalf("CreateWriter() error = %v", err)
+				}
+				writer.Write([]byte("data"))
+				if err := writer.Close(); err != nil {
+					t.Fatalf("Close() error = %v", err)
+				}
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatalf("ReadDir() error = %v", err)
+				}
+				if len(entries) != 1 || entries[0].Name() != filepath.Base(want) {
+					var names []string
+					for _, e := range entries {
+						names = append(names, e.Name())
+					}
+					t.Errorf("files = %v, want [%s]", names, filepath.Base(want))
+				}
+			})
+		}
+	}
+}
+
+func TestZipEntryKeepsFormatExtension(t *testing.T) {
+	tests := []struct {
+		path      string
+		format    string
+		wantEntry string
+	}{
+		{"out.json", "json", "out.json"},
+		{"out", "json", "out.json"},
+		{"out.json.gz", "json", "out.json"},
+		{"out.json.zip", "json", "out.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cfg := OutputConfig{Path: filepath.Join(t.TempDir(), tt.path), Compression: "zip", Format: tt.format}
+			writer, err := CreateWriter(cfg)
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			writer.Write([]byte("[]"))
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			archive, err := zip.OpenReader(FinalPath(cfg))
+			if err != nil {
+				t.Fatalf("Failed to open zip: %v", err)
+			}
+			defer archive.Close()
+			if len(archive.File) != 1 || archive.File[0].Name != tt.wantEntry {
+				t.Errorf("entry = %q, want %q", archive.File[0].Name, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestTrimCompressionExtension(t *testing.T) {
+	tests := map[string]string{
+		"out.json.gz":  "out.json",
+		"out.json.ZST": "out.json",
+		"out.zip":      "out",
+		"out.json":     "out.json",
+		"out.tar":      "out.tar",
+	}
+	for path, want := range tests {
+		if got := TrimCompressionExtension(path); got != want {
+			t.Errorf("TrimCompressionExtension(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFinalPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		compression string
+		want        string
+	}{
+		{"none", "data.csv", "none", "data.csv"},
+		{"gzip", "data.csv", "gzip", "data.csv.gz"},
+		{"gzip already has extension", "data.csv.GZ", "gzip", "data.csv.GZ"},
+		{"zip replaces extension", "data.csv", "zip", "data.zip"},
+		{"zstd", "data.csv", "zstd", "data.csv.zst"},
+		{"lz4 case insensitive", "data.csv", " LZ4 ", "data.csv.lz4"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := fixExtension(tt.input, tt.ext)
-			if got != tt.want {
-				t.Errorf("fixExtension(%q, %q) = %q, want %q", tt.input, tt.ext, got, tt.want)
+			cfg := OutputConfig{Path: filepath.Join(t.TempDir(), tt.path), Compression: tt.compression, Format: "csv"}
+			want := filepath.Join(filepath.Dir(cfg.Path), tt.want)
+
+			if got := FinalPath(cfg); got != want {
+				t.Errorf("FinalPath() = %q, want %q", got, want)
+			}
+
+			// FinalPath must match the file CreateWriter actually creates
+			writer, err := CreateWriter(cfg)
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if _, err := os.Stat(want); err != nil {
+				t.Errorf("expected file %s to exist: %v", want, err)
 			}
 		})
 	}
@@ -782,3 +993,402 @@ func BenchmarkCreateOutputWriter_ZIP(b *testing.B) {
 		os.Remove(testPath + ".zip")
 	}
 }
+
+func TestFlush_NoCompression(t *testing.T) {
+	testPath := filepath.Join(t.TempDir(), "test.csv")
+
+	writer, err := CreateWriter(OutputConfig{Format: "csv", Compression: "none", Path: testPath})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	defer writer.Close()
+
+	testData := "id,name\n1,alice\n"
+	if _, err := writer.Write([]byte(testData)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(testPath)
+	if len(content) != 0 {
+		t.Fatalf("data should still be buffered before Flush, got %q", string(content))
+	}
+
+	if err := Flush(writer); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	content, err = os.ReadFile(testPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(content) != testData {
+		t.Errorf("File content after Flush = %q, want %q", string(content), testData)
+	}
+}
+
+func TestFlush_GZIP(t *testing.T) {
+	testPath := filepath.Join(t.TempDir(), "test.csv")
+
+	writer, err := CreateWriter(OutputConfig{Format: "csv", Compression: "gzip", Path: testPath})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	defer writer.Close()
+
+	testData := "id,name\n1,alice\n"
+	if _, err := writer.Write([]byte(testData)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := Flush(writer); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// The stream is not terminated yet, but flushed data must be decodable
+	file, err := os.Open(testPath + ".gz")
+	if err != nil {
+		t.Fatalf("Failed to open gzip file: %v", err)
+	}
+	defer file.Close()
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	buf := make([]byte, len(testData))
+	if _, err := io.ReadFull(gr, buf); err != nil {
+		t.Fatalf("Failed to read flushed gzip data: %v", err)
+	}
+	if string(buf) != testData {
+		t.Errorf("Flushed gzip content = %q, want %q", string(buf), testData)
+	}
+}
+
+func TestFlush_UnbufferedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Flush(&buf); err != nil {
+		t.Errorf("Flush() on a writer without buffering should be a no-op, got %v", err)
+	}
+}
+
+func TestCreateOutputWriter_AppendAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.csv")
+	// The last record was cut short by an interrupted export
+	if err := os.WriteFile(path, []byte("id,name\n1,alice\n2,bo"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	writer, err := CreateWriter(OutputConfig{Path: path, Compression: "none", Format: "csv", AppendAt: 16})
+	if err != nil {
+		t.Fatalf("CreateWriter() error = %v", err)
+	}
+	if _, err := writer.Write([]byte("2,bob\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if want := "id,name\n1,alice\n2,bob\n"; string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestCreateOutputWriter_AppendAtErrors(t *testing.T) {
+	dir := t.TempDir()
+	short := filepath.Join(dir, "short.csv")
+	if err := os.WriteFile(short, []byte("id\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     OutputConfig
+		wantErr string
+	}{
+		{"missing file", OutputConfig{Path: filepath.Join(dir, "missing.csv"), Compression: "none", AppendAt: 10}, "error opening file"},
+		{"shorter than offset", OutputConfig{Path: short, Compression: "none", AppendAt: 10}, "expected at least 10"},
+		{"compressed", OutputConfig{Path: short, Compression: "gzip", AppendAt: 1}, "requires compression none"},
+		{"atomic", OutputConfig{Path: short, Compression: "none", Atomic: true, AppendAt: 1}, "cannot be atomic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, err := CreateWriter(tt.cfg)
+			if err == nil {
+				writer.Close()
+				t.Fatal("CreateWriter() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A failed append leaves the file as it was
+	if content, _ := os.ReadFile(short); string(content) != "id\n" {
+		t.Errorf("content = %q, want unchanged", content)
+	}
+}
+
+// bufferCloser is a bytes.Buffer that records being closed.
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCreateOutputWriter_Writer(t *testing.T) {
+	const content = "id,name\n1,alice\n"
+
+	tests := []struct {
+		compression string
+		path        string
+		read        func(t *testing.T, data []byte) string
+	}{
+		{"none", "", func(t *testing.T, data []byte) string { return string(data) }},
+		{"gzip", "", func(t *testing.T, data []byte) string {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("gzip.NewReader() error = %v", err)
+			}
+			out, _ := io.ReadAll(r)
+			return string(out)
+		}},
+		{"zstd", "", func(t *testing.T, data []byte) string {
+			r, err := zstd.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("zstd.NewReader() error = %v", err)
+			}
+			defer r.Close()
+			out, _ := io.ReadAll(r)
+			return string(out)
+		}},
+		{"lz4", "", func(t *testing.T, data []byte) string {
+			out, _ := io.ReadAll(lz4.NewReader(bytes.NewReader(data)))
+			return string(out)
+		}},
+		{"zip", "", readZipEntry("export.csv")},
+		{"zip", "users.csv", readZipEntry("users.csv")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression+"/"+tt.path, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+
+			var buf bufferCloser
+			writer, err := CreateWriter(OutputConfig{Path: tt.path, Compression: tt.compression, Format: "csv", Writer: &buf})
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			if _, err := writer.Write([]byte(content)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if !buf.closed {
+				t.Error("Close() should close the supplied writer")
+			}
+			if got := tt.read(t, buf.Bytes()); got != content {
+				t.Errorf("content = %q, want %q", got, content)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("no file should be created, found %v", entries)
+			}
+		})
+	}
+}
+
+// readZipEntry returns a reader of the zip archive in data that expects a
+// single entry called name.
+func readZipEntry(name string) func(t *testing.T, data []byte) string {
+	return func(t *testing.T, data []byte) string {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("zip.NewReader() error = %v", err)
+		}
+		if len(archive.File) != 1 || archive.File[0].Name != name {
+			t.Fatalf("zip entries = %v, want only %s", archive.File, name)
+		}
+		r, err := archive.File[0].Open()
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		defer r.Close()
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+}
+
+func TestCreateOutputWriter_WriterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     OutputConfig
+		wantErr string
+	}{
+		{"atomic", OutputConfig{Compression: "none", Atomic: true}, "require a file"},
+		{"append", OutputConfig{Compression: "none", AppendAt: 10}, "require a file"},
+		{"invalid compression", OutputConfig{Compression: "brotli"}, "unsupported compression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bufferCloser
+			tt.cfg.Writer = &buf
+			writer, err := CreateWriter(tt.cfg)
+			if err == nil {
+				writer.Close()
+				t.Fatal("CreateWriter() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("nothing should be written, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestCompressedOutputSingleStream(t *testing.T) {
+	tests := []struct {
+		compression string
+		ext         string
+	}{
+		{"gzip", ".gz"},
+		{"zstd", ".zst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			testPath := filepath.Join(t.TempDir(), "test.csv")
+			writer, err := CreateWriter(OutputConfig{Format: "csv", Compression: tt.compression, Path: testPath})
+			if err != nil {
+				t.Fatalf("CreateWriter() error = %v", err)
+			}
+			// Periodic flushes (--flush-every) must not split the stream
+			for _, chunk := range []string{"id,name\n", "1,alice\n", "2,bob\n"} {
+				if _, err := writer.Write([]byte(chunk)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if err := Flush(writer); err != nil {
+					t.Fatalf("Flush() error = %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			data, err := os.ReadFile(testPath + tt.ext)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+
+			var content []byte
+			switch tt.compression {
+			case "gzip":
+				br := bytes.NewReader(data)
+				gr, err := gzip.NewReader(br)
+				if err != nil {
+					t.Fatalf("Failed to create gzip reader: %v", err)
+				}
+				gr.Multistream(false)
+				if content, err = io.ReadAll(gr); err != nil {
+					t.Fatalf("Failed to read gzip member: %v", err)
+				}
+				if br.Len() != 0 {
+					t.Errorf("expected a single gzip member, %d bytes follow the first one", br.Len())
+				}
+			case "zstd":
+				var header zstd.Header
+				if err := header.Decode(data); err != nil {
+					t.Fatalf("Failed to decode zstd frame header: %v", err)
+				}
+				dec, err := zstd.NewReader(nil)
+				if err != nil {
+					t.Fatalf("Failed to create zstd decoder: %v", err)
+				}
+				defer dec.Close()
+				if content, err = dec.DecodeAll(data, nil); err != nil {
+					t.Fatalf("Failed to decode zstd data: %v", err)
+				}
+			}
+
+			if want := "id,name\n1,alice\n2,bob\n"; string(content) != want {
+				t.Errorf("content = %q, want %q", content, want)
+			}
+		})
+	}
+}
+
+func TestCompressedOutputConcatenated(t *testing.T) {
+	tests := []struct {
+		compression string
+		ext         string
+	}{
+		{"gzip", ".gz"},
+		{"zstd", ".zst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			dir := t.TempDir()
+			var appended []byte
+			for i, part := range []string{"id,name\n1,alice\n", "2,bob\n"} {
+				testPath := filepath.Join(dir, fmt.Sprintf("part%d.csv", i))
+				writer, err := CreateWriter(OutputConfig{Format: "csv", Compression: tt.compression, Path: testPath})
+				if err != nil {
+					t.Fatalf("CreateWriter() error = %v", err)
+				}
+				if _, err := writer.Write([]byte(part)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if err := writer.Close(); err != nil {
+					t.Fatalf("Close() error = %v", err)
+				}
+				data, err := os.ReadFile(testPath + tt.ext)
+				if err != nil {
+					t.Fatalf("Failed to read output: %v", err)
+				}
+				// Same as "cat part1.csv.gz >> part0.csv.gz"
+				appended = append(appended, data...)
+			}
+
+			var r io.Reader
+			switch tt.compression {
+			case "gzip":
+				gr, err := gzip.NewReader(bytes.NewReader(appended))
+				if err != nil {
+					t.Fatalf("Failed to create gzip reader: %v", err)
+				}
+				defer gr.Close()
+				r = gr
+			case "zstd":
+				zr, err := zstd.NewReader(bytes.NewReader(appended))
+				if err != nil {
+					t.Fatalf("Failed to create zstd reader: %v", err)
+				}
+				defer zr.Close()
+				r = zr
+			}
+
+			content, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Failed to decompress appended output: %v", err)
+			}
+			if want := "id,name\n1,alice\n2,bob\n"; string(content) != want {
+				t.Errorf("content = %q, want %q", content, want)
+			}
+		})
+	}
+}
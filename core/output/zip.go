@@ -4,7 +4,6 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,14 +11,9 @@ import (
 	"github.com/fbz-tec/pgxport/internal/logger"
 )
 
-func newZipWriter(path, format string) (io.WriteCloser, error) {
+func newZipWriter(file io.WriteCloser, path, format string) (io.WriteCloser, error) {
 	start := time.Now()
-	fixedPath := fixExtension(path, ".zip")
-	logger.Debug("Creating zip-compressed output file: %s", fixedPath)
-	file, err := os.Create(fixedPath)
-	if err != nil {
-		return nil, fmt.Errorf("error creating file: %w", err)
-	}
+	logger.Debug("Creating zip-compressed output file: %s", path)
 	zipWriter := zip.NewWriter(file)
 	entryName := determineZipEntryName(path, format)
 	logger.Debug("Creating zip entry: %s", entryName)
@@ -30,9 +24,10 @@ func newZipWriter(path, format string) (io.WriteCloser, error) {
 		return nil, fmt.Errorf("error creating zip entry: %w", err)
 	}
 	return &compositeWriteCloser{
+		file:   file,
 		Writer: entryWriter,
 		closeFunc: func() error {
-			logger.Debug("Finalizing zip archive: %s", fixedPath)
+			logger.Debug("Finalizing zip archive: %s", path)
 			var err error
 			if cerr := zipWriter.Close(); cerr != nil {
 				err = cerr
@@ -62,12 +57,3 @@ func determineZipEntryName(outputPath, format string) string {
 
 	return name
 }
-
-func fixExtension(path, extention string) string {
-	ext := filepath.Ext(path)
-
-	if strings.ToLower(ext) != extention {
-		path = path[:len(path)-len(ext)] + extention
-	}
-	return path
-}
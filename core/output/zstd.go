@@ -3,30 +3,24 @@ package output
 import (
 	"fmt"
 	"io"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/klauspost/compress/zstd"
 )
 
-func newZstdWriter(path string) (io.WriteCloser, error) {
+// newZstdWriter compresses to a single Zstandard frame, closed by Close.
+// Flush ends the current block but not the frame.
+func newZstdWriter(file io.WriteCloser, path string) (io.WriteCloser, error) {
 	start := time.Now()
-	if !strings.HasSuffix(strings.ToLower(path), ".zst") {
-		path += ".zst"
-	}
 	logger.Debug("Creating Zstandard-compressed output file: %s", path)
-	file, err := os.Create(path)
-	if err != nil {
-		return nil, fmt.Errorf("error creating file: %w", err)
-	}
 	zstdWriter, err := zstd.NewWriter(file)
 	if err != nil {
 		file.Close()
 		return nil, fmt.Errorf("error creating zstd writer: %w", err)
 	}
 	return &compositeWriteCloser{
+		file:   file,
 		Writer: zstdWriter,
 		closeFunc: func() error {
 			logger.Debug("Finalizing zstd compression for: %s", path)
@@ -0,0 +1,23 @@
+package validation
+
+import "errors"
+
+// ErrValidation is matched by errors.Is for a query or setting rejected by
+// this package, e.g. a write statement or an invalid time format.
+var ErrValidation = errors.New("validation failed")
+
+// validationError tags a rejection with ErrValidation, keeping its message.
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string   { return e.err.Error() }
+func (e *validationError) Unwrap() []error { return []error{ErrValidation, e.err} }
+
+// invalid returns err tagged with ErrValidation, or nil when err is nil.
+func invalid(err error) error {
+	if err == nil || errors.Is(err, ErrValidation) {
+		return err
+	}
+	return &validationError{err: err}
+}
@@ -30,8 +30,34 @@ var forbiddenCommands = []string{
 	"COPY", // COPY TO is allowed in COPY mode, but COPY FROM is not
 }
 
+// QueryOptions relaxes ValidateQuery for statements other than SELECT and WITH.
+type QueryOptions struct {
+	// AllowExplain accepts EXPLAIN of a SELECT or WITH query, which returns
+	// the plan without running the query
+	AllowExplain bool
+	// AllowExplainAnalyze also accepts EXPLAIN ANALYZE, which runs the query
+	AllowExplainAnalyze bool
+	// CheckFunction, when set, is called with the (upper-case) name of every
+	// function called in a FROM or JOIN clause, e.g. "MY_REPORT" for
+	// "SELECT * FROM my_report()". Returning an error rejects the query.
+	// RejectUserFunctions is the stock implementation.
+	CheckFunction func(name string) error
+}
+
 // ValidateQuery checks if the query is safe for export (read-only)
 func ValidateQuery(query string) error {
+	return ValidateQueryWithOptions(query, QueryOptions{})
+}
+
+// ValidateQueryWithOptions checks if the query is safe for export (read-only),
+// additionally accepting the statements enabled in opts. A rejected query
+// returns an error matching ErrValidation.
+func ValidateQueryWithOptions(query string, opts QueryOptions) error {
+	return invalid(validateQuery(query, opts))
+}
+
+// validateQuery implements ValidateQueryWithOptions.
+func validateQuery(query string, opts QueryOptions) error {
 	if strings.TrimSpace(query) == "" {
 		return fmt.Errorf("query cannot be empty")
 	}
@@ -60,6 +86,20 @@ func ValidateQuery(query string) error {
 		// Step 5: Extract the first command from the statement
 		firstCommand := extractFirstCommand(normalized)
 
+		// EXPLAIN is checked on its own, then the explained statement goes
+		// through the usual whitelist
+		if firstCommand == "EXPLAIN" {
+			inner, err := checkExplain(normalized, opts)
+			if err != nil {
+				return err
+			}
+			normalized = inner
+			firstCommand = extractFirstCommand(inner)
+			if !allowedCommands[firstCommand] {
+				return fmt.Errorf("EXPLAIN is only allowed for SELECT and WITH queries, got %s", firstCommand)
+			}
+		}
+
 		if firstCommand == "" {
 			// If we can't identify a command, reject for safety
 			return fmt.Errorf("unable to identify SQL command in statement %d (security: unknown command)", i+1)
@@ -82,6 +122,18 @@ func ValidateQuery(query string) error {
 		if err := scanForForbiddenCommands(normalized); err != nil {
 			return err
 		}
+
+		// Step 8: Functions. The whitelist above only looks at statements, so
+		// "SELECT * FROM f()" passes even though f could modify data. By default
+		// the query is trusted like any other SELECT (the database role is the
+		// real safeguard); CheckFunction lets callers vet functions in FROM.
+		if opts.CheckFunction != nil {
+			for _, name := range functionsInFrom(normalized) {
+				if err := opts.CheckFunction(name); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	return nil
@@ -398,6 +450,358 @@ func extractFirstCommand(normalized string) string {
 	return firstWord
 }
 
+// checkExplain validates the options of a normalized EXPLAIN statement against
+// opts and returns the explained statement. Both the legacy form
+// (EXPLAIN ANALYZE VERBOSE ...) and the parenthesized option list
+// (EXPLAIN (ANALYZE false, FORMAT JSON) ...) are understood.
+func checkExplain(normalized string, opts QueryOptions) (string, error) {
+	if !opts.AllowExplain {
+		return "", fmt.Errorf("unsupported SQL command: EXPLAIN (use --allow-explain to export query plans)")
+	}
+
+	rest := strings.TrimSpace(strings.TrimPrefix(normalized, "EXPLAIN"))
+	analyze := false
+
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end == -1 {
+			return "", fmt.Errorf("invalid EXPLAIN options: missing closing parenthesis")
+		}
+		for _, option := range strings.Split(rest[1:end], ",") {
+			words := strings.Fields(option)
+			if len(words) == 0 {
+				continue
+			}
+			if words[0] == "ANALYZE" || words[0] == "ANALYSE" {
+				analyze = len(words) == 1 || !isFalseOption(words[1])
+			}
+		}
+		rest = strings.TrimSpace(rest[end+1:])
+	} else {
+		for {
+			word, remainder, _ := strings.Cut(rest, " ")
+			if word == "ANALYZE" || word == "ANALYSE" {
+				analyze = true
+			} else if word != "VERBOSE" {
+				break
+			}
+			rest = remainder
+		}
+	}
+
+	if analyze && !opts.AllowExplainAnalyze {
+		return "", fmt.Errorf("EXPLAIN ANALYZE runs the query and requires --allow-explain-analyze")
+	}
+	return rest, nil
+}
+
+// isFalseOption reports whether an EXPLAIN option value turns the option off.
+func isFalseOption(value string) bool {
+	switch value {
+	case "FALSE", "OFF", "0":
+		return true
+	}
+	return false
+}
+
+// readOnlySetFunctions are built-in set-returning functions that never modify
+// data, accepted by RejectUserFunctions.
+var readOnlySetFunctions = map[string]bool{
+	"GENERATE_SERIES":           true,
+	"GENERATE_SUBSCRIPTS":       true,
+	"UNNEST":                    true,
+	"STRING_TO_TABLE":           true,
+	"REGEXP_MATCHES":            true,
+	"REGEXP_SPLIT_TO_TABLE":     true,
+	"JSON_EACH":                 true,
+	"JSON_EACH_TEXT":            true,
+	"JSONB_EACH":                true,
+	"JSONB_EACH_TEXT":           true,
+	"JSON_ARRAY_ELEMENTS":       true,
+	"JSON_ARRAY_ELEMENTS_TEXT":  true,
+	"JSONB_ARRAY_ELEMENTS":      true,
+	"JSONB_ARRAY_ELEMENTS_TEXT": true,
+	"JSON_OBJECT_KEYS":          true,
+	"JSONB_OBJECT_KEYS":         true,
+	"JSON_TO_RECORDSET":         true,
+	"JSONB_TO_RECORDSET":        true,
+	"JSON_POPULATE_RECORDSET":   true,
+	"JSONB_POPULATE_RECORDSET":  true,
+	"JSONB_PATH_QUERY":          true,
+}
+
+// RejectUserFunctions is a QueryOptions.CheckFunction that only accepts the
+// built-in set-returning functions known to be read-only (generate_series,
+// unnest, json_each, ...), optionally qualified with pg_catalog.
+func RejectUserFunctions(name string) error {
+	if readOnlySetFunctions[strings.TrimPrefix(name, "PG_CATALOG.")] {
+		return nil
+	}
+	return invalid(fmt.Errorf("function call in FROM clause: %s() (functions may modify data; allow them with --allow-functions)", strings.ToLower(name)))
+}
+
+// sqlFromSyntaxFunctions take FROM inside their argument list
+// (EXTRACT(YEAR FROM ts), SUBSTRING(s FROM 2), ...), which is not a FROM clause.
+var sqlFromSyntaxFunctions = map[string]bool{
+	"EXTRACT":   true,
+	"SUBSTRING": true,
+	"TRIM":      true,
+	"OVERLAY":   true,
+}
+
+// fromListEnd are the keywords ending a FROM list.
+var fromListEnd = map[string]bool{
+	"WHERE":     true,
+	"GROUP":     true,
+	"HAVING":    true,
+	"WINDOW":    true,
+	"ORDER":     true,
+	"LIMIT":     true,
+	"OFFSET":    true,
+	"FETCH":     true,
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+	"FOR":       true,
+	"SELECT":    true,
+	"WITH":      true,
+	"VALUES":    true,
+}
+
+// fromFrame is the state of one level of parentheses while scanning a query
+// for functionsInFrom.
+type fromFrame struct {
+	fn       string // word before the opening parenthesis, e.g. "EXTRACT"
+	fromList bool   // inside a FROM list, where a comma starts a new item
+	expect   bool   // the next token starts a FROM item
+}
+
+// functionsInFrom returns the names of the functions called in the FROM and
+// JOIN clauses of a normalized query: every item of a FROM list, including
+// those after a comma and inside ROWS FROM (...). Quoted identifiers keep
+// their quotes ("MY_REPORT", REPORTS."DAILY"), so they never match a built-in
+// name. Functions called elsewhere (select list, WHERE) cannot be told apart
+// from built-ins and are not reported.
+func functionsInFrom(normalized string) []string {
+	tokens := tokenizeSQL(normalized)
+	frames := []fromFrame{{}}
+
+	var names []string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		cur := &frames[len(frames)-1]
+
+		switch {
+		case tok.kind == tokPunct && tok.text == "(":
+			if cur.expect {
+				// A subquery or a parenthesized join: its first item is
+				// expected, unless it starts with SELECT, WITH or VALUES
+				cur.expect = false
+				frames = append(frames, fromFrame{fromList: true, expect: true})
+				continue
+			}
+			fn := ""
+			if i > 0 && tokens[i-1].kind == tokWord {
+				fn = tokens[i-1].text
+			}
+			frames = append(frames, fromFrame{fn: fn})
+
+		case tok.kind == tokPunct && tok.text == ")":
+			if len(frames) > 1 {
+				frames = frames[:len(frames)-1]
+			}
+
+		case cur.expect && tok.kind == tokWord && (tok.text == "LATERAL" || tok.text == "ONLY"):
+			// The item follows
+
+		case cur.expect && tok.kind == tokWord && tok.text == "ROWS" && i+1 < len(tokens) && tokens[i+1].text == "FROM":
+			// ROWS FROM (f(), g()): every item of the list is a function
+			i++
+
+		case cur.expect && (tok.kind == tokQuoted || tok.kind == tokWord && !fromListEnd[tok.text]):
+			cur.expect = false
+			name := tok.text
+			for i+2 < len(tokens) && tokens[i+1].text == "." && (tokens[i+2].kind == tokWord || tokens[i+2].kind == tokQuoted) {
+				name += "." + tokens[i+2].text
+				i += 2
+			}
+			if i+1 < len(tokens) && tokens[i+1].kind == tokPunct && tokens[i+1].text == "(" {
+				names = append(names, name)
+			}
+
+		case tok.kind == tokWord && tok.text == "FROM":
+			if i > 0 && tokens[i-1].text == "DISTINCT" {
+				continue // a IS DISTINCT FROM f(b)
+			}
+			if sqlFromSyntaxFunctions[cur.fn] {
+				continue
+			}
+			cur.fromList, cur.expect = true, true
+
+		case tok.kind == tokWord && tok.text == "JOIN":
+			cur.fromList, cur.expect = true, true
+
+		case tok.kind == tokPunct && tok.text == ",":
+			cur.expect = cur.fromList
+
+		case tok.kind == tokWord && fromListEnd[tok.text]:
+			cur.fromList, cur.expect = false, false
+
+		default:
+			cur.expect = false
+		}
+	}
+	return names
+}
+
+// Kinds of sqlToken.
+const (
+	tokWord    = iota // keyword or unquoted identifier
+	tokQuoted         // double-quoted identifier, quotes included
+	tokLiteral        // string, number or parameter
+	tokPunct          // any other single character
+)
+
+// sqlToken is a token of a query scanned by tokenizeSQL.
+type sqlToken struct {
+	kind int
+	text string
+}
+
+// tokenizeSQL splits a query without comments into words, quoted
+// identifiers, literals and punctuation. String literals (including E'...'
+// and dollar-quoted ones) become a single literal token, so their content is
+// never read as SQL.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '\'':
+			i = skipQuoted(query, i, '\'', false)
+			tokens = append(tokens, sqlToken{tokLiteral, ""})
+
+		case c == '"':
+			end := skipQuoted(query, i, '"', false)
+			tokens = append(tokens, sqlToken{tokQuoted, query[i:end]})
+			i = end
+
+		case c == '$':
+			end := i + 1
+			for end < len(query) && isIdentChar(query[end]) && query[end] != '$' {
+				end++
+			}
+			if end < len(query) && query[end] == '$' && !isDigit(query[i+1]) {
+				// Dollar-quoted string: $$...$$ or $tag$...$tag$; $1 is a parameter
+				tag := query[i : end+1]
+				if closing := strings.Index(query[end+1:], tag); closing >= 0 {
+					end = end + 1 + closing + len(tag)
+				} else {
+					end = len(query)
+				}
+			}
+			tokens = append(tokens, sqlToken{tokLiteral, ""})
+			i = end
+
+		case isIdentStart(c):
+			end := i
+			for end < len(query) && isIdentChar(query[end]) {
+				end++
+			}
+			word := query[i:end]
+			if end < len(query) && query[end] == '\'' {
+				// E'...' escapes quotes with backslashes; B'...', X'...' and
+				// N'...' are plain strings
+				i = skipQuoted(query, end, '\'', word == "E")
+				tokens = append(tokens, sqlToken{tokLiteral, ""})
+				continue
+			}
+			tokens = append(tokens, sqlToken{tokWord, word})
+			i = end
+
+		case isDigit(c):
+			end := i
+			for end < len(query) && (isIdentChar(query[end]) || query[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{tokLiteral, query[i:end]})
+			i = end
+
+		default:
+			tokens = append(tokens, sqlToken{tokPunct, string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+// skipQuoted returns the position after the quoted text starting at query[i],
+// where a doubled quote stands for itself and, with backslashes set, a
+// backslash escapes the next character.
+func skipQuoted(query string, i int, quote byte, backslashes bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch {
+		case backslashes && query[j] == '\\':
+			j++
+		case query[j] == quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '$'
+}
+
+// orderByPattern matches ORDER BY in a normalized query.
+var orderByPattern = regexp.MustCompile(`\bORDER BY\b`)
+
+// HasTopLevelOrderBy reports whether query sorts its result with an ORDER BY
+// outside any parentheses. An ORDER BY in a subquery, a CTE, a window
+// definition or an aggregate call (string_agg(x ORDER BY y)) does not fix the
+// order of the rows returned and is not counted. This is a heuristic on the
+// text of the query, which is not parsed: a whole query wrapped in
+// parentheses is reported as unordered.
+func HasTopLevelOrderBy(query string) bool {
+	normalized := normalizeSQL(removeStringLiterals(removeSQLComments(query)))
+
+	// Blank out everything inside parentheses
+	topLevel := []byte(normalized)
+	depth := 0
+	for i, c := range topLevel {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+				topLevel[i] = ' '
+				continue
+			}
+		}
+		if depth > 0 {
+			topLevel[i] = ' '
+		}
+	}
+	return orderByPattern.Match(topLevel)
+}
+
 // findSelectAfterWith finds the position of SELECT after a WITH clause
 func findSelectAfterWith(query string) int {
 	// Simple approach: look for SELECT after WITH
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -138,6 +139,24 @@ func TestValidateQuery(t *testing.T) {
 			wantErr: true,
 			errMsg:  "MERGE",
 		},
+		{
+			name:    "forbidden COPY FROM",
+			query:   "COPY users FROM '/tmp/users.csv'",
+			wantErr: true,
+			errMsg:  "COPY",
+		},
+		{
+			name:    "forbidden COPY TO",
+			query:   "COPY (SELECT * FROM users) TO STDOUT",
+			wantErr: true,
+			errMsg:  "COPY",
+		},
+		{
+			name:    "forbidden COPY FROM PROGRAM",
+			query:   "copy users from program 'curl http://example.com'",
+			wantErr: true,
+			errMsg:  "COPY",
+		},
 
 		// ========== Case Insensitivity ==========
 		{
@@ -231,6 +250,17 @@ func TestValidateQuery(t *testing.T) {
 			wantErr: true,
 			errMsg:  "DELETE",
 		},
+		{
+			name:    "COPY hidden after a comment in a CTE",
+			query:   "WITH x AS (SELECT 1) -- export\nSELECT * FROM x WHERE EXISTS (SELECT 1 FROM (COPY users FROM STDIN) c)",
+			wantErr: true,
+			errMsg:  "COPY",
+		},
+		{
+			name:    "COPY in a string literal",
+			query:   "SELECT 'COPY users FROM STDIN' AS hint",
+			wantErr: false,
+		},
 		{
 			name:    "valid CTE with SELECT",
 			query:   "WITH users_cte AS (SELECT * FROM users) SELECT * FROM users_cte",
@@ -338,6 +368,175 @@ func TestValidateQuery(t *testing.T) {
 	}
 }
 
+func TestValidateQueryWithOptionsExplain(t *testing.T) {
+	explain := QueryOptions{AllowExplain: true}
+	analyze := QueryOptions{AllowExplain: true, AllowExplainAnalyze: true}
+
+	tests := []struct {
+		name    string
+		query   string
+		opts    QueryOptions
+		wantErr bool
+		errMsg  string
+	}{
+		{name: "EXPLAIN rejected by default", query: "EXPLAIN SELECT * FROM users", wantErr: true, errMsg: "--allow-explain"},
+		{name: "EXPLAIN SELECT allowed", query: "EXPLAIN SELECT * FROM users", opts: explain},
+		{name: "EXPLAIN FORMAT JSON", query: "EXPLAIN (FORMAT JSON) SELECT * FROM users", opts: explain},
+		{name: "EXPLAIN VERBOSE WITH", query: "explain verbose WITH t AS (SELECT 1) SELECT * FROM t", opts: explain},
+		{name: "EXPLAIN ANALYZE false", query: "EXPLAIN (ANALYZE false, FORMAT JSON) SELECT 1", opts: explain},
+		{name: "EXPLAIN ANALYZE off", query: "EXPLAIN (ANALYZE OFF) SELECT 1", opts: explain},
+		{name: "EXPLAIN ANALYZE needs extra option", query: "EXPLAIN ANALYZE SELECT * FROM users", opts: explain, wantErr: true, errMsg: "--allow-explain-analyze"},
+		{name: "EXPLAIN (ANALYZE) needs extra option", query: "EXPLAIN (ANALYZE, BUFFERS) SELECT 1", opts: explain, wantErr: true, errMsg: "--allow-explain-analyze"},
+		{name: "EXPLAIN (ANALYZE true) needs extra option", query: "EXPLAIN (FORMAT JSON, ANALYZE true) SELECT 1", opts: explain, wantErr: true, errMsg: "--allow-explain-analyze"},
+		{name: "EXPLAIN ANALYZE allowed explicitly", query: "EXPLAIN ANALYZE SELECT * FROM users", opts: analyze},
+		{name: "EXPLAIN ANALYZE INSERT rejected", query: "EXPLAIN ANALYZE INSERT INTO users VALUES (1)", opts: analyze, wantErr: true, errMsg: "only allowed for SELECT and WITH"},
+		{name: "EXPLAIN DELETE rejected", query: "EXPLAIN DELETE FROM users", opts: explain, wantErr: true, errMsg: "only allowed for SELECT and WITH"},
+		{name: "EXPLAIN SELECT hiding DELETE", query: "EXPLAIN WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", opts: analyze, wantErr: true, errMsg: "DELETE"},
+		{name: "EXPLAIN with second statement", query: "EXPLAIN SELECT 1; DROP TABLE users", opts: analyze, wantErr: true, errMsg: "single SQL statement"},
+		{name: "unclosed options", query: "EXPLAIN (FORMAT JSON SELECT 1", opts: explain, wantErr: true, errMsg: "missing closing parenthesis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQueryWithOptions(tt.query, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateQueryWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("ValidateQueryWithOptions() error = %v, expected to contain %v", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateQueryWithOptionsFunctions(t *testing.T) {
+	strict := QueryOptions{CheckFunction: RejectUserFunctions}
+
+	tests := []struct {
+		name      string
+		query     string
+		strictErr string // expected error with RejectUserFunctions, "" = accepted
+	}{
+		{name: "function in FROM", query: "SELECT * FROM my_readonly_function()", strictErr: "my_readonly_function()"},
+		{name: "schema-qualified function", query: "SELECT * FROM reports.daily(1, 'x')", strictErr: "reports.daily()"},
+		{name: "function in JOIN LATERAL", query: "SELECT * FROM users u JOIN LATERAL user_orders(u.id) o ON true", strictErr: "user_orders()"},
+		{name: "function in subquery FROM", query: "SELECT * FROM (SELECT * FROM refresh_stats()) s", strictErr: "refresh_stats()"},
+		{name: "built-in generate_series", query: "SELECT * FROM generate_series(1, 10)"},
+		{name: "built-in unnest and jsonb_each", query: "SELECT * FROM unnest(ARRAY[1,2]) JOIN jsonb_each('{}') ON true"},
+		{name: "pg_catalog qualified built-in", query: "SELECT * FROM pg_catalog.generate_series(1, 3)"},
+		{name: "plain table", query: "SELECT * FROM users"},
+		{name: "subquery", query: "SELECT * FROM (SELECT 1) t"},
+		{name: "lateral subquery", query: "SELECT * FROM users u JOIN LATERAL (SELECT 1) x ON true"},
+		{name: "extract from function", query: "SELECT EXTRACT(YEAR FROM now()) FROM users"},
+		{name: "substring from expression", query: "SELECT substring(name FROM position('a' IN name)) FROM users"},
+		{name: "is distinct from function", query: "SELECT * FROM users WHERE a IS DISTINCT FROM lower(b)"},
+		{name: "function name in string", query: "SELECT 'FROM evil()' AS txt FROM users"},
+		{name: "function in select list", query: "SELECT my_function(id) FROM users"},
+		{name: "function after comma in FROM", query: "SELECT * FROM users, my_report()", strictErr: "my_report()"},
+		{name: "function after aliased table and comma", query: "SELECT * FROM users AS u, LATERAL my_report(u.id) r", strictErr: "my_report()"},
+		{name: "function after join condition and comma", query: "SELECT * FROM users u JOIN orders o ON u.id = o.user_id, my_report()", strictErr: "my_report()"},
+		{name: "function after comma in subquery", query: "SELECT * FROM (SELECT * FROM users, refresh_stats()) s", strictErr: "refresh_stats()"},
+		{name: "quoted function name", query: `SELECT * FROM "my_report"()`, strictErr: `"my_report"()`},
+		{name: "quoted built-in name", query: `SELECT * FROM "generate_series"(1, 3)`, strictErr: `"generate_series"()`},
+		{name: "schema-qualified quoted function", query: `SELECT * FROM "reports"."daily"()`, strictErr: `"reports"."daily"()`},
+		{name: "quoted schema unquoted function", query: `SELECT * FROM "reports".daily()`, strictErr: `"reports".daily()`},
+		{name: "quoted function after comma", query: `SELECT * FROM users, public."My Report"()`, strictErr: `public."my report"()`},
+		{name: "rows from", query: "SELECT * FROM ROWS FROM (my_report())", strictErr: "my_report()"},
+		{name: "rows from after comma", query: "SELECT * FROM users, ROWS FROM (my_report()) r", strictErr: "my_report()"},
+		{name: "rows from second function", query: "SELECT * FROM ROWS FROM (generate_series(1, 3), my_report()) AS r(a, b)", strictErr: "my_report()"},
+		{name: "rows from built-ins", query: "SELECT * FROM ROWS FROM (generate_series(1, 3), unnest(ARRAY[1])) AS r(a, b)"},
+		{name: "function in parenthesized join", query: "SELECT * FROM (users u JOIN my_report() r ON true)", strictErr: "my_report()"},
+		{name: "comma-separated tables", query: "SELECT * FROM users u, orders o WHERE u.id = o.user_id"},
+		{name: "comma in select list and where", query: "SELECT a, lower(b) FROM users WHERE id IN (1, 2) ORDER BY a, lower(b)"},
+		{name: "alias column list", query: "SELECT * FROM generate_series(1, 3) AS g(n), users"},
+		{name: "comma in string of join condition", query: "SELECT * FROM users u JOIN t ON u.name = ', my_report()'"},
+		{name: "comma in dollar-quoted string", query: "SELECT * FROM users u JOIN t ON u.name = $$x', my_report()$$"},
+		{name: "comma in tagged dollar-quoted string", query: "SELECT * FROM users u JOIN t ON u.name = $q$x$$, my_report()$q$"},
+		{name: "comma in escaped string", query: `SELECT * FROM users u JOIN t ON u.name = E'\', my_report()'`},
+		{name: "cte body", query: "WITH r AS (SELECT * FROM users, my_report()) SELECT * FROM r", strictErr: "my_report()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Default: functions are trusted
+			if err := ValidateQueryWithOptions(tt.query, QueryOptions{}); err != nil {
+				t.Errorf("ValidateQueryWithOptions() default error = %v, want nil", err)
+			}
+
+			err := ValidateQueryWithOptions(tt.query, strict)
+			if tt.strictErr == "" {
+				if err != nil {
+					t.Errorf("ValidateQueryWithOptions() strict error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.strictErr) {
+				t.Errorf("ValidateQueryWithOptions() strict error = %v, want it to contain %q", err, tt.strictErr)
+			}
+		})
+	}
+}
+
+func TestErrValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "valid query", err: ValidateQuery("SELECT 1"), want: false},
+		{name: "write statement", err: ValidateQuery("DELETE FROM users"), want: true},
+		{name: "empty query", err: ValidateQuery("   "), want: true},
+		{name: "user function", err: ValidateQueryWithOptions("SELECT * FROM my_report()", QueryOptions{CheckFunction: RejectUserFunctions}), want: true},
+		{name: "custom check", err: ValidateQueryWithOptions("SELECT * FROM my_report()", QueryOptions{CheckFunction: func(string) error { return errors.New("not allowed") }}), want: true},
+		{name: "valid time zone", err: ValidateTimeZone("UTC"), want: false},
+		{name: "invalid time zone", err: ValidateTimeZone("Mars/Olympus"), want: true},
+		{name: "invalid Go layout", err: ValidateTimeFormat("go:yyyy"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, ErrValidation); got != tt.want {
+				t.Errorf("errors.Is(%v, ErrValidation) = %v, want %v", tt.err, got, tt.want)
+			}
+			if tt.err != nil && strings.HasPrefix(tt.err.Error(), ErrValidation.Error()) {
+				t.Errorf("error should keep its own message, got %q", tt.err)
+			}
+		})
+	}
+}
+
+func TestHasTopLevelOrderBy(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "order by", query: "SELECT * FROM users ORDER BY id", want: true},
+		{name: "lower case and extra whitespace", query: "select * from users\norder\n\tby id;", want: true},
+		{name: "union", query: "SELECT id FROM a UNION ALL SELECT id FROM b ORDER BY id", want: true},
+		{name: "cte with final order by", query: "WITH x AS (SELECT * FROM users) SELECT * FROM x ORDER BY id", want: true},
+		{name: "order by with subquery in select list", query: "SELECT (SELECT max(n) FROM t), id FROM users ORDER BY 2", want: true},
+		{name: "no order by", query: "SELECT * FROM users", want: false},
+		{name: "order by in subquery", query: "SELECT * FROM (SELECT * FROM users ORDER BY id) s", want: false},
+		{name: "order by in cte", query: "WITH x AS (SELECT * FROM users ORDER BY id) SELECT * FROM x", want: false},
+		{name: "window definition", query: "SELECT id, row_number() OVER (ORDER BY id) FROM users", want: false},
+		{name: "aggregate argument", query: "SELECT string_agg(name, ',' ORDER BY name) FROM users", want: false},
+		{name: "order by in string", query: "SELECT 'ORDER BY id' AS txt FROM users", want: false},
+		{name: "order by in comment", query: "SELECT * FROM users -- ORDER BY id", want: false},
+		{name: "order by in quoted identifier", query: `SELECT "order by" FROM users`, want: false},
+		{name: "order by in block comment", query: "SELECT * FROM users /* ORDER BY id */", want: false},
+		{name: "column named order_by", query: "SELECT * FROM users WHERE order_by = 1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasTopLevelOrderBy(tt.query); got != tt.want {
+				t.Errorf("HasTopLevelOrderBy(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestValidateQuery_ComplexQueries tests complex real-world queries
 func TestValidateQuery_ComplexQueries(t *testing.T) {
 	tests := []struct {
@@ -2,13 +2,14 @@ package validation
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
 )
 
 // ValidateTimeZone checks if a timezone string is valid.
-// Returns an error if the timezone cannot be loaded. Empty string is considered valid (uses local time).
+// Returns an error matching ErrValidation if the timezone cannot be loaded. Empty string is considered valid (uses local time).
 func ValidateTimeZone(timezone string) error {
 	if timezone == "" {
 		return nil // Empty is valid (uses Local)
@@ -16,31 +17,43 @@ func ValidateTimeZone(timezone string) error {
 
 	_, err := time.LoadLocation(timezone)
 	if err != nil {
-		return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		return invalid(fmt.Errorf("invalid timezone %q: %w", timezone, err))
 	}
 
 	return nil
 }
 
 // ValidateTimeFormat validates that a time format string is valid by testing it with a known time.
-// Returns an error if the format cannot be used to format and parse a time value.
+// Returns an error matching ErrValidation if the format cannot be used to format and parse a time value.
 func ValidateTimeFormat(format string) error {
 
 	// Empty format is invalid
 	if format == "" {
-		return fmt.Errorf("time format cannot be empty")
+		return invalid(fmt.Errorf("time format cannot be empty"))
 	}
 
 	// Test the format with a known time
 	testTime := time.Date(2006, 1, 2, 15, 4, 5, 123456789, time.UTC)
 	layout := formatters.ConvertUserTimeFormat(format)
 
+	// A Go layout is used verbatim, so it must contain at least one element
+	// of the reference time; otherwise every value would print the same text
+	if strings.HasPrefix(format, formatters.GoLayoutPrefix) {
+		if layout == "" {
+			return invalid(fmt.Errorf("Go time layout cannot be empty"))
+		}
+		other := time.Date(2007, 2, 3, 16, 5, 6, 234567891, time.UTC)
+		if other.Format(layout) == testTime.Format(layout) {
+			return invalid(fmt.Errorf("Go time layout %q contains no reference time elements (e.g. 2006-01-02 15:04:05)", layout))
+		}
+	}
+
 	// Try to format and parse back
 	formatted := testTime.Format(layout)
 	_, err := time.Parse(layout, formatted)
 
 	if err != nil {
-		return fmt.Errorf("invalid time format %q: %w", format, err)
+		return invalid(fmt.Errorf("invalid time format %q: %w", format, err))
 	}
 
 	return nil
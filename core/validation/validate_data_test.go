@@ -50,6 +50,36 @@ func TestValidateTimeFormat(t *testing.T) {
 			format:  "",
 			wantErr: true,
 		},
+		{
+			name:    "valid microseconds with zone offset",
+			format:  "yyyy-MM-ddTHH:mm:ss.SSSSSSxxx",
+			wantErr: false,
+		},
+		{
+			name:    "valid 12-hour clock",
+			format:  "dd/MM/yyyy hh:mm a",
+			wantErr: false,
+		},
+		{
+			name:    "valid Go layout",
+			format:  "go:2006-01-02T15:04:05.000Z07:00",
+			wantErr: false,
+		},
+		{
+			name:    "valid Go layout with time only",
+			format:  "go:15:04",
+			wantErr: false,
+		},
+		{
+			name:    "empty Go layout",
+			format:  "go:",
+			wantErr: true,
+		},
+		{
+			name:    "Go layout without reference elements",
+			format:  "go:yyyy-MM-dd",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,126 @@
+// Package markdown is an example of a custom pgxport format. It writes the
+// query result as a GitHub-flavored Markdown table.
+//
+// Importing the package registers the "markdown" format, so a custom build of
+// pgxport only needs a main package such as:
+//
+//	package main
+//
+//	import (
+//		"github.com/fbz-tec/pgxport/cmd"
+//		_ "github.com/fbz-tec/pgxport/examples/markdown"
+//	)
+//
+//	func main() {
+//		cmd.Execute()
+//	}
+//
+// after which "pgxport -f markdown" is available.
+package markdown
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/output"
+	"github.com/jackc/pgx/v5"
+)
+
+// Format is the name the exporter is registered under.
+const Format = "markdown"
+
+// cellEscaper keeps values on one line and inside their cell.
+var cellEscaper = strings.NewReplacer(
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+type exporter struct{}
+
+// Export writes rows as a Markdown table. NULL values are left empty.
+func (e *exporter) Export(rows pgx.Rows, options exporters.ExportOptions) (int, error) {
+	fields := rows.FieldDescriptions()
+
+	// Column names first, so a result without columns leaves no file behind
+	columns, err := exporters.ColumnNames(fields, options, false)
+	if err != nil {
+		return 0, err
+	}
+
+	writerCloser, err := output.CreateWriter(output.OutputConfig{
+		Path:        options.OutputPath,
+		Compression: options.Compression,
+		Format:      options.Format,
+		Atomic:      options.Atomic,
+		Writer:      options.WriteCloser(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer writerCloser.Close()
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	w := bufio.NewWriter(writerCloser)
+
+	header := make([]string, len(columns))
+	separator := make([]string, len(columns))
+	for i, name := range columns {
+		header[i] = cellEscaper.Replace(name)
+		separator[i] = "---"
+	}
+	writeLine(w, header)
+	writeLine(w, separator)
+
+	rowCount := 0
+	cells := make([]string, len(fields))
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return rowCount, fmt.Errorf("export cancelled after %d rows: %w", rowCount, err)
+		}
+
+		values, err := rows.Values()
+		if err != nil {
+			return rowCount, fmt.Errorf("error reading row %d: %w", rowCount+1, err)
+		}
+		for i, v := range values {
+			cells[i] = ""
+			if v != nil {
+				text := formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+				cells[i] = cellEscaper.Replace(text)
+			}
+		}
+		writeLine(w, cells)
+		rowCount++
+	}
+
+	if err := rows.Err(); err != nil {
+		return rowCount, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return rowCount, fmt.Errorf("error writing Markdown: %w", err)
+	}
+
+	output.Commit(writerCloser)
+	return rowCount, nil
+}
+
+// writeLine writes one table row. Write errors are reported by Flush.
+func writeLine(w *bufio.Writer, cells []string) {
+	w.WriteString("| ")
+	w.WriteString(strings.Join(cells, " | "))
+	w.WriteString(" |\n")
+}
+
+func init() {
+	exporters.MustRegister(Format, func() exporters.Exporter { return &exporter{} })
+}
@@ -0,0 +1,91 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// staticRows is a minimal pgx.Rows over in-memory values.
+type staticRows struct {
+	fields []pgconn.FieldDescription
+	data   [][]any
+	pos    int
+}
+
+func (r *staticRows) Close()                                       {}
+func (r *staticRows) Err() error                                   { return nil }
+func (r *staticRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *staticRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *staticRows) Next() bool                                   { r.pos++; return r.pos < len(r.data) }
+func (r *staticRows) Scan(dest ...any) error                       { return nil }
+func (r *staticRows) Values() ([]any, error)                       { return r.data[r.pos], nil }
+func (r *staticRows) RawValues() [][]byte                          { return nil }
+func (r *staticRows) Conn() *pgx.Conn                              { return nil }
+
+func TestMarkdownExporterRegistered(t *testing.T) {
+	rows := &staticRows{
+		fields: []pgconn.FieldDescription{
+			{Name: "id", DataTypeOID: pgtype.Int4OID},
+			{Name: "note", DataTypeOID: pgtype.TextOID},
+		},
+		data: [][]any{
+			{int32(1), "a|b"},
+			{int32(2), nil},
+			{int32(3), "two\nlines"},
+		},
+		pos: -1,
+	}
+
+	exporter, err := exporters.Get("Markdown")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "report.md")
+	rowCount, err := exporter.Export(rows, exporters.ExportOptions{
+		Format:      Format,
+		Compression: "none",
+		OutputPath:  outputPath,
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if rowCount != 3 {
+		t.Errorf("Export() rowCount = %d, want 3", rowCount)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := "| id | note |\n| --- | --- |\n| 1 | a\\|b |\n| 2 |  |\n| 3 | two<br>lines |\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestMarkdownExporterNoColumns(t *testing.T) {
+	exporter, err := exporters.Get(Format)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "report.md")
+	_, err = exporter.Export(&staticRows{pos: -1}, exporters.ExportOptions{
+		Format:      Format,
+		Compression: "none",
+		OutputPath:  outputPath,
+	})
+	if err != exporters.ErrNoColumns {
+		t.Fatalf("Export() error = %v, want ErrNoColumns", err)
+	}
+	if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+		t.Errorf("no output file should be created for a result without columns")
+	}
+}
@@ -2,6 +2,8 @@ package ui
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/yarlson/pin"
 )
@@ -43,3 +45,21 @@ func (s *Spinner) Stop(message string) {
 	}
 	s.p.Stop(message)
 }
+
+// ProgressMessage formats the row progress shown by the spinner. When the
+// expected total is known (> 0), it adds a percentage and an ETA based on the
+// rate so far; the percentage is capped at 100% since totals may be estimates.
+func ProgressMessage(label string, rows, total int, elapsed time.Duration) string {
+	seconds := int(elapsed.Seconds())
+	if total <= 0 {
+		return fmt.Sprintf("%s %d rows [%ds]", label, rows, seconds)
+	}
+
+	percent := min(rows*100/total, 100)
+	if rows == 0 || rows >= total {
+		return fmt.Sprintf("%s %d/%d rows (%d%%) [%ds]", label, rows, total, percent, seconds)
+	}
+
+	eta := time.Duration(float64(elapsed) * float64(total-rows) / float64(rows))
+	return fmt.Sprintf("%s %d/%d rows (%d%%) [%ds, ETA %ds]", label, rows, total, percent, seconds, int(eta.Seconds()))
+}
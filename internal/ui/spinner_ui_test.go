@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     int
+		total    int
+		elapsed  time.Duration
+		expected string
+	}{
+		{
+			name:     "unknown total",
+			rows:     1500,
+			elapsed:  3 * time.Second,
+			expected: "Processing rows... 1500 rows [3s]",
+		},
+		{
+			name:     "known total with ETA",
+			rows:     250,
+			total:    1000,
+			elapsed:  2 * time.Second,
+			expected: "Processing rows... 250/1000 rows (25%) [2s, ETA 6s]",
+		},
+		{
+			name:     "no rows yet",
+			rows:     0,
+			total:    1000,
+			elapsed:  0,
+			expected: "Processing rows... 0/1000 rows (0%) [0s]",
+		},
+		{
+			name:     "total reached",
+			rows:     1000,
+			total:    1000,
+			elapsed:  8 * time.Second,
+			expected: "Processing rows... 1000/1000 rows (100%) [8s]",
+		},
+		{
+			name:     "estimate exceeded is capped",
+			rows:     1200,
+			total:    1000,
+			elapsed:  9 * time.Second,
+			expected: "Processing rows... 1200/1000 rows (100%) [9s]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ProgressMessage("Processing rows...", tt.rows, tt.total, tt.elapsed)
+			if result != tt.expected {
+				t.Errorf("ProgressMessage() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -12,6 +12,26 @@ var (
 	GitCommit  = "unknown"
 )
 
+// Info is the machine-readable version information.
+type Info struct {
+	Version   string `json:"version"`
+	Build     string `json:"build"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the version information as a struct.
+func Get() Info {
+	return Info{
+		Version:   AppVersion,
+		Build:     BuildTime,
+		Commit:    GitCommit,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
 // GetVersionInfo returns formatted version information
 func GetInfo() string {
 	return fmt.Sprintf(